@@ -0,0 +1,539 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"filippo.io/age"
+	"github.com/vmpyr/afterlight/internal/backup"
+	"github.com/vmpyr/afterlight/internal/bundle"
+	"github.com/vmpyr/afterlight/internal/config"
+	"github.com/vmpyr/afterlight/internal/core"
+	"github.com/vmpyr/afterlight/internal/keys"
+	"github.com/vmpyr/afterlight/internal/seal"
+	"github.com/vmpyr/afterlight/internal/store"
+	"github.com/vmpyr/afterlight/internal/vaultcrypt"
+)
+
+const usage = `Usage: afterlight [-config FILE] [-print-config] [command]
+
+Without a command, the server is started.
+
+Options:
+  -config FILE
+      Read settings from a TOML file (default AFTERLIGHT_CONFIG). Environment
+      variables override values from the file.
+  -print-config
+      Print the effective configuration with secrets redacted and exit.
+
+Commands:
+  rotate-master-key -new-key-file PATH
+      Re-wrap every sealed vault key under a new master key. The current key
+      is read from MASTER_KEY_FILE or MASTER_KEY. If PATH does not exist, a new
+      key is generated and written there. The current keys are added to PATH
+      after the new one, so vaults the server seals until it is restarted
+      still open. Point MASTER_KEY_FILE at PATH and restart the server
+      afterwards; the next rotation moves those vaults over.
+
+  set-role -email EMAIL -role admin|user
+      Change an account's role. The first account registered on a new
+      instance is made an admin; use this to promote one on an existing
+      instance, or to hand administration over. The last active admin
+      cannot be demoted.
+
+  decrypt -bundle FILE -out DIR [-passphrase-file FILE]
+      Decrypt a saved vault without the server. FILE is a bundle from
+      GET /api/v1/vaults/{id}/export, or the JSON returned by
+      GET /api/v1/vaults/{id}/artifacts or the release artifacts endpoint.
+      Text messages are written to DIR as .txt files, everything else as .bin.
+      The passphrase is read from -passphrase-file, AFTERLIGHT_PASSPHRASE, or
+      the first line of standard input.
+
+  backup [-out FILE]
+      Snapshot the database and ARTIFACTS_PATH into one archive while the
+      server keeps running. Without -out, the archive is written to
+      BACKUP_DIR; "-out -" writes it to standard output. Archives are
+      encrypted when BACKUP_RECIPIENTS is set.
+
+  restore -archive FILE [-identity FILE] [-verify-only] [-force]
+      Check an archive's checksums and database integrity, then replace
+      DB_PATH and ARTIFACTS_PATH with its contents. Stop the server first.
+      -identity is an age identity file, needed for encrypted archives.
+      Existing data is only overwritten with -force.
+
+  healthcheck [-timeout DURATION]
+      Request /readyz from the server this configuration starts, over HTTPS
+      when TLS is configured, and exit non-zero unless it is ready. Meant for
+      container health checks; the certificate is not verified.
+`
+
+// Runs a maintenance subcommand and returns the process exit code
+func runCommand(args []string, configPath string) int {
+	var err error
+	switch args[0] {
+	case "rotate-master-key":
+		err = rotateMasterKey(args[1:], configPath)
+	case "set-role":
+		err = setRole(args[1:], configPath)
+	case "decrypt":
+		err = decryptBundle(args[1:])
+	case "backup":
+		err = runBackup(args[1:], configPath)
+	case "restore":
+		err = runRestore(args[1:], configPath)
+	case "healthcheck":
+		err = healthcheck(args[1:], configPath)
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+		return 0
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\n%s", args[0], usage)
+		return 2
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", args[0], err)
+		return 1
+	}
+	return 0
+}
+
+func rotateMasterKey(args []string, configPath string) error {
+	flags := flag.NewFlagSet("rotate-master-key", flag.ContinueOnError)
+	newKeyFile := flags.String("new-key-file", "", "file holding the new master key (created if missing)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *newKeyFile == "" {
+		return errors.New("-new-key-file is required")
+	}
+
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	current, err := keys.LoadKeyring(cfg.Keys.MasterKeyFile, string(cfg.Keys.MasterKey))
+	if err != nil {
+		return fmt.Errorf("loading current master key: %w", err)
+	}
+	if current == nil {
+		return errors.New("set MASTER_KEY_FILE or MASTER_KEY to the current master key")
+	}
+
+	next, created, err := loadOrCreateKeyring(*newKeyFile, current)
+	if err != nil {
+		return fmt.Errorf("loading new master key: %w", err)
+	}
+	if next.PrimaryID() == current.PrimaryID() {
+		return errors.New("the new master key is the same as the current one")
+	}
+
+	storage, err := openStorage(cfg)
+	if err != nil {
+		return err
+	}
+	defer storage.Close()
+
+	sealer := seal.NewSealer(store.NewStore(storage.DB()), current)
+	n, err := sealer.Rotate(context.Background(), next)
+	if err != nil {
+		if created {
+			os.Remove(*newKeyFile)
+		}
+		return err
+	}
+
+	fmt.Printf("Re-wrapped %d sealed vault(s) under master key %s\n", n, next.PrimaryID())
+	fmt.Printf("Now set MASTER_KEY_FILE=%s and restart the server\n", *newKeyFile)
+	return nil
+}
+
+// The new keyring lists the new key first, followed by the current keys. The
+// server keeps sealing with the current key until it is restarted, and those
+// vaults must still open under the new file; the next rotation re-wraps them.
+func loadOrCreateKeyring(path string, current *keys.Keyring) (*keys.Keyring, bool, error) {
+	data, err := os.ReadFile(path)
+	if err == nil {
+		k, err := keys.ParseKeyring(data)
+		if err != nil {
+			return nil, false, err
+		}
+		missing := k.Missing(current)
+		if len(missing) == 0 {
+			return k, false, nil
+		}
+		if !bytes.HasSuffix(data, []byte("\n")) {
+			data = append(data, '\n')
+		}
+		data = append(data, missing...)
+		if err := os.WriteFile(path, data, 0600); err != nil {
+			return nil, false, err
+		}
+		k, err = keys.ParseKeyring(data)
+		return k, false, err
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return nil, false, err
+	}
+
+	key, err := keys.GenerateKey()
+	if err != nil {
+		return nil, false, err
+	}
+	primary := base64.StdEncoding.EncodeToString(key) + "\n"
+	k, err := keys.ParseKeyring([]byte(primary))
+	if err != nil {
+		return nil, false, err
+	}
+	encoded := append([]byte(primary), k.Missing(current)...)
+	if err := os.WriteFile(path, encoded, 0600); err != nil {
+		return nil, false, err
+	}
+	k, err = keys.ParseKeyring(encoded)
+	return k, true, err
+}
+
+func runBackup(args []string, configPath string) error {
+	flags := flag.NewFlagSet("backup", flag.ContinueOnError)
+	out := flags.String("out", "", `archive to write ("-" for standard output, default a new file in BACKUP_DIR)`)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+	recipients, err := backup.ParseRecipients(cfg.Backup.Recipients)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(cfg.Database.Path); err != nil {
+		return err
+	}
+
+	// Read-only, so a running server is not disturbed and no migrations run
+	db, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?mode=ro&_busy_timeout=5000", cfg.Database.Path))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	switch *out {
+	case "":
+		path, m, err := backup.WriteFile(ctx, db, cfg.Storage.ArtifactsPath, cfg.Backup.Dir, recipients)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Wrote %s (%d files)\n", path, len(m.Files))
+	case "-":
+		_, err = backup.Create(ctx, db, cfg.Storage.ArtifactsPath, os.Stdout, recipients)
+		return err
+	default:
+		f, err := os.OpenFile(*out, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if err != nil {
+			return err
+		}
+		m, err := backup.Create(ctx, db, cfg.Storage.ArtifactsPath, f, recipients)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			os.Remove(*out)
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Wrote %s (%d files)\n", *out, len(m.Files))
+	}
+	return nil
+}
+
+func runRestore(args []string, configPath string) error {
+	flags := flag.NewFlagSet("restore", flag.ContinueOnError)
+	archive := flags.String("archive", "", "backup archive to restore")
+	identityFile := flags.String("identity", "", "age identity file for encrypted archives")
+	verifyOnly := flags.Bool("verify-only", false, "check the archive without restoring it")
+	force := flags.Bool("force", false, "overwrite an existing database and artifacts")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *archive == "" {
+		return errors.New("-archive is required")
+	}
+
+	var identities []age.Identity
+	if *identityFile != "" {
+		f, err := os.Open(*identityFile)
+		if err != nil {
+			return err
+		}
+		identities, err = age.ParseIdentities(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("reading identity: %w", err)
+		}
+	}
+
+	f, err := os.Open(*archive)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	ctx := context.Background()
+	if *verifyOnly {
+		m, err := backup.Verify(ctx, f, identities)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Archive OK: created %s, schema version %d, %d files\n",
+			m.CreatedAt.Format(time.RFC3339), m.SchemaVersion, len(m.Files))
+		return nil
+	}
+
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+	m, err := backup.Restore(ctx, f, identities, cfg.Database.Path, cfg.Storage.ArtifactsPath, *force)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Restored backup from %s (%d files) to %s and %s\n",
+		m.CreatedAt.Format(time.RFC3339), len(m.Files), cfg.Database.Path, cfg.Storage.ArtifactsPath)
+	return nil
+}
+
+func setRole(args []string, configPath string) error {
+	flags := flag.NewFlagSet("set-role", flag.ContinueOnError)
+	email := flags.String("email", "", "email address of the account")
+	roleName := flags.String("role", "", "admin or user")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	role := core.UserRole(strings.ToUpper(*roleName))
+	if *email == "" || (role != core.RoleAdmin && role != core.RoleUser) {
+		return errors.New("-email and -role (admin or user) are required")
+	}
+
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+	storage, err := openStorage(cfg)
+	if err != nil {
+		return err
+	}
+	defer storage.Close()
+
+	s := store.NewStore(storage.DB())
+	ctx := context.Background()
+	user, err := s.GetUserByEmail(ctx, *email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("no account with email %s", *email)
+	}
+	if err != nil {
+		return err
+	}
+	if err := s.SetUserRoleTx(ctx, user.ID, role); err != nil {
+		return err
+	}
+
+	fmt.Printf("%s is now %s\n", *email, strings.ToLower(string(role)))
+	return nil
+}
+
+func healthcheck(args []string, configPath string) error {
+	flags := flag.NewFlagSet("healthcheck", flag.ContinueOnError)
+	timeout := flags.Duration("timeout", 5*time.Second, "how long to wait for the server")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+	url, err := readyURL(cfg.Server)
+	if err != nil {
+		return err
+	}
+
+	client := &http.Client{
+		Timeout: *timeout,
+		// The certificate names the public host, not the loopback address probed here
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	resp, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+		return fmt.Errorf("%s answered %s: %s", url, resp.Status, bytes.TrimSpace(body))
+	}
+	return nil
+}
+
+// Where the server's readiness probe answers locally. Servers listening on
+// every interface are reached over loopback.
+func readyURL(server config.ServerConfig) (string, error) {
+	host, port, err := net.SplitHostPort(server.ListenAddr)
+	if err != nil {
+		return "", err
+	}
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		host = "127.0.0.1"
+		if ip != nil && ip.To4() == nil {
+			host = "::1"
+		}
+	}
+	scheme := "http"
+	if server.TLS.CertFile != "" {
+		scheme = "https"
+	}
+	return scheme + "://" + net.JoinHostPort(host, port) + "/readyz", nil
+}
+
+func decryptBundle(args []string) error {
+	flags := flag.NewFlagSet("decrypt", flag.ContinueOnError)
+	bundlePath := flags.String("bundle", "", "vault bundle (JSON) to decrypt")
+	outDir := flags.String("out", "", "directory the decrypted artifacts are written to")
+	passphraseFile := flags.String("passphrase-file", "", "file holding the vault passphrase")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *bundlePath == "" || *outDir == "" {
+		return errors.New("-bundle and -out are required")
+	}
+
+	raw, err := os.ReadFile(*bundlePath)
+	if err != nil {
+		return err
+	}
+	vault, err := parseVaultBundle(raw)
+	if err != nil {
+		return fmt.Errorf("reading bundle: %w", err)
+	}
+	if vault.KdfSalt == "" {
+		return errors.New("bundle has no kdf_salt")
+	}
+
+	if vault.Hint != "" {
+		fmt.Printf("Passphrase hint: %s\n", vault.Hint)
+	}
+	passphrase, err := readPassphrase(*passphraseFile)
+	if err != nil {
+		return err
+	}
+	key, err := vaultcrypt.DeriveKey(passphrase, vault.KdfSalt, vault.KDF)
+	if err != nil {
+		return err
+	}
+
+	// Decrypt everything first, so a wrong passphrase leaves nothing on disk
+	plaintexts := make([][]byte, len(vault.Artifacts))
+	for i, a := range vault.Artifacts {
+		if plaintexts[i], err = vaultcrypt.Decrypt(key, a.IV, a.EncryptedBlob); err != nil {
+			return fmt.Errorf("artifact %s: %w", a.ID, err)
+		}
+	}
+
+	if err := os.MkdirAll(*outDir, 0700); err != nil {
+		return err
+	}
+	for i, a := range vault.Artifacts {
+		ext := ".bin"
+		if a.MessageType == core.MsgText {
+			ext = ".txt"
+		}
+		name := filepath.Join(*outDir, fmt.Sprintf("%03d-%s%s", i+1, filepath.Base(a.ID), ext))
+		if err := os.WriteFile(name, plaintexts[i], 0600); err != nil {
+			return err
+		}
+		fmt.Println(name)
+	}
+
+	fmt.Printf("Decrypted %d artifact(s) from vault %q\n", len(vault.Artifacts), vault.VaultName)
+	return nil
+}
+
+// Accepts an exported tar bundle or the JSON of an artifacts endpoint
+func parseVaultBundle(raw []byte) (vaultcrypt.Bundle, error) {
+	var out vaultcrypt.Bundle
+	if !bundle.IsBundle(raw) {
+		err := json.Unmarshal(raw, &out)
+		return out, err
+	}
+
+	b, err := bundle.Read(bytes.NewReader(raw))
+	if err != nil {
+		return out, err
+	}
+	m := b.Manifest
+	out = vaultcrypt.Bundle{
+		VaultName: m.Vault.Name,
+		Hint:      m.Vault.Hint,
+		KdfSalt:   m.Vault.KdfSalt,
+		KDF:       m.Vault.KDF,
+		CreatedAt: m.Vault.CreatedAt,
+	}
+	for _, a := range m.Artifacts {
+		out.Artifacts = append(out.Artifacts, vaultcrypt.BundleArtifact{
+			ID:            a.ID,
+			MessageType:   a.MessageType,
+			EncryptedBlob: b.Blobs[a.ID],
+			IV:            a.IV,
+			CreatedAt:     a.CreatedAt,
+		})
+	}
+	return out, nil
+}
+
+func readPassphrase(path string) (string, error) {
+	var raw string
+	switch {
+	case path != "":
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		raw = string(b)
+	case os.Getenv("AFTERLIGHT_PASSPHRASE") != "":
+		raw = os.Getenv("AFTERLIGHT_PASSPHRASE")
+	default:
+		fmt.Fprint(os.Stderr, "Passphrase: ")
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return "", errors.New("no passphrase given")
+		}
+		raw = line
+	}
+
+	passphrase := strings.TrimRight(raw, "\r\n")
+	if passphrase == "" {
+		return "", errors.New("passphrase is empty")
+	}
+	return passphrase, nil
+}
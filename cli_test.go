@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/vmpyr/afterlight/internal/config"
+)
+
+// The decrypt command opens what the web client encrypted, saved as the
+// artifacts endpoint returns it
+func TestDecryptBundleWebClientFixture(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out")
+	err := decryptBundle([]string{
+		"-bundle", "internal/vaultcrypt/testdata/web-client.json",
+		"-passphrase-file", "internal/vaultcrypt/testdata/web-client.passphrase",
+		"-out", out,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	text, err := os.ReadFile(filepath.Join(out, "001-text.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "The seed phrase is in the blue folder.\nÜmlauts and emoji 🔑 survive."; string(text) != want {
+		t.Fatalf("text artifact = %q, want %q", text, want)
+	}
+	binary, err := os.ReadFile(filepath.Join(out, "002-binary.bin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := make([]byte, 300)
+	for i := range want {
+		want[i] = byte(i*37 + 11)
+	}
+	if !bytes.Equal(binary, want) {
+		t.Fatalf("binary artifact = %x, want %x", binary, want)
+	}
+}
+
+func TestDecryptBundleWrongPassphraseWritesNothing(t *testing.T) {
+	dir := t.TempDir()
+	passphrase := filepath.Join(dir, "passphrase")
+	if err := os.WriteFile(passphrase, []byte("not the passphrase\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "out")
+	err := decryptBundle([]string{
+		"-bundle", "internal/vaultcrypt/testdata/web-client.json",
+		"-passphrase-file", passphrase,
+		"-out", out,
+	})
+	if err == nil {
+		t.Fatal("wrong passphrase was accepted")
+	}
+	if _, err := os.Stat(out); !os.IsNotExist(err) {
+		t.Fatalf("output directory exists after a failed decrypt: %v", err)
+	}
+}
+
+func TestReadyURL(t *testing.T) {
+	tls := config.TLSConfig{CertFile: "cert.pem", KeyFile: "key.pem"}
+	tests := []struct {
+		server config.ServerConfig
+		want   string
+	}{
+		{config.ServerConfig{ListenAddr: ":8080"}, "http://127.0.0.1:8080/readyz"},
+		{config.ServerConfig{ListenAddr: ":8443", TLS: tls}, "https://127.0.0.1:8443/readyz"},
+		{config.ServerConfig{ListenAddr: "0.0.0.0:9000"}, "http://127.0.0.1:9000/readyz"},
+		{config.ServerConfig{ListenAddr: "[::]:9000"}, "http://[::1]:9000/readyz"},
+		{config.ServerConfig{ListenAddr: "10.0.0.5:9000", TLS: tls}, "https://10.0.0.5:9000/readyz"},
+	}
+	for _, tt := range tests {
+		got, err := readyURL(tt.server)
+		if err != nil {
+			t.Fatalf("readyURL(%q): %v", tt.server.ListenAddr, err)
+		}
+		if got != tt.want {
+			t.Errorf("readyURL(%q) = %q, want %q", tt.server.ListenAddr, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,306 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/alexedwards/argon2id"
+	"github.com/go-chi/chi/v5"
+	"github.com/vmpyr/afterlight/internal/core"
+	"github.com/vmpyr/afterlight/internal/liveness"
+	"github.com/vmpyr/afterlight/internal/seal"
+	"github.com/vmpyr/afterlight/internal/store"
+)
+
+type AccountHandler struct {
+	store         *store.Store
+	sealer        *seal.Sealer
+	deletionDelay time.Duration // Time to change one's mind before the account is deleted
+}
+
+func NewAccountHandler(s *store.Store, sealer *seal.Sealer, deletionDelay time.Duration) *AccountHandler {
+	return &AccountHandler{store: s, sealer: sealer, deletionDelay: deletionDelay}
+}
+
+func (h *AccountHandler) Routes(authMiddleware func(http.Handler) http.Handler) chi.Router {
+	r := chi.NewRouter()
+
+	r.Use(authMiddleware)
+
+	r.Get("/export", h.ExportAccount)
+	r.Get("/deletion", h.GetDeletion)
+	r.Post("/deletion", h.ScheduleDeletion)
+	r.Delete("/deletion", h.CancelDeletion)
+
+	return r
+}
+
+// Handlers
+func (h *AccountHandler) ExportAccount(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(UserKey).(*store.User)
+
+	export, err := h.export(r, user)
+	if err != nil {
+		http.Error(w, "Failed to export account", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="afterlight-export-%s.json"`, export.ExportedAt.Format("2006-01-02")))
+	json.NewEncoder(w).Encode(export)
+}
+
+func (h *AccountHandler) export(r *http.Request, u *store.User) (core.AccountExport, error) {
+	ctx := r.Context()
+	export := core.AccountExport{
+		Format:     core.AccountExportFormat,
+		Version:    core.AccountExportVersion,
+		ExportedAt: time.Now().UTC(),
+		Profile:    userResponse(*u),
+		Liveness: core.LivenessExport{
+			LivenessResponse: core.LivenessResponse{
+				CurrentStatus: u.CurrentStatus,
+				LastCheckIn:   u.LastCheckIn,
+				Deadline:      liveness.Deadline(*u),
+				IsPaused:      u.IsPaused,
+			},
+			CheckInInterval:  u.CheckInInterval,
+			TriggerIntervals: u.TriggerIntervalNum,
+			BufferPeriod:     u.BufferPeriod,
+			VerifierQuorum:   u.VerifierQuorum.Int64,
+		},
+		ContactMethods: []core.ContactMethodResponse{},
+		Beneficiaries:  []core.BeneficiaryExport{},
+		Vaults:         []core.VaultExport{},
+		Invites:        []core.InviteResponse{},
+		Identities:     []core.IdentityResponse{},
+		Notifications:  []core.NotificationExport{},
+	}
+
+	policy, err := liveness.LoadPolicy(ctx, h.store, u.ID)
+	if err != nil {
+		return export, err
+	}
+	export.ReminderPolicy = core.ReminderPolicyResponse{
+		PrimaryContactID: policy.PrimaryContactID,
+		Steps:            policy.Steps,
+		QuietHoursStart:  policy.QuietHoursStart,
+		QuietHoursEnd:    policy.QuietHoursEnd,
+		TimeZone:         policy.Location.String(),
+		IsDefault:        policy.IsDefault,
+	}
+
+	contacts, err := h.store.ListContactMethodsByUserID(ctx, sql.NullString{String: u.ID, Valid: true})
+	if err != nil {
+		return export, err
+	}
+	for _, c := range contacts {
+		export.ContactMethods = append(export.ContactMethods, contactResponse(c))
+	}
+
+	beneficiaries, err := h.store.ListBeneficiariesByUser(ctx, u.ID)
+	if err != nil {
+		return export, err
+	}
+	for _, b := range beneficiaries {
+		contacts, err := h.store.ListContactMethodsByBeneficiaryID(ctx, sql.NullString{String: b.ID, Valid: true})
+		if err != nil {
+			return export, err
+		}
+		item := core.BeneficiaryExport{BeneficiaryResponse: beneficiaryResponse(b), ContactMethods: []core.ContactMethodResponse{}}
+		for _, c := range contacts {
+			item.ContactMethods = append(item.ContactMethods, contactResponse(c))
+		}
+		export.Beneficiaries = append(export.Beneficiaries, item)
+	}
+
+	vaults, err := h.store.GetVaultsByUser(ctx, u.ID)
+	if err != nil {
+		return export, err
+	}
+	for _, v := range vaults {
+		item, err := h.exportVault(r, v)
+		if err != nil {
+			return export, err
+		}
+		export.Vaults = append(export.Vaults, item)
+	}
+
+	invites, err := h.store.ListInvitesByCreator(ctx, u.ID)
+	if err != nil {
+		return export, err
+	}
+	for _, i := range invites {
+		export.Invites = append(export.Invites, inviteResponse(i))
+	}
+
+	identities, err := h.store.ListUserIdentities(ctx, u.ID)
+	if err != nil {
+		return export, err
+	}
+	for _, i := range identities {
+		export.Identities = append(export.Identities, identityResponse(i))
+	}
+
+	notifications, err := h.store.ListNotificationsByUser(ctx, u.ID)
+	if err != nil {
+		return export, err
+	}
+	for _, n := range notifications {
+		item := core.NotificationExport{
+			ID:              n.ID,
+			ContactMethodID: n.ContactMethodID,
+			Event:           n.Event,
+			Subject:         string(n.Subject),
+			Status:          n.Status,
+			Attempts:        n.Attempts,
+			CreatedAt:       n.CreatedAt,
+		}
+		if n.SentAt.Valid {
+			item.SentAt = &n.SentAt.Time
+		}
+		export.Notifications = append(export.Notifications, item)
+	}
+	return export, nil
+}
+
+// Sealed material is opened, so the export holds what the client uploaded
+func (h *AccountHandler) exportVault(r *http.Request, v store.Vault) (core.VaultExport, error) {
+	hint, err := h.sealer.OpenString(v, "hint", sql.NullString(v.Hint))
+	if err != nil {
+		return core.VaultExport{}, err
+	}
+	item := core.VaultExport{
+		ID:             v.ID,
+		VaultName:      v.VaultName,
+		Hint:           hint.String,
+		KdfSalt:        v.KdfSalt,
+		KDF:            v.KdfParams,
+		Sealed:         v.SealedKey.Valid,
+		ShareThreshold: v.ShareThreshold.Int64,
+		ShareCount:     v.ShareCount.Int64,
+		Access:         []core.VaultAccessExport{},
+		Artifacts:      []core.ArtifactExport{},
+		CreatedAt:      v.CreatedAt,
+	}
+
+	access, err := h.store.ListVaultAccess(r.Context(), v.ID)
+	if err != nil {
+		return item, err
+	}
+	for _, a := range access {
+		share, err := h.sealer.OpenString(v, "encrypted_share/"+a.BeneficiaryID, a.EncryptedShare)
+		if err != nil {
+			return item, err
+		}
+		wrapped, err := h.sealer.OpenString(v, "wrapped_key/"+a.BeneficiaryID, a.WrappedKey)
+		if err != nil {
+			return item, err
+		}
+		item.Access = append(item.Access, core.VaultAccessExport{
+			BeneficiaryID:  a.BeneficiaryID,
+			ShareIndex:     a.ShareIndex.Int64,
+			EncryptedShare: share.String,
+			WrappedKey:     wrapped.String,
+			GrantedAt:      a.GrantedAt,
+		})
+	}
+
+	artifacts, err := h.store.ListArtifactsByVaultID(r.Context(), v.ID)
+	if err != nil {
+		return item, err
+	}
+	for _, a := range artifacts {
+		blob, err := h.sealer.Open(v, "artifact/"+a.ID, a.EncryptedBlob)
+		if err != nil {
+			return item, err
+		}
+		item.Artifacts = append(item.Artifacts, core.ArtifactExport{
+			ID:              a.ID,
+			MessageType:     a.MessageType,
+			EncryptedBlob:   blob,
+			IV:              a.Iv,
+			EnvelopeVersion: a.EnvelopeVersion,
+			CreatedAt:       a.CreatedAt,
+		})
+	}
+	return item, nil
+}
+
+func (h *AccountHandler) GetDeletion(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(UserKey).(*store.User)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(deletionResponse(*user))
+}
+
+// Until the delay has passed the owner can cancel, and can still log in to
+// do so. Beneficiaries are only told once the deletion goes ahead.
+func (h *AccountHandler) ScheduleDeletion(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(UserKey).(*store.User)
+
+	var req core.DeleteAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if !strings.EqualFold(strings.TrimSpace(req.Email), user.Email) {
+		http.Error(w, core.ErrDeletionConfirmation.Error(), http.StatusBadRequest)
+		return
+	}
+	// Behind forward auth the proxy has just authenticated the user, and
+	// provisioned accounts have no password they know
+	if !forwardAuthenticated(r) {
+		match, err := argon2id.ComparePasswordAndHash(req.Password, user.PasswordHash)
+		if err != nil {
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		if !match {
+			http.Error(w, core.ErrDeletionConfirmation.Error(), http.StatusForbidden)
+			return
+		}
+	}
+
+	due := time.Now().UTC().Add(h.deletionDelay)
+	if err := h.store.ScheduleDeletionTx(r.Context(), user.ID, due); err != nil {
+		switch {
+		case errors.Is(err, core.ErrLastAdmin), errors.Is(err, core.ErrDeletionInProgress):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, "Failed to schedule deletion", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(core.AccountDeletionResponse{Scheduled: true, DeletionDueAt: &due})
+}
+
+func (h *AccountHandler) CancelDeletion(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(UserKey).(*store.User)
+
+	n, err := h.store.CancelUserDeletion(r.Context(), user.ID)
+	if err != nil {
+		http.Error(w, "Failed to cancel deletion", http.StatusInternalServerError)
+		return
+	}
+	if n == 0 {
+		http.Error(w, "No deletion is scheduled", http.StatusConflict)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func deletionResponse(u store.User) core.AccountDeletionResponse {
+	if !u.DeletionDueAt.Valid {
+		return core.AccountDeletionResponse{}
+	}
+	return core.AccountDeletionResponse{Scheduled: true, DeletionDueAt: &u.DeletionDueAt.Time}
+}
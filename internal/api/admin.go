@@ -0,0 +1,292 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/vmpyr/afterlight/internal/core"
+	"github.com/vmpyr/afterlight/internal/health"
+	"github.com/vmpyr/afterlight/internal/liveness"
+	"github.com/vmpyr/afterlight/internal/store"
+)
+
+type AdminHandler struct {
+	store        *store.Store
+	checker      *health.Checker
+	backup       *BackupHandler
+	registration core.RegistrationMode // Configured default, see AuthHandler
+	quotas       core.QuotaLimits      // Configured defaults, see VaultHandler
+}
+
+func NewAdminHandler(s *store.Store, checker *health.Checker, backup *BackupHandler, registration core.RegistrationMode, quotas core.QuotaLimits) *AdminHandler {
+	return &AdminHandler{store: s, checker: checker, backup: backup, registration: registration, quotas: quotas}
+}
+
+func (h *AdminHandler) Routes(authMiddleware func(http.Handler) http.Handler) chi.Router {
+	r := chi.NewRouter()
+
+	r.Use(authMiddleware)
+	r.Use(AdminMiddleware)
+
+	r.Get("/users", h.ListUsers)
+	r.Post("/users/{id}/disable", h.DisableUser)
+	r.Post("/users/{id}/enable", h.EnableUser)
+	r.Post("/users/{id}/logout", h.LogoutUser)
+	r.Get("/users/{id}/quota", h.GetUserQuota)
+	r.Put("/users/{id}/quota", h.SetUserQuota)
+	r.Delete("/users/{id}/quota", h.ClearUserQuota)
+	r.Get("/settings/registration", h.GetRegistration)
+	r.Put("/settings/registration", h.SetRegistration)
+	r.Get("/health", h.Health)
+	r.Post("/backup", h.backup.CreateBackup)
+
+	return r
+}
+
+// Handlers
+func (h *AdminHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	users, err := h.store.ListUsers(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to list users", http.StatusInternalServerError)
+		return
+	}
+	counts, err := h.store.CountActiveSessionsByUser(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to count sessions", http.StatusInternalServerError)
+		return
+	}
+	sessions := make(map[string]int64, len(counts))
+	for _, c := range counts {
+		sessions[c.UserID] = c.Count
+	}
+
+	resp := make([]core.AdminUserResponse, 0, len(users))
+	for _, u := range users {
+		item := core.AdminUserResponse{
+			ID:             u.ID,
+			Name:           string(u.Name),
+			Email:          u.Email,
+			Role:           u.Role,
+			CurrentStatus:  u.CurrentStatus,
+			IsPaused:       u.IsPaused,
+			LastCheckIn:    u.LastCheckIn,
+			Deadline:       liveness.Deadline(u),
+			Disabled:       u.DisabledAt.Valid,
+			ActiveSessions: sessions[u.ID],
+			CreatedAt:      u.CreatedAt,
+		}
+		if u.DisabledAt.Valid {
+			item.DisabledAt = &u.DisabledAt.Time
+		}
+		if u.DeletionDueAt.Valid {
+			item.DeletionDueAt = &u.DeletionDueAt.Time
+		}
+		resp = append(resp, item)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (h *AdminHandler) DisableUser(w http.ResponseWriter, r *http.Request) {
+	admin := r.Context().Value(UserKey).(*store.User)
+	userID := chi.URLParam(r, "id")
+	if userID == admin.ID {
+		http.Error(w, "You cannot disable your own account", http.StatusBadRequest)
+		return
+	}
+
+	changed, err := h.store.DisableUserTx(r.Context(), userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			http.Error(w, "User not found", http.StatusNotFound)
+		case errors.Is(err, core.ErrLastAdmin):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, "Failed to disable user", http.StatusInternalServerError)
+		}
+		return
+	}
+	if !changed {
+		http.Error(w, "User is already disabled", http.StatusConflict)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *AdminHandler) EnableUser(w http.ResponseWriter, r *http.Request) {
+	userID := chi.URLParam(r, "id")
+	if _, err := h.store.GetUserByID(r.Context(), userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to enable user", http.StatusInternalServerError)
+		return
+	}
+
+	changed, err := h.store.EnableUserAccountTx(r.Context(), userID)
+	if err != nil {
+		http.Error(w, "Failed to enable user", http.StatusInternalServerError)
+		return
+	}
+	if !changed {
+		http.Error(w, "User is not disabled or is being deleted", http.StatusConflict)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Ends every session of the user; the account itself stays usable
+func (h *AdminHandler) LogoutUser(w http.ResponseWriter, r *http.Request) {
+	userID := chi.URLParam(r, "id")
+	if _, err := h.store.GetUserByID(r.Context(), userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to log out user", http.StatusInternalServerError)
+		return
+	}
+
+	n, err := h.store.DeleteUserSessions(r.Context(), userID)
+	if err != nil {
+		http.Error(w, "Failed to log out user", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int64{"sessions_ended": n})
+}
+
+// Usage, effective limits and the overrides that produced them
+func (h *AdminHandler) GetUserQuota(w http.ResponseWriter, r *http.Request) {
+	userID := chi.URLParam(r, "id")
+	if _, err := h.store.GetUserByID(r.Context(), userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to load quota", http.StatusInternalServerError)
+		return
+	}
+
+	resp, err := usageResponse(r.Context(), h.store, userID, h.quotas)
+	if err != nil {
+		http.Error(w, "Failed to load quota", http.StatusInternalServerError)
+		return
+	}
+	if resp.Overrides == nil {
+		resp.Overrides = &core.QuotaOverrides{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// Replaces the user's overrides. Omitted or null fields fall back to the
+// server default; 0 lifts the limit. Existing data over a lowered limit is
+// kept, only further writes are refused.
+func (h *AdminHandler) SetUserQuota(w http.ResponseWriter, r *http.Request) {
+	var req core.QuotaOverrides
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	userID := chi.URLParam(r, "id")
+	if _, err := h.store.GetUserByID(r.Context(), userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to save quota", http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.store.SetUserQuota(r.Context(), userID, req); err != nil {
+		if errors.Is(err, core.ErrInvalidQuota) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "Failed to save quota", http.StatusInternalServerError)
+		return
+	}
+	h.GetUserQuota(w, r)
+}
+
+// Returns the user to the server defaults
+func (h *AdminHandler) ClearUserQuota(w http.ResponseWriter, r *http.Request) {
+	if err := h.store.DeleteUserQuota(r.Context(), chi.URLParam(r, "id")); err != nil {
+		http.Error(w, "Failed to clear quota", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *AdminHandler) GetRegistration(w http.ResponseWriter, r *http.Request) {
+	mode, err := h.store.RegistrationMode(r.Context(), h.registration)
+	if err != nil {
+		http.Error(w, "Failed to load settings", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(core.RegistrationSettings{Mode: mode})
+}
+
+func (h *AdminHandler) SetRegistration(w http.ResponseWriter, r *http.Request) {
+	var req core.RegistrationSettings
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.store.SetRegistrationMode(r.Context(), req.Mode); err != nil {
+		if errors.Is(err, core.ErrInvalidRegistrationMode) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "Failed to save settings", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(req)
+}
+
+// The readiness report plus instance-wide counts. Unlike /readyz this always
+// answers 200; the status is in the body.
+func (h *AdminHandler) Health(w http.ResponseWriter, r *http.Request) {
+	resp := core.AdminHealthResponse{
+		HealthResponse: h.checker.Report(r.Context()),
+		UsersByStatus:  map[core.UserStatus]int64{},
+	}
+
+	users, err := h.store.ListUsers(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to list users", http.StatusInternalServerError)
+		return
+	}
+	for _, u := range users {
+		resp.UsersByStatus[u.CurrentStatus]++
+		if u.DisabledAt.Valid {
+			resp.DisabledUsers++
+		}
+	}
+	if resp.PendingNotifications, err = h.store.CountPendingNotifications(r.Context()); err != nil {
+		http.Error(w, "Failed to count notifications", http.StatusInternalServerError)
+		return
+	}
+	if resp.RegistrationMode, err = h.store.RegistrationMode(r.Context(), h.registration); err != nil {
+		http.Error(w, "Failed to load settings", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
@@ -15,11 +15,15 @@ import (
 )
 
 type AuthHandler struct {
-	store *store.Store
+	store        *store.Store
+	sessionTTL   time.Duration
+	defaults     store.UserDefaults
+	registration core.RegistrationMode // Used until an admin changes it
+	forwardAuth  *ForwardAuth          // nil unless behind an authenticating proxy
 }
 
-func NewAuthHandler(s *store.Store) *AuthHandler {
-	return &AuthHandler{store: s}
+func NewAuthHandler(s *store.Store, sessionTTL time.Duration, defaults store.UserDefaults, registration core.RegistrationMode, forwardAuth *ForwardAuth) *AuthHandler {
+	return &AuthHandler{store: s, sessionTTL: sessionTTL, defaults: defaults, registration: registration, forwardAuth: forwardAuth}
 }
 
 func (h *AuthHandler) Routes() chi.Router {
@@ -47,29 +51,51 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	open, err := h.registrationOpen(r)
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	var invite *store.Invite
+	if !open {
+		if req.InviteToken == "" {
+			http.Error(w, core.ErrRegistrationClosed.Error(), http.StatusForbidden)
+			return
+		}
+		if invite, err = h.store.LookupInvite(r.Context(), req.InviteToken, req.Email); err != nil {
+			if errors.Is(err, core.ErrInvalidInvite) || errors.Is(err, core.ErrInviteEmailMismatch) {
+				http.Error(w, err.Error(), http.StatusForbidden)
+				return
+			}
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+	}
+
 	user, err := h.store.CreateUserTx(r.Context(), core.RegisterRequest{
 		Name:     req.Name,
 		Email:    req.Email,
 		Password: req.Password,
-	})
+	}, h.defaults, invite)
 
 	if err != nil {
+		if errors.Is(err, core.ErrInvalidInvite) {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
 		// TODO: Handle specific errors (e.g., duplicate email)
 		http.Error(w, "Registration failed: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	h.RefreshCookie(w, r, &user)
+	if err := h.startSession(w, r, &user); err != nil {
+		http.Error(w, "Failed to create session", http.StatusInternalServerError)
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(core.UserResponse{
-		ID:            user.ID,
-		Name:          user.Name,
-		Email:         user.Email,
-		CurrentStatus: user.CurrentStatus,
-		CreatedAt:     user.CreatedAt,
-	})
+	json.NewEncoder(w).Encode(userResponse(user))
 }
 
 func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
@@ -98,18 +124,19 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Incorrect password", http.StatusUnauthorized)
 		return
 	}
+	if user.DisabledAt.Valid {
+		http.Error(w, core.ErrAccountDisabled.Error(), http.StatusForbidden)
+		return
+	}
 
-	h.RefreshCookie(w, r, &user)
+	if err := h.startSession(w, r, &user); err != nil {
+		http.Error(w, "Failed to create session", http.StatusInternalServerError)
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(core.UserResponse{
-		ID:            user.ID,
-		Name:          user.Name,
-		Email:         user.Email,
-		CurrentStatus: user.CurrentStatus,
-		CreatedAt:     user.CreatedAt,
-	})
+	json.NewEncoder(w).Encode(userResponse(user))
 }
 
 func (h *AuthHandler) GetCurrentUser(w http.ResponseWriter, r *http.Request) {
@@ -119,13 +146,7 @@ func (h *AuthHandler) GetCurrentUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	json.NewEncoder(w).Encode(core.UserResponse{
-		ID:            userCtx.ID,
-		Name:          userCtx.Name,
-		Email:         userCtx.Email,
-		CurrentStatus: userCtx.CurrentStatus,
-		CreatedAt:     userCtx.CreatedAt,
-	})
+	json.NewEncoder(w).Encode(userResponse(*userCtx))
 }
 
 func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
@@ -142,6 +163,7 @@ func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
 		Value:    "",
 		Expires:  time.Unix(0, 0),
 		HttpOnly: true,
+		Secure:   IsHTTPS(r),
 		Path:     "/",
 		SameSite: http.SameSiteLaxMode,
 	})
@@ -150,13 +172,25 @@ func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte("Logged out"))
 }
 
-func (h *AuthHandler) RefreshCookie(w http.ResponseWriter, r *http.Request, user *store.User) {
+// Registration is always open on an instance without accounts, so the
+// first user (who becomes its admin) can sign up
+func (h *AuthHandler) registrationOpen(r *http.Request) (bool, error) {
+	mode, err := h.store.RegistrationMode(r.Context(), h.registration)
+	if err != nil || mode == core.RegistrationOpen {
+		return err == nil, err
+	}
+	users, err := h.store.CountUsers(r.Context())
+	return users == 0, err
+}
+
+// Replaces the request's session, if any, with a new one for user
+func (h *AuthHandler) startSession(w http.ResponseWriter, r *http.Request, user *store.User) error {
 	if oldCookie, err := r.Cookie("session_token"); err == nil {
 		_ = h.store.DeleteSession(r.Context(), oldCookie.Value)
 	}
 
 	token := uuid.New().String()
-	expiresAt := time.Now().Add(30 * 24 * time.Hour) // 30 days
+	expiresAt := time.Now().Add(h.sessionTTL)
 
 	_, err := h.store.CreateSession(r.Context(), store.CreateSessionParams{
 		Token:     token,
@@ -164,8 +198,7 @@ func (h *AuthHandler) RefreshCookie(w http.ResponseWriter, r *http.Request, user
 		ExpiresAt: expiresAt,
 	})
 	if err != nil {
-		http.Error(w, "Failed to create session", http.StatusInternalServerError)
-		return
+		return err
 	}
 
 	http.SetCookie(w, &http.Cookie{
@@ -173,8 +206,24 @@ func (h *AuthHandler) RefreshCookie(w http.ResponseWriter, r *http.Request, user
 		Value:    token,
 		Expires:  expiresAt,
 		HttpOnly: true,
-		Secure:   false, // TODO: Set to true in production with HTTPS
+		Secure:   IsHTTPS(r),
 		Path:     "/",
 		SameSite: http.SameSiteLaxMode,
 	})
+	return nil
+}
+
+func userResponse(u store.User) core.UserResponse {
+	resp := core.UserResponse{
+		ID:            u.ID,
+		Name:          string(u.Name),
+		Email:         u.Email,
+		Role:          u.Role,
+		CurrentStatus: u.CurrentStatus,
+		CreatedAt:     u.CreatedAt,
+	}
+	if u.DeletionDueAt.Valid {
+		resp.DeletionDueAt = &u.DeletionDueAt.Time
+	}
+	return resp
 }
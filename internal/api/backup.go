@@ -0,0 +1,81 @@
+package api
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"filippo.io/age"
+	"github.com/go-chi/chi/v5"
+	"github.com/vmpyr/afterlight/internal/backup"
+)
+
+type BackupHandler struct {
+	db            *sql.DB
+	artifactsPath string
+	recipients    []age.Recipient
+	token         string
+}
+
+func NewBackupHandler(db *sql.DB, artifactsPath string, recipients []age.Recipient, token string) *BackupHandler {
+	return &BackupHandler{db: db, artifactsPath: artifactsPath, recipients: recipients, token: token}
+}
+
+func (h *BackupHandler) Routes() chi.Router {
+	r := chi.NewRouter()
+
+	r.Use(h.requireToken)
+
+	r.Post("/", h.CreateBackup)
+
+	return r
+}
+
+// Requires "Authorization: Bearer <BACKUP_API_TOKEN>"
+func (h *BackupHandler) requireToken(next http.Handler) http.Handler {
+	want := []byte("Bearer " + h.token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if h.token == "" || subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), want) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="backup"`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Handlers
+
+// Streams a fresh archive. Once the body has started a failure can only be
+// signalled by cutting the response short, which leaves the archive without
+// its manifest and so fails verification.
+func (h *BackupHandler) CreateBackup(w http.ResponseWriter, r *http.Request) {
+	name := backup.FileName(time.Now(), len(h.recipients) > 0)
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+
+	out := &startedWriter{w: w}
+	if _, err := backup.Create(r.Context(), h.db, h.artifactsPath, out, h.recipients); err != nil {
+		slog.ErrorContext(r.Context(), "backup failed", "error", err)
+		if !out.started {
+			w.Header().Del("Content-Disposition")
+			http.Error(w, "Backup failed", http.StatusInternalServerError)
+			return
+		}
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// Notes whether any of the body has been sent
+type startedWriter struct {
+	w       http.ResponseWriter
+	started bool
+}
+
+func (s *startedWriter) Write(p []byte) (int, error) {
+	s.started = true
+	return s.w.Write(p)
+}
@@ -0,0 +1,155 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/vmpyr/afterlight/internal/core"
+	"github.com/vmpyr/afterlight/internal/store"
+)
+
+type BeneficiaryHandler struct {
+	store    *store.Store
+	contacts *ContactHandler
+}
+
+func NewBeneficiaryHandler(s *store.Store, contacts *ContactHandler) *BeneficiaryHandler {
+	return &BeneficiaryHandler{store: s, contacts: contacts}
+}
+
+func (h *BeneficiaryHandler) Routes(authMiddleware func(http.Handler) http.Handler) chi.Router {
+	r := chi.NewRouter()
+
+	r.Use(authMiddleware)
+
+	r.Post("/", h.CreateBeneficiary)
+	r.Get("/", h.ListBeneficiaries)
+	r.Put("/{id}/public-key", h.SetPublicKey)
+	r.Route("/{id}/contact-methods", func(r chi.Router) {
+		r.Use(h.beneficiaryContactOwner)
+		h.contacts.register(r)
+	})
+
+	return r
+}
+
+// Scopes contact method routes to a beneficiary owned by the logged-in user
+func (h *BeneficiaryHandler) beneficiaryContactOwner(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value(UserKey).(*store.User).ID
+
+		beneficiary, err := h.store.GetBeneficiaryByID(r.Context(), store.GetBeneficiaryByIDParams{
+			ID:     chi.URLParam(r, "id"),
+			UserID: userID,
+		})
+		if err != nil {
+			http.Error(w, "Beneficiary not found", http.StatusNotFound)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), ContactOwnerKey, contactOwner{
+			UserID:        userID,
+			BeneficiaryID: beneficiary.ID,
+		})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// Handlers
+func (h *BeneficiaryHandler) CreateBeneficiary(w http.ResponseWriter, r *http.Request) {
+	var req core.CreateBeneficiaryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.BeneficiaryName == "" {
+		http.Error(w, "Beneficiary name is required", http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value(UserKey).(*store.User).ID
+
+	beneficiary, err := h.store.CreateBeneficiary(r.Context(), store.CreateBeneficiaryParams{
+		ID:              uuid.New().String(),
+		UserID:          userID,
+		BeneficiaryName: core.SecretString(req.BeneficiaryName),
+		IsVerifier:      sql.NullBool{Bool: req.IsVerifier, Valid: true},
+	})
+	if err != nil {
+		http.Error(w, "Failed to create beneficiary", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(beneficiaryResponse(beneficiary))
+}
+
+func (h *BeneficiaryHandler) ListBeneficiaries(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(UserKey).(*store.User).ID
+
+	beneficiaries, err := h.store.ListBeneficiariesByUser(r.Context(), userID)
+	if err != nil {
+		http.Error(w, "Failed to retrieve beneficiaries", http.StatusInternalServerError)
+		return
+	}
+
+	resp := make([]core.BeneficiaryResponse, 0, len(beneficiaries))
+	for _, b := range beneficiaries {
+		resp = append(resp, beneficiaryResponse(b))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// Registers the beneficiary's age X25519 recipient. Vault keys wrapped to a
+// previous key are dropped, since the beneficiary may no longer be able to open them.
+func (h *BeneficiaryHandler) SetPublicKey(w http.ResponseWriter, r *http.Request) {
+	var req core.SetPublicKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := core.IsValidPublicKey(req.PublicKey); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value(UserKey).(*store.User).ID
+
+	beneficiary, err := h.store.GetBeneficiaryByID(r.Context(), store.GetBeneficiaryByIDParams{
+		ID:     chi.URLParam(r, "id"),
+		UserID: userID,
+	})
+	if err != nil {
+		http.Error(w, "Beneficiary not found", http.StatusNotFound)
+		return
+	}
+
+	if beneficiary.PublicKey.String != req.PublicKey {
+		if err := h.store.SetBeneficiaryPublicKeyTx(r.Context(), beneficiary.ID, req.PublicKey); err != nil {
+			http.Error(w, "Failed to save public key", http.StatusInternalServerError)
+			return
+		}
+		beneficiary.PublicKey = sql.NullString{String: req.PublicKey, Valid: true}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(beneficiaryResponse(beneficiary))
+}
+
+func beneficiaryResponse(b store.Beneficiary) core.BeneficiaryResponse {
+	return core.BeneficiaryResponse{
+		ID:              b.ID,
+		BeneficiaryName: string(b.BeneficiaryName),
+		IsVerifier:      b.IsVerifier.Bool,
+		HasConfirmed:    b.HasConfirmed.Bool,
+		PublicKey:       b.PublicKey.String,
+		CreatedAt:       b.CreatedAt,
+	}
+}
@@ -0,0 +1,296 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/vmpyr/afterlight/internal/core"
+	"github.com/vmpyr/afterlight/internal/notify"
+	"github.com/vmpyr/afterlight/internal/store"
+)
+
+const (
+	ContactOwnerKey ContextKey = "contact_owner"
+
+	verificationCodeTTL = 15 * time.Minute
+	maxVerifyAttempts   = 5
+)
+
+// Who the contact methods under the current route belong to.
+// BeneficiaryID is empty for the logged-in user's own contact methods.
+type contactOwner struct {
+	UserID        string
+	BeneficiaryID string
+}
+
+type ContactHandler struct {
+	store    *store.Store
+	notifier *notify.Notifier
+}
+
+func NewContactHandler(s *store.Store, n *notify.Notifier) *ContactHandler {
+	return &ContactHandler{store: s, notifier: n}
+}
+
+// Routes for the logged-in user's own contact methods
+func (h *ContactHandler) Routes(authMiddleware func(http.Handler) http.Handler) chi.Router {
+	r := chi.NewRouter()
+
+	r.Use(authMiddleware)
+	r.Use(userContactOwner)
+	h.register(r)
+
+	return r
+}
+
+func (h *ContactHandler) register(r chi.Router) {
+	r.Get("/", h.ListContactMethods)
+	r.Post("/", h.CreateContactMethod)
+	r.Delete("/{contactID}", h.DeleteContactMethod)
+	r.Post("/{contactID}/verify", h.SendVerificationCode)
+	r.Post("/{contactID}/confirm", h.ConfirmVerificationCode)
+	r.Post("/{contactID}/test", h.SendTestNotification)
+}
+
+func userContactOwner(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value(UserKey).(*store.User).ID
+		ctx := context.WithValue(r.Context(), ContactOwnerKey, contactOwner{UserID: userID})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// Handlers
+func (h *ContactHandler) ListContactMethods(w http.ResponseWriter, r *http.Request) {
+	owner := r.Context().Value(ContactOwnerKey).(contactOwner)
+
+	var contacts []store.ContactMethod
+	var err error
+	if owner.BeneficiaryID != "" {
+		contacts, err = h.store.ListContactMethodsByBeneficiaryID(r.Context(), sql.NullString{String: owner.BeneficiaryID, Valid: true})
+	} else {
+		contacts, err = h.store.ListContactMethodsByUserID(r.Context(), sql.NullString{String: owner.UserID, Valid: true})
+	}
+	if err != nil {
+		http.Error(w, "Failed to retrieve contact methods", http.StatusInternalServerError)
+		return
+	}
+
+	resp := make([]core.ContactMethodResponse, 0, len(contacts))
+	for _, c := range contacts {
+		resp = append(resp, contactResponse(c))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (h *ContactHandler) CreateContactMethod(w http.ResponseWriter, r *http.Request) {
+	var req core.CreateContactMethodRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	owner := r.Context().Value(ContactOwnerKey).(contactOwner)
+
+	if err := core.IsValidContactDestination(req.Channel, req.Destination, req.Metadata); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Metadata == nil {
+		req.Metadata = core.Metadata{}
+	}
+
+	params := store.CreateContactMethodParams{
+		ID:          uuid.New().String(),
+		Channel:     req.Channel,
+		Destination: core.SecretString(req.Destination),
+		Metadata:    req.Metadata,
+		CreatedAt:   time.Now().UTC(),
+	}
+	if owner.BeneficiaryID != "" {
+		params.BeneficiaryID = sql.NullString{String: owner.BeneficiaryID, Valid: true}
+	} else {
+		params.UserID = sql.NullString{String: owner.UserID, Valid: true}
+	}
+
+	contact, err := h.store.CreateContactMethod(r.Context(), params)
+	if err != nil {
+		http.Error(w, "Failed to create contact method", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(contactResponse(contact))
+}
+
+func (h *ContactHandler) DeleteContactMethod(w http.ResponseWriter, r *http.Request) {
+	contact, err := h.loadContact(r)
+	if err != nil {
+		http.Error(w, "Contact method not found", http.StatusNotFound)
+		return
+	}
+
+	if err := h.store.DeleteContactMethod(r.Context(), contact.ID); err != nil {
+		http.Error(w, "Failed to delete contact method", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *ContactHandler) SendVerificationCode(w http.ResponseWriter, r *http.Request) {
+	contact, err := h.loadContact(r)
+	if err != nil {
+		http.Error(w, "Contact method not found", http.StatusNotFound)
+		return
+	}
+
+	owner := r.Context().Value(ContactOwnerKey).(contactOwner)
+
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	code := fmt.Sprintf("%06d", n.Int64())
+
+	if err := h.store.UpsertContactVerification(r.Context(), store.UpsertContactVerificationParams{
+		ContactMethodID: contact.ID,
+		CodeHash:        hashVerificationCode(code),
+		ExpiresAt:       time.Now().UTC().Add(verificationCodeTTL),
+	}); err != nil {
+		http.Error(w, "Failed to start verification", http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.notifier.Notify(r.Context(), owner.UserID, contact, core.EventContactVerification, notify.TemplateData{
+		Code:      code,
+		ExpiresIn: int(verificationCodeTTL.Minutes()),
+	}); err != nil {
+		http.Error(w, "Failed to send verification code", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	w.Write([]byte("Verification code sent"))
+}
+
+func (h *ContactHandler) ConfirmVerificationCode(w http.ResponseWriter, r *http.Request) {
+	var req core.ConfirmContactMethodRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	contact, err := h.loadContact(r)
+	if err != nil {
+		http.Error(w, "Contact method not found", http.StatusNotFound)
+		return
+	}
+
+	// Each attempt is counted before the code is compared, so concurrent
+	// guesses cannot get past maxVerifyAttempts
+	pending, err := h.store.ClaimContactVerificationAttempt(r.Context(), store.ClaimContactVerificationAttemptParams{
+		ContactMethodID: contact.ID,
+		Attempts:        maxVerifyAttempts,
+		ExpiresAt:       time.Now().UTC(),
+	})
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		if _, err := h.store.GetContactVerification(r.Context(), contact.ID); errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "No verification in progress", http.StatusBadRequest)
+			return
+		}
+		http.Error(w, core.ErrVerificationExpired.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hashVerificationCode(req.Code)), []byte(pending.CodeHash)) != 1 {
+		http.Error(w, core.ErrVerificationFailed.Error(), http.StatusBadRequest)
+		return
+	}
+
+	verified, err := h.store.VerifyContactMethodTx(r.Context(), contact.ID)
+	if err != nil {
+		http.Error(w, "Failed to verify contact method", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(contactResponse(verified))
+}
+
+func (h *ContactHandler) SendTestNotification(w http.ResponseWriter, r *http.Request) {
+	contact, err := h.loadContact(r)
+	if err != nil {
+		http.Error(w, "Contact method not found", http.StatusNotFound)
+		return
+	}
+
+	owner := r.Context().Value(ContactOwnerKey).(contactOwner)
+
+	if err := h.notifier.Notify(r.Context(), owner.UserID, contact, core.EventTestNotification, notify.TemplateData{}); err != nil {
+		http.Error(w, "Failed to queue test notification", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	w.Write([]byte("Test notification queued"))
+}
+
+// Loads the contact method in the URL, making sure it belongs to the route's owner
+func (h *ContactHandler) loadContact(r *http.Request) (store.ContactMethod, error) {
+	owner := r.Context().Value(ContactOwnerKey).(contactOwner)
+
+	contact, err := h.store.GetContactMethodByID(r.Context(), chi.URLParam(r, "contactID"))
+	if err != nil {
+		return store.ContactMethod{}, err
+	}
+
+	if owner.BeneficiaryID != "" {
+		if contact.BeneficiaryID.String != owner.BeneficiaryID {
+			return store.ContactMethod{}, sql.ErrNoRows
+		}
+	} else if contact.UserID.String != owner.UserID || contact.InviteID.Valid {
+		return store.ContactMethod{}, sql.ErrNoRows
+	}
+
+	return contact, nil
+}
+
+func hashVerificationCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+func contactResponse(c store.ContactMethod) core.ContactMethodResponse {
+	resp := core.ContactMethodResponse{
+		ID:          c.ID,
+		Channel:     c.Channel,
+		Destination: string(c.Destination),
+		Verified:    c.VerifiedAt.Valid,
+		CreatedAt:   c.CreatedAt,
+	}
+	if c.VerifiedAt.Valid {
+		resp.VerifiedAt = &c.VerifiedAt.Time
+	}
+	return resp
+}
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/vmpyr/afterlight/internal/core"
+	"github.com/vmpyr/afterlight/internal/store"
+)
+
+// ForwardAuth trusts the user's email in Header when the request comes
+// straight from one of TrustedProxies, e.g. Authelia or oauth2-proxy in front
+// of the server. It never counts as a check-in.
+type ForwardAuth struct {
+	Header         string
+	NameHeader     string // Display name for provisioned accounts
+	TrustedProxies []netip.Prefix
+	AutoProvision  bool
+}
+
+// Reports whether the proxy vouched for the user on this request, rather
+// than a session cookie
+func forwardAuthenticated(r *http.Request) bool {
+	forwarded, _ := r.Context().Value(ForwardedKey).(bool)
+	return forwarded
+}
+
+func (f *ForwardAuth) trusts(r *http.Request) bool {
+	peer, ok := r.Context().Value(PeerKey).(netip.Addr)
+	if !ok {
+		return false
+	}
+	for _, p := range f.TrustedProxies {
+		if p.Contains(peer) {
+			return true
+		}
+	}
+	return false
+}
+
+// The header is ignored on requests from anywhere else, where a client could
+// have set it itself
+func (h *AuthHandler) forwardedEmail(r *http.Request) (string, bool) {
+	if h.forwardAuth == nil {
+		return "", false
+	}
+	email := strings.TrimSpace(r.Header.Get(h.forwardAuth.Header))
+	if email == "" || !h.forwardAuth.trusts(r) {
+		return "", false
+	}
+	return email, true
+}
+
+// Looks up the account for a forwarded email, creating it if allowed.
+// Fails with sql.ErrNoRows if there is none and provisioning is off, and with
+// core.ErrRegistrationClosed if registration is invite only.
+func (h *AuthHandler) forwardedUser(r *http.Request, email string) (store.User, error) {
+	if err := core.IsValidContactDestination(core.ChannelEmail, email, nil); err != nil {
+		return store.User{}, err
+	}
+
+	user, err := h.store.GetUserByEmail(r.Context(), email)
+	if !errors.Is(err, sql.ErrNoRows) || !h.forwardAuth.AutoProvision {
+		return user, err
+	}
+	// Unlike Register, an empty instance is no exception: provisioned
+	// accounts are never admins, so the first one is better made by hand
+	mode, err := h.store.RegistrationMode(r.Context(), h.registration)
+	if err != nil {
+		return store.User{}, err
+	}
+	if mode != core.RegistrationOpen {
+		return store.User{}, core.ErrRegistrationClosed
+	}
+
+	name := strings.TrimSpace(r.Header.Get(h.forwardAuth.NameHeader))
+	if name == "" {
+		name, _, _ = strings.Cut(email, "@")
+	}
+	user, err = h.store.ProvisionUserTx(r.Context(), name, email, h.defaults)
+	if err != nil {
+		// Another request for the same user may have created it first
+		if existing, lookupErr := h.store.GetUserByEmail(r.Context(), email); lookupErr == nil {
+			return existing, nil
+		}
+		return store.User{}, err
+	}
+	slog.InfoContext(r.Context(), "provisioned account for forwarded user", "user_id", user.ID)
+	return user, nil
+}
@@ -0,0 +1,100 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vmpyr/afterlight/internal/core"
+	"github.com/vmpyr/afterlight/internal/store"
+)
+
+func openTestStore(t *testing.T) *store.Store {
+	t.Helper()
+	storage, err := store.NewStorage(filepath.Join(t.TempDir(), "afterlight.db"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { storage.Close() })
+	return store.NewStore(storage.DB())
+}
+
+func newForwardAuthTest(t *testing.T, mode core.RegistrationMode) (*store.Store, http.Handler) {
+	t.Helper()
+	s := openTestStore(t)
+	auth := NewAuthHandler(s, time.Hour, store.UserDefaults{CheckInInterval: time.Hour, TriggerIntervals: 1}, mode, &ForwardAuth{
+		Header:         "Remote-Email",
+		NameHeader:     "Remote-Name",
+		TrustedProxies: []netip.Prefix{netip.MustParsePrefix("192.0.2.0/24")},
+		AutoProvision:  true,
+	})
+	account := NewAccountHandler(s, nil, time.Hour)
+	return s, PeerAddress(account.Routes(auth.AuthMiddleware))
+}
+
+func forwardedRequest(method, path, email, body string) *http.Request {
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	r.RemoteAddr = "192.0.2.10:40000"
+	r.Header.Set("Remote-Email", email)
+	return r
+}
+
+func TestForwardAuthProvisionsOnlyWhenRegistrationOpen(t *testing.T) {
+	s, handler := newForwardAuthTest(t, core.RegistrationInviteOnly)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, forwardedRequest(http.MethodGet, "/deletion", "new@example.com", ""))
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("unknown forwarded user with invite-only registration = %d, want 403", w.Code)
+	}
+	if _, err := s.GetUserByEmail(context.Background(), "new@example.com"); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("account was provisioned with registration closed: %v", err)
+	}
+}
+
+func TestForwardAuthProvisionedFirstUserIsNotAdmin(t *testing.T) {
+	s, handler := newForwardAuthTest(t, core.RegistrationOpen)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, forwardedRequest(http.MethodGet, "/deletion", "first@example.com", ""))
+	if w.Code != http.StatusOK {
+		t.Fatalf("forwarded request = %d, want 200", w.Code)
+	}
+	user, err := s.GetUserByEmail(context.Background(), "first@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Role != core.RoleUser {
+		t.Fatalf("provisioned first account has role %q, want %q", user.Role, core.RoleUser)
+	}
+}
+
+func TestForwardAuthSchedulesDeletionWithoutPassword(t *testing.T) {
+	s, handler := newForwardAuthTest(t, core.RegistrationOpen)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, forwardedRequest(http.MethodPost, "/deletion", "owner@example.com", `{"email": "someone@example.com"}`))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("deletion confirmed with another email = %d, want 400", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, forwardedRequest(http.MethodPost, "/deletion", "owner@example.com", `{"email": "owner@example.com"}`))
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("forwarded deletion request = %d (%s), want 202", w.Code, w.Body)
+	}
+	user, err := s.GetUserByEmail(context.Background(), "owner@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !user.DeletionDueAt.Valid {
+		t.Fatal("deletion was not scheduled")
+	}
+}
@@ -0,0 +1,174 @@
+package api
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/vmpyr/afterlight/internal/core"
+	"github.com/vmpyr/afterlight/internal/notify"
+	"github.com/vmpyr/afterlight/internal/store"
+)
+
+type InviteHandler struct {
+	store       *store.Store
+	notifier    *notify.Notifier
+	baseURL     string
+	userInvites int64         // Open invites a non-admin may have, 0 = none
+	ttl         time.Duration // Default lifetime
+}
+
+func NewInviteHandler(s *store.Store, n *notify.Notifier, baseURL string, userInvites int64, ttl time.Duration) *InviteHandler {
+	return &InviteHandler{store: s, notifier: n, baseURL: baseURL, userInvites: userInvites, ttl: ttl}
+}
+
+func (h *InviteHandler) Routes(authMiddleware func(http.Handler) http.Handler) chi.Router {
+	r := chi.NewRouter()
+
+	r.Use(authMiddleware)
+
+	r.Post("/", h.CreateInvite)
+	r.Get("/", h.ListInvites)
+	r.Delete("/{id}", h.DeleteInvite)
+
+	return r
+}
+
+// Handlers
+
+// The token is only ever returned here. With an email address it is also
+// sent there through the outbox.
+func (h *InviteHandler) CreateInvite(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(UserKey).(*store.User)
+	isAdmin := user.Role == core.RoleAdmin
+
+	var req core.CreateInviteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.MaxUses == 0 {
+		req.MaxUses = 1
+	}
+	if req.ExpiresIn == 0 {
+		req.ExpiresIn = int64(h.ttl.Seconds())
+	}
+	if err := core.IsValidInviteRequest(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var quota int64
+	if !isAdmin {
+		if h.userInvites == 0 {
+			http.Error(w, "Forbidden: Only admins can create invites", http.StatusForbidden)
+			return
+		}
+		if req.MaxUses != 1 {
+			http.Error(w, "Only admins can create multi-use invites", http.StatusForbidden)
+			return
+		}
+		quota = h.userInvites
+	}
+
+	raw := make([]byte, 24)
+	if _, err := rand.Read(raw); err != nil {
+		http.Error(w, "Failed to create invite", http.StatusInternalServerError)
+		return
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+
+	invite, contact, err := h.store.CreateInviteTx(r.Context(), user.ID, token, req, quota)
+	if err != nil {
+		if errors.Is(err, core.ErrInviteQuotaExceeded) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		http.Error(w, "Failed to create invite", http.StatusInternalServerError)
+		return
+	}
+
+	resp := inviteResponse(invite)
+	resp.Token = token
+	resp.URL = notify.InviteURL(h.baseURL, token)
+	if contact != nil {
+		err := h.notifier.Notify(r.Context(), user.ID, *contact, core.EventInvitation, notify.TemplateData{
+			OwnerName:   string(user.Name),
+			InviteToken: token,
+			Deadline:    invite.ExpiresAt,
+		})
+		if err != nil {
+			// The invite still works; the creator can pass the token on themselves
+			slog.ErrorContext(r.Context(), "queueing invitation failed", "invite_id", invite.ID, "error", err)
+		}
+		resp.EmailQueued = err == nil
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// Admins see every invite, other users the ones they created
+func (h *InviteHandler) ListInvites(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(UserKey).(*store.User)
+
+	var invites []store.Invite
+	var err error
+	if user.Role == core.RoleAdmin {
+		invites, err = h.store.ListInvites(r.Context())
+	} else {
+		invites, err = h.store.ListInvitesByCreator(r.Context(), user.ID)
+	}
+	if err != nil {
+		http.Error(w, "Failed to list invites", http.StatusInternalServerError)
+		return
+	}
+
+	resp := make([]core.InviteResponse, 0, len(invites))
+	for _, i := range invites {
+		resp = append(resp, inviteResponse(i))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// Revokes an invite; accounts already created with it are unaffected
+func (h *InviteHandler) DeleteInvite(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(UserKey).(*store.User)
+
+	invite, err := h.store.GetInvite(r.Context(), chi.URLParam(r, "id"))
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Failed to delete invite", http.StatusInternalServerError)
+		return
+	}
+	if err != nil || (invite.CreatedBy != user.ID && user.Role != core.RoleAdmin) {
+		http.Error(w, "Invite not found", http.StatusNotFound)
+		return
+	}
+
+	if err := h.store.DeleteInvite(r.Context(), invite.ID); err != nil {
+		http.Error(w, "Failed to delete invite", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func inviteResponse(i store.Invite) core.InviteResponse {
+	return core.InviteResponse{
+		ID:        i.ID,
+		Email:     i.Email.String,
+		MaxUses:   i.MaxUses,
+		Uses:      i.Uses,
+		ExpiresAt: i.ExpiresAt,
+		CreatedBy: i.CreatedBy,
+		CreatedAt: i.CreatedAt,
+	}
+}
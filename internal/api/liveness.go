@@ -0,0 +1,147 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/vmpyr/afterlight/internal/core"
+	"github.com/vmpyr/afterlight/internal/liveness"
+	"github.com/vmpyr/afterlight/internal/store"
+)
+
+type LivenessHandler struct {
+	store *store.Store
+}
+
+func NewLivenessHandler(s *store.Store) *LivenessHandler {
+	return &LivenessHandler{store: s}
+}
+
+func (h *LivenessHandler) Routes(authMiddleware func(http.Handler) http.Handler) chi.Router {
+	r := chi.NewRouter()
+
+	r.Use(authMiddleware)
+
+	r.Get("/", h.GetStatus)
+	r.Post("/check-in", h.CheckIn)
+	r.Get("/reminders", h.GetReminderPolicy)
+	r.Put("/reminders", h.UpdateReminderPolicy)
+
+	return r
+}
+
+// Handlers
+func (h *LivenessHandler) GetStatus(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(UserKey).(*store.User)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(core.LivenessResponse{
+		CurrentStatus: user.CurrentStatus,
+		LastCheckIn:   user.LastCheckIn,
+		Deadline:      liveness.Deadline(*user),
+		IsPaused:      user.IsPaused,
+	})
+}
+
+func (h *LivenessHandler) CheckIn(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(UserKey).(*store.User)
+
+	if user.CurrentStatus == core.StatusDead {
+		http.Error(w, "Account has already been released", http.StatusConflict)
+		return
+	}
+
+	now, err := h.store.CheckInTx(r.Context(), user.ID)
+	if err != nil {
+		if errors.Is(err, core.ErrAccountReleased) {
+			http.Error(w, "Account has already been released", http.StatusConflict)
+			return
+		}
+		http.Error(w, "Failed to record check-in", http.StatusInternalServerError)
+		return
+	}
+	user.LastCheckIn = now
+	user.CurrentStatus = core.StatusAlive
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(core.LivenessResponse{
+		CurrentStatus: user.CurrentStatus,
+		LastCheckIn:   user.LastCheckIn,
+		Deadline:      liveness.Deadline(*user),
+		IsPaused:      user.IsPaused,
+	})
+}
+
+func (h *LivenessHandler) GetReminderPolicy(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(UserKey).(*store.User).ID
+
+	policy, err := liveness.LoadPolicy(r.Context(), h.store, userID)
+	if err != nil {
+		http.Error(w, "Failed to retrieve reminder policy", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(core.ReminderPolicyResponse{
+		PrimaryContactID: policy.PrimaryContactID,
+		Steps:            policy.Steps,
+		QuietHoursStart:  policy.QuietHoursStart,
+		QuietHoursEnd:    policy.QuietHoursEnd,
+		TimeZone:         policy.Location.String(),
+		IsDefault:        policy.IsDefault,
+	})
+}
+
+func (h *LivenessHandler) UpdateReminderPolicy(w http.ResponseWriter, r *http.Request) {
+	var req core.ReminderPolicyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value(UserKey).(*store.User).ID
+
+	if err := liveness.ValidatePolicy(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.PrimaryContactID != "" {
+		contact, err := h.store.GetContactMethodByID(r.Context(), req.PrimaryContactID)
+		if err != nil || contact.UserID.String != userID || contact.InviteID.Valid {
+			http.Error(w, "Primary contact method not found", http.StatusBadRequest)
+			return
+		}
+		if !contact.VerifiedAt.Valid {
+			http.Error(w, "Primary contact method must be verified first", http.StatusBadRequest)
+			return
+		}
+	}
+
+	policy, err := h.store.UpsertReminderPolicy(r.Context(), store.UpsertReminderPolicyParams{
+		UserID:           userID,
+		PrimaryContactID: sql.NullString{String: req.PrimaryContactID, Valid: req.PrimaryContactID != ""},
+		Steps:            req.Steps,
+		QuietHoursStart:  sql.NullString{String: req.QuietHoursStart, Valid: req.QuietHoursStart != ""},
+		QuietHoursEnd:    sql.NullString{String: req.QuietHoursEnd, Valid: req.QuietHoursEnd != ""},
+		TimeZone:         req.TimeZone,
+		UpdatedAt:        time.Now().UTC(),
+	})
+	if err != nil {
+		http.Error(w, "Failed to save reminder policy", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(core.ReminderPolicyResponse{
+		PrimaryContactID: policy.PrimaryContactID.String,
+		Steps:            policy.Steps,
+		QuietHoursStart:  policy.QuietHoursStart.String,
+		QuietHoursEnd:    policy.QuietHoursEnd.String,
+		TimeZone:         policy.TimeZone,
+	})
+}
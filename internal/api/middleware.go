@@ -2,28 +2,124 @@ package api
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
 	"net/http"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/vmpyr/afterlight/internal/core"
+	"github.com/vmpyr/afterlight/internal/logging"
+	"github.com/vmpyr/afterlight/internal/store"
 )
 
 type ContextKey string
 
 const UserKey ContextKey = "user"
 
+// Set to true on requests authenticated by the forward auth header
+const ForwardedKey ContextKey = "forwarded"
+
+// Behind an authenticating proxy, requests carrying its identity header are
+// authenticated by that; all others need a session cookie
 func (h *AuthHandler) AuthMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		cookie, err := r.Cookie("session_token")
-		if err != nil {
-			http.Error(w, "Unauthorized: No session cookie", http.StatusUnauthorized)
-			return
+		var user store.User
+		email, forwarded := h.forwardedEmail(r)
+		if forwarded {
+			var err error
+			if user, err = h.forwardedUser(r, email); err != nil {
+				switch {
+				case errors.Is(err, core.ErrInvalidDestination):
+					http.Error(w, "Unauthorized: Invalid forwarded email", http.StatusUnauthorized)
+				case errors.Is(err, sql.ErrNoRows):
+					http.Error(w, "Unauthorized: No account for forwarded user", http.StatusUnauthorized)
+				case errors.Is(err, core.ErrRegistrationClosed):
+					http.Error(w, err.Error(), http.StatusForbidden)
+				default:
+					http.Error(w, "Internal server error", http.StatusInternalServerError)
+				}
+				return
+			}
+		} else {
+			cookie, err := r.Cookie("session_token")
+			if err != nil {
+				http.Error(w, "Unauthorized: No session cookie", http.StatusUnauthorized)
+				return
+			}
+
+			user, err = h.store.GetUserBySessionToken(r.Context(), cookie.Value)
+			if err != nil {
+				http.Error(w, "Unauthorized: Invalid session", http.StatusUnauthorized)
+				return
+			}
 		}
 
-		user, err := h.store.GetUserBySessionToken(r.Context(), cookie.Value)
-		if err != nil {
-			http.Error(w, "Unauthorized: Invalid session", http.StatusUnauthorized)
+		if user.DisabledAt.Valid {
+			http.Error(w, core.ErrAccountDisabled.Error(), http.StatusForbidden)
 			return
 		}
 
+		logging.SetUser(r.Context(), user.ID)
 		ctx := context.WithValue(r.Context(), UserKey, &user)
+		ctx = context.WithValue(ctx, ForwardedKey, forwarded)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
+
+// AdminMiddleware goes after AuthMiddleware and lets only admins through
+func AdminMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, _ := r.Context().Value(UserKey).(*store.User)
+		if user == nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if user.Role != core.RoleAdmin {
+			http.Error(w, "Forbidden: Admins only", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+const HTTPSKey ContextKey = "https"
+
+// SecureTransport records whether the request reached us over HTTPS, directly
+// or through a proxy whose X-Forwarded-Proto is trusted, and sends HSTS if so
+func SecureTransport(trustProxy bool, hstsMaxAge time.Duration) func(http.Handler) http.Handler {
+	hsts := fmt.Sprintf("max-age=%d", int64(hstsMaxAge.Seconds()))
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			https := r.TLS != nil || (trustProxy && strings.EqualFold(r.Header.Get("X-Forwarded-Proto"), "https"))
+			if https && hstsMaxAge > 0 {
+				w.Header().Set("Strict-Transport-Security", hsts)
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), HTTPSKey, https)))
+		})
+	}
+}
+
+// Cookies are marked Secure whenever the client is talking HTTPS
+func IsHTTPS(r *http.Request) bool {
+	https, _ := r.Context().Value(HTTPSKey).(bool)
+	return https
+}
+
+const PeerKey ContextKey = "peer"
+
+// PeerAddress records the address the connection comes from. It must run
+// before middleware.RealIP, which replaces RemoteAddr with what the client
+// claims in X-Forwarded-For.
+func PeerAddress(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		addr, err := netip.ParseAddrPort(r.RemoteAddr)
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), PeerKey, addr.Addr().Unmap())))
+	})
+}
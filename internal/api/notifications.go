@@ -0,0 +1,77 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/vmpyr/afterlight/internal/core"
+	"github.com/vmpyr/afterlight/internal/notify"
+)
+
+type NotificationHandler struct {
+	templates *notify.Templates
+}
+
+func NewNotificationHandler(t *notify.Templates) *NotificationHandler {
+	return &NotificationHandler{templates: t}
+}
+
+func (h *NotificationHandler) Routes(authMiddleware func(http.Handler) http.Handler) chi.Router {
+	r := chi.NewRouter()
+
+	r.Use(authMiddleware)
+
+	r.Get("/templates", h.ListTemplates)
+	r.Get("/templates/{event}/preview", h.PreviewTemplate)
+
+	return r
+}
+
+// Handlers
+func (h *NotificationHandler) ListTemplates(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(core.TemplateListResponse{
+		Events:        notify.TemplateEvents,
+		Locales:       h.templates.Locales(),
+		DefaultLocale: h.templates.ResolveLocale(""),
+	})
+}
+
+// Renders a template with sample data. ?format=html returns the HTML body as a page.
+func (h *NotificationHandler) PreviewTemplate(w http.ResponseWriter, r *http.Request) {
+	event := core.NotificationEvent(strings.ToUpper(chi.URLParam(r, "event")))
+	known := false
+	for _, e := range notify.TemplateEvents {
+		if e == event {
+			known = true
+		}
+	}
+	if !known {
+		http.Error(w, "Unknown notification event", http.StatusNotFound)
+		return
+	}
+
+	locale := h.templates.ResolveLocale(r.URL.Query().Get("locale"))
+	msg, err := h.templates.Render(event, locale, notify.SampleData())
+	if err != nil {
+		http.Error(w, "Failed to render template: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if r.URL.Query().Get("format") == "html" {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(msg.HTML))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(core.TemplatePreviewResponse{
+		Event:   event,
+		Locale:  locale,
+		Subject: msg.Subject,
+		Text:    msg.Body,
+		HTML:    msg.HTML,
+	})
+}
@@ -0,0 +1,335 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/vmpyr/afterlight/internal/core"
+	"github.com/vmpyr/afterlight/internal/sso"
+	"github.com/vmpyr/afterlight/internal/store"
+)
+
+// How long the user has to get through the provider's login page
+const oidcLoginTTL = 10 * time.Minute
+
+const oidcStateCookie = "oidc_state"
+
+// Codes passed back to the web client as ?sso_error= when a login fails
+const (
+	ssoErrorDenied        = "denied"
+	ssoErrorExpired       = "expired"
+	ssoErrorFailed        = "failed"
+	ssoErrorNotLinked     = "not_linked"
+	ssoErrorAlreadyLinked = "already_linked"
+	ssoErrorDisabled      = "disabled"
+)
+
+// OIDCHandler logs users in through the configured OpenID Connect provider.
+// provider is nil when single sign-on is off.
+type OIDCHandler struct {
+	store       *store.Store
+	auth        *AuthHandler
+	provider    *sso.Provider
+	name        string
+	linkByEmail bool
+}
+
+func NewOIDCHandler(s *store.Store, auth *AuthHandler, provider *sso.Provider, name string, linkByEmail bool) *OIDCHandler {
+	return &OIDCHandler{store: s, auth: auth, provider: provider, name: name, linkByEmail: linkByEmail}
+}
+
+func (h *OIDCHandler) Routes(authMiddleware func(http.Handler) http.Handler) chi.Router {
+	r := chi.NewRouter()
+
+	// Public routes
+	r.Get("/", h.Status)
+
+	r.Group(func(r chi.Router) {
+		r.Use(h.requireProvider)
+		r.Get("/login", h.Login)
+		r.Get("/callback", h.Callback)
+
+		// Protected routes
+		r.Group(func(r chi.Router) {
+			r.Use(authMiddleware)
+			r.Post("/link", h.Link)
+			r.Get("/identities", h.ListIdentities)
+			r.Delete("/identities/{id}", h.DeleteIdentity)
+		})
+	})
+
+	return r
+}
+
+func (h *OIDCHandler) requireProvider(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if h.provider == nil {
+			http.Error(w, "Single sign-on is not enabled", http.StatusNotFound)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Handlers
+func (h *OIDCHandler) Status(w http.ResponseWriter, r *http.Request) {
+	resp := core.SSOStatusResponse{Enabled: h.provider != nil}
+	if resp.Enabled {
+		resp.Name = h.name
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// Sends the browser to the provider; ?redirect= is where to land afterwards
+func (h *OIDCHandler) Login(w http.ResponseWriter, r *http.Request) {
+	authURL, err := h.begin(w, r, "", localPath(r.URL.Query().Get("redirect"), "/"))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "starting single sign-on failed", "error", err)
+		http.Redirect(w, r, loginErrorURL("/login", ssoErrorFailed), http.StatusFound)
+		return
+	}
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// Returns the provider URL for linking an identity to the current account.
+// The web client navigates there itself, as the request carries the state cookie.
+func (h *OIDCHandler) Link(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(UserKey).(*store.User)
+
+	authURL, err := h.begin(w, r, user.ID, localPath(r.URL.Query().Get("redirect"), "/settings"))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "starting single sign-on failed", "error", err)
+		http.Error(w, "Single sign-on provider is unavailable", http.StatusBadGateway)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(core.SSOLinkResponse{URL: authURL})
+}
+
+func (h *OIDCHandler) Callback(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	state := query.Get("state")
+	cookie, cookieErr := r.Cookie(oidcStateCookie)
+	h.clearStateCookie(w, r)
+	if cookieErr != nil || state == "" || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(state)) != 1 {
+		http.Redirect(w, r, loginErrorURL("/login", ssoErrorExpired), http.StatusFound)
+		return
+	}
+
+	now := time.Now().UTC()
+	login, err := h.store.TakeOIDCLogin(r.Context(), store.TakeOIDCLoginParams{
+		StateHash: core.HashToken(state),
+		ExpiresAt: now,
+	})
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			slog.ErrorContext(r.Context(), "loading single sign-on state failed", "error", err)
+		}
+		http.Redirect(w, r, loginErrorURL("/login", ssoErrorExpired), http.StatusFound)
+		return
+	}
+
+	// Failed links go back to the page that started them, failed logins to the login page
+	failTo := "/login"
+	if login.UserID.Valid {
+		failTo = login.RedirectTo
+	}
+	if query.Get("error") != "" {
+		http.Redirect(w, r, loginErrorURL(failTo, ssoErrorDenied), http.StatusFound)
+		return
+	}
+
+	claims, err := h.provider.Exchange(r.Context(), query.Get("code"), login.CodeVerifier, login.Nonce)
+	if err != nil {
+		slog.WarnContext(r.Context(), "single sign-on failed", "error", err)
+		http.Redirect(w, r, loginErrorURL(failTo, ssoErrorFailed), http.StatusFound)
+		return
+	}
+	identity := store.IdentityClaims{
+		Issuer:        claims.Issuer,
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+	}
+
+	if login.UserID.Valid {
+		if _, err := h.store.LinkIdentityTx(r.Context(), login.UserID.String, identity, now); err != nil {
+			code := ssoErrorFailed
+			if errors.Is(err, core.ErrIdentityLinked) {
+				code = ssoErrorAlreadyLinked
+			} else {
+				slog.ErrorContext(r.Context(), "linking identity failed", "error", err)
+			}
+			http.Redirect(w, r, loginErrorURL(failTo, code), http.StatusFound)
+			return
+		}
+		http.Redirect(w, r, login.RedirectTo, http.StatusFound)
+		return
+	}
+
+	user, err := h.store.LoginIdentityTx(r.Context(), identity, h.linkByEmail, now)
+	if err != nil {
+		code := ssoErrorFailed
+		if errors.Is(err, core.ErrIdentityNotLinked) {
+			code = ssoErrorNotLinked
+		} else {
+			slog.ErrorContext(r.Context(), "single sign-on login failed", "error", err)
+		}
+		http.Redirect(w, r, loginErrorURL(failTo, code), http.StatusFound)
+		return
+	}
+	if user.DisabledAt.Valid {
+		http.Redirect(w, r, loginErrorURL(failTo, ssoErrorDisabled), http.StatusFound)
+		return
+	}
+
+	if err := h.auth.startSession(w, r, &user); err != nil {
+		slog.ErrorContext(r.Context(), "creating session failed", "error", err)
+		http.Redirect(w, r, loginErrorURL(failTo, ssoErrorFailed), http.StatusFound)
+		return
+	}
+	http.Redirect(w, r, login.RedirectTo, http.StatusFound)
+}
+
+func (h *OIDCHandler) ListIdentities(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(UserKey).(*store.User)
+
+	identities, err := h.store.ListUserIdentities(r.Context(), user.ID)
+	if err != nil {
+		http.Error(w, "Failed to fetch identities", http.StatusInternalServerError)
+		return
+	}
+
+	resp := make([]core.IdentityResponse, 0, len(identities))
+	for _, i := range identities {
+		resp = append(resp, identityResponse(i))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (h *OIDCHandler) DeleteIdentity(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(UserKey).(*store.User)
+
+	n, err := h.store.DeleteUserIdentity(r.Context(), store.DeleteUserIdentityParams{
+		ID:     chi.URLParam(r, "id"),
+		UserID: user.ID,
+	})
+	if err != nil {
+		http.Error(w, "Failed to unlink identity", http.StatusInternalServerError)
+		return
+	}
+	if n == 0 {
+		http.Error(w, "Identity not found", http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Records the login and sets the state cookie that ties the callback to this
+// browser. userID is set when linking.
+func (h *OIDCHandler) begin(w http.ResponseWriter, r *http.Request, userID, redirectTo string) (string, error) {
+	state, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	nonce, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	verifier := sso.NewVerifier()
+
+	authURL, err := h.provider.AuthCodeURL(state, nonce, verifier)
+	if err != nil {
+		return "", err
+	}
+
+	expiresAt := time.Now().UTC().Add(oidcLoginTTL)
+	err = h.store.CreateOIDCLogin(r.Context(), store.CreateOIDCLoginParams{
+		StateHash:    core.HashToken(state),
+		CodeVerifier: verifier,
+		Nonce:        nonce,
+		UserID:       sql.NullString{String: userID, Valid: userID != ""},
+		RedirectTo:   redirectTo,
+		ExpiresAt:    expiresAt,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    state,
+		Expires:  expiresAt,
+		HttpOnly: true,
+		Secure:   IsHTTPS(r),
+		Path:     "/api/v1/auth/oidc",
+		SameSite: http.SameSiteLaxMode,
+	})
+	return authURL, nil
+}
+
+func (h *OIDCHandler) clearStateCookie(w http.ResponseWriter, r *http.Request) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    "",
+		Expires:  time.Unix(0, 0),
+		HttpOnly: true,
+		Secure:   IsHTTPS(r),
+		Path:     "/api/v1/auth/oidc",
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func randomToken() (string, error) {
+	raw := make([]byte, 24)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// Only paths on this server are followed, so the login cannot be used to
+// bounce users to another site
+func localPath(p, fallback string) string {
+	if !strings.HasPrefix(p, "/") || strings.HasPrefix(p, "//") || strings.Contains(p, `\`) {
+		return fallback
+	}
+	return p
+}
+
+func loginErrorURL(path, code string) string {
+	u, err := url.Parse(path)
+	if err != nil {
+		return "/login?sso_error=" + code
+	}
+	q := u.Query()
+	q.Set("sso_error", code)
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+func identityResponse(i store.UserIdentity) core.IdentityResponse {
+	resp := core.IdentityResponse{
+		ID:        i.ID,
+		Issuer:    i.Issuer,
+		Subject:   i.Subject,
+		Email:     i.Email.String,
+		CreatedAt: i.CreatedAt,
+	}
+	if i.LastLoginAt.Valid {
+		resp.LastLoginAt = &i.LastLoginAt.Time
+	}
+	return resp
+}
@@ -0,0 +1,383 @@
+package api
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vmpyr/afterlight/internal/core"
+	"github.com/vmpyr/afterlight/internal/sso"
+	"github.com/vmpyr/afterlight/internal/store"
+)
+
+const (
+	mockClientID    = "afterlight"
+	mockRedirectURL = "https://afterlight.test/api/v1/auth/oidc/callback"
+)
+
+// mockProvider is a minimal OpenID Connect provider: discovery, an authorize
+// endpoint that logs in whoever the test has set, a token endpoint that
+// checks the PKCE verifier, and an RS256 key.
+type mockProvider struct {
+	t      *testing.T
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	mu            sync.Mutex
+	subject       string
+	email         string
+	emailVerified bool
+	nonce         string // Overrides the nonce sent to /authorize when set
+	codes         map[string]mockAuthorization
+	authorizes    []url.Values
+}
+
+type mockAuthorization struct {
+	challenge string
+	nonce     string
+}
+
+func newMockProvider(t *testing.T) *mockProvider {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &mockProvider{t: t, key: key, codes: map[string]mockAuthorization{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", p.discovery)
+	mux.HandleFunc("GET /keys", p.keys)
+	mux.HandleFunc("GET /authorize", p.authorize)
+	mux.HandleFunc("POST /token", p.token)
+	p.server = httptest.NewServer(mux)
+	t.Cleanup(p.server.Close)
+	return p
+}
+
+func (p *mockProvider) login(subject, email string, verified bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.subject, p.email, p.emailVerified = subject, email, verified
+}
+
+func (p *mockProvider) discovery(w http.ResponseWriter, r *http.Request) {
+	issuer := p.server.URL
+	json.NewEncoder(w).Encode(map[string]any{
+		"issuer":                                issuer,
+		"authorization_endpoint":                issuer + "/authorize",
+		"token_endpoint":                        issuer + "/token",
+		"jwks_uri":                              issuer + "/keys",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+func (p *mockProvider) keys(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+		"kty": "RSA",
+		"kid": "mock",
+		"use": "sig",
+		"alg": "RS256",
+		"n":   base64.RawURLEncoding.EncodeToString(p.key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(p.key.E)).Bytes()),
+	}}})
+}
+
+// Logs the current user straight in and redirects back with a code
+func (p *mockProvider) authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	p.mu.Lock()
+	p.authorizes = append(p.authorizes, q)
+	code := randomCode(p.t)
+	p.codes[code] = mockAuthorization{challenge: q.Get("code_challenge"), nonce: q.Get("nonce")}
+	p.mu.Unlock()
+
+	back, err := url.Parse(q.Get("redirect_uri"))
+	if err != nil {
+		http.Error(w, "bad redirect_uri", http.StatusBadRequest)
+		return
+	}
+	values := url.Values{"code": {code}, "state": {q.Get("state")}}
+	back.RawQuery = values.Encode()
+	http.Redirect(w, r, back.String(), http.StatusFound)
+}
+
+func (p *mockProvider) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "bad form", http.StatusBadRequest)
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	auth, ok := p.codes[r.PostForm.Get("code")]
+	delete(p.codes, r.PostForm.Get("code"))
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if !ok || base64.RawURLEncoding.EncodeToString(sum[:]) != auth.challenge {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	nonce := auth.nonce
+	if p.nonce != "" {
+		nonce = p.nonce
+	}
+	now := time.Now()
+	idToken := p.sign(map[string]any{
+		"iss":            p.server.URL,
+		"sub":            p.subject,
+		"aud":            mockClientID,
+		"iat":            now.Unix(),
+		"exp":            now.Add(time.Hour).Unix(),
+		"nonce":          nonce,
+		"email":          p.email,
+		"email_verified": p.emailVerified,
+	})
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"access_token": "access",
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     idToken,
+	})
+}
+
+func (p *mockProvider) sign(claims map[string]any) string {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": "mock", "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signingInput))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, p.key, crypto.SHA256, digest[:])
+	if err != nil {
+		p.t.Fatal(err)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func randomCode(t *testing.T) string {
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		t.Fatal(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+type oidcTest struct {
+	store    *store.Store
+	handler  *OIDCHandler
+	provider *mockProvider
+	client   *http.Client
+}
+
+func newOIDCTest(t *testing.T, linkByEmail bool) *oidcTest {
+	t.Helper()
+	s := openTestStore(t)
+
+	provider := newMockProvider(t)
+	auth := NewAuthHandler(s, time.Hour, store.UserDefaults{CheckInInterval: time.Hour, TriggerIntervals: 1}, core.RegistrationOpen, nil)
+	sp := sso.NewProvider(sso.Config{
+		Issuer:      provider.server.URL,
+		ClientID:    mockClientID,
+		RedirectURL: mockRedirectURL,
+		Scopes:      []string{"openid", "email"},
+	})
+	return &oidcTest{
+		store:    s,
+		handler:  NewOIDCHandler(s, auth, sp, "Mock", linkByEmail),
+		provider: provider,
+		client: &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		}},
+	}
+}
+
+// Starts a login and follows the provider back to the callback URL, returning
+// it with the state cookie the login set
+func (o *oidcTest) authorize(t *testing.T) (*url.URL, *http.Cookie) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	o.handler.Login(w, httptest.NewRequest(http.MethodGet, "/api/v1/auth/oidc/login?redirect=/vaults", nil))
+	if w.Code != http.StatusFound {
+		t.Fatalf("login = %d, want a redirect to the provider", w.Code)
+	}
+	var state *http.Cookie
+	for _, c := range w.Result().Cookies() {
+		if c.Name == oidcStateCookie {
+			state = c
+		}
+	}
+	if state == nil {
+		t.Fatal("login set no state cookie")
+	}
+
+	resp, err := o.client.Get(w.Header().Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	callback, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return callback, state
+}
+
+func (o *oidcTest) callback(callback *url.URL, state *http.Cookie) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/auth/oidc/callback?"+callback.RawQuery, nil)
+	if state != nil {
+		r.AddCookie(state)
+	}
+	w := httptest.NewRecorder()
+	o.handler.Callback(w, r)
+	return w
+}
+
+func sessionCookie(w *httptest.ResponseRecorder) *http.Cookie {
+	for _, c := range w.Result().Cookies() {
+		if c.Name == "session_token" && c.Value != "" {
+			return c
+		}
+	}
+	return nil
+}
+
+func createOIDCUser(t *testing.T, s *store.Store, email string) store.User {
+	t.Helper()
+	u, err := s.CreateUserTx(context.Background(), core.RegisterRequest{
+		Name:     "Owner",
+		Email:    email,
+		Password: "Correct-horse-9",
+	}, store.UserDefaults{CheckInInterval: time.Hour, TriggerIntervals: 1}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
+
+func TestOIDCLoginLinksVerifiedEmail(t *testing.T) {
+	o := newOIDCTest(t, true)
+	user := createOIDCUser(t, o.store, "owner@example.com")
+	o.provider.login("sub-1", "owner@example.com", true)
+
+	callback, state := o.authorize(t)
+
+	// The provider was asked for a code bound to an S256 PKCE challenge and a nonce
+	sent := o.provider.authorizes[0]
+	if sent.Get("code_challenge_method") != "S256" || sent.Get("code_challenge") == "" {
+		t.Fatalf("authorize request without PKCE: %v", sent)
+	}
+	if sent.Get("nonce") == "" || sent.Get("state") != state.Value {
+		t.Fatalf("authorize request state or nonce missing: %v", sent)
+	}
+
+	w := o.callback(callback, state)
+	if w.Code != http.StatusFound || w.Header().Get("Location") != "/vaults" {
+		t.Fatalf("callback = %d to %q, want a redirect to /vaults", w.Code, w.Header().Get("Location"))
+	}
+	session := sessionCookie(w)
+	if session == nil {
+		t.Fatal("callback started no session")
+	}
+	got, err := o.store.GetUserBySessionToken(context.Background(), session.Value)
+	if err != nil || got.ID != user.ID {
+		t.Fatalf("session belongs to %q (%v), want %q", got.ID, err, user.ID)
+	}
+
+	identities, err := o.store.ListUserIdentities(context.Background(), user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(identities) != 1 || identities[0].Subject != "sub-1" || identities[0].Issuer != o.provider.server.URL {
+		t.Fatalf("identities = %+v, want sub-1 linked", identities)
+	}
+
+	// The state is single use
+	if w := o.callback(callback, state); sessionCookie(w) != nil || !strings.Contains(w.Header().Get("Location"), "sso_error="+ssoErrorExpired) {
+		t.Fatalf("replayed callback = %q, want sso_error=%s", w.Header().Get("Location"), ssoErrorExpired)
+	}
+}
+
+func TestOIDCLoginUnverifiedEmailNotLinked(t *testing.T) {
+	o := newOIDCTest(t, true)
+	user := createOIDCUser(t, o.store, "owner@example.com")
+	o.provider.login("sub-1", "owner@example.com", false)
+
+	callback, state := o.authorize(t)
+	w := o.callback(callback, state)
+	if sessionCookie(w) != nil || !strings.Contains(w.Header().Get("Location"), "sso_error="+ssoErrorNotLinked) {
+		t.Fatalf("callback = %q, want sso_error=%s", w.Header().Get("Location"), ssoErrorNotLinked)
+	}
+	if identities, _ := o.store.ListUserIdentities(context.Background(), user.ID); len(identities) != 0 {
+		t.Fatalf("unverified email was linked: %+v", identities)
+	}
+}
+
+func TestOIDCLoginRejectsStateMismatch(t *testing.T) {
+	o := newOIDCTest(t, true)
+	createOIDCUser(t, o.store, "owner@example.com")
+	o.provider.login("sub-1", "owner@example.com", true)
+
+	callback, _ := o.authorize(t)
+	for name, cookie := range map[string]*http.Cookie{
+		"missing":   nil,
+		"different": {Name: oidcStateCookie, Value: "another-login"},
+	} {
+		w := o.callback(callback, cookie)
+		if sessionCookie(w) != nil || !strings.Contains(w.Header().Get("Location"), "sso_error="+ssoErrorExpired) {
+			t.Fatalf("%s state cookie: callback = %q, want sso_error=%s", name, w.Header().Get("Location"), ssoErrorExpired)
+		}
+	}
+	if len(o.provider.codes) != 1 {
+		t.Fatal("code was redeemed without a matching state")
+	}
+}
+
+func TestOIDCLoginRejectsNonceMismatch(t *testing.T) {
+	o := newOIDCTest(t, true)
+	createOIDCUser(t, o.store, "owner@example.com")
+	o.provider.login("sub-1", "owner@example.com", true)
+	o.provider.nonce = "replayed-token"
+
+	callback, state := o.authorize(t)
+	w := o.callback(callback, state)
+	if sessionCookie(w) != nil || !strings.Contains(w.Header().Get("Location"), "sso_error="+ssoErrorFailed) {
+		t.Fatalf("callback = %q, want sso_error=%s", w.Header().Get("Location"), ssoErrorFailed)
+	}
+}
+
+func TestOIDCLoginRejectsWrongPKCEVerifier(t *testing.T) {
+	o := newOIDCTest(t, true)
+	createOIDCUser(t, o.store, "owner@example.com")
+	o.provider.login("sub-1", "owner@example.com", true)
+
+	// A code issued for another login's challenge does not redeem with this verifier
+	callback, state := o.authorize(t)
+	o.provider.mu.Lock()
+	code := callback.Query().Get("code")
+	auth := o.provider.codes[code]
+	auth.challenge = "not-this-verifier"
+	o.provider.codes[code] = auth
+	o.provider.mu.Unlock()
+
+	w := o.callback(callback, state)
+	if sessionCookie(w) != nil || !strings.Contains(w.Header().Get("Location"), "sso_error="+ssoErrorFailed) {
+		t.Fatalf("callback = %q, want sso_error=%s", w.Header().Get("Location"), ssoErrorFailed)
+	}
+}
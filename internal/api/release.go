@@ -0,0 +1,131 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/vmpyr/afterlight/internal/core"
+	"github.com/vmpyr/afterlight/internal/store"
+)
+
+const BeneficiaryKey ContextKey = "beneficiary"
+
+// ReleaseHandler serves released vaults to beneficiaries. There is no login:
+// the access code sent in the VAULT_RELEASED notification is the credential,
+// and it only works once the owner has reached CONFIRMED_DEAD.
+type ReleaseHandler struct {
+	store *store.Store
+}
+
+func NewReleaseHandler(s *store.Store) *ReleaseHandler {
+	return &ReleaseHandler{store: s}
+}
+
+func (h *ReleaseHandler) Routes() chi.Router {
+	r := chi.NewRouter()
+
+	r.Route("/{token}", func(r chi.Router) {
+		r.Use(h.releaseTokenMiddleware)
+		r.Get("/", h.GetRelease)
+		r.Get("/vaults/{vaultID}/artifacts", h.ListArtifacts)
+	})
+
+	return r
+}
+
+func (h *ReleaseHandler) releaseTokenMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		beneficiary, err := h.store.GetBeneficiaryByReleaseToken(r.Context(), sql.NullString{
+			String: core.HashToken(chi.URLParam(r, "token")),
+			Valid:  true,
+		})
+		if err != nil {
+			http.Error(w, "Unknown access code", http.StatusNotFound)
+			return
+		}
+
+		owner, err := h.store.GetUserByID(r.Context(), beneficiary.UserID)
+		if err != nil || owner.CurrentStatus != core.StatusDead {
+			http.Error(w, "Nothing has been released", http.StatusForbidden)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), BeneficiaryKey, &beneficiary)
+		ctx = context.WithValue(ctx, UserKey, &owner)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// Handlers
+func (h *ReleaseHandler) GetRelease(w http.ResponseWriter, r *http.Request) {
+	beneficiary := r.Context().Value(BeneficiaryKey).(*store.Beneficiary)
+	owner := r.Context().Value(UserKey).(*store.User)
+
+	vaults, err := h.store.ListReleasedVaults(r.Context(), beneficiary.ID)
+	if err != nil {
+		http.Error(w, "Failed to retrieve vaults", http.StatusInternalServerError)
+		return
+	}
+
+	resp := core.ReleaseResponse{
+		OwnerName:       string(owner.Name),
+		BeneficiaryName: string(beneficiary.BeneficiaryName),
+		Vaults:          make([]core.ReleasedVaultResponse, 0, len(vaults)),
+	}
+	for _, v := range vaults {
+		resp.Vaults = append(resp.Vaults, core.ReleasedVaultResponse{
+			ID:             v.ID,
+			VaultName:      v.VaultName,
+			Hint:           v.Hint.String,
+			KdfSalt:        v.KdfSalt,
+			KDF:            v.KdfParams,
+			ShareThreshold: v.ShareThreshold.Int64,
+			ShareCount:     v.ShareCount.Int64,
+			ShareIndex:     v.ShareIndex.Int64,
+			EncryptedShare: v.EncryptedShare.String,
+			WrappedKey:     v.WrappedKey.String,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (h *ReleaseHandler) ListArtifacts(w http.ResponseWriter, r *http.Request) {
+	beneficiary := r.Context().Value(BeneficiaryKey).(*store.Beneficiary)
+
+	vault, err := h.store.GetReleasedVault(r.Context(), store.GetReleasedVaultParams{
+		ID:            chi.URLParam(r, "vaultID"),
+		BeneficiaryID: beneficiary.ID,
+	})
+	if err != nil {
+		http.Error(w, "Vault not found", http.StatusNotFound)
+		return
+	}
+
+	artifacts, err := h.store.GetArtifactsByVault(r.Context(), store.GetArtifactsByVaultParams{
+		VaultID: vault.ID,
+		UserID:  vault.UserID,
+	})
+	if err != nil {
+		http.Error(w, "Failed to retrieve artifacts", http.StatusInternalServerError)
+		return
+	}
+	if artifacts == nil {
+		artifacts = []store.Artifact{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(store.ListArtifactsResponse{
+		VaultName:              vault.VaultName,
+		Hint:                   vault.Hint.String,
+		KdfSalt:                vault.KdfSalt,
+		KDF:                    vault.KdfParams,
+		Artifacts:              artifacts,
+		CreatedAt:              vault.CreatedAt,
+		CurrentEnvelopeVersion: core.CurrentEnvelopeVersion,
+	})
+}
@@ -0,0 +1,268 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/vmpyr/afterlight/internal/core"
+	"github.com/vmpyr/afterlight/internal/store"
+)
+
+// How long a rotation session stays open for uploads
+const rotationTTL = 24 * time.Hour
+
+// Rotation Handlers
+func (h *VaultHandler) StartRotation(w http.ResponseWriter, r *http.Request) {
+	var req core.StartRotationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value(UserKey).(*store.User).ID
+
+	vault, err := h.GetVaultByID(r, chi.URLParam(r, "id"), userID)
+	if err != nil {
+		http.Error(w, "Vault not found", http.StatusNotFound)
+		return
+	}
+
+	if req.KdfSalt == "" || req.KdfSalt == vault.KdfSalt {
+		http.Error(w, "A new kdf_salt is required", http.StatusBadRequest)
+		return
+	}
+	kdf := vault.KdfParams
+	if req.KDF != nil {
+		kdf = *req.KDF
+	}
+	if err := core.IsValidKDFParams(kdf); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !vault.KdfParams.AtLeastAsStrong(kdf) {
+		http.Error(w, core.ErrKDFDowngrade.Error(), http.StatusBadRequest)
+		return
+	}
+
+	params := store.CreateVaultRotationParams{
+		ID:          uuid.New().String(),
+		VaultID:     vault.ID,
+		KdfSalt:     req.KdfSalt,
+		KdfParams:   kdf,
+		ReplaceHint: req.Hint != nil,
+		ExpiresAt:   time.Now().UTC().Add(rotationTTL),
+	}
+	if req.Hint != nil {
+		hint, err := h.sealer.SealString(*vault, "hint", sql.NullString{String: *req.Hint, Valid: *req.Hint != ""})
+		if err != nil {
+			http.Error(w, "Failed to seal hint", http.StatusInternalServerError)
+			return
+		}
+		params.Hint = core.NullSecretString(hint)
+	}
+
+	rotation, err := h.store.StartVaultRotationTx(r.Context(), params)
+	if err != nil {
+		if errors.Is(err, store.ErrRotationInProgress) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		http.Error(w, "Failed to start rotation", http.StatusInternalServerError)
+		return
+	}
+
+	h.writeRotation(w, r, rotation, http.StatusCreated)
+}
+
+func (h *VaultHandler) GetRotation(w http.ResponseWriter, r *http.Request) {
+	_, rotation, ok := h.rotationFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	h.writeRotation(w, r, *rotation, http.StatusOK)
+}
+
+// Stores the re-encrypted replacement for one artifact; uploading again overwrites it
+func (h *VaultHandler) UploadRotationArtifact(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(UserKey).(*store.User).ID
+
+	limits, _, err := h.store.UserQuotaLimits(r.Context(), userID, h.quotas)
+	if err != nil {
+		http.Error(w, "Failed to load quota", http.StatusInternalServerError)
+		return
+	}
+
+	var req core.RotationArtifactRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestSize(limits))).Decode(&req); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			if !writeQuotaError(w, limits.AllowBlob(int(tooLarge.Limit))) {
+				http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+			}
+			return
+		}
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	vault, rotation, ok := h.rotationFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	artifact, err := h.store.GetArtifact(r.Context(), store.GetArtifactParams{
+		ID:      chi.URLParam(r, "artifactID"),
+		VaultID: vault.ID,
+	})
+	if err != nil {
+		http.Error(w, "Artifact not found", http.StatusNotFound)
+		return
+	}
+
+	envelopeVersion, err := core.IsValidArtifact(req.Envelope, rotation.KdfParams, req.IV, req.EncryptedBlob)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if writeQuotaError(w, limits.AllowBlob(len(req.EncryptedBlob))) {
+		return
+	}
+	blob, err := h.sealer.Seal(*vault, "artifact/"+artifact.ID, req.EncryptedBlob)
+	if err != nil {
+		http.Error(w, "Failed to seal artifact", http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.store.UpsertRotationArtifact(r.Context(), store.UpsertRotationArtifactParams{
+		RotationID:      rotation.ID,
+		ArtifactID:      artifact.ID,
+		EncryptedBlob:   blob,
+		Iv:              req.IV,
+		EnvelopeVersion: envelopeVersion,
+	}); err != nil {
+		http.Error(w, "Failed to save replacement", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Swaps in every replacement, the new salt and KDF and the new key material at once.
+// If artifacts were added or removed since the uploads, nothing changes and the
+// session stays open so the missing replacements can still be uploaded.
+func (h *VaultHandler) CommitRotation(w http.ResponseWriter, r *http.Request) {
+	var req core.CommitRotationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	vault, rotation, ok := h.rotationFromRequest(w, r)
+	if !ok {
+		return
+	}
+	material, ok := h.keyMaterial(w, r, *vault, req.Shares, req.WrappedKeys)
+	if !ok {
+		return
+	}
+
+	if err := h.store.CommitVaultRotationTx(r.Context(), *rotation, material); err != nil {
+		if errors.Is(err, core.ErrArtifactSetMismatch) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Rotation not found or expired", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to commit rotation", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *VaultHandler) AbortRotation(w http.ResponseWriter, r *http.Request) {
+	_, rotation, ok := h.rotationFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.store.DeleteVaultRotation(r.Context(), rotation.ID); err != nil {
+		http.Error(w, "Failed to abort rotation", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Loads the caller's vault and its open rotation, writing a 404 if either is
+// missing. Expired sessions are removed on the way.
+func (h *VaultHandler) rotationFromRequest(w http.ResponseWriter, r *http.Request) (*store.Vault, *store.VaultRotation, bool) {
+	userID := r.Context().Value(UserKey).(*store.User).ID
+
+	vault, err := h.GetVaultByID(r, chi.URLParam(r, "id"), userID)
+	if err != nil {
+		http.Error(w, "Vault not found", http.StatusNotFound)
+		return nil, nil, false
+	}
+
+	rotation, err := h.store.GetVaultRotation(r.Context(), store.GetVaultRotationParams{
+		ID:      chi.URLParam(r, "rotationID"),
+		VaultID: vault.ID,
+	})
+	if err == nil && !rotation.ExpiresAt.After(time.Now()) {
+		h.store.DeleteVaultRotation(r.Context(), rotation.ID)
+		err = sql.ErrNoRows
+	}
+	if err != nil {
+		http.Error(w, "Rotation not found or expired", http.StatusNotFound)
+		return nil, nil, false
+	}
+
+	return vault, &rotation, true
+}
+
+func (h *VaultHandler) writeRotation(w http.ResponseWriter, r *http.Request, rotation store.VaultRotation, status int) {
+	artifacts, err := h.store.ListArtifactsByVaultID(r.Context(), rotation.VaultID)
+	if err != nil {
+		http.Error(w, "Failed to retrieve artifacts", http.StatusInternalServerError)
+		return
+	}
+	replacements, err := h.store.ListRotationArtifacts(r.Context(), rotation.ID)
+	if err != nil {
+		http.Error(w, "Failed to retrieve rotation", http.StatusInternalServerError)
+		return
+	}
+
+	resp := core.VaultRotationResponse{
+		ID:        rotation.ID,
+		VaultID:   rotation.VaultID,
+		KdfSalt:   rotation.KdfSalt,
+		KDF:       rotation.KdfParams,
+		Uploaded:  []string{},
+		Missing:   []string{},
+		ExpiresAt: rotation.ExpiresAt,
+		CreatedAt: rotation.CreatedAt,
+	}
+	uploaded := make(map[string]bool, len(replacements))
+	for _, a := range replacements {
+		uploaded[a.ArtifactID] = true
+		resp.Uploaded = append(resp.Uploaded, a.ArtifactID)
+	}
+	for _, a := range artifacts {
+		if !uploaded[a.ID] {
+			resp.Missing = append(resp.Missing, a.ID)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
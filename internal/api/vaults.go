@@ -1,22 +1,35 @@
 package api
 
 import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
+	"github.com/vmpyr/afterlight/internal/bundle"
 	"github.com/vmpyr/afterlight/internal/core"
+	"github.com/vmpyr/afterlight/internal/seal"
 	"github.com/vmpyr/afterlight/internal/store"
 )
 
+// Upper bound on an uploaded vault bundle
+const maxBundleSize = 256 << 20
+
 type VaultHandler struct {
-	store *store.Store
+	store  *store.Store
+	sealer *seal.Sealer
+	quotas core.QuotaLimits // Defaults, admins can override them per user
 }
 
-func NewVaultHandler(s *store.Store) *VaultHandler {
-	return &VaultHandler{store: s}
+func NewVaultHandler(s *store.Store, sealer *seal.Sealer, quotas core.QuotaLimits) *VaultHandler {
+	return &VaultHandler{store: s, sealer: sealer, quotas: quotas}
 }
 
 func (h *VaultHandler) Routes(authMiddleware func(http.Handler) http.Handler) chi.Router {
@@ -26,8 +39,24 @@ func (h *VaultHandler) Routes(authMiddleware func(http.Handler) http.Handler) ch
 
 	r.Post("/", h.CreateVault)
 	r.Get("/", h.ListVaults)
+	r.Get("/usage", h.GetUsage)
+	r.Post("/import", h.ImportVault)
+	r.Post("/{id}/seal", h.SealVault)
+	r.Post("/{id}/rekey", h.RekeyVault)
+	r.Post("/{id}/rotations", h.StartRotation)
+	r.Get("/{id}/rotations/{rotationID}", h.GetRotation)
+	r.Delete("/{id}/rotations/{rotationID}", h.AbortRotation)
+	r.Put("/{id}/rotations/{rotationID}/artifacts/{artifactID}", h.UploadRotationArtifact)
+	r.Post("/{id}/rotations/{rotationID}/commit", h.CommitRotation)
 	r.Post("/{id}/artifacts", h.CreateArtifact)
 	r.Get("/{id}/artifacts", h.ListArtifacts)
+	r.Get("/{id}/export", h.ExportVault)
+	r.Get("/{id}/shares", h.GetShares)
+	r.Put("/{id}/shares", h.SetShares)
+	r.Delete("/{id}/shares", h.ClearShares)
+	r.Get("/{id}/recipients", h.ListRecipients)
+	r.Put("/{id}/wrapped-keys", h.SetWrappedKeys)
+	r.Delete("/{id}/wrapped-keys/{beneficiaryID}", h.DeleteWrappedKey)
 
 	return r
 }
@@ -40,19 +69,58 @@ func (h *VaultHandler) CreateVault(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	kdf := core.DefaultKDFParams
+	if req.KDF != nil {
+		kdf = *req.KDF
+	}
+	if err := core.IsValidKDFParams(kdf); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	userID := r.Context().Value(UserKey).(*store.User).ID
 
-	vault, err := h.store.CreateVault(r.Context(), store.CreateVaultParams{
+	params := store.CreateVaultParams{
 		ID:        uuid.New().String(),
 		UserID:    userID,
 		VaultName: req.VaultName,
-		Hint:      sql.NullString{String: req.Hint, Valid: req.Hint != ""},
+		Hint:      core.NullSecretString{String: req.Hint, Valid: req.Hint != ""},
 		KdfSalt:   req.KdfSalt,
-	})
+		KdfParams: kdf,
+	}
+
+	// A vault sealed from the start never has its hint stored in the clear
+	if req.Sealed {
+		if !h.sealer.Enabled() {
+			http.Error(w, core.ErrSealingDisabled.Error(), http.StatusBadRequest)
+			return
+		}
+		dataKey, keyID, wrapped, err := h.sealer.NewVaultKey(params.ID)
+		if err != nil {
+			http.Error(w, "Failed to seal vault", http.StatusInternalServerError)
+			return
+		}
+		if params.Hint.Valid {
+			hint, err := seal.SealWith(dataKey, params.ID, "hint", []byte(req.Hint))
+			if err != nil {
+				http.Error(w, "Failed to seal vault", http.StatusInternalServerError)
+				return
+			}
+			params.Hint.String = string(hint)
+		}
+		params.SealedKey = sql.NullString{String: wrapped, Valid: true}
+		params.SealedKeyID = sql.NullString{String: keyID, Valid: true}
+	}
+
+	vault, err := h.store.CreateVaultTx(r.Context(), params, h.quotas)
 	if err != nil {
+		if writeQuotaError(w, err) {
+			return
+		}
 		http.Error(w, "Failed to create vault", http.StatusInternalServerError)
 		return
 	}
+	vault.Hint = core.NullSecretString{String: req.Hint, Valid: req.Hint != ""}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
@@ -72,11 +140,130 @@ func (h *VaultHandler) ListVaults(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	for i := range vaults {
+		hint, err := h.sealer.OpenString(vaults[i], "hint", sql.NullString(vaults[i].Hint))
+		if err != nil {
+			http.Error(w, "Failed to unseal vault", http.StatusInternalServerError)
+			return
+		}
+		vaults[i].Hint = core.NullSecretString(hint)
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(vaults)
 }
 
+// Turns on sealed release for an existing vault
+func (h *VaultHandler) SealVault(w http.ResponseWriter, r *http.Request) {
+	if !h.sealer.Enabled() {
+		http.Error(w, core.ErrSealingDisabled.Error(), http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value(UserKey).(*store.User).ID
+
+	vault, err := h.GetVaultByID(r, chi.URLParam(r, "id"), userID)
+	if err != nil {
+		http.Error(w, "Vault not found", http.StatusNotFound)
+		return
+	}
+
+	if err := h.sealer.SealVault(r.Context(), *vault); err != nil {
+		if errors.Is(err, seal.ErrAlreadySealed) || errors.Is(err, store.ErrRotationInProgress) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		http.Error(w, "Failed to seal vault", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Replaces the salt, KDF, every artifact's ciphertext and the vault's shares
+// and wrapped keys in one transaction, e.g. to move a vault to a stronger KDF.
+// The client re-encrypts; the server only checks the replacements and swaps
+// them in.
+func (h *VaultHandler) RekeyVault(w http.ResponseWriter, r *http.Request) {
+	var req core.RekeyVaultRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBundleSize)).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value(UserKey).(*store.User).ID
+
+	vault, err := h.GetVaultByID(r, chi.URLParam(r, "id"), userID)
+	if err != nil {
+		http.Error(w, "Vault not found", http.StatusNotFound)
+		return
+	}
+
+	if req.KdfSalt == "" {
+		http.Error(w, "Missing kdf_salt", http.StatusBadRequest)
+		return
+	}
+	if err := core.IsValidKDFParams(req.KDF); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !vault.KdfParams.AtLeastAsStrong(req.KDF) {
+		http.Error(w, core.ErrKDFDowngrade.Error(), http.StatusBadRequest)
+		return
+	}
+
+	limits, _, err := h.store.UserQuotaLimits(r.Context(), userID, h.quotas)
+	if err != nil {
+		http.Error(w, "Failed to load quota", http.StatusInternalServerError)
+		return
+	}
+
+	material, ok := h.keyMaterial(w, r, *vault, req.Shares, req.WrappedKeys)
+	if !ok {
+		return
+	}
+
+	artifacts := make([]store.UpdateArtifactCiphertextParams, 0, len(req.Artifacts))
+	for _, a := range req.Artifacts {
+		envelopeVersion, err := core.IsValidArtifact(a.Envelope, req.KDF, a.IV, a.EncryptedBlob)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Artifact %s: %v", a.ID, err), http.StatusBadRequest)
+			return
+		}
+		if writeQuotaError(w, limits.AllowBlob(len(a.EncryptedBlob))) {
+			return
+		}
+		blob, err := h.sealer.Seal(*vault, "artifact/"+a.ID, a.EncryptedBlob)
+		if err != nil {
+			http.Error(w, "Failed to seal artifact", http.StatusInternalServerError)
+			return
+		}
+		artifacts = append(artifacts, store.UpdateArtifactCiphertextParams{
+			ID:              a.ID,
+			EncryptedBlob:   blob,
+			Iv:              a.IV,
+			EnvelopeVersion: envelopeVersion,
+		})
+	}
+
+	err = h.store.RekeyVaultTx(r.Context(), store.UpdateVaultKDFParams{
+		KdfSalt:   req.KdfSalt,
+		KdfParams: req.KDF,
+		ID:        vault.ID,
+	}, artifacts, material)
+	if err != nil {
+		if errors.Is(err, core.ErrArtifactSetMismatch) || errors.Is(err, store.ErrRotationInProgress) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		http.Error(w, "Failed to re-key vault", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
 func (h *VaultHandler) GetVaultByID(r *http.Request, vaultID, userID string) (*store.Vault, error) {
 	vault, err := h.store.GetVaultByID(r.Context(), store.GetVaultByIDParams{
 		ID:     vaultID,
@@ -87,37 +274,71 @@ func (h *VaultHandler) GetVaultByID(r *http.Request, vaultID, userID string) (*s
 
 // Artifact Handlers
 func (h *VaultHandler) CreateArtifact(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(UserKey).(*store.User).ID
+
+	limits, _, err := h.store.UserQuotaLimits(r.Context(), userID, h.quotas)
+	if err != nil {
+		http.Error(w, "Failed to load quota", http.StatusInternalServerError)
+		return
+	}
+
 	var req core.CreateArtifactRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestSize(limits))).Decode(&req); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			if !writeQuotaError(w, limits.AllowBlob(int(tooLarge.Limit))) {
+				http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+			}
+			return
+		}
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
-	userID := r.Context().Value(UserKey).(*store.User).ID
-
 	vaultID := chi.URLParam(r, "id")
 	if vaultID == "" {
 		http.Error(w, "Missing vault ID", http.StatusBadRequest)
 		return
 	}
 
-	_, err := h.GetVaultByID(r, vaultID, userID)
+	vault, err := h.GetVaultByID(r, vaultID, userID)
 	if err != nil {
 		http.Error(w, "Vault not found", http.StatusNotFound)
 		return
 	}
 
-	artifact, err := h.store.CreateArtifact(r.Context(), store.CreateArtifactParams{
-		ID:            uuid.New().String(),
-		VaultID:       vaultID,
-		MessageType:   req.MessageType,
-		EncryptedBlob: req.EncryptedBlob,
-		Iv:            req.IV,
-	})
+	envelopeVersion, err := core.IsValidArtifact(req.Envelope, vault.KdfParams, req.IV, req.EncryptedBlob)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if writeQuotaError(w, limits.AllowBlob(len(req.EncryptedBlob))) {
+		return
+	}
+
+	artifactID := uuid.New().String()
+	blob, err := h.sealer.Seal(*vault, "artifact/"+artifactID, req.EncryptedBlob)
+	if err != nil {
+		http.Error(w, "Failed to seal artifact", http.StatusInternalServerError)
+		return
+	}
+
+	artifact, err := h.store.CreateArtifactTx(r.Context(), userID, store.CreateArtifactParams{
+		ID:              artifactID,
+		VaultID:         vaultID,
+		MessageType:     req.MessageType,
+		EncryptedBlob:   blob,
+		Iv:              req.IV,
+		EnvelopeVersion: envelopeVersion,
+	}, h.quotas)
 	if err != nil {
+		if writeQuotaError(w, err) {
+			return
+		}
 		http.Error(w, "Failed to create artifact", http.StatusInternalServerError)
 		return
 	}
+	artifact.EncryptedBlob = req.EncryptedBlob
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
@@ -160,12 +381,523 @@ func (h *VaultHandler) ListArtifacts(w http.ResponseWriter, r *http.Request) {
 		artifacts = []store.Artifact{}
 	}
 
+	for i := range artifacts {
+		if artifacts[i].EncryptedBlob, err = h.sealer.Open(*vault, "artifact/"+artifacts[i].ID, artifacts[i].EncryptedBlob); err != nil {
+			http.Error(w, "Failed to unseal artifact", http.StatusInternalServerError)
+			return
+		}
+	}
+	hint, err := h.sealer.OpenString(*vault, "hint", sql.NullString(vault.Hint))
+	if err != nil {
+		http.Error(w, "Failed to unseal vault", http.StatusInternalServerError)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(store.ListArtifactsResponse{
-		VaultName: vault.VaultName,
-		Hint:      vault.Hint.String,
-		Artifacts: artifacts,
-		CreatedAt: vault.CreatedAt,
+		VaultName:              vault.VaultName,
+		Hint:                   hint.String,
+		KdfSalt:                vault.KdfSalt,
+		KDF:                    vault.KdfParams,
+		Artifacts:              artifacts,
+		CreatedAt:              vault.CreatedAt,
+		CurrentEnvelopeVersion: core.CurrentEnvelopeVersion,
 	})
 }
+
+// Bundle Handlers
+func (h *VaultHandler) ExportVault(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(UserKey).(*store.User).ID
+
+	vault, err := h.GetVaultByID(r, chi.URLParam(r, "id"), userID)
+	if err != nil {
+		http.Error(w, "Vault not found", http.StatusNotFound)
+		return
+	}
+
+	artifacts, err := h.store.ListArtifactsByVaultID(r.Context(), vault.ID)
+	if err != nil {
+		http.Error(w, "Failed to retrieve artifacts", http.StatusInternalServerError)
+		return
+	}
+
+	// Bundles carry the client ciphertext, so sealed material is opened first
+	hint, err := h.sealer.OpenString(*vault, "hint", sql.NullString(vault.Hint))
+	if err != nil {
+		http.Error(w, "Failed to unseal vault", http.StatusInternalServerError)
+		return
+	}
+	manifest := bundle.Manifest{
+		ExportedAt: time.Now().UTC(),
+		Vault: bundle.Vault{
+			Name:      vault.VaultName,
+			Hint:      hint.String,
+			KdfSalt:   vault.KdfSalt,
+			KDF:       vault.KdfParams,
+			CreatedAt: vault.CreatedAt,
+		},
+		Artifacts: make([]bundle.Artifact, 0, len(artifacts)),
+	}
+	blobs := make(map[string][]byte, len(artifacts))
+	for _, a := range artifacts {
+		if blobs[a.ID], err = h.sealer.Open(*vault, "artifact/"+a.ID, a.EncryptedBlob); err != nil {
+			http.Error(w, "Failed to unseal artifact", http.StatusInternalServerError)
+			return
+		}
+		manifest.Artifacts = append(manifest.Artifacts, bundle.Artifact{
+			ID:              a.ID,
+			MessageType:     a.MessageType,
+			IV:              a.Iv,
+			EnvelopeVersion: a.EnvelopeVersion,
+			CreatedAt:       a.CreatedAt,
+		})
+	}
+
+	key, err := h.store.BundleSigningKey(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to load signing key", http.StatusInternalServerError)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := bundle.Write(&buf, manifest, blobs, key); err != nil {
+		http.Error(w, "Failed to export vault", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-tar")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="vault-%s.tar"`, vault.ID))
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
+
+// Creates a new vault in the caller's account from a bundle exported here or elsewhere
+func (h *VaultHandler) ImportVault(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(UserKey).(*store.User).ID
+
+	b, err := bundle.Read(http.MaxBytesReader(w, r.Body, maxBundleSize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	m := b.Manifest
+	if m.Vault.Name == "" || m.Vault.KdfSalt == "" {
+		http.Error(w, "Bundle is missing the vault name or kdf_salt", http.StatusBadRequest)
+		return
+	}
+	if m.Vault.KDF.Algorithm == "" {
+		m.Vault.KDF = core.DefaultKDFParams
+	}
+	if err := core.IsValidKDFParams(m.Vault.KDF); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Imported vaults get fresh IDs, so one bundle can be imported more than once
+	vault := store.ImportVaultParams{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		VaultName: m.Vault.Name,
+		Hint:      core.NullSecretString{String: m.Vault.Hint, Valid: m.Vault.Hint != ""},
+		KdfSalt:   m.Vault.KdfSalt,
+		KdfParams: m.Vault.KDF,
+		CreatedAt: m.Vault.CreatedAt.UTC(),
+	}
+	limits, _, err := h.store.UserQuotaLimits(r.Context(), userID, h.quotas)
+	if err != nil {
+		http.Error(w, "Failed to load quota", http.StatusInternalServerError)
+		return
+	}
+
+	artifacts := make([]store.ImportArtifactParams, 0, len(m.Artifacts))
+	for _, a := range m.Artifacts {
+		if writeQuotaError(w, limits.AllowBlob(len(b.Blobs[a.ID]))) {
+			return
+		}
+		// Unversioned artifacts predate envelope checks and are carried over as they are
+		if a.EnvelopeVersion != core.EnvelopeUnversioned {
+			if _, err := core.IsValidArtifact(&core.ArtifactEnvelope{Version: a.EnvelopeVersion}, m.Vault.KDF, a.IV, b.Blobs[a.ID]); err != nil {
+				http.Error(w, fmt.Sprintf("Artifact %s: %v", a.ID, err), http.StatusBadRequest)
+				return
+			}
+		}
+		artifacts = append(artifacts, store.ImportArtifactParams{
+			ID:              uuid.New().String(),
+			MessageType:     a.MessageType,
+			EncryptedBlob:   b.Blobs[a.ID],
+			Iv:              a.IV,
+			EnvelopeVersion: a.EnvelopeVersion,
+			CreatedAt:       a.CreatedAt.UTC(),
+		})
+	}
+
+	if err := h.store.ImportVaultTx(r.Context(), vault, artifacts, h.quotas); err != nil {
+		if writeQuotaError(w, err) {
+			return
+		}
+		http.Error(w, "Failed to import vault", http.StatusInternalServerError)
+		return
+	}
+
+	key, err := h.store.BundleSigningKey(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to load signing key", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(core.ImportVaultResponse{
+		VaultID:        vault.ID,
+		VaultName:      vault.VaultName,
+		Artifacts:      len(artifacts),
+		SignatureKeyID: b.Signature.KeyID,
+		SignedHere:     b.Signature.KeyID == bundle.KeyID(key.Public().(ed25519.PublicKey)),
+	})
+}
+
+// Counts and stored bytes for the caller's account and each of its vaults
+func (h *VaultHandler) GetUsage(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(UserKey).(*store.User).ID
+
+	resp, err := usageResponse(r.Context(), h.store, userID, h.quotas)
+	if err != nil {
+		http.Error(w, "Failed to load usage", http.StatusInternalServerError)
+		return
+	}
+	resp.Overrides = nil
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func usageResponse(ctx context.Context, s *store.Store, userID string, defaults core.QuotaLimits) (core.UsageResponse, error) {
+	limits, overrides, err := s.UserQuotaLimits(ctx, userID, defaults)
+	if err != nil {
+		return core.UsageResponse{}, err
+	}
+	usage, vaults, err := s.UserUsage(ctx, userID)
+	if err != nil {
+		return core.UsageResponse{}, err
+	}
+	return core.UsageResponse{Limits: limits, Usage: usage, Vaults: vaults, Overrides: overrides}, nil
+}
+
+// Answers a quota error with 413 for an oversized artifact and 403 for a full
+// account, naming the limit in X-Quota-Exceeded. Reports whether err was one.
+func writeQuotaError(w http.ResponseWriter, err error) bool {
+	var qe *core.QuotaError
+	if !errors.As(err, &qe) {
+		return false
+	}
+	w.Header().Set("X-Quota-Exceeded", qe.Resource)
+	status := http.StatusForbidden
+	if qe.Resource == core.QuotaArtifactSize {
+		status = http.StatusRequestEntityTooLarge
+	}
+	http.Error(w, qe.Error(), status)
+	return true
+}
+
+// Bounds a JSON request carrying one base64 blob, with room for the other fields
+func maxRequestSize(limits core.QuotaLimits) int64 {
+	if limits.MaxArtifactBytes == 0 {
+		return maxBundleSize
+	}
+	return limits.MaxArtifactBytes/3*4 + 64<<10
+}
+
+// Share Handlers
+func (h *VaultHandler) GetShares(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(UserKey).(*store.User).ID
+
+	vault, err := h.GetVaultByID(r, chi.URLParam(r, "id"), userID)
+	if err != nil {
+		http.Error(w, "Vault not found", http.StatusNotFound)
+		return
+	}
+
+	shares, err := h.store.ListVaultShares(r.Context(), vault.ID)
+	if err != nil {
+		http.Error(w, "Failed to retrieve shares", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sharesResponse(*vault, shares))
+}
+
+// Replaces the vault's share set. Shares are encrypted client-side, so only
+// their metadata (threshold, indexes, holders) can be checked here.
+func (h *VaultHandler) SetShares(w http.ResponseWriter, r *http.Request) {
+	var req core.SetVaultSharesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value(UserKey).(*store.User).ID
+
+	vault, err := h.GetVaultByID(r, chi.URLParam(r, "id"), userID)
+	if err != nil {
+		http.Error(w, "Vault not found", http.StatusNotFound)
+		return
+	}
+
+	if err := h.validateShares(r.Context(), userID, req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	params, err := h.shareParams(*vault, req.Shares)
+	if err != nil {
+		http.Error(w, "Failed to seal shares", http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.store.SetVaultSharesTx(r.Context(), vault.ID, int64(req.Threshold), params); err != nil {
+		if errors.Is(err, store.ErrRotationInProgress) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		http.Error(w, "Failed to save shares", http.StatusInternalServerError)
+		return
+	}
+
+	h.GetShares(w, r)
+}
+
+func (h *VaultHandler) ClearShares(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(UserKey).(*store.User).ID
+
+	vault, err := h.GetVaultByID(r, chi.URLParam(r, "id"), userID)
+	if err != nil {
+		http.Error(w, "Vault not found", http.StatusNotFound)
+		return
+	}
+
+	if err := h.store.ClearVaultSharesTx(r.Context(), vault.ID); err != nil {
+		if errors.Is(err, store.ErrRotationInProgress) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		http.Error(w, "Failed to clear shares", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Checks a share set and that every shareholder is one of the owner's beneficiaries
+func (h *VaultHandler) validateShares(ctx context.Context, userID string, req core.SetVaultSharesRequest) error {
+	if err := core.IsValidShareSet(req); err != nil {
+		return err
+	}
+	for _, share := range req.Shares {
+		if _, err := h.store.GetBeneficiaryByID(ctx, store.GetBeneficiaryByIDParams{
+			ID:     share.BeneficiaryID,
+			UserID: userID,
+		}); err != nil {
+			return errors.New("Beneficiary not found: " + share.BeneficiaryID)
+		}
+	}
+	return nil
+}
+
+// Seals validated shares the way the vault stores them
+func (h *VaultHandler) shareParams(vault store.Vault, shares []core.VaultShareRequest) ([]store.UpsertVaultShareParams, error) {
+	params := make([]store.UpsertVaultShareParams, 0, len(shares))
+	for _, share := range shares {
+		encrypted, err := h.sealer.SealString(vault, "encrypted_share/"+share.BeneficiaryID, sql.NullString{String: share.EncryptedShare, Valid: true})
+		if err != nil {
+			return nil, err
+		}
+		params = append(params, store.UpsertVaultShareParams{
+			BeneficiaryID:  share.BeneficiaryID,
+			ShareIndex:     sql.NullInt64{Int64: int64(share.Index), Valid: true},
+			EncryptedShare: encrypted,
+		})
+	}
+	return params, nil
+}
+
+// Checks and seals the key material sent with a re-key or rotation commit,
+// writing a response if it is rejected
+func (h *VaultHandler) keyMaterial(w http.ResponseWriter, r *http.Request, vault store.Vault, shares *core.SetVaultSharesRequest, keys []core.WrappedKeyRequest) (store.VaultKeyMaterial, bool) {
+	userID := r.Context().Value(UserKey).(*store.User).ID
+
+	var material store.VaultKeyMaterial
+	var err error
+	if shares != nil {
+		if err := h.validateShares(r.Context(), userID, *shares); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return material, false
+		}
+		material.ShareThreshold = int64(shares.Threshold)
+		if material.Shares, err = h.shareParams(vault, shares.Shares); err != nil {
+			http.Error(w, "Failed to seal shares", http.StatusInternalServerError)
+			return material, false
+		}
+	}
+	if err := h.validateWrappedKeys(r.Context(), userID, keys); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return material, false
+	}
+	if material.WrappedKeys, err = h.wrappedKeyParams(vault, keys); err != nil {
+		http.Error(w, "Failed to seal wrapped keys", http.StatusInternalServerError)
+		return material, false
+	}
+	return material, true
+}
+
+func sharesResponse(v store.Vault, shares []store.VaultAccess) core.VaultSharesResponse {
+	resp := core.VaultSharesResponse{
+		VaultID:   v.ID,
+		Threshold: v.ShareThreshold.Int64,
+		Count:     v.ShareCount.Int64,
+		Shares:    make([]core.VaultShareResponse, 0, len(shares)),
+	}
+	for _, s := range shares {
+		resp.Shares = append(resp.Shares, core.VaultShareResponse{
+			BeneficiaryID: s.BeneficiaryID,
+			Index:         s.ShareIndex.Int64,
+		})
+	}
+	return resp
+}
+
+// Wrapped Key Handlers
+
+// Lists the user's beneficiaries with their public keys, so the client knows
+// whom to wrap the vault key to and who already has it
+func (h *VaultHandler) ListRecipients(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(UserKey).(*store.User).ID
+
+	vault, err := h.GetVaultByID(r, chi.URLParam(r, "id"), userID)
+	if err != nil {
+		http.Error(w, "Vault not found", http.StatusNotFound)
+		return
+	}
+
+	recipients, err := h.store.ListVaultRecipients(r.Context(), store.ListVaultRecipientsParams{
+		VaultID: vault.ID,
+		UserID:  userID,
+	})
+	if err != nil {
+		http.Error(w, "Failed to retrieve recipients", http.StatusInternalServerError)
+		return
+	}
+
+	resp := make([]core.VaultRecipientResponse, 0, len(recipients))
+	for _, rcpt := range recipients {
+		resp = append(resp, core.VaultRecipientResponse{
+			BeneficiaryID:   rcpt.ID,
+			BeneficiaryName: string(rcpt.BeneficiaryName),
+			PublicKey:       rcpt.PublicKey.String,
+			HasWrappedKey:   rcpt.WrappedKey.Valid,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// Stores vault keys wrapped client-side to beneficiaries' public keys. The
+// server never sees the plaintext key, only the age-encrypted result.
+func (h *VaultHandler) SetWrappedKeys(w http.ResponseWriter, r *http.Request) {
+	var req core.SetWrappedKeysRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value(UserKey).(*store.User).ID
+
+	vault, err := h.GetVaultByID(r, chi.URLParam(r, "id"), userID)
+	if err != nil {
+		http.Error(w, "Vault not found", http.StatusNotFound)
+		return
+	}
+
+	if err := h.validateWrappedKeys(r.Context(), userID, req.Keys); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	params, err := h.wrappedKeyParams(*vault, req.Keys)
+	if err != nil {
+		http.Error(w, "Failed to seal wrapped keys", http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.store.SetWrappedKeysTx(r.Context(), vault.ID, params); err != nil {
+		if errors.Is(err, store.ErrRotationInProgress) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		http.Error(w, "Failed to save wrapped keys", http.StatusInternalServerError)
+		return
+	}
+
+	h.ListRecipients(w, r)
+}
+
+// Checks that every key is an age file for one of the owner's beneficiaries with a public key
+func (h *VaultHandler) validateWrappedKeys(ctx context.Context, userID string, keys []core.WrappedKeyRequest) error {
+	for _, key := range keys {
+		beneficiary, err := h.store.GetBeneficiaryByID(ctx, store.GetBeneficiaryByIDParams{
+			ID:     key.BeneficiaryID,
+			UserID: userID,
+		})
+		if err != nil {
+			return errors.New("Beneficiary not found: " + key.BeneficiaryID)
+		}
+		if !beneficiary.PublicKey.Valid {
+			return fmt.Errorf("%w: %s", core.ErrMissingPublicKey, key.BeneficiaryID)
+		}
+		if err := core.IsValidWrappedKey(key.WrappedKey); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Seals validated wrapped keys the way the vault stores them
+func (h *VaultHandler) wrappedKeyParams(vault store.Vault, keys []core.WrappedKeyRequest) ([]store.UpsertWrappedKeyParams, error) {
+	params := make([]store.UpsertWrappedKeyParams, 0, len(keys))
+	for _, key := range keys {
+		wrapped, err := h.sealer.SealString(vault, "wrapped_key/"+key.BeneficiaryID, sql.NullString{String: key.WrappedKey, Valid: true})
+		if err != nil {
+			return nil, err
+		}
+		params = append(params, store.UpsertWrappedKeyParams{
+			VaultID:       vault.ID,
+			BeneficiaryID: key.BeneficiaryID,
+			WrappedKey:    wrapped,
+		})
+	}
+	return params, nil
+}
+
+func (h *VaultHandler) DeleteWrappedKey(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(UserKey).(*store.User).ID
+
+	vault, err := h.GetVaultByID(r, chi.URLParam(r, "id"), userID)
+	if err != nil {
+		http.Error(w, "Vault not found", http.StatusNotFound)
+		return
+	}
+
+	if err := h.store.DeleteWrappedKeyTx(r.Context(), store.DeleteWrappedKeyParams{
+		VaultID:       vault.ID,
+		BeneficiaryID: chi.URLParam(r, "beneficiaryID"),
+	}); err != nil {
+		if errors.Is(err, store.ErrRotationInProgress) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		http.Error(w, "Failed to delete wrapped key", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,111 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/vmpyr/afterlight/internal/core"
+	"github.com/vmpyr/afterlight/internal/store"
+)
+
+// VerifierHandler lets verifiers confirm the owner's death. Like release,
+// there is no login: the code sent in VERIFICATION_REQUESTED is the
+// credential. It stops working when the owner checks in.
+type VerifierHandler struct {
+	store *store.Store
+}
+
+func NewVerifierHandler(s *store.Store) *VerifierHandler {
+	return &VerifierHandler{store: s}
+}
+
+func (h *VerifierHandler) Routes() chi.Router {
+	r := chi.NewRouter()
+
+	r.Route("/{token}", func(r chi.Router) {
+		r.Use(h.verifyTokenMiddleware)
+		r.Get("/", h.GetVerification)
+		r.Post("/confirm", h.Confirm)
+	})
+
+	return r
+}
+
+func (h *VerifierHandler) verifyTokenMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		verifier, err := h.store.GetBeneficiaryByVerifyToken(r.Context(), sql.NullString{
+			String: core.HashToken(chi.URLParam(r, "token")),
+			Valid:  true,
+		})
+		if err != nil {
+			http.Error(w, "Unknown verification code", http.StatusNotFound)
+			return
+		}
+
+		owner, err := h.store.GetUserByID(r.Context(), verifier.UserID)
+		if err != nil {
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), BeneficiaryKey, &verifier)
+		ctx = context.WithValue(ctx, UserKey, &owner)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// Handlers
+
+// Safe to open from an email client that prefetches links; only POST confirms
+func (h *VerifierHandler) GetVerification(w http.ResponseWriter, r *http.Request) {
+	verifier := r.Context().Value(BeneficiaryKey).(*store.Beneficiary)
+	owner := r.Context().Value(UserKey).(*store.User)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(verifierResponse(*verifier, *owner))
+}
+
+func (h *VerifierHandler) Confirm(w http.ResponseWriter, r *http.Request) {
+	verifier := r.Context().Value(BeneficiaryKey).(*store.Beneficiary)
+	owner := r.Context().Value(UserKey).(*store.User)
+
+	if owner.CurrentStatus != core.StatusVerify || owner.DisabledAt.Valid {
+		http.Error(w, core.ErrNotAwaitingVerification.Error(), http.StatusConflict)
+		return
+	}
+
+	now := time.Now().UTC()
+	n, err := h.store.ConfirmVerifier(r.Context(), store.ConfirmVerifierParams{
+		ConfirmedAt: sql.NullTime{Time: now, Valid: true},
+		ID:          verifier.ID,
+	})
+	if err != nil {
+		http.Error(w, "Failed to record confirmation", http.StatusInternalServerError)
+		return
+	}
+	// A repeated confirmation keeps the original time
+	if n > 0 {
+		verifier.HasConfirmed = sql.NullBool{Bool: true, Valid: true}
+		verifier.ConfirmedAt = sql.NullTime{Time: now, Valid: true}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(verifierResponse(*verifier, *owner))
+}
+
+func verifierResponse(b store.Beneficiary, owner store.User) core.VerifierResponse {
+	resp := core.VerifierResponse{
+		OwnerName:            string(owner.Name),
+		VerifierName:         string(b.BeneficiaryName),
+		AwaitingConfirmation: owner.CurrentStatus == core.StatusVerify && !owner.DisabledAt.Valid,
+		Confirmed:            b.HasConfirmed.Bool,
+	}
+	if b.ConfirmedAt.Valid {
+		resp.ConfirmedAt = &b.ConfirmedAt.Time
+	}
+	return resp
+}
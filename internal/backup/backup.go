@@ -0,0 +1,239 @@
+// Package backup writes consistent snapshots of the database and the
+// artifacts directory into a single archive and restores them.
+//
+// An archive is a gzipped tar holding afterlight.db, artifacts/... and,
+// last, manifest.json with the size and SHA-256 of every other entry. It is
+// optionally encrypted to age X25519 recipients as a whole.
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"filippo.io/age"
+	"github.com/mattn/go-sqlite3"
+)
+
+const (
+	Format  = "afterlight-backup"
+	Version = 1
+
+	dbEntry       = "afterlight.db"
+	artifactsDir  = "artifacts"
+	manifestEntry = "manifest.json"
+)
+
+var ErrInvalidArchive = errors.New("not a valid afterlight backup")
+var ErrUnsupportedVersion = errors.New("unsupported backup version")
+var ErrEncrypted = errors.New("backup is encrypted, an age identity is required")
+var ErrChecksumMismatch = errors.New("backup entry does not match its checksum")
+var ErrIntegrity = errors.New("restored database failed the integrity check")
+
+type Manifest struct {
+	Format        string    `json:"format"`
+	Version       int       `json:"version"`
+	CreatedAt     time.Time `json:"created_at"`
+	SchemaVersion int64     `json:"schema_version"` // Highest applied migration
+	Files         []File    `json:"files"`
+}
+
+type File struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// Create snapshots db with the SQLite online backup API, so writers are not
+// blocked and the copy is consistent, and streams it to w together with
+// every file under artifactsPath. With recipients, the archive is encrypted.
+func Create(ctx context.Context, db *sql.DB, artifactsPath string, w io.Writer, recipients []age.Recipient) (*Manifest, error) {
+	tmp, err := os.MkdirTemp("", "afterlight-backup-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+
+	snapshotPath := filepath.Join(tmp, dbEntry)
+	if err := snapshot(ctx, db, snapshotPath); err != nil {
+		return nil, fmt.Errorf("snapshotting database: %w", err)
+	}
+
+	m := &Manifest{Format: Format, Version: Version, CreatedAt: time.Now().UTC()}
+	if m.SchemaVersion, err = schemaVersion(ctx, snapshotPath); err != nil {
+		return nil, err
+	}
+
+	out := w
+	var enc io.WriteCloser
+	if len(recipients) > 0 {
+		if enc, err = age.Encrypt(w, recipients...); err != nil {
+			return nil, err
+		}
+		out = enc
+	}
+	gz := gzip.NewWriter(out)
+	tw := tar.NewWriter(gz)
+
+	if err := addFile(tw, m, dbEntry, snapshotPath); err != nil {
+		return nil, err
+	}
+	if err := addArtifacts(tw, m, artifactsPath); err != nil {
+		return nil, err
+	}
+
+	manifest, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := tw.WriteHeader(&tar.Header{Name: manifestEntry, Mode: 0600, Size: int64(len(manifest)), ModTime: m.CreatedAt}); err != nil {
+		return nil, err
+	}
+	if _, err := tw.Write(manifest); err != nil {
+		return nil, err
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	if enc != nil {
+		if err := enc.Close(); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+func snapshot(ctx context.Context, db *sql.DB, dest string) error {
+	destDB, err := sql.Open("sqlite3", "file:"+dest+"?mode=rwc")
+	if err != nil {
+		return err
+	}
+	defer destDB.Close()
+
+	destConn, err := destDB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer destConn.Close()
+	srcConn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer srcConn.Close()
+
+	return destConn.Raw(func(dc any) error {
+		return srcConn.Raw(func(sc any) error {
+			bk, err := dc.(*sqlite3.SQLiteConn).Backup("main", sc.(*sqlite3.SQLiteConn), "main")
+			if err != nil {
+				return err
+			}
+			for {
+				// Step reports busy and locked sources as not done; retry until it is
+				done, err := bk.Step(-1)
+				if err != nil {
+					bk.Finish()
+					return err
+				}
+				if done {
+					return bk.Finish()
+				}
+				select {
+				case <-ctx.Done():
+					bk.Finish()
+					return ctx.Err()
+				case <-time.After(50 * time.Millisecond):
+				}
+			}
+		})
+	})
+}
+
+func schemaVersion(ctx context.Context, dbPath string) (int64, error) {
+	db, err := sql.Open("sqlite3", "file:"+dbPath+"?mode=ro")
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+
+	var version int64
+	err = db.QueryRowContext(ctx, "SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&version)
+	return version, err
+}
+
+func addArtifacts(tw *tar.Writer, m *Manifest, root string) error {
+	return filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && p == root {
+				return nil
+			}
+			return err
+		}
+		// Skips directories, symlinks and the readiness probe's scratch files
+		if !d.Type().IsRegular() || strings.HasPrefix(d.Name(), ".readyz-") {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		return addFile(tw, m, path.Join(artifactsDir, filepath.ToSlash(rel)), p)
+	})
+}
+
+// Hashes while copying, so a file changing underneath is still recorded as written
+func addFile(tw *tar.Writer, m *Manifest, name, src string) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0600, Size: info.Size(), ModTime: info.ModTime()}); err != nil {
+		return err
+	}
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(tw, h), io.LimitReader(f, info.Size()))
+	if err != nil {
+		return err
+	}
+	if n != info.Size() {
+		return fmt.Errorf("%s shrank while being backed up", name)
+	}
+
+	m.Files = append(m.Files, File{Path: name, Size: n, SHA256: hex.EncodeToString(h.Sum(nil))})
+	return nil
+}
+
+// ParseRecipients parses age X25519 public keys ("age1...")
+func ParseRecipients(keys []string) ([]age.Recipient, error) {
+	recipients := make([]age.Recipient, 0, len(keys))
+	for _, k := range keys {
+		r, err := age.ParseX25519Recipient(strings.TrimSpace(k))
+		if err != nil {
+			return nil, fmt.Errorf("invalid recipient %q: %w", k, err)
+		}
+		recipients = append(recipients, r)
+	}
+	return recipients, nil
+}
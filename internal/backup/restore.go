@@ -0,0 +1,213 @@
+package backup
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"filippo.io/age"
+)
+
+const ageHeader = "age-encryption.org/v1"
+
+// Verify checks an archive end to end without touching the installation:
+// every entry against the manifest, and the database's integrity
+func Verify(ctx context.Context, r io.Reader, identities []age.Identity) (*Manifest, error) {
+	tmp, err := os.MkdirTemp("", "afterlight-restore-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+
+	return extract(ctx, r, identities, tmp)
+}
+
+// Restore verifies the archive, then replaces the database at dbPath and the
+// contents of artifactsPath. The server must be stopped. Existing data is only
+// overwritten with force.
+func Restore(ctx context.Context, r io.Reader, identities []age.Identity, dbPath, artifactsPath string, force bool) (*Manifest, error) {
+	if !force {
+		if _, err := os.Stat(dbPath); err == nil {
+			return nil, fmt.Errorf("%s already exists", dbPath)
+		}
+		if entries, err := os.ReadDir(artifactsPath); err == nil && len(entries) > 0 {
+			return nil, fmt.Errorf("%s is not empty", artifactsPath)
+		}
+	}
+
+	// Extracting next to the target keeps the final renames on one filesystem
+	if dir := filepath.Dir(dbPath); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, err
+		}
+	}
+	tmp, err := os.MkdirTemp(filepath.Dir(dbPath), ".afterlight-restore-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+
+	m, err := extract(ctx, r, identities, tmp)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, suffix := range []string{"-wal", "-shm"} {
+		if err := os.Remove(dbPath + suffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+	}
+	if err := os.Rename(filepath.Join(tmp, dbEntry), dbPath); err != nil {
+		return nil, err
+	}
+
+	if err := os.RemoveAll(artifactsPath); err != nil {
+		return nil, err
+	}
+	restored := filepath.Join(tmp, artifactsDir)
+	if _, err := os.Stat(restored); errors.Is(err, os.ErrNotExist) {
+		return m, os.MkdirAll(artifactsPath, 0700)
+	}
+	if err := os.Rename(restored, artifactsPath); err != nil {
+		// Different filesystems; fall back to copying
+		if err := os.CopyFS(artifactsPath, os.DirFS(restored)); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// Unpacks the archive into dir and checks it against its manifest
+func extract(ctx context.Context, r io.Reader, identities []age.Identity, dir string) (*Manifest, error) {
+	br := bufio.NewReader(r)
+	if head, _ := br.Peek(len(ageHeader)); string(head) == ageHeader {
+		if len(identities) == 0 {
+			return nil, ErrEncrypted
+		}
+		dec, err := age.Decrypt(br, identities...)
+		if err != nil {
+			return nil, fmt.Errorf("decrypting backup: %w", err)
+		}
+		br = bufio.NewReader(dec)
+	}
+
+	gz, err := gzip.NewReader(br)
+	if err != nil {
+		return nil, ErrInvalidArchive
+	}
+	tr := tar.NewReader(gz)
+
+	written := make(map[string]File)
+	var manifest []byte
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			return nil, fmt.Errorf("%w: unexpected entry %s", ErrInvalidArchive, hdr.Name)
+		}
+
+		name := hdr.Name
+		switch {
+		case name == manifestEntry:
+			if manifest, err = io.ReadAll(io.LimitReader(tr, 64<<20)); err != nil {
+				return nil, err
+			}
+			continue
+		case name == dbEntry:
+		case strings.HasPrefix(name, artifactsDir+"/") && path.Clean(name) == name && !strings.Contains(name, ".."):
+		default:
+			return nil, fmt.Errorf("%w: unexpected entry %s", ErrInvalidArchive, name)
+		}
+		if _, dup := written[name]; dup {
+			return nil, fmt.Errorf("%w: duplicate entry %s", ErrInvalidArchive, name)
+		}
+
+		f, err := writeEntry(tr, filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil {
+			return nil, err
+		}
+		f.Path = name
+		written[name] = f
+	}
+
+	if manifest == nil {
+		return nil, fmt.Errorf("%w: no manifest", ErrInvalidArchive)
+	}
+	var m Manifest
+	if err := json.NewDecoder(bytes.NewReader(manifest)).Decode(&m); err != nil || m.Format != Format {
+		return nil, ErrInvalidArchive
+	}
+	if m.Version != Version {
+		return nil, ErrUnsupportedVersion
+	}
+
+	if len(m.Files) != len(written) {
+		return nil, fmt.Errorf("%w: manifest lists %d files, archive holds %d", ErrInvalidArchive, len(m.Files), len(written))
+	}
+	for _, want := range m.Files {
+		if got, ok := written[want.Path]; !ok || got != want {
+			return nil, fmt.Errorf("%w: %s", ErrChecksumMismatch, want.Path)
+		}
+	}
+	if _, ok := written[dbEntry]; !ok {
+		return nil, fmt.Errorf("%w: no database", ErrInvalidArchive)
+	}
+
+	if err := checkIntegrity(ctx, filepath.Join(dir, dbEntry)); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func writeEntry(r io.Reader, dest string) (File, error) {
+	if err := os.MkdirAll(filepath.Dir(dest), 0700); err != nil {
+		return File{}, err
+	}
+	f, err := os.OpenFile(dest, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return File{}, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(f, h), r)
+	if err != nil {
+		return File{}, err
+	}
+	return File{Size: n, SHA256: hex.EncodeToString(h.Sum(nil))}, f.Sync()
+}
+
+func checkIntegrity(ctx context.Context, dbPath string) error {
+	db, err := sql.Open("sqlite3", "file:"+dbPath+"?mode=ro")
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	var result string
+	if err := db.QueryRowContext(ctx, "PRAGMA integrity_check").Scan(&result); err != nil {
+		return fmt.Errorf("%w: %v", ErrIntegrity, err)
+	}
+	if result != "ok" {
+		return fmt.Errorf("%w: %s", ErrIntegrity, result)
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package backup
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"filippo.io/age"
+)
+
+const filePrefix = "afterlight-"
+
+// FileName is the name a backup taken at t gets in a backup directory.
+// Names sort by time, which is what retention relies on.
+func FileName(t time.Time, encrypted bool) string {
+	name := filePrefix + t.UTC().Format("20060102T150405Z") + ".tar.gz"
+	if encrypted {
+		name += ".age"
+	}
+	return name
+}
+
+// WriteFile creates a backup in dir and returns its path. The archive only
+// appears under its final name once complete.
+func WriteFile(ctx context.Context, db *sql.DB, artifactsPath, dir string, recipients []age.Recipient) (string, *Manifest, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", nil, err
+	}
+	dest := filepath.Join(dir, FileName(time.Now(), len(recipients) > 0))
+	f, err := os.OpenFile(dest+".partial", os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return "", nil, err
+	}
+	defer os.Remove(f.Name())
+
+	m, err := Create(ctx, db, artifactsPath, f, recipients)
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return "", nil, err
+	}
+	return dest, m, os.Rename(f.Name(), dest)
+}
+
+// Prune deletes all but the newest keep backups in dir
+func Prune(dir string, keep int) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var backups []string
+	for _, e := range entries {
+		name := e.Name()
+		if e.Type().IsRegular() && strings.HasPrefix(name, filePrefix) &&
+			(strings.HasSuffix(name, ".tar.gz") || strings.HasSuffix(name, ".tar.gz.age")) {
+			backups = append(backups, name)
+		}
+	}
+	if len(backups) <= keep {
+		return nil, nil
+	}
+
+	slices.Sort(backups)
+	var removed []string
+	for _, name := range backups[:len(backups)-keep] {
+		if err := os.Remove(filepath.Join(dir, name)); err != nil {
+			return removed, err
+		}
+		removed = append(removed, name)
+	}
+	return removed, nil
+}
+
+// Scheduler takes a backup every interval and keeps the newest ones
+type Scheduler struct {
+	db            *sql.DB
+	artifactsPath string
+	dir           string
+	interval      time.Duration
+	keep          int
+	recipients    []age.Recipient
+}
+
+func NewScheduler(db *sql.DB, artifactsPath, dir string, interval time.Duration, keep int, recipients []age.Recipient) *Scheduler {
+	return &Scheduler{db: db, artifactsPath: artifactsPath, dir: dir, interval: interval, keep: keep, recipients: recipients}
+}
+
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		path, m, err := WriteFile(ctx, s.db, s.artifactsPath, s.dir, s.recipients)
+		if err != nil {
+			if ctx.Err() == nil {
+				slog.ErrorContext(ctx, "scheduled backup failed", "error", err)
+			}
+			continue
+		}
+		slog.InfoContext(ctx, "backup written", "path", path, "files", len(m.Files))
+
+		removed, err := Prune(s.dir, s.keep)
+		if err != nil {
+			slog.ErrorContext(ctx, "pruning backups failed", "error", err)
+		}
+		if len(removed) > 0 {
+			slog.InfoContext(ctx, "old backups removed", "count", len(removed))
+		}
+	}
+}
@@ -0,0 +1,264 @@
+// Package bundle reads and writes portable vault bundles.
+//
+// A bundle is a tar archive with three kinds of entries:
+//
+//	manifest.json      vault metadata and one record per artifact
+//	artifacts/<id>.bin the artifact ciphertext exactly as the client uploaded it
+//	signature.json     an Ed25519 signature over the manifest bytes
+//
+// Each artifact record carries the SHA-256 of its entry, so the signature over
+// the manifest covers the whole bundle. Nothing in a bundle is decrypted by
+// the server; it holds the same client-side ciphertext the vault does.
+package bundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"time"
+
+	"github.com/vmpyr/afterlight/internal/core"
+)
+
+const (
+	Format  = "afterlight-vault"
+	Version = 1
+
+	ManifestPath  = "manifest.json"
+	SignaturePath = "signature.json"
+
+	SignatureAlgorithm = "ed25519"
+	MaxEntrySize       = 64 << 20
+)
+
+var ErrInvalidBundle = errors.New("invalid vault bundle")
+var ErrUnsupportedVersion = errors.New("unsupported vault bundle version")
+var ErrBadSignature = errors.New("vault bundle signature does not match its manifest")
+var ErrHashMismatch = errors.New("vault bundle entry does not match its manifest hash")
+
+type Manifest struct {
+	Format     string     `json:"format"`
+	Version    int        `json:"version"`
+	ExportedAt time.Time  `json:"exported_at"`
+	Vault      Vault      `json:"vault"`
+	Artifacts  []Artifact `json:"artifacts"`
+}
+
+type Vault struct {
+	Name      string         `json:"name"`
+	Hint      string         `json:"hint,omitempty"`
+	KdfSalt   string         `json:"kdf_salt"`
+	KDF       core.KDFParams `json:"kdf"` // Unset in bundles from before KDFs were stored: the default
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+type Artifact struct {
+	ID              string               `json:"id"`
+	MessageType     core.MessageType     `json:"message_type"`
+	IV              string               `json:"iv"`
+	EnvelopeVersion core.EnvelopeVersion `json:"envelope_version"`
+	CreatedAt       time.Time            `json:"created_at"`
+	Path            string               `json:"path"`
+	Size            int64                `json:"size"`
+	SHA256          string               `json:"sha256"` // Hex
+}
+
+type Signature struct {
+	Algorithm string `json:"algorithm"`
+	KeyID     string `json:"key_id"`
+	PublicKey string `json:"public_key"` // Base64
+	Signature string `json:"signature"`  // Base64, over the raw manifest.json bytes
+}
+
+// A bundle that has been read and verified
+type Bundle struct {
+	Manifest  Manifest
+	Signature Signature
+	Blobs     map[string][]byte // Artifact ciphertext by artifact ID
+}
+
+// Short fingerprint of a signing key
+func KeyID(pub ed25519.PublicKey) string {
+	sum := sha256.Sum256(pub)
+	return hex.EncodeToString(sum[:8])
+}
+
+// Write fills in the format fields and entry hashes of m, signs it and writes
+// the bundle. blobs holds the ciphertext of every artifact in m, by ID.
+func Write(w io.Writer, m Manifest, blobs map[string][]byte, key ed25519.PrivateKey) error {
+	m.Format = Format
+	m.Version = Version
+	for i := range m.Artifacts {
+		a := &m.Artifacts[i]
+		blob, ok := blobs[a.ID]
+		if !ok {
+			return fmt.Errorf("no ciphertext for artifact %s", a.ID)
+		}
+		sum := sha256.Sum256(blob)
+		a.Path = artifactPath(a.ID)
+		a.Size = int64(len(blob))
+		a.SHA256 = hex.EncodeToString(sum[:])
+	}
+
+	manifest, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	pub := key.Public().(ed25519.PublicKey)
+	signature, err := json.MarshalIndent(Signature{
+		Algorithm: SignatureAlgorithm,
+		KeyID:     KeyID(pub),
+		PublicKey: base64.StdEncoding.EncodeToString(pub),
+		Signature: base64.StdEncoding.EncodeToString(ed25519.Sign(key, manifest)),
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tw := tar.NewWriter(w)
+	add := func(name string, data []byte) error {
+		if err := tw.WriteHeader(&tar.Header{
+			Name:     name,
+			Mode:     0600,
+			Size:     int64(len(data)),
+			ModTime:  m.ExportedAt,
+			Typeflag: tar.TypeReg,
+		}); err != nil {
+			return err
+		}
+		_, err := tw.Write(data)
+		return err
+	}
+
+	if err := add(ManifestPath, manifest); err != nil {
+		return err
+	}
+	for _, a := range m.Artifacts {
+		if err := add(a.Path, blobs[a.ID]); err != nil {
+			return err
+		}
+	}
+	if err := add(SignaturePath, signature); err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+// Read parses a bundle and checks its signature and every entry hash. The
+// signature is checked against the key embedded in the bundle; whether that
+// key is trusted is up to the caller.
+func Read(r io.Reader) (*Bundle, error) {
+	entries := map[string][]byte{}
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidBundle, err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			return nil, fmt.Errorf("%w: %s is not a regular file", ErrInvalidBundle, hdr.Name)
+		}
+		if _, dup := entries[hdr.Name]; dup {
+			return nil, fmt.Errorf("%w: duplicate entry %s", ErrInvalidBundle, hdr.Name)
+		}
+		if hdr.Size > MaxEntrySize {
+			return nil, fmt.Errorf("%w: %s is too large", ErrInvalidBundle, hdr.Name)
+		}
+		data, err := io.ReadAll(io.LimitReader(tr, MaxEntrySize))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidBundle, err)
+		}
+		entries[hdr.Name] = data
+	}
+
+	manifestBytes, ok := entries[ManifestPath]
+	if !ok {
+		return nil, fmt.Errorf("%w: missing %s", ErrInvalidBundle, ManifestPath)
+	}
+	signatureBytes, ok := entries[SignaturePath]
+	if !ok {
+		return nil, fmt.Errorf("%w: missing %s", ErrInvalidBundle, SignaturePath)
+	}
+
+	var b Bundle
+	if err := json.Unmarshal(signatureBytes, &b.Signature); err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidBundle, SignaturePath, err)
+	}
+	if err := b.verifySignature(manifestBytes); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(manifestBytes, &b.Manifest); err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidBundle, ManifestPath, err)
+	}
+	if b.Manifest.Format != Format {
+		return nil, fmt.Errorf("%w: format is %q", ErrInvalidBundle, b.Manifest.Format)
+	}
+	if b.Manifest.Version != Version {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedVersion, b.Manifest.Version)
+	}
+
+	b.Blobs = make(map[string][]byte, len(b.Manifest.Artifacts))
+	for _, a := range b.Manifest.Artifacts {
+		if a.ID == "" || a.Path != artifactPath(a.ID) {
+			return nil, fmt.Errorf("%w: artifact %q has path %q", ErrInvalidBundle, a.ID, a.Path)
+		}
+		if _, dup := b.Blobs[a.ID]; dup {
+			return nil, fmt.Errorf("%w: duplicate artifact %s", ErrInvalidBundle, a.ID)
+		}
+		blob, ok := entries[a.Path]
+		if !ok {
+			return nil, fmt.Errorf("%w: missing %s", ErrInvalidBundle, a.Path)
+		}
+		sum := sha256.Sum256(blob)
+		if int64(len(blob)) != a.Size || hex.EncodeToString(sum[:]) != a.SHA256 {
+			return nil, fmt.Errorf("%w: %s", ErrHashMismatch, a.Path)
+		}
+		b.Blobs[a.ID] = blob
+	}
+
+	if len(entries) != len(b.Blobs)+2 {
+		return nil, fmt.Errorf("%w: entries not listed in the manifest", ErrInvalidBundle)
+	}
+	return &b, nil
+}
+
+func (b *Bundle) verifySignature(manifest []byte) error {
+	s := b.Signature
+	if s.Algorithm != SignatureAlgorithm {
+		return fmt.Errorf("%w: unsupported signature algorithm %q", ErrInvalidBundle, s.Algorithm)
+	}
+	pub, err := base64.StdEncoding.DecodeString(s.PublicKey)
+	if err != nil || len(pub) != ed25519.PublicKeySize {
+		return fmt.Errorf("%w: invalid public key", ErrInvalidBundle)
+	}
+	sig, err := base64.StdEncoding.DecodeString(s.Signature)
+	if err != nil {
+		return ErrBadSignature
+	}
+	if s.KeyID != KeyID(pub) || !ed25519.Verify(pub, manifest, sig) {
+		return ErrBadSignature
+	}
+	return nil
+}
+
+// Reports whether data looks like a tar archive rather than a JSON export
+func IsBundle(data []byte) bool {
+	_, err := tar.NewReader(bytes.NewReader(data)).Next()
+	return err == nil
+}
+
+func artifactPath(id string) string {
+	return path.Join("artifacts", path.Base(id)+".bin")
+}
@@ -0,0 +1,100 @@
+// Package certs serves a TLS certificate that can be replaced on disk without
+// restarting the server.
+package certs
+
+import (
+	"context"
+	"crypto/tls"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Reloader holds the current certificate and reloads it on SIGHUP or when
+// either file changes. A failed reload keeps serving the previous certificate.
+type Reloader struct {
+	certFile string
+	keyFile  string
+
+	mu      sync.RWMutex
+	cert    *tls.Certificate
+	modTime time.Time
+}
+
+func NewReloader(certFile, keyFile string) (*Reloader, error) {
+	r := &Reloader{certFile: certFile, keyFile: keyFile}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *Reloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
+
+// A broken pair is not retried until the files change again
+func (r *Reloader) Reload() error {
+	modTime := r.latestModTime()
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.modTime = modTime
+	if err != nil {
+		return err
+	}
+	r.cert = &cert
+	return nil
+}
+
+// Watch reloads on SIGHUP and checks the files for changes every interval
+// until ctx is cancelled
+func (r *Reloader) Watch(ctx context.Context, interval time.Duration) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			r.reload("SIGHUP")
+		case <-ticker.C:
+			r.mu.RLock()
+			changed := r.latestModTime().After(r.modTime)
+			r.mu.RUnlock()
+			if changed {
+				r.reload("file change")
+			}
+		}
+	}
+}
+
+func (r *Reloader) reload(reason string) {
+	if err := r.Reload(); err != nil {
+		slog.Error("TLS reload failed, keeping the current certificate", "reason", reason, "error", err)
+		return
+	}
+	slog.Info("TLS certificate reloaded", "reason", reason)
+}
+
+// Certificates are often replaced as a pair, so the newer of the two counts
+func (r *Reloader) latestModTime() time.Time {
+	var latest time.Time
+	for _, path := range []string{r.certFile, r.keyFile} {
+		if info, err := os.Stat(path); err == nil && info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest
+}
@@ -0,0 +1,605 @@
+// Package config loads the server settings from an optional TOML file and
+// environment variables, which take precedence over the file.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/vmpyr/afterlight/internal/backup"
+	"github.com/vmpyr/afterlight/internal/core"
+	"github.com/vmpyr/afterlight/internal/logging"
+)
+
+type Config struct {
+	Server        ServerConfig        `toml:"server"`
+	Database      DatabaseConfig      `toml:"database"`
+	Storage       StorageConfig       `toml:"storage"`
+	Quotas        QuotasConfig        `toml:"quotas"`
+	Session       SessionConfig       `toml:"session"`
+	Registration  RegistrationConfig  `toml:"registration"`
+	Account       AccountConfig       `toml:"account"`
+	OIDC          OIDCConfig          `toml:"oidc"`
+	ForwardAuth   ForwardAuthConfig   `toml:"forward_auth"`
+	Liveness      LivenessConfig      `toml:"liveness"`
+	SMTP          SMTPConfig          `toml:"smtp"`
+	Notifications NotificationsConfig `toml:"notifications"`
+	Keys          KeysConfig          `toml:"keys"`
+	Metrics       MetricsConfig       `toml:"metrics"`
+	Log           LogConfig           `toml:"log"`
+	Health        HealthConfig        `toml:"health"`
+	Backup        BackupConfig        `toml:"backup"`
+}
+
+// TrustProxyHeaders believes X-Forwarded-Proto from a reverse proxy that
+// terminates TLS. HSTSMaxAge is sent on HTTPS responses; 0 disables it.
+type ServerConfig struct {
+	ListenAddr        string    `toml:"listen_addr"`
+	RequestTimeout    Duration  `toml:"request_timeout"`
+	ShutdownTimeout   Duration  `toml:"shutdown_timeout"`
+	TrustProxyHeaders bool      `toml:"trust_proxy_headers"`
+	HSTSMaxAge        Duration  `toml:"hsts_max_age"`
+	TLS               TLSConfig `toml:"tls"`
+}
+
+// TLS is served when both files are set. RedirectAddr, if set, listens for
+// plain HTTP and redirects it to HTTPS.
+type TLSConfig struct {
+	CertFile       string   `toml:"cert_file"`
+	KeyFile        string   `toml:"key_file"`
+	ReloadInterval Duration `toml:"reload_interval"`
+	RedirectAddr   string   `toml:"redirect_addr"`
+}
+
+type DatabaseConfig struct {
+	Driver string `toml:"driver"`
+	Path   string `toml:"path"`
+}
+
+type StorageConfig struct {
+	ArtifactsPath string `toml:"artifacts_path"`
+}
+
+// Default limits per account; admins can override them per user. 0 means
+// unlimited.
+type QuotasConfig struct {
+	MaxVaults     int `toml:"max_vaults"`
+	MaxArtifacts  int `toml:"max_artifacts"`
+	MaxArtifactMB int `toml:"max_artifact_mb"`
+	MaxStorageMB  int `toml:"max_storage_mb"`
+}
+
+func (q QuotasConfig) Limits() core.QuotaLimits {
+	return core.QuotaLimits{
+		MaxVaults:        int64(q.MaxVaults),
+		MaxArtifacts:     int64(q.MaxArtifacts),
+		MaxArtifactBytes: int64(q.MaxArtifactMB) << 20,
+		MaxStorageBytes:  int64(q.MaxStorageMB) << 20,
+	}
+}
+
+type SessionConfig struct {
+	TTL           Duration `toml:"ttl"`
+	SweepInterval Duration `toml:"sweep_interval"`
+}
+
+// Mode is the initial setting; admins can change it at runtime, which then
+// takes precedence. Admins can always create invites; other users may have
+// up to UserInvites open at a time.
+type RegistrationConfig struct {
+	Mode        core.RegistrationMode `toml:"mode"`
+	UserInvites int                   `toml:"user_invites"`
+	InviteTTL   Duration              `toml:"invite_ttl"`
+}
+
+// How long a requested account deletion can still be cancelled
+type AccountConfig struct {
+	DeletionDelay Duration `toml:"deletion_delay"`
+}
+
+// Single sign-on is off without an issuer. RedirectURL defaults to the
+// callback under notifications.public_url. With LinkByEmail, the first login
+// attaches to the account whose email the provider reports as verified;
+// otherwise users link their identity from a password session.
+type OIDCConfig struct {
+	Issuer       string   `toml:"issuer"`
+	ClientID     string   `toml:"client_id"`
+	ClientSecret Secret   `toml:"client_secret"`
+	RedirectURL  string   `toml:"redirect_url"`
+	Scopes       []string `toml:"scopes"`
+	Name         string   `toml:"name"`
+	LinkByEmail  bool     `toml:"link_by_email"`
+}
+
+func (o OIDCConfig) Enabled() bool {
+	return o.Issuer != ""
+}
+
+// Trusts the email in Header, set by an authenticating reverse proxy such as
+// Authelia or oauth2-proxy, on requests whose peer is in TrustedProxies
+// (CIDRs or addresses). Off without a header. With AutoProvision, unknown
+// emails get an account named from NameHeader.
+type ForwardAuthConfig struct {
+	Header         string   `toml:"header"`
+	NameHeader     string   `toml:"name_header"`
+	TrustedProxies []string `toml:"trusted_proxies"`
+	AutoProvision  bool     `toml:"auto_provision"`
+}
+
+func (f ForwardAuthConfig) Enabled() bool {
+	return f.Header != ""
+}
+
+func (f ForwardAuthConfig) Networks() ([]netip.Prefix, error) {
+	networks := make([]netip.Prefix, 0, len(f.TrustedProxies))
+	for _, p := range f.TrustedProxies {
+		if addr, err := netip.ParseAddr(p); err == nil {
+			networks = append(networks, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(p)
+		if err != nil {
+			return nil, fmt.Errorf("%q is neither an address nor a CIDR", p)
+		}
+		networks = append(networks, prefix.Masked())
+	}
+	return networks, nil
+}
+
+// Defaults applied to new accounts, and how often the engine checks them
+type LivenessConfig struct {
+	CheckInterval    Duration `toml:"check_interval"`
+	CheckInInterval  Duration `toml:"check_in_interval"`
+	TriggerIntervals int      `toml:"trigger_intervals"`
+	BufferPeriod     Duration `toml:"buffer_period"`
+	VerifierQuorum   int      `toml:"verifier_quorum"`
+}
+
+// Without a host, emails are written to the log instead of being sent
+type SMTPConfig struct {
+	Host     string `toml:"host"`
+	Port     int    `toml:"port"`
+	Username string `toml:"username"`
+	Password Secret `toml:"password"`
+	From     string `toml:"from"`
+}
+
+type NotificationsConfig struct {
+	TemplatesPath    string   `toml:"templates_path"`
+	DefaultLocale    string   `toml:"default_locale"`
+	PublicURL        string   `toml:"public_url"`
+	DispatchInterval Duration `toml:"dispatch_interval"`
+}
+
+// Each key can be given as a file or inline; the file wins when both are set
+type KeysConfig struct {
+	MasterKeyFile     string `toml:"master_key_file"`
+	MasterKey         Secret `toml:"master_key"`
+	EncryptionKeyFile string `toml:"encryption_key_file"`
+	EncryptionKey     Secret `toml:"encryption_key"`
+}
+
+// Without a token, /metrics is open to anyone who can reach the server
+type MetricsConfig struct {
+	Enabled bool   `toml:"enabled"`
+	Token   Secret `toml:"token"`
+}
+
+// Level is debug, info, warn or error; Format is json or text
+type LogConfig struct {
+	Level  string `toml:"level"`
+	Format string `toml:"format"`
+}
+
+// Thresholds for /readyz. Low space on the artifacts volume fails the probe;
+// a large outbox backlog only warns.
+type HealthConfig struct {
+	MinFreeMB        int `toml:"min_free_mb"`
+	MaxOutboxBacklog int `toml:"max_outbox_backlog"`
+}
+
+// Scheduled backups are off while Interval is 0. Recipients are age public
+// keys the archives are encrypted to. The backup endpoint is only served
+// when APIToken is set.
+type BackupConfig struct {
+	Dir        string   `toml:"dir"`
+	Interval   Duration `toml:"interval"`
+	Keep       int      `toml:"keep"`
+	Recipients []string `toml:"recipients"`
+	APIToken   Secret   `toml:"api_token"`
+}
+
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			ListenAddr:      ":8080",
+			RequestTimeout:  Duration(60 * time.Second),
+			ShutdownTimeout: Duration(30 * time.Second),
+			HSTSMaxAge:      Duration(365 * 24 * time.Hour),
+			TLS: TLSConfig{
+				ReloadInterval: Duration(time.Minute),
+			},
+		},
+		Database: DatabaseConfig{
+			Driver: "sqlite3",
+			Path:   "afterlight.db",
+		},
+		Storage: StorageConfig{
+			ArtifactsPath: "artifacts",
+		},
+		Quotas: QuotasConfig{
+			MaxVaults:     25,
+			MaxArtifacts:  1000,
+			MaxArtifactMB: 25,
+			MaxStorageMB:  500,
+		},
+		Session: SessionConfig{
+			TTL:           Duration(30 * 24 * time.Hour),
+			SweepInterval: Duration(time.Hour),
+		},
+		Registration: RegistrationConfig{
+			Mode:      core.RegistrationOpen,
+			InviteTTL: Duration(7 * 24 * time.Hour),
+		},
+		Account: AccountConfig{
+			DeletionDelay: Duration(7 * 24 * time.Hour),
+		},
+		OIDC: OIDCConfig{
+			Scopes:      []string{"openid", "email", "profile"},
+			Name:        "Single sign-on",
+			LinkByEmail: true,
+		},
+		ForwardAuth: ForwardAuthConfig{
+			NameHeader:    "Remote-Name",
+			AutoProvision: true,
+		},
+		Liveness: LivenessConfig{
+			CheckInterval:    Duration(time.Minute),
+			CheckInInterval:  Duration(30 * 24 * time.Hour),
+			TriggerIntervals: 4,
+			BufferPeriod:     Duration(7 * 24 * time.Hour),
+			VerifierQuorum:   1,
+		},
+		SMTP: SMTPConfig{
+			Port: 587,
+			From: "afterlight@localhost",
+		},
+		Notifications: NotificationsConfig{
+			DefaultLocale:    "en",
+			DispatchInterval: Duration(30 * time.Second),
+		},
+		Metrics: MetricsConfig{
+			Enabled: true,
+		},
+		Log: LogConfig{
+			Level:  "info",
+			Format: "json",
+		},
+		Health: HealthConfig{
+			MinFreeMB:        100,
+			MaxOutboxBacklog: 1000,
+		},
+		Backup: BackupConfig{
+			Dir:  "backups",
+			Keep: 7,
+		},
+	}
+}
+
+// Load reads the defaults, then the TOML file at path (if any), then the
+// environment, and validates the result
+func Load(path string) (*Config, error) {
+	cfg := Default()
+
+	if path != "" {
+		md, err := toml.DecodeFile(path, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", path, err)
+		}
+		if undecoded := md.Undecoded(); len(undecoded) > 0 {
+			return nil, fmt.Errorf("reading %s: unknown setting %q", path, undecoded[0].String())
+		}
+	}
+
+	if err := cfg.applyEnv(os.LookupEnv); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Environment variables and the settings they override
+func (c *Config) applyEnv(lookup func(string) (string, bool)) error {
+	overrides := []struct {
+		name string
+		set  func(string) error
+	}{
+		{"LISTEN_ADDR", setString(&c.Server.ListenAddr)},
+		{"PORT", func(v string) error { c.Server.ListenAddr = ":" + v; return nil }},
+		{"REQUEST_TIMEOUT", setDuration(&c.Server.RequestTimeout)},
+		{"SHUTDOWN_TIMEOUT", setDuration(&c.Server.ShutdownTimeout)},
+		{"TRUST_PROXY_HEADERS", setBool(&c.Server.TrustProxyHeaders)},
+		{"HSTS_MAX_AGE", setDuration(&c.Server.HSTSMaxAge)},
+		{"TLS_CERT_FILE", setString(&c.Server.TLS.CertFile)},
+		{"TLS_KEY_FILE", setString(&c.Server.TLS.KeyFile)},
+		{"TLS_RELOAD_INTERVAL", setDuration(&c.Server.TLS.ReloadInterval)},
+		{"TLS_REDIRECT_ADDR", setString(&c.Server.TLS.RedirectAddr)},
+		{"DB_DRIVER", setString(&c.Database.Driver)},
+		{"DB_PATH", setString(&c.Database.Path)},
+		{"ARTIFACTS_PATH", setString(&c.Storage.ArtifactsPath)},
+		{"QUOTA_MAX_VAULTS", setInt(&c.Quotas.MaxVaults)},
+		{"QUOTA_MAX_ARTIFACTS", setInt(&c.Quotas.MaxArtifacts)},
+		{"QUOTA_MAX_ARTIFACT_MB", setInt(&c.Quotas.MaxArtifactMB)},
+		{"QUOTA_MAX_STORAGE_MB", setInt(&c.Quotas.MaxStorageMB)},
+		{"SESSION_TTL", setDuration(&c.Session.TTL)},
+		{"SESSION_SWEEP_INTERVAL", setDuration(&c.Session.SweepInterval)},
+		{"REGISTRATION_MODE", setString((*string)(&c.Registration.Mode))},
+		{"REGISTRATION_USER_INVITES", setInt(&c.Registration.UserInvites)},
+		{"REGISTRATION_INVITE_TTL", setDuration(&c.Registration.InviteTTL)},
+		{"ACCOUNT_DELETION_DELAY", setDuration(&c.Account.DeletionDelay)},
+		{"OIDC_ISSUER", setString(&c.OIDC.Issuer)},
+		{"OIDC_CLIENT_ID", setString(&c.OIDC.ClientID)},
+		{"OIDC_CLIENT_SECRET", setSecret(&c.OIDC.ClientSecret)},
+		{"OIDC_REDIRECT_URL", setString(&c.OIDC.RedirectURL)},
+		{"OIDC_SCOPES", setList(&c.OIDC.Scopes)},
+		{"OIDC_NAME", setString(&c.OIDC.Name)},
+		{"OIDC_LINK_BY_EMAIL", setBool(&c.OIDC.LinkByEmail)},
+		{"FORWARD_AUTH_HEADER", setString(&c.ForwardAuth.Header)},
+		{"FORWARD_AUTH_NAME_HEADER", setString(&c.ForwardAuth.NameHeader)},
+		{"FORWARD_AUTH_TRUSTED_PROXIES", setList(&c.ForwardAuth.TrustedProxies)},
+		{"FORWARD_AUTH_AUTO_PROVISION", setBool(&c.ForwardAuth.AutoProvision)},
+		{"LIVENESS_CHECK_INTERVAL", setDuration(&c.Liveness.CheckInterval)},
+		{"DEFAULT_CHECK_IN_INTERVAL", setDuration(&c.Liveness.CheckInInterval)},
+		{"DEFAULT_TRIGGER_INTERVALS", setInt(&c.Liveness.TriggerIntervals)},
+		{"DEFAULT_BUFFER_PERIOD", setDuration(&c.Liveness.BufferPeriod)},
+		{"DEFAULT_VERIFIER_QUORUM", setInt(&c.Liveness.VerifierQuorum)},
+		{"SMTP_HOST", setString(&c.SMTP.Host)},
+		{"SMTP_PORT", setInt(&c.SMTP.Port)},
+		{"SMTP_USERNAME", setString(&c.SMTP.Username)},
+		{"SMTP_PASSWORD", setSecret(&c.SMTP.Password)},
+		{"SMTP_FROM", setString(&c.SMTP.From)},
+		{"TEMPLATES_PATH", setString(&c.Notifications.TemplatesPath)},
+		{"DEFAULT_LOCALE", setString(&c.Notifications.DefaultLocale)},
+		{"PUBLIC_URL", setString(&c.Notifications.PublicURL)},
+		{"DISPATCH_INTERVAL", setDuration(&c.Notifications.DispatchInterval)},
+		{"MASTER_KEY_FILE", setString(&c.Keys.MasterKeyFile)},
+		{"MASTER_KEY", setSecret(&c.Keys.MasterKey)},
+		{"ENCRYPTION_KEY_FILE", setString(&c.Keys.EncryptionKeyFile)},
+		{"ENCRYPTION_KEY", setSecret(&c.Keys.EncryptionKey)},
+		{"LOG_LEVEL", setString(&c.Log.Level)},
+		{"LOG_FORMAT", setString(&c.Log.Format)},
+		{"HEALTH_MIN_FREE_MB", setInt(&c.Health.MinFreeMB)},
+		{"HEALTH_MAX_OUTBOX_BACKLOG", setInt(&c.Health.MaxOutboxBacklog)},
+		{"BACKUP_DIR", setString(&c.Backup.Dir)},
+		{"BACKUP_INTERVAL", setDuration(&c.Backup.Interval)},
+		{"BACKUP_KEEP", setInt(&c.Backup.Keep)},
+		{"BACKUP_RECIPIENTS", setList(&c.Backup.Recipients)},
+		{"BACKUP_API_TOKEN", setSecret(&c.Backup.APIToken)},
+		{"METRICS_ENABLED", setBool(&c.Metrics.Enabled)},
+		{"METRICS_TOKEN", setSecret(&c.Metrics.Token)},
+	}
+
+	for _, o := range overrides {
+		v, ok := lookup(o.name)
+		if !ok || v == "" {
+			continue
+		}
+		if err := o.set(v); err != nil {
+			return fmt.Errorf("%s: %w", o.name, err)
+		}
+	}
+	return nil
+}
+
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	_, port, err := net.SplitHostPort(c.Server.ListenAddr)
+	_, portErr := strconv.ParseUint(port, 10, 16)
+	check(err == nil && portErr == nil, "server.listen_addr %q must be host:port or :port", c.Server.ListenAddr)
+	check(c.Server.RequestTimeout > 0, "server.request_timeout must be positive")
+	check(c.Server.ShutdownTimeout > 0, "server.shutdown_timeout must be positive")
+	check(c.Server.HSTSMaxAge >= 0, "server.hsts_max_age must not be negative")
+	check((c.Server.TLS.CertFile == "") == (c.Server.TLS.KeyFile == ""), "server.tls needs both cert_file and key_file")
+	check(c.Server.TLS.ReloadInterval >= Duration(time.Second), "server.tls.reload_interval must be at least 1s")
+	if c.Server.TLS.RedirectAddr != "" {
+		check(c.Server.TLS.CertFile != "", "server.tls.redirect_addr needs cert_file and key_file")
+		_, err := net.ResolveTCPAddr("tcp", c.Server.TLS.RedirectAddr)
+		check(err == nil, "server.tls.redirect_addr %q must be host:port or :port", c.Server.TLS.RedirectAddr)
+	}
+
+	check(c.Database.Driver == "sqlite3", "database.driver %q is not supported (only sqlite3)", c.Database.Driver)
+	check(c.Database.Path != "", "database.path is required")
+	check(c.Storage.ArtifactsPath != "", "storage.artifacts_path is required")
+	check(c.Quotas.MaxVaults >= 0, "quotas.max_vaults must not be negative")
+	check(c.Quotas.MaxArtifacts >= 0, "quotas.max_artifacts must not be negative")
+	check(c.Quotas.MaxArtifactMB >= 0, "quotas.max_artifact_mb must not be negative")
+	check(c.Quotas.MaxStorageMB >= 0, "quotas.max_storage_mb must not be negative")
+
+	check(c.Session.TTL >= Duration(time.Minute), "session.ttl must be at least 1m")
+	check(c.Session.SweepInterval >= Duration(time.Minute), "session.sweep_interval must be at least 1m")
+	check(core.IsValidRegistrationMode(c.Registration.Mode) == nil, "registration.mode must be %q or %q",
+		core.RegistrationOpen, core.RegistrationInviteOnly)
+	check(c.Registration.UserInvites >= 0, "registration.user_invites must not be negative")
+	check(c.Registration.InviteTTL >= Duration(time.Hour) && c.Registration.InviteTTL.Std() <= core.MaxInviteLifetime,
+		"registration.invite_ttl must be between 1h and 365d")
+	check(c.Account.DeletionDelay >= 0, "account.deletion_delay must not be negative")
+	if c.OIDC.Enabled() {
+		u, err := url.Parse(c.OIDC.Issuer)
+		check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "",
+			"oidc.issuer %q must be an absolute http(s) URL", c.OIDC.Issuer)
+		check(c.OIDC.ClientID != "", "oidc.client_id is required when oidc.issuer is set")
+		check(c.OIDC.RedirectURL != "" || c.Notifications.PublicURL != "",
+			"oidc.redirect_url or notifications.public_url is required when oidc.issuer is set")
+	}
+	if c.ForwardAuth.Enabled() {
+		check(len(c.ForwardAuth.TrustedProxies) > 0, "forward_auth.trusted_proxies is required when forward_auth.header is set")
+		_, err := c.ForwardAuth.Networks()
+		check(err == nil, "forward_auth.trusted_proxies: %v", err)
+	}
+
+	check(c.Liveness.CheckInterval >= Duration(time.Second), "liveness.check_interval must be at least 1s")
+	check(c.Liveness.CheckInInterval >= Duration(time.Hour), "liveness.check_in_interval must be at least 1h")
+	check(c.Liveness.TriggerIntervals >= 1, "liveness.trigger_intervals must be at least 1")
+	check(c.Liveness.BufferPeriod >= 0, "liveness.buffer_period must not be negative")
+	check(c.Liveness.VerifierQuorum >= 0, "liveness.verifier_quorum must not be negative")
+
+	check(c.SMTP.Port > 0 && c.SMTP.Port < 65536, "smtp.port %d is out of range", c.SMTP.Port)
+	check(c.SMTP.Host == "" || c.SMTP.From != "", "smtp.from is required when smtp.host is set")
+
+	check(c.Notifications.DefaultLocale != "", "notifications.default_locale is required")
+	check(c.Notifications.DispatchInterval >= Duration(time.Second), "notifications.dispatch_interval must be at least 1s")
+	if c.Notifications.PublicURL != "" {
+		u, err := url.Parse(c.Notifications.PublicURL)
+		check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "",
+			"notifications.public_url %q must be an absolute http(s) URL", c.Notifications.PublicURL)
+	}
+
+	check(c.Health.MinFreeMB >= 0, "health.min_free_mb must not be negative")
+	check(c.Health.MaxOutboxBacklog >= 0, "health.max_outbox_backlog must not be negative")
+
+	check(c.Backup.Dir != "", "backup.dir is required")
+	check(c.Backup.Interval == 0 || c.Backup.Interval >= Duration(time.Minute), "backup.interval must be 0 (off) or at least 1m")
+	check(c.Backup.Keep >= 1, "backup.keep must be at least 1")
+	_, err = backup.ParseRecipients(c.Backup.Recipients)
+	check(err == nil, "backup.recipients: %v", err)
+
+	_, err = logging.ParseLevel(c.Log.Level)
+	check(err == nil, "log.level %q must be debug, info, warn or error", c.Log.Level)
+	check(logging.IsValidFormat(c.Log.Format), "log.format %q must be json or text", c.Log.Format)
+
+	return errors.Join(errs...)
+}
+
+// Redacted returns a copy that is safe to print
+func (c *Config) Redacted() *Config {
+	out := *c
+	out.SMTP.Password = out.SMTP.Password.redacted()
+	out.OIDC.ClientSecret = out.OIDC.ClientSecret.redacted()
+	out.Keys.MasterKey = out.Keys.MasterKey.redacted()
+	out.Keys.EncryptionKey = out.Keys.EncryptionKey.redacted()
+	out.Metrics.Token = out.Metrics.Token.redacted()
+	out.Backup.APIToken = out.Backup.APIToken.redacted()
+	return &out
+}
+
+// Writes the effective configuration as TOML with secrets redacted
+func (c *Config) Print(w io.Writer) error {
+	return toml.NewEncoder(w).Encode(c.Redacted())
+}
+
+func setString(p *string) func(string) error {
+	return func(v string) error {
+		*p = v
+		return nil
+	}
+}
+
+func setSecret(p *Secret) func(string) error {
+	return func(v string) error {
+		*p = Secret(v)
+		return nil
+	}
+}
+
+func setBool(p *bool) func(string) error {
+	return func(v string) error {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("%q is not true or false", v)
+		}
+		*p = b
+		return nil
+	}
+}
+
+// Comma separated
+func setList(p *[]string) func(string) error {
+	return func(v string) error {
+		*p = nil
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				*p = append(*p, item)
+			}
+		}
+		return nil
+	}
+}
+
+func setInt(p *int) func(string) error {
+	return func(v string) error {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", v)
+		}
+		*p = n
+		return nil
+	}
+}
+
+func setDuration(p *Duration) func(string) error {
+	return func(v string) error {
+		return p.UnmarshalText([]byte(v))
+	}
+}
+
+// Secret is a setting that is never printed
+type Secret string
+
+const redactedValue = "REDACTED"
+
+func (s Secret) redacted() Secret {
+	if s == "" {
+		return ""
+	}
+	return redactedValue
+}
+
+// Duration accepts Go durations ("90s", "72h") plus whole days ("30d")
+type Duration time.Duration
+
+func (d Duration) Std() time.Duration {
+	return time.Duration(d)
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	s := string(text)
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", s)
+		}
+		*d = Duration(time.Duration(n) * 24 * time.Hour)
+		return nil
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q", s)
+	}
+	*d = Duration(v)
+	return nil
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	v := time.Duration(d)
+	if v != 0 && v%(24*time.Hour) == 0 {
+		return []byte(strconv.FormatInt(int64(v/(24*time.Hour)), 10) + "d"), nil
+	}
+	return []byte(v.String()), nil
+}
@@ -0,0 +1,95 @@
+package core
+
+import "time"
+
+// Scheduling a deletion needs the password and, as a typed confirmation,
+// the account email. Requests authenticated by forward auth need only the email.
+type DeleteAccountRequest struct {
+	Password string `json:"password"`
+	Email    string `json:"email"`
+}
+
+type AccountDeletionResponse struct {
+	Scheduled     bool       `json:"scheduled"`
+	DeletionDueAt *time.Time `json:"deletion_due_at,omitempty"`
+}
+
+const (
+	AccountExportFormat  = "afterlight-account-export"
+	AccountExportVersion = 1
+)
+
+// Everything stored about an account. Vault hints, key material and artifacts
+// are as the client uploaded them, so artifacts are still encrypted under the
+// vault passphrase. Contact metadata is left out as in ContactMethodResponse.
+type AccountExport struct {
+	Format         string                  `json:"format"`
+	Version        int                     `json:"version"`
+	ExportedAt     time.Time               `json:"exported_at"`
+	Profile        UserResponse            `json:"profile"`
+	Liveness       LivenessExport          `json:"liveness"`
+	ReminderPolicy ReminderPolicyResponse  `json:"reminder_policy"`
+	ContactMethods []ContactMethodResponse `json:"contact_methods"`
+	Beneficiaries  []BeneficiaryExport     `json:"beneficiaries"`
+	Vaults         []VaultExport           `json:"vaults"`
+	Invites        []InviteResponse        `json:"invites"`
+	Identities     []IdentityResponse      `json:"identities"`
+	Notifications  []NotificationExport    `json:"notifications"`
+}
+
+// Durations are in seconds, as on the users table
+type LivenessExport struct {
+	LivenessResponse
+	CheckInInterval  int64 `json:"check_in_interval"`
+	TriggerIntervals int64 `json:"trigger_intervals"`
+	BufferPeriod     int64 `json:"buffer_period"`
+	VerifierQuorum   int64 `json:"verifier_quorum"`
+}
+
+type BeneficiaryExport struct {
+	BeneficiaryResponse
+	ContactMethods []ContactMethodResponse `json:"contact_methods"`
+}
+
+type VaultExport struct {
+	ID             string              `json:"id"`
+	VaultName      string              `json:"vault_name"`
+	Hint           string              `json:"hint,omitempty"`
+	KdfSalt        string              `json:"kdf_salt"`
+	KDF            KDFParams           `json:"kdf"`
+	Sealed         bool                `json:"sealed"`
+	ShareThreshold int64               `json:"share_threshold,omitempty"`
+	ShareCount     int64               `json:"share_count,omitempty"`
+	Access         []VaultAccessExport `json:"access"`
+	Artifacts      []ArtifactExport    `json:"artifacts"`
+	CreatedAt      time.Time           `json:"created_at"`
+}
+
+type VaultAccessExport struct {
+	BeneficiaryID  string    `json:"beneficiary_id"`
+	ShareIndex     int64     `json:"share_index,omitempty"`
+	EncryptedShare string    `json:"encrypted_share,omitempty"`
+	WrappedKey     string    `json:"wrapped_key,omitempty"`
+	GrantedAt      time.Time `json:"granted_at"`
+}
+
+type ArtifactExport struct {
+	ID              string          `json:"id"`
+	MessageType     MessageType     `json:"message_type"`
+	EncryptedBlob   EncryptedBlob   `json:"encrypted_blob"`
+	IV              string          `json:"iv"`
+	EnvelopeVersion EnvelopeVersion `json:"envelope_version"`
+	CreatedAt       time.Time       `json:"created_at"`
+}
+
+// Message bodies are left out; they can hold one-time codes
+type NotificationExport struct {
+	ID              string             `json:"id"`
+	ContactMethodID string             `json:"contact_method_id"`
+	Event           NotificationEvent  `json:"event"`
+	Subject         string             `json:"subject"`
+	Status          NotificationStatus `json:"status"`
+	Attempts        int64              `json:"attempts"`
+	CreatedAt       time.Time          `json:"created_at"`
+	SentAt          *time.Time         `json:"sent_at,omitempty"`
+}
@@ -0,0 +1,120 @@
+package core
+
+import (
+	"encoding/hex"
+	"math"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Envelope versions describe how a client encrypted an artifact. The version
+// is stored with every artifact so clients can find ones that need migrating.
+type EnvelopeVersion int64
+
+const (
+	EnvelopeUnversioned EnvelopeVersion = 0 // Uploaded before envelopes were recorded, never verified
+	EnvelopeV1          EnvelopeVersion = 1
+
+	CurrentEnvelopeVersion = EnvelopeV1
+)
+
+// ArtifactEnvelope is what a client declares about an upload. Zero fields
+// are taken from the version's definition; set ones must match it. The KDF
+// is a property of the vault, so declared KDF fields must match the vault's.
+type ArtifactEnvelope struct {
+	Version       EnvelopeVersion `json:"version"`
+	Algorithm     string          `json:"algorithm,omitempty"`
+	KDF           string          `json:"kdf,omitempty"`
+	KDFIterations int             `json:"kdf_iterations,omitempty"`
+	IVLength      int             `json:"iv_length,omitempty"` // Bytes
+	TagLength     int             `json:"tag_length,omitempty"`
+}
+
+var ArtifactEnvelopes = map[EnvelopeVersion]ArtifactEnvelope{
+	// AES-256-GCM with the tag appended (the WebCrypto layout), the key derived
+	// from the vault passphrase and kdf_salt with the vault's KDF
+	EnvelopeV1: {
+		Version:   EnvelopeV1,
+		Algorithm: "AES-256-GCM",
+		IVLength:  12,
+		TagLength: 16,
+	},
+}
+
+const (
+	minEntropySample     = 64  // Below this, byte frequencies say too little
+	minNormalizedEntropy = 0.8 // Ciphertext is ~0.87 at worst, text rarely above 0.7
+	minPrintableSample   = 16
+)
+
+// Resolves the declared envelope (nil means the current version) and checks
+// that the IV and blob fit it and that the blob does not look like plaintext.
+// The server cannot decrypt, so this only catches clients that forgot to encrypt.
+func IsValidArtifact(envelope *ArtifactEnvelope, kdf KDFParams, iv string, blob []byte) (EnvelopeVersion, error) {
+	declared := ArtifactEnvelope{Version: CurrentEnvelopeVersion}
+	if envelope != nil {
+		declared = *envelope
+	}
+	spec, ok := ArtifactEnvelopes[declared.Version]
+	if !ok {
+		return 0, ErrUnknownEnvelope
+	}
+	if !matches(declared.Algorithm, spec.Algorithm) || !matches(declared.KDF, kdf.Algorithm) ||
+		!matches(declared.KDFIterations, kdf.Iterations) || !matches(declared.IVLength, spec.IVLength) ||
+		!matches(declared.TagLength, spec.TagLength) {
+		return 0, ErrEnvelopeMismatch
+	}
+
+	nonce, err := hex.DecodeString(iv)
+	if err != nil || len(nonce) != spec.IVLength {
+		return 0, ErrInvalidIV
+	}
+	if len(blob) <= spec.TagLength {
+		return 0, ErrMissingAuthTag
+	}
+	if looksUnencrypted(blob) {
+		return 0, ErrUnencryptedArtifact
+	}
+	return spec.Version, nil
+}
+
+func matches[T comparable](declared, spec T) bool {
+	var zero T
+	return declared == zero || declared == spec
+}
+
+func looksUnencrypted(blob []byte) bool {
+	if len(blob) >= minPrintableSample && isPrintableText(blob) {
+		return true
+	}
+	return len(blob) >= minEntropySample && normalizedEntropy(blob) < minNormalizedEntropy
+}
+
+func isPrintableText(b []byte) bool {
+	if !utf8.Valid(b) {
+		return false
+	}
+	for _, r := range string(b) {
+		if !unicode.IsPrint(r) && !unicode.IsSpace(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// Shannon entropy of the byte distribution, scaled so 1 is the maximum
+// reachable for a sample of this length
+func normalizedEntropy(b []byte) float64 {
+	var counts [256]int
+	for _, c := range b {
+		counts[c]++
+	}
+	var h float64
+	for _, n := range counts {
+		if n > 0 {
+			p := float64(n) / float64(len(b))
+			h -= p * math.Log2(p)
+		}
+	}
+	return h / math.Min(8, math.Log2(float64(len(b))))
+}
@@ -8,3 +8,42 @@ import (
 var ErrUserNotFound = errors.New("user not found")
 var ErrPasswordLength = errors.New("password must be at least 8 characters")
 var ErrWeakPassword = errors.New("password must contain at least one uppercase letter, one lowercase letter, one digit, and one special character")
+var ErrInvalidTimeZone = errors.New("time zone must be a valid IANA zone name")
+var ErrInvalidQuietHours = errors.New("quiet hours must both be set in HH:MM format")
+var ErrInvalidEscalation = errors.New("escalation steps must start before the deadline, have a positive interval and be ordered from earliest to latest")
+var ErrUnsupportedChannel = errors.New("unsupported contact channel")
+var ErrInvalidDestination = errors.New("destination is not valid for this channel")
+var ErrMissingBotToken = errors.New("telegram contact methods require a bot_token in metadata")
+var ErrVerificationExpired = errors.New("verification code has expired, request a new one")
+var ErrVerificationFailed = errors.New("verification code does not match")
+var ErrInvalidShare = errors.New("encrypted share must be non-empty base64")
+var ErrDuplicateShareholder = errors.New("each beneficiary can hold only one share of a vault")
+var ErrInvalidPublicKey = errors.New("public key must be an age X25519 recipient (age1...)")
+var ErrInvalidWrappedKey = errors.New("wrapped key must be a base64 age file encrypted to an X25519 recipient")
+var ErrMissingPublicKey = errors.New("beneficiary has no public key")
+var ErrSealingDisabled = errors.New("sealed release is not enabled on this server")
+var ErrUnknownEnvelope = errors.New("unknown artifact envelope version")
+var ErrEnvelopeMismatch = errors.New("artifact envelope does not match its version")
+var ErrInvalidIV = errors.New("iv must be hex of the envelope's IV length")
+var ErrMissingAuthTag = errors.New("encrypted blob is too short to hold an authentication tag")
+var ErrUnencryptedArtifact = errors.New("encrypted blob looks like plaintext; encrypt it before uploading")
+var ErrUnsupportedKDF = errors.New("kdf algorithm must be PBKDF2-SHA256 or ARGON2ID")
+var ErrInvalidKDFParams = errors.New("kdf parameters are outside the allowed range for the algorithm")
+var ErrKDFDowngrade = errors.New("a vault can only be re-keyed under a KDF at least as strong as its current one")
+var ErrArtifactSetMismatch = errors.New("replacements must cover exactly the vault's current artifacts")
+var ErrInvalidRegistrationMode = errors.New("registration mode must be open or invite_only")
+var ErrRegistrationClosed = errors.New("registration is invite-only on this server")
+var ErrAccountDisabled = errors.New("this account has been disabled by an administrator")
+var ErrLastAdmin = errors.New("the last active administrator cannot be disabled, demoted or deleted")
+var ErrInvalidInvite = errors.New("invite is invalid, used up or expired")
+var ErrInviteEmailMismatch = errors.New("invite was issued for a different email address")
+var ErrInviteQuotaExceeded = errors.New("you have no invites left; wait for one to be used or expire")
+var ErrInvalidInviteRequest = errors.New("max_uses and expires_in must be positive and expires_in at most 365 days")
+var ErrInvalidQuota = errors.New("quota limits must not be negative")
+var ErrDeletionConfirmation = errors.New("confirm the deletion with your password and account email")
+var ErrDeletionInProgress = errors.New("this account is being deleted")
+var ErrIdentityNotLinked = errors.New("no account is linked to this single sign-on identity; log in with your password and link it first")
+var ErrIdentityLinked = errors.New("this single sign-on identity is already linked to another account")
+var ErrAccountReleased = errors.New("account has already been released")
+var ErrNotAwaitingVerification = errors.New("the account holder is not awaiting verification")
+var ErrSealsChanged = errors.New("sealed vaults changed while their keys were being re-wrapped; run the rotation again")
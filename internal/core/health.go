@@ -0,0 +1,23 @@
+package core
+
+import "time"
+
+// A warning is reported but does not make the instance unready
+type HealthStatus string
+
+const (
+	HealthOK   HealthStatus = "ok"
+	HealthWarn HealthStatus = "warn"
+	HealthFail HealthStatus = "fail"
+)
+
+type HealthCheck struct {
+	Status  HealthStatus `json:"status"`
+	Message string       `json:"message,omitempty"`
+}
+
+type HealthResponse struct {
+	Status    HealthStatus           `json:"status"`
+	Checks    map[string]HealthCheck `json:"checks,omitempty"`
+	CheckedAt time.Time              `json:"checked_at"`
+}
@@ -0,0 +1,86 @@
+package core
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+)
+
+// Key derivation functions the client may use to turn the vault passphrase
+// and kdf_salt into the vault key
+const (
+	KDFPBKDF2SHA256 = "PBKDF2-SHA256"
+	KDFArgon2id     = "ARGON2ID"
+)
+
+// KDFParams is stored per vault. Iterations is the PBKDF2 iteration count or
+// the Argon2id time cost; memory and parallelism only apply to Argon2id.
+type KDFParams struct {
+	Algorithm   string `json:"algorithm"`
+	Iterations  int    `json:"iterations"`
+	MemoryKiB   int    `json:"memory_kib,omitempty"`
+	Parallelism int    `json:"parallelism,omitempty"`
+}
+
+// What vaults created before KDF parameters were stored were encrypted with
+var DefaultKDFParams = KDFParams{Algorithm: KDFPBKDF2SHA256, Iterations: 600000}
+
+// Bounds per algorithm. Minimums follow the OWASP recommendations; maximums
+// keep a beneficiary's browser from being asked for something it cannot do.
+var kdfLimits = map[string]struct{ min, max KDFParams }{
+	KDFPBKDF2SHA256: {
+		min: KDFParams{Iterations: 600000},
+		max: KDFParams{Iterations: 10000000},
+	},
+	KDFArgon2id: {
+		min: KDFParams{Iterations: 2, MemoryKiB: 19 * 1024, Parallelism: 1},
+		max: KDFParams{Iterations: 64, MemoryKiB: 2 * 1024 * 1024, Parallelism: 16},
+	},
+}
+
+// Ranks algorithms for upgrades; a vault never moves to a lower one
+var kdfRank = map[string]int{
+	KDFPBKDF2SHA256: 1,
+	KDFArgon2id:     2,
+}
+
+func IsValidKDFParams(p KDFParams) error {
+	limits, ok := kdfLimits[p.Algorithm]
+	if !ok {
+		return ErrUnsupportedKDF
+	}
+	if p.Iterations < limits.min.Iterations || p.Iterations > limits.max.Iterations ||
+		p.MemoryKiB < limits.min.MemoryKiB || p.MemoryKiB > limits.max.MemoryKiB ||
+		p.Parallelism < limits.min.Parallelism || p.Parallelism > limits.max.Parallelism {
+		return ErrInvalidKDFParams
+	}
+	return nil
+}
+
+// AtLeastAsStrong reports whether moving a vault from p to next is not a downgrade
+func (p KDFParams) AtLeastAsStrong(next KDFParams) bool {
+	if kdfRank[next.Algorithm] != kdfRank[p.Algorithm] {
+		return kdfRank[next.Algorithm] > kdfRank[p.Algorithm]
+	}
+	return next.Iterations >= p.Iterations && next.MemoryKiB >= p.MemoryKiB && next.Parallelism >= p.Parallelism
+}
+
+func (p *KDFParams) Scan(value interface{}) error {
+	raw, valid, err := columnString(value)
+	if err != nil {
+		return err
+	}
+	if !valid {
+		*p = DefaultKDFParams
+		return nil
+	}
+	return json.Unmarshal([]byte(raw), p)
+}
+
+func (p KDFParams) Value() (driver.Value, error) {
+	if p.Algorithm == "" {
+		return nil, errors.New("kdf params have no algorithm")
+	}
+	b, err := json.Marshal(p)
+	return string(b), err
+}
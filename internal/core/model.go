@@ -16,6 +16,21 @@ const (
 	StatusDead    UserStatus = "CONFIRMED_DEAD"
 )
 
+type UserRole string
+
+const (
+	RoleUser  UserRole = "USER"
+	RoleAdmin UserRole = "ADMIN"
+)
+
+// Who may create an account through POST /auth/register
+type RegistrationMode string
+
+const (
+	RegistrationOpen       RegistrationMode = "open"
+	RegistrationInviteOnly RegistrationMode = "invite_only"
+)
+
 type MessageType string
 
 const (
@@ -24,10 +39,40 @@ const (
 	MsgS3   MessageType = "S3_OBJECT_LINK"
 )
 
+type Channel string
+
+const (
+	ChannelEmail    Channel = "EMAIL"
+	ChannelDiscord  Channel = "DISCORD_WEBHOOK"
+	ChannelTelegram Channel = "TELEGRAM"
+	ChannelSlack    Channel = "SLACK"
+)
+
+type NotificationEvent string
+
+const (
+	EventCheckInReminder       NotificationEvent = "CHECK_IN_REMINDER"
+	EventVerificationRequested NotificationEvent = "VERIFICATION_REQUESTED"
+	EventVaultReleased         NotificationEvent = "VAULT_RELEASED"
+	EventContactVerification   NotificationEvent = "CONTACT_VERIFICATION"
+	EventTestNotification      NotificationEvent = "TEST_NOTIFICATION"
+	EventInvitation            NotificationEvent = "INVITATION"
+	EventBeneficiaryRemoved    NotificationEvent = "BENEFICIARY_REMOVED"
+)
+
+type NotificationStatus string
+
+const (
+	NotificationPending NotificationStatus = "PENDING"
+	NotificationSent    NotificationStatus = "SENT"
+	NotificationFailed  NotificationStatus = "FAILED"
+)
+
 type RegisterRequest struct {
-	Name     string `json:"name"`
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Name        string `json:"name"`
+	Email       string `json:"email"`
+	Password    string `json:"password"`
+	InviteToken string `json:"invite_token,omitempty"` // Required while registration is invite-only
 }
 
 type LoginRequest struct {
@@ -39,38 +84,398 @@ type UserResponse struct {
 	ID            string     `json:"id"`
 	Name          string     `json:"name"`
 	Email         string     `json:"email"`
+	Role          UserRole   `json:"role"`
 	CurrentStatus UserStatus `json:"current_status"`
+	DeletionDueAt *time.Time `json:"deletion_due_at,omitempty"` // Set while a deletion is scheduled
 	CreatedAt     time.Time  `json:"created_at"`
 }
 
+// An account as seen by an instance admin
+type AdminUserResponse struct {
+	ID             string     `json:"id"`
+	Name           string     `json:"name"`
+	Email          string     `json:"email"`
+	Role           UserRole   `json:"role"`
+	CurrentStatus  UserStatus `json:"current_status"`
+	IsPaused       bool       `json:"is_paused"`
+	LastCheckIn    time.Time  `json:"last_check_in"`
+	Deadline       time.Time  `json:"deadline"`
+	Disabled       bool       `json:"disabled"`
+	DisabledAt     *time.Time `json:"disabled_at,omitempty"`
+	ActiveSessions int64      `json:"active_sessions"`
+	DeletionDueAt  *time.Time `json:"deletion_due_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// With an email, the invite is only valid for that address and is sent there
+type CreateInviteRequest struct {
+	Email     string `json:"email,omitempty"`
+	MaxUses   int64  `json:"max_uses,omitempty"`   // Defaults to 1
+	ExpiresIn int64  `json:"expires_in,omitempty"` // Seconds, defaults to the server's invite TTL
+	Locale    string `json:"locale,omitempty"`     // Language of the email
+}
+
+// Token and URL are only returned when the invite is created
+type InviteResponse struct {
+	ID          string    `json:"id"`
+	Token       string    `json:"token,omitempty"`
+	URL         string    `json:"url,omitempty"`
+	Email       string    `json:"email,omitempty"`
+	MaxUses     int64     `json:"max_uses"`
+	Uses        int64     `json:"uses"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	CreatedBy   string    `json:"created_by"`
+	CreatedAt   time.Time `json:"created_at"`
+	EmailQueued bool      `json:"email_queued,omitempty"`
+}
+
+// Whether the login page should offer single sign-on, and under which name
+type SSOStatusResponse struct {
+	Enabled bool   `json:"enabled"`
+	Name    string `json:"name,omitempty"`
+}
+
+// Where to send the browser to link an identity to the current account
+type SSOLinkResponse struct {
+	URL string `json:"url"`
+}
+
+type IdentityResponse struct {
+	ID          string     `json:"id"`
+	Issuer      string     `json:"issuer"`
+	Subject     string     `json:"subject"`
+	Email       string     `json:"email,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	LastLoginAt *time.Time `json:"last_login_at,omitempty"`
+}
+
+type RegistrationSettings struct {
+	Mode RegistrationMode `json:"mode"`
+}
+
+// Readiness checks plus instance-wide counts, for admins
+type AdminHealthResponse struct {
+	HealthResponse
+	UsersByStatus        map[UserStatus]int64 `json:"users_by_status"`
+	DisabledUsers        int64                `json:"disabled_users"`
+	PendingNotifications int64                `json:"pending_notifications"`
+	RegistrationMode     RegistrationMode     `json:"registration_mode"`
+}
+
 type CreateVaultRequest struct {
-	VaultName string `json:"vault_name"`
-	Hint      string `json:"hint,omitempty"`
-	KdfSalt   string `json:"kdf_salt"`
+	VaultName string     `json:"vault_name"`
+	Hint      string     `json:"hint,omitempty"`
+	KdfSalt   string     `json:"kdf_salt"`
+	KDF       *KDFParams `json:"kdf,omitempty"`    // Defaults to DefaultKDFParams
+	Sealed    bool       `json:"sealed,omitempty"` // Keep release material encrypted under the server master key until release
+}
+
+// Replaces a vault's Shamir share set. Each share is encrypted client-side for its beneficiary.
+type SetVaultSharesRequest struct {
+	Threshold int                 `json:"threshold"`
+	Shares    []VaultShareRequest `json:"shares"`
+}
+
+type VaultShareRequest struct {
+	BeneficiaryID  string `json:"beneficiary_id"`
+	Index          int    `json:"index"`
+	EncryptedShare string `json:"encrypted_share"` // Base64
+}
+
+type VaultSharesResponse struct {
+	VaultID   string               `json:"vault_id"`
+	Threshold int64                `json:"threshold"`
+	Count     int64                `json:"count"`
+	Shares    []VaultShareResponse `json:"shares"`
+}
+
+type VaultShareResponse struct {
+	BeneficiaryID string `json:"beneficiary_id"`
+	Index         int64  `json:"index"`
+}
+
+// What a verifier sees behind the link in VERIFICATION_REQUESTED. Confirming
+// is only possible while the owner is awaiting verification.
+type VerifierResponse struct {
+	OwnerName            string     `json:"owner_name"`
+	VerifierName         string     `json:"verifier_name"`
+	AwaitingConfirmation bool       `json:"awaiting_confirmation"`
+	Confirmed            bool       `json:"confirmed"`
+	ConfirmedAt          *time.Time `json:"confirmed_at,omitempty"`
+}
+
+// What a beneficiary receives once the owner is confirmed dead
+type ReleaseResponse struct {
+	OwnerName       string                  `json:"owner_name"`
+	BeneficiaryName string                  `json:"beneficiary_name"`
+	Vaults          []ReleasedVaultResponse `json:"vaults"`
+}
+
+type ReleasedVaultResponse struct {
+	ID             string    `json:"id"`
+	VaultName      string    `json:"vault_name"`
+	Hint           string    `json:"hint,omitempty"`
+	KdfSalt        string    `json:"kdf_salt"`
+	KDF            KDFParams `json:"kdf"`
+	ShareThreshold int64     `json:"share_threshold,omitempty"`
+	ShareCount     int64     `json:"share_count,omitempty"`
+	ShareIndex     int64     `json:"share_index,omitempty"`
+	EncryptedShare string    `json:"encrypted_share,omitempty"`
+	WrappedKey     string    `json:"wrapped_key,omitempty"`
+}
+
+// Vault keys wrapped client-side to each beneficiary's public key
+type SetWrappedKeysRequest struct {
+	Keys []WrappedKeyRequest `json:"keys"`
+}
+
+type WrappedKeyRequest struct {
+	BeneficiaryID string `json:"beneficiary_id"`
+	WrappedKey    string `json:"wrapped_key"` // Base64 age file
+}
+
+type VaultRecipientResponse struct {
+	BeneficiaryID   string `json:"beneficiary_id"`
+	BeneficiaryName string `json:"beneficiary_name"`
+	PublicKey       string `json:"public_key,omitempty"`
+	HasWrappedKey   bool   `json:"has_wrapped_key"`
+}
+
+// Re-encrypts a whole vault under a new salt and KDF. Artifacts must list
+// every artifact of the vault exactly once.
+// Shares and WrappedKeys replace the vault's key material, as on a rotation commit
+type RekeyVaultRequest struct {
+	KdfSalt     string                 `json:"kdf_salt"`
+	KDF         KDFParams              `json:"kdf"`
+	Artifacts   []RekeyArtifactRequest `json:"artifacts"`
+	Shares      *SetVaultSharesRequest `json:"shares,omitempty"`
+	WrappedKeys []WrappedKeyRequest    `json:"wrapped_keys,omitempty"`
+}
+
+type RekeyArtifactRequest struct {
+	ID            string            `json:"id"`
+	EncryptedBlob EncryptedBlob     `json:"encrypted_blob"`
+	IV            string            `json:"iv"`
+	Envelope      *ArtifactEnvelope `json:"envelope,omitempty"`
+}
+
+// Opens a two-phase passphrase rotation. Hint replaces the vault's hint when
+// set; an empty string removes it.
+type StartRotationRequest struct {
+	KdfSalt string     `json:"kdf_salt"`
+	KDF     *KDFParams `json:"kdf,omitempty"` // Defaults to the vault's current KDF
+	Hint    *string    `json:"hint,omitempty"`
+}
+
+type RotationArtifactRequest struct {
+	EncryptedBlob EncryptedBlob     `json:"encrypted_blob"`
+	IV            string            `json:"iv"`
+	Envelope      *ArtifactEnvelope `json:"envelope,omitempty"`
+}
+
+// Key material for the new passphrase. The old shares and wrapped keys are
+// dropped on commit either way, so leave these out only to stop using them.
+type CommitRotationRequest struct {
+	Shares      *SetVaultSharesRequest `json:"shares,omitempty"`
+	WrappedKeys []WrappedKeyRequest    `json:"wrapped_keys,omitempty"`
+}
+
+type VaultRotationResponse struct {
+	ID        string    `json:"id"`
+	VaultID   string    `json:"vault_id"`
+	KdfSalt   string    `json:"kdf_salt"`
+	KDF       KDFParams `json:"kdf"`
+	Uploaded  []string  `json:"uploaded"` // Artifact IDs with a replacement
+	Missing   []string  `json:"missing"`  // Artifact IDs still to upload
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Result of importing a vault bundle into the caller's account
+type ImportVaultResponse struct {
+	VaultID        string `json:"vault_id"`
+	VaultName      string `json:"vault_name"`
+	Artifacts      int    `json:"artifacts"`
+	SignatureKeyID string `json:"signature_key_id"`
+	SignedHere     bool   `json:"signed_here"` // Bundle was signed by this server's key
 }
 
 type EncryptedBlob []byte
 type CreateArtifactRequest struct {
-	MessageType   MessageType   `json:"message_type"`
-	EncryptedBlob EncryptedBlob `json:"encrypted_blob"`
-	IV            string        `json:"iv"`
+	MessageType   MessageType       `json:"message_type"`
+	EncryptedBlob EncryptedBlob     `json:"encrypted_blob"`
+	IV            string            `json:"iv"`
+	Envelope      *ArtifactEnvelope `json:"envelope,omitempty"` // Defaults to CurrentEnvelopeVersion
+}
+
+type CreateBeneficiaryRequest struct {
+	BeneficiaryName string `json:"beneficiary_name"`
+	IsVerifier      bool   `json:"is_verifier"`
+}
+
+type BeneficiaryResponse struct {
+	ID              string    `json:"id"`
+	BeneficiaryName string    `json:"beneficiary_name"`
+	IsVerifier      bool      `json:"is_verifier"`
+	HasConfirmed    bool      `json:"has_confirmed"`
+	PublicKey       string    `json:"public_key,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// An age X25519 recipient, e.g. "age1..."
+type SetPublicKeyRequest struct {
+	PublicKey string `json:"public_key"`
+}
+
+type CreateContactMethodRequest struct {
+	Channel     Channel  `json:"channel"`
+	Destination string   `json:"destination"`
+	Metadata    Metadata `json:"metadata,omitempty"`
+}
+
+type ConfirmContactMethodRequest struct {
+	Code string `json:"code"`
+}
+
+// Metadata is left out on purpose: it can hold provider secrets such as bot tokens
+type ContactMethodResponse struct {
+	ID          string     `json:"id"`
+	Channel     Channel    `json:"channel"`
+	Destination string     `json:"destination"`
+	Verified    bool       `json:"verified"`
+	VerifiedAt  *time.Time `json:"verified_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// A single stage of the reminder escalation schedule.
+// Durations are in seconds, matching the liveness columns on users.
+type EscalationStep struct {
+	StartsBefore int64 `json:"starts_before"` // Stage begins this long before the trigger deadline
+	Interval     int64 `json:"interval"`      // Time between reminders while the stage is active
+	AllChannels  bool  `json:"all_channels"`  // Fan out to every contact method instead of the primary one
+}
+
+type ReminderPolicyRequest struct {
+	PrimaryContactID string           `json:"primary_contact_id,omitempty"`
+	Steps            []EscalationStep `json:"steps"`
+	QuietHoursStart  string           `json:"quiet_hours_start,omitempty"`
+	QuietHoursEnd    string           `json:"quiet_hours_end,omitempty"`
+	TimeZone         string           `json:"time_zone"`
+}
+
+type ReminderPolicyResponse struct {
+	PrimaryContactID string           `json:"primary_contact_id,omitempty"`
+	Steps            []EscalationStep `json:"steps"`
+	QuietHoursStart  string           `json:"quiet_hours_start,omitempty"`
+	QuietHoursEnd    string           `json:"quiet_hours_end,omitempty"`
+	TimeZone         string           `json:"time_zone"`
+	IsDefault        bool             `json:"is_default"`
+}
+
+type LivenessResponse struct {
+	CurrentStatus UserStatus `json:"current_status"`
+	LastCheckIn   time.Time  `json:"last_check_in"`
+	Deadline      time.Time  `json:"deadline"`
+	IsPaused      bool       `json:"is_paused"`
+}
+
+type TemplateListResponse struct {
+	Events        []NotificationEvent `json:"events"`
+	Locales       []string            `json:"locales"`
+	DefaultLocale string              `json:"default_locale"`
+}
+
+type TemplatePreviewResponse struct {
+	Event   NotificationEvent `json:"event"`
+	Locale  string            `json:"locale"`
+	Subject string            `json:"subject"`
+	Text    string            `json:"text"`
+	HTML    string            `json:"html"`
 }
 
 // Metadata field specific scanner
 type Metadata map[string]string
 
+// Until the store opens it, an encrypted value is kept under this key
+const sealedMetadataKey = "\x00sealed"
+
 func (m *Metadata) Scan(value interface{}) error {
-	b, ok := value.([]byte)
-	if !ok {
-		if value == nil {
-			*m = make(map[string]string)
-			return nil
-		}
+	if value == nil {
+		*m = make(map[string]string)
+		return nil
+	}
+	raw, _, err := columnString(value)
+	if err != nil {
 		return errors.New("type assertion to []byte failed")
 	}
-	return json.Unmarshal(b, m)
+	if IsEncryptedColumn(raw) {
+		*m = Metadata{sealedMetadataKey: raw}
+		return nil
+	}
+	return json.Unmarshal([]byte(raw), m)
 }
 
+// Metadata can hold provider secrets, so it is encrypted at rest like SecretString
 func (m Metadata) Value() (driver.Value, error) {
-	return json.Marshal(m)
+	if sealed, ok := m[sealedMetadataKey]; ok {
+		return sealedValue(sealed)
+	}
+	b, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	return sealedValue(string(b))
+}
+
+func (m *Metadata) Seal(ref ColumnRef) error {
+	if !ColumnEncryptionEnabled() {
+		return nil
+	}
+	b, err := json.Marshal(*m)
+	if err != nil {
+		return err
+	}
+	sealed, err := SealColumn(ref, b)
+	if err != nil {
+		return err
+	}
+	*m = Metadata{sealedMetadataKey: sealed}
+	return nil
+}
+
+func (m *Metadata) Open(ref ColumnRef) error {
+	sealed, ok := (*m)[sealedMetadataKey]
+	if !ok {
+		return nil
+	}
+	b, err := OpenColumn(ref, sealed)
+	if err != nil {
+		return err
+	}
+	opened := Metadata{}
+	if err := json.Unmarshal(b, &opened); err != nil {
+		return err
+	}
+	*m = opened
+	return nil
+}
+
+// Escalation steps field specific scanner
+type EscalationSteps []EscalationStep
+
+func (e *EscalationSteps) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*e = EscalationSteps{}
+		return nil
+	case []byte:
+		return json.Unmarshal(v, e)
+	case string:
+		return json.Unmarshal([]byte(v), e)
+	}
+	return errors.New("type assertion to []byte failed")
+}
+
+func (e EscalationSteps) Value() (driver.Value, error) {
+	return json.Marshal(e)
 }
@@ -0,0 +1,123 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Storage limits of an account. Zero means unlimited.
+type QuotaLimits struct {
+	MaxVaults        int64 `json:"max_vaults"`
+	MaxArtifacts     int64 `json:"max_artifacts"`      // Across all vaults
+	MaxArtifactBytes int64 `json:"max_artifact_bytes"` // Size of one encrypted blob
+	MaxStorageBytes  int64 `json:"max_storage_bytes"`  // Total size of all encrypted blobs
+}
+
+// Per-user overrides set by an admin. A nil field keeps the server default.
+type QuotaOverrides struct {
+	MaxVaults        *int64 `json:"max_vaults"`
+	MaxArtifacts     *int64 `json:"max_artifacts"`
+	MaxArtifactBytes *int64 `json:"max_artifact_bytes"`
+	MaxStorageBytes  *int64 `json:"max_storage_bytes"`
+}
+
+func (o QuotaOverrides) Apply(limits QuotaLimits) QuotaLimits {
+	for _, f := range []struct {
+		override *int64
+		limit    *int64
+	}{
+		{o.MaxVaults, &limits.MaxVaults},
+		{o.MaxArtifacts, &limits.MaxArtifacts},
+		{o.MaxArtifactBytes, &limits.MaxArtifactBytes},
+		{o.MaxStorageBytes, &limits.MaxStorageBytes},
+	} {
+		if f.override != nil {
+			*f.limit = *f.override
+		}
+	}
+	return limits
+}
+
+func IsValidQuotaOverrides(o QuotaOverrides) error {
+	for _, v := range []*int64{o.MaxVaults, o.MaxArtifacts, o.MaxArtifactBytes, o.MaxStorageBytes} {
+		if v != nil && *v < 0 {
+			return ErrInvalidQuota
+		}
+	}
+	return nil
+}
+
+// What an account currently stores
+type Usage struct {
+	Vaults    int64 `json:"vaults"`
+	Artifacts int64 `json:"artifacts"`
+	Bytes     int64 `json:"bytes"`
+}
+
+type VaultUsage struct {
+	VaultID   string `json:"vault_id"`
+	VaultName string `json:"vault_name"`
+	Artifacts int64  `json:"artifacts"`
+	Bytes     int64  `json:"bytes"`
+}
+
+type UsageResponse struct {
+	Limits    QuotaLimits     `json:"limits"`
+	Usage     Usage           `json:"usage"`
+	Vaults    []VaultUsage    `json:"vaults"`
+	Overrides *QuotaOverrides `json:"overrides,omitempty"` // Only shown to admins
+}
+
+// Quota resources, as reported in QuotaError and the X-Quota-Exceeded header
+const (
+	QuotaVaults       = "vaults"
+	QuotaArtifacts    = "artifacts"
+	QuotaArtifactSize = "artifact_size"
+	QuotaStorage      = "storage"
+)
+
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
+// Returned when a write would take an account past one of its limits.
+// errors.Is(err, ErrQuotaExceeded) matches it.
+type QuotaError struct {
+	Resource string
+	Limit    int64
+	Used     int64 // Before the rejected write
+}
+
+func (e *QuotaError) Error() string {
+	switch e.Resource {
+	case QuotaArtifactSize:
+		return fmt.Sprintf("quota exceeded: artifacts may be at most %d bytes", e.Limit)
+	case QuotaStorage:
+		return fmt.Sprintf("quota exceeded: storage is limited to %d bytes, %d in use", e.Limit, e.Used)
+	}
+	return fmt.Sprintf("quota exceeded: %s are limited to %d, %d in use", e.Resource, e.Limit, e.Used)
+}
+
+func (e *QuotaError) Is(target error) bool {
+	return target == ErrQuotaExceeded
+}
+
+// Checks that adding the given vaults, artifacts and bytes stays within limits
+func (l QuotaLimits) Allow(used Usage, vaults, artifacts, bytes int64) error {
+	if l.MaxVaults > 0 && vaults > 0 && used.Vaults+vaults > l.MaxVaults {
+		return &QuotaError{Resource: QuotaVaults, Limit: l.MaxVaults, Used: used.Vaults}
+	}
+	if l.MaxArtifacts > 0 && artifacts > 0 && used.Artifacts+artifacts > l.MaxArtifacts {
+		return &QuotaError{Resource: QuotaArtifacts, Limit: l.MaxArtifacts, Used: used.Artifacts}
+	}
+	if l.MaxStorageBytes > 0 && bytes > 0 && used.Bytes+bytes > l.MaxStorageBytes {
+		return &QuotaError{Resource: QuotaStorage, Limit: l.MaxStorageBytes, Used: used.Bytes}
+	}
+	return nil
+}
+
+// Checks a single blob against MaxArtifactBytes
+func (l QuotaLimits) AllowBlob(size int) error {
+	if l.MaxArtifactBytes > 0 && int64(size) > l.MaxArtifactBytes {
+		return &QuotaError{Resource: QuotaArtifactSize, Limit: l.MaxArtifactBytes}
+	}
+	return nil
+}
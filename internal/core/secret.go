@@ -0,0 +1,204 @@
+package core
+
+import (
+	"database/sql/driver"
+	"errors"
+	"strings"
+)
+
+// Sensitive columns (names, hints, contact destinations and metadata,
+// notification bodies) are encrypted at rest when the store installs a cipher
+// at startup. Encrypted values carry a prefix, so rows written before
+// encryption was turned on still read back as plaintext until they are
+// migrated. v1 values were not bound to their row; v2 values are. Without a
+// cipher, text that starts with "enc:" itself is stored behind the v0 prefix
+// so it is never mistaken for ciphertext.
+const (
+	escapedColumnPrefix = "enc:v0:"
+	unboundColumnPrefix = "enc:v1:"
+	boundColumnPrefix   = "enc:v2:"
+)
+
+var ErrColumnNotSealed = errors.New("column value was not sealed to its row before being written")
+
+// ColumnCipher encrypts and decrypts column values, output is printable text.
+// aad must be passed to Decrypt unchanged.
+type ColumnCipher interface {
+	Encrypt(plaintext, aad []byte) (string, error)
+	Decrypt(ciphertext string, aad []byte) ([]byte, error)
+}
+
+var columnCipher ColumnCipher
+
+// Installed once at startup, before any query runs
+func SetColumnCipher(c ColumnCipher) {
+	columnCipher = c
+}
+
+func ColumnEncryptionEnabled() bool {
+	return columnCipher != nil
+}
+
+// ColumnRef is where a column value is stored: the table, the column and the
+// row's primary key. Sealed values only open under the same ref, so one that
+// is copied into another row or column is rejected.
+type ColumnRef struct {
+	Table  string
+	Column string
+	Key    string
+}
+
+func (r ColumnRef) aad() []byte {
+	return []byte(r.Table + "/" + r.Column + "/" + r.Key)
+}
+
+func IsEncryptedColumn(value string) bool {
+	return strings.HasPrefix(value, boundColumnPrefix) || strings.HasPrefix(value, unboundColumnPrefix)
+}
+
+// Reports whether the value is already bound to its row, so the migration can skip it
+func IsSealedColumn(value string) bool {
+	return strings.HasPrefix(value, boundColumnPrefix)
+}
+
+// Encrypts a column value for the row it is stored in if a cipher is
+// installed, otherwise returns it as is
+func SealColumn(ref ColumnRef, plaintext []byte) (string, error) {
+	if columnCipher == nil {
+		if strings.HasPrefix(string(plaintext), "enc:") {
+			return escapedColumnPrefix + string(plaintext), nil
+		}
+		return string(plaintext), nil
+	}
+	ct, err := columnCipher.Encrypt(plaintext, ref.aad())
+	if err != nil {
+		return "", err
+	}
+	return boundColumnPrefix + ct, nil
+}
+
+// The inverse of SealColumn. Plaintext and v1 values are accepted too.
+// Without a cipher every value is plaintext: the store refuses to start
+// without a key once a data key exists, so anything that looks encrypted
+// was written unescaped by an older version.
+func OpenColumn(ref ColumnRef, value string) ([]byte, error) {
+	var aad []byte
+	switch {
+	case strings.HasPrefix(value, escapedColumnPrefix):
+		return []byte(strings.TrimPrefix(value, escapedColumnPrefix)), nil
+	case columnCipher == nil:
+		return []byte(value), nil
+	case strings.HasPrefix(value, boundColumnPrefix):
+		value = strings.TrimPrefix(value, boundColumnPrefix)
+		aad = ref.aad()
+	case strings.HasPrefix(value, unboundColumnPrefix):
+		value = strings.TrimPrefix(value, unboundColumnPrefix)
+	default:
+		return []byte(value), nil
+	}
+	return columnCipher.Decrypt(value, aad)
+}
+
+// What the column types write. The store seals values before they reach the
+// driver, so a plaintext value here means a query was not wrapped.
+func sealedValue(value string) (driver.Value, error) {
+	if columnCipher != nil && !IsSealedColumn(value) {
+		return nil, ErrColumnNotSealed
+	}
+	return value, nil
+}
+
+func columnString(value interface{}) (string, bool, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", false, nil
+	case string:
+		return v, true, nil
+	case []byte:
+		return string(v), true, nil
+	}
+	return "", false, errors.New("type assertion to string failed")
+}
+
+// SecretString is a NOT NULL text column encrypted at rest. Scan keeps the
+// stored value; the store opens it with the row it was read from.
+type SecretString string
+
+func (s *SecretString) Scan(value interface{}) error {
+	raw, _, err := columnString(value)
+	if err != nil {
+		return err
+	}
+	*s = SecretString(raw)
+	return nil
+}
+
+func (s SecretString) Value() (driver.Value, error) {
+	return sealedValue(string(s))
+}
+
+func (s *SecretString) Seal(ref ColumnRef) error {
+	sealed, err := SealColumn(ref, []byte(*s))
+	if err != nil {
+		return err
+	}
+	*s = SecretString(sealed)
+	return nil
+}
+
+func (s *SecretString) Open(ref ColumnRef) error {
+	plain, err := OpenColumn(ref, string(*s))
+	if err != nil {
+		return err
+	}
+	*s = SecretString(plain)
+	return nil
+}
+
+// NullSecretString is a nullable text column encrypted at rest.
+// Fields mirror sql.NullString so the two convert into each other.
+type NullSecretString struct {
+	String string
+	Valid  bool
+}
+
+func (n *NullSecretString) Scan(value interface{}) error {
+	raw, valid, err := columnString(value)
+	if err != nil || !valid {
+		*n = NullSecretString{}
+		return err
+	}
+	*n = NullSecretString{String: raw, Valid: true}
+	return nil
+}
+
+func (n NullSecretString) Value() (driver.Value, error) {
+	if !n.Valid {
+		return nil, nil
+	}
+	return sealedValue(n.String)
+}
+
+func (n *NullSecretString) Seal(ref ColumnRef) error {
+	if !n.Valid {
+		return nil
+	}
+	sealed, err := SealColumn(ref, []byte(n.String))
+	if err != nil {
+		return err
+	}
+	n.String = sealed
+	return nil
+}
+
+func (n *NullSecretString) Open(ref ColumnRef) error {
+	if !n.Valid {
+		return nil
+	}
+	plain, err := OpenColumn(ref, n.String)
+	if err != nil {
+		return err
+	}
+	n.String = string(plain)
+	return nil
+}
@@ -1,5 +1,20 @@
 package core
 
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"filippo.io/age"
+	"github.com/vmpyr/afterlight/internal/shamir"
+)
+
 func IsValidPassword(password string) error {
 	if len(password) < 8 {
 		return ErrPasswordLength
@@ -29,3 +44,109 @@ func IsValidPassword(password string) error {
 
 	return nil
 }
+
+var telegramChatID = regexp.MustCompile(`^(-?[0-9]+|@[A-Za-z0-9_]{5,32})$`)
+var telegramBotToken = regexp.MustCompile(`^[0-9]+:[A-Za-z0-9_-]+$`)
+
+// Checks that a destination is plausible for its channel before anything is sent to it
+func IsValidContactDestination(channel Channel, destination string, metadata Metadata) error {
+	switch channel {
+	case ChannelEmail:
+		addr, err := mail.ParseAddress(destination)
+		if err != nil || addr.Address != destination {
+			return ErrInvalidDestination
+		}
+	case ChannelDiscord:
+		if !isWebhookURL(destination, []string{"discord.com", "discordapp.com"}, "/api/webhooks/") {
+			return ErrInvalidDestination
+		}
+	case ChannelSlack:
+		if !isWebhookURL(destination, []string{"hooks.slack.com"}, "/services/") {
+			return ErrInvalidDestination
+		}
+	case ChannelTelegram:
+		if !telegramChatID.MatchString(destination) {
+			return ErrInvalidDestination
+		}
+		if !telegramBotToken.MatchString(metadata["bot_token"]) {
+			return ErrMissingBotToken
+		}
+	default:
+		return ErrUnsupportedChannel
+	}
+	return nil
+}
+
+func isWebhookURL(raw string, hosts []string, pathPrefix string) bool {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "https" || !strings.HasPrefix(u.Path, pathPrefix) {
+		return false
+	}
+	return slices.Contains(hosts, u.Hostname())
+}
+
+// Checks share metadata only; the shares themselves are encrypted and opaque to the server
+func IsValidShareSet(req SetVaultSharesRequest) error {
+	indexes := make([]int, 0, len(req.Shares))
+	holders := make(map[string]bool, len(req.Shares))
+	for _, share := range req.Shares {
+		if holders[share.BeneficiaryID] {
+			return ErrDuplicateShareholder
+		}
+		holders[share.BeneficiaryID] = true
+
+		raw, err := base64.StdEncoding.DecodeString(share.EncryptedShare)
+		if err != nil || len(raw) == 0 {
+			return ErrInvalidShare
+		}
+		indexes = append(indexes, share.Index)
+	}
+	return shamir.ValidateShareSet(req.Threshold, indexes)
+}
+
+// Bearer tokens are stored as SHA-256 hex so a database leak does not expose them
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func IsValidPublicKey(key string) error {
+	if _, err := age.ParseX25519Recipient(key); err != nil {
+		return ErrInvalidPublicKey
+	}
+	return nil
+}
+
+// Only the age header is inspected; the server cannot (and must not) decrypt the key
+func IsValidWrappedKey(wrapped string) error {
+	raw, err := base64.StdEncoding.DecodeString(wrapped)
+	if err != nil {
+		return ErrInvalidWrappedKey
+	}
+	header := string(raw)
+	if !strings.HasPrefix(header, "age-encryption.org/v1\n") || !strings.Contains(header, "\n-> X25519 ") {
+		return ErrInvalidWrappedKey
+	}
+	return nil
+}
+
+func IsValidRegistrationMode(mode RegistrationMode) error {
+	switch mode {
+	case RegistrationOpen, RegistrationInviteOnly:
+		return nil
+	}
+	return ErrInvalidRegistrationMode
+}
+
+// Longest an invite can stay valid
+const MaxInviteLifetime = 365 * 24 * time.Hour
+
+func IsValidInviteRequest(req CreateInviteRequest) error {
+	if req.MaxUses < 1 || req.ExpiresIn < 1 || time.Duration(req.ExpiresIn)*time.Second > MaxInviteLifetime {
+		return ErrInvalidInviteRequest
+	}
+	if req.Email != "" {
+		return IsValidContactDestination(ChannelEmail, req.Email, nil)
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+// Package health serves the liveness and readiness probes used by Docker
+// and Kubernetes.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/vmpyr/afterlight/internal/core"
+	"github.com/vmpyr/afterlight/internal/liveness"
+	"github.com/vmpyr/afterlight/internal/store"
+)
+
+// How long all readiness checks together may take
+const checkTimeout = 5 * time.Second
+
+// The liveness engine counts as stalled after this many missed intervals
+const stalledIntervals = 3
+
+type Checker struct {
+	storage       *store.SQLiteStorage
+	store         *store.Store
+	engine        *liveness.Engine
+	artifactsPath string
+	minFreeBytes  uint64
+	maxBacklog    int64
+}
+
+func NewChecker(storage *store.SQLiteStorage, engine *liveness.Engine, artifactsPath string, minFreeBytes uint64, maxBacklog int64) *Checker {
+	return &Checker{
+		storage:       storage,
+		store:         store.NewStore(storage.DB()),
+		engine:        engine,
+		artifactsPath: artifactsPath,
+		minFreeBytes:  minFreeBytes,
+		maxBacklog:    maxBacklog,
+	}
+}
+
+// Live answers as long as the process can serve HTTP at all
+func (c *Checker) Live(w http.ResponseWriter, r *http.Request) {
+	writeHealth(w, core.HealthResponse{Status: core.HealthOK, CheckedAt: time.Now().UTC()})
+}
+
+// Ready runs every check and answers 503 if any of them failed
+func (c *Checker) Ready(w http.ResponseWriter, r *http.Request) {
+	writeHealth(w, c.Report(r.Context()))
+}
+
+// Report runs every readiness check
+func (c *Checker) Report(ctx context.Context) core.HealthResponse {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	resp := core.HealthResponse{
+		Status: core.HealthOK,
+		Checks: map[string]core.HealthCheck{
+			"database":        c.checkDatabase(ctx),
+			"migrations":      c.checkMigrations(ctx),
+			"artifacts":       c.checkArtifacts(),
+			"notifier":        c.checkNotifier(ctx),
+			"liveness_engine": c.checkEngine(),
+		},
+		CheckedAt: time.Now().UTC(),
+	}
+	for _, check := range resp.Checks {
+		if check.Status == core.HealthFail {
+			resp.Status = core.HealthFail
+			break
+		}
+		if check.Status == core.HealthWarn {
+			resp.Status = core.HealthWarn
+		}
+	}
+	return resp
+}
+
+func (c *Checker) checkDatabase(ctx context.Context) core.HealthCheck {
+	if err := c.storage.DB().PingContext(ctx); err != nil {
+		return fail("unreachable: %v", err)
+	}
+	if err := c.storage.ProbeWrite(ctx); err != nil {
+		return fail("not writable: %v", err)
+	}
+	return ok("")
+}
+
+// Deferred code migrations (such as column encryption without a key) are
+// expected and only reported
+func (c *Checker) checkMigrations(ctx context.Context) core.HealthCheck {
+	pending, err := c.storage.PendingMigrations(ctx)
+	if err != nil {
+		return fail("%v", err)
+	}
+	var blocking, deferred []string
+	for _, m := range pending {
+		if m.Deferrable {
+			deferred = append(deferred, m.Name)
+		} else {
+			blocking = append(blocking, m.Name)
+		}
+	}
+	switch {
+	case len(blocking) > 0:
+		return fail("pending: %s", strings.Join(blocking, ", "))
+	case len(deferred) > 0:
+		return ok("deferred: %s", strings.Join(deferred, ", "))
+	}
+	return ok("")
+}
+
+func (c *Checker) checkArtifacts() core.HealthCheck {
+	f, err := os.CreateTemp(c.artifactsPath, ".readyz-*")
+	if err != nil {
+		return fail("not writable: %v", err)
+	}
+	_, err = f.Write([]byte("ok"))
+	f.Close()
+	os.Remove(f.Name())
+	if err != nil {
+		return fail("not writable: %v", err)
+	}
+
+	free, err := freeBytes(c.artifactsPath)
+	if err != nil {
+		return ok("free space unknown: %v", err)
+	}
+	if free < c.minFreeBytes {
+		return fail("%d MiB free, need %d MiB", free>>20, c.minFreeBytes>>20)
+	}
+	return ok("%d MiB free", free>>20)
+}
+
+// A growing backlog usually means a channel is down; the server can still serve
+func (c *Checker) checkNotifier(ctx context.Context) core.HealthCheck {
+	pending, err := c.store.CountPendingNotifications(ctx)
+	if err != nil {
+		return fail("%v", err)
+	}
+	if pending > c.maxBacklog {
+		return warn("%d notifications pending", pending)
+	}
+	return ok("%d notifications pending", pending)
+}
+
+func (c *Checker) checkEngine() core.HealthCheck {
+	last := c.engine.LastRun()
+	if last.IsZero() {
+		return fail("no successful run yet")
+	}
+	ago := time.Since(last).Round(time.Second)
+	if ago > stalledIntervals*c.engine.Interval() {
+		return fail("last successful run %s ago", ago)
+	}
+	return ok("last successful run %s ago", ago)
+}
+
+func ok(format string, args ...any) core.HealthCheck {
+	return core.HealthCheck{Status: core.HealthOK, Message: fmt.Sprintf(format, args...)}
+}
+
+func warn(format string, args ...any) core.HealthCheck {
+	return core.HealthCheck{Status: core.HealthWarn, Message: fmt.Sprintf(format, args...)}
+}
+
+func fail(format string, args ...any) core.HealthCheck {
+	return core.HealthCheck{Status: core.HealthFail, Message: fmt.Sprintf(format, args...)}
+}
+
+func writeHealth(w http.ResponseWriter, resp core.HealthResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if resp.Status == core.HealthFail {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(resp)
+}
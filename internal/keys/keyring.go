@@ -0,0 +1,175 @@
+// Package keys loads server-side key-encryption keys and provides the
+// AES-256-GCM primitives used to wrap data keys and encrypt data with them.
+package keys
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const KeySize = 32
+
+var (
+	ErrNoKey      = errors.New("no key configured")
+	ErrUnknownKey = errors.New("data is wrapped with a key that is not loaded")
+	ErrInvalidKey = errors.New("keys must be 32 bytes, base64 encoded")
+)
+
+// Keyring holds key-encryption keys by ID. The first key wraps; every key can
+// unwrap, so a file listing the new key first and the old one second keeps
+// working while data keys are being re-wrapped.
+type Keyring struct {
+	primary string
+	ids     []string // In file order
+	keys    map[string][]byte
+}
+
+// LoadKeyring reads the keyring from path, or from value if path is empty.
+// Returns nil without error when neither is set, since every use is optional.
+func LoadKeyring(path, value string) (*Keyring, error) {
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return ParseKeyring(data)
+	}
+	if value != "" {
+		return ParseKeyring([]byte(value))
+	}
+	return nil, nil
+}
+
+// One base64 key per line; blank lines and lines starting with # are ignored
+func ParseKeyring(data []byte) (*Keyring, error) {
+	k := &Keyring{keys: make(map[string][]byte)}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, err := base64.StdEncoding.DecodeString(line)
+		if err != nil || len(key) != KeySize {
+			return nil, ErrInvalidKey
+		}
+		id := KeyID(key)
+		if k.primary == "" {
+			k.primary = id
+		}
+		if _, ok := k.keys[id]; !ok {
+			k.ids = append(k.ids, id)
+		}
+		k.keys[id] = key
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if k.primary == "" {
+		return nil, ErrNoKey
+	}
+
+	return k, nil
+}
+
+func GenerateKey() ([]byte, error) {
+	key := make([]byte, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// Short fingerprint stored next to each wrapped key, never the key itself
+func KeyID(key []byte) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:8])
+}
+
+func (k *Keyring) PrimaryID() string {
+	return k.primary
+}
+
+// Has reports whether the key with this ID is loaded
+func (k *Keyring) Has(keyID string) bool {
+	_, ok := k.keys[keyID]
+	return ok
+}
+
+// Missing returns the keys of other that k lacks, in keyring file format and
+// other's order, so they can be appended to k's file
+func (k *Keyring) Missing(other *Keyring) []byte {
+	var b bytes.Buffer
+	for _, id := range other.ids {
+		if !k.Has(id) {
+			b.WriteString(base64.StdEncoding.EncodeToString(other.keys[id]))
+			b.WriteByte('\n')
+		}
+	}
+	return b.Bytes()
+}
+
+// Wrap encrypts a data key under the primary key. aad binds the result to
+// where it is stored, and must be passed to Unwrap unchanged.
+func (k *Keyring) Wrap(dataKey []byte, aad string) (keyID, wrapped string, err error) {
+	ct, err := Encrypt(k.keys[k.primary], dataKey, []byte(aad))
+	if err != nil {
+		return "", "", err
+	}
+	return k.primary, base64.StdEncoding.EncodeToString(ct), nil
+}
+
+func (k *Keyring) Unwrap(keyID, wrapped, aad string) ([]byte, error) {
+	key, ok := k.keys[keyID]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	ct, err := base64.StdEncoding.DecodeString(wrapped)
+	if err != nil {
+		return nil, err
+	}
+	return Decrypt(key, ct, []byte(aad))
+}
+
+// AES-256-GCM, output is nonce || ciphertext
+func Encrypt(key, plaintext, aad []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize(), gcm.NonceSize()+len(plaintext)+gcm.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+func Decrypt(key, data, aad []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, fmt.Errorf("sealed data too short")
+	}
+	return gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], aad)
+}
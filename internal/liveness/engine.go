@@ -0,0 +1,325 @@
+package liveness
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/vmpyr/afterlight/internal/core"
+	"github.com/vmpyr/afterlight/internal/notify"
+	"github.com/vmpyr/afterlight/internal/seal"
+	"github.com/vmpyr/afterlight/internal/store"
+)
+
+// Engine periodically evaluates every active user's dead man's switch:
+// it advances the status state machine and queues reminders and notices.
+type Engine struct {
+	store    *store.Store
+	notifier *notify.Notifier
+	sealer   *seal.Sealer
+	interval time.Duration
+	lastRun  atomic.Int64 // Unix nanoseconds of the last tick that completed
+}
+
+func NewEngine(s *store.Store, n *notify.Notifier, sealer *seal.Sealer, interval time.Duration) *Engine {
+	return &Engine{store: s, notifier: n, sealer: sealer, interval: interval}
+}
+
+// The moment the switch triggers: trigger_interval_num missed check-ins in a row
+func Deadline(u store.User) time.Time {
+	return u.LastCheckIn.Add(time.Duration(u.CheckInInterval*u.TriggerIntervalNum) * time.Second)
+}
+
+// NextStatus computes where the state machine should be at now.
+// confirmed is only consulted once the buffer period after the deadline has elapsed.
+func NextStatus(u store.User, confirmed int64, now time.Time) core.UserStatus {
+	firstMiss := u.LastCheckIn.Add(time.Duration(u.CheckInInterval) * time.Second)
+	verifyAt := Deadline(u).Add(time.Duration(u.BufferPeriod) * time.Second)
+
+	switch {
+	case now.Before(firstMiss):
+		return core.StatusAlive
+	case now.Before(verifyAt):
+		return core.StatusWarning
+	case u.VerifierQuorum.Int64 <= 0 || confirmed >= u.VerifierQuorum.Int64:
+		return core.StatusDead
+	default:
+		return core.StatusVerify
+	}
+}
+
+func (e *Engine) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		now := time.Now().UTC()
+		if err := e.Tick(ctx, now); err != nil {
+			if ctx.Err() == nil {
+				slog.ErrorContext(ctx, "liveness tick failed", "error", err)
+			}
+		} else {
+			e.lastRun.Store(now.UnixNano())
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// LastRun is when the last successful tick started; zero before the first one
+func (e *Engine) LastRun() time.Time {
+	if n := e.lastRun.Load(); n != 0 {
+		return time.Unix(0, n).UTC()
+	}
+	return time.Time{}
+}
+
+func (e *Engine) Interval() time.Duration {
+	return e.interval
+}
+
+// Tick evaluates all users that are not paused and not yet confirmed dead
+func (e *Engine) Tick(ctx context.Context, now time.Time) error {
+	users, err := e.store.ListLivenessCandidates(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, u := range users {
+		if err := e.evaluate(ctx, u, now); err != nil {
+			// One broken account must not stall everyone else's switch
+			slog.ErrorContext(ctx, "liveness evaluation failed", "user_id", u.ID, "error", err)
+		}
+	}
+
+	// Only owners whose CONFIRMED_DEAD status has been committed are unsealed,
+	// so a transition that loses a race with a check-in never opens a vault
+	if _, err := e.sealer.UnsealReleased(ctx); err != nil {
+		slog.ErrorContext(ctx, "unsealing released vaults failed", "error", err)
+	}
+	return nil
+}
+
+func (e *Engine) evaluate(ctx context.Context, u store.User, now time.Time) error {
+	var confirmed int64
+	if !now.Before(Deadline(u).Add(time.Duration(u.BufferPeriod) * time.Second)) {
+		var err error
+		confirmed, err = e.store.CountConfirmedVerifiers(ctx, u.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	next := NextStatus(u, confirmed, now)
+	if next != u.CurrentStatus {
+		if err := e.transition(ctx, u, next); err != nil {
+			return err
+		}
+	}
+
+	if next == core.StatusAlive || next == core.StatusWarning {
+		return e.remind(ctx, u, now)
+	}
+	return nil
+}
+
+func (e *Engine) transition(ctx context.Context, u store.User, next core.UserStatus) error {
+	change := store.StatusTransition{UserID: u.ID, From: u.CurrentStatus, To: next}
+
+	var event core.NotificationEvent
+	var tokens map[string]string
+	switch next {
+	case core.StatusVerify:
+		event = core.EventVerificationRequested
+		var err error
+		if tokens, change.VerifyTokens, err = e.issueTokens(ctx, u.ID, true); err != nil {
+			return err
+		}
+	case core.StatusDead:
+		event = core.EventVaultReleased
+		var err error
+		if tokens, change.ReleaseTokens, err = e.issueTokens(ctx, u.ID, false); err != nil {
+			return err
+		}
+	}
+
+	if tokens != nil {
+		beneficiaries, err := e.store.ListBeneficiariesByUser(ctx, u.ID)
+		if err != nil {
+			return err
+		}
+		for _, b := range beneficiaries {
+			token, ok := tokens[b.ID]
+			if !ok {
+				continue
+			}
+			contacts, err := e.store.ListContactMethodsByBeneficiaryID(ctx, sql.NullString{String: b.ID, Valid: true})
+			if err != nil {
+				return err
+			}
+			data := notify.TemplateData{
+				OwnerName:     string(u.Name),
+				RecipientName: string(b.BeneficiaryName),
+				LastCheckIn:   u.LastCheckIn,
+			}
+			if next == core.StatusVerify {
+				data.VerifyToken = token
+			} else {
+				data.ReleaseToken = token
+			}
+			for _, c := range contacts {
+				params, err := e.notifier.Prepare(u.ID, c, event, data)
+				if err != nil {
+					return err
+				}
+				change.Notifications = append(change.Notifications, params)
+			}
+		}
+	}
+
+	// The codes and notices are only written if the status actually changes
+	applied, err := e.store.TransitionUserTx(ctx, change)
+	if err != nil {
+		return err
+	}
+	if !applied {
+		slog.InfoContext(ctx, "liveness status changed concurrently, skipping", "user_id", u.ID, "from", u.CurrentStatus, "to_status", next)
+		return nil
+	}
+	slog.InfoContext(ctx, "liveness status changed", "user_id", u.ID, "from", u.CurrentStatus, "to_status", next)
+	return nil
+}
+
+// Creates a fresh code for every beneficiary, or only the verifiers, for
+// collecting released vaults or confirming a death. Returns the plaintext codes
+// and their hashes, both by beneficiary ID.
+func (e *Engine) issueTokens(ctx context.Context, userID string, verifiersOnly bool) (map[string]string, map[string]string, error) {
+	beneficiaries, err := e.store.ListBeneficiariesByUser(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tokens := make(map[string]string, len(beneficiaries))
+	hashes := make(map[string]string, len(beneficiaries))
+	for _, b := range beneficiaries {
+		if verifiersOnly && !b.IsVerifier.Bool {
+			continue
+		}
+		raw := make([]byte, 32)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, nil, err
+		}
+		token := base64.RawURLEncoding.EncodeToString(raw)
+		tokens[b.ID] = token
+		hashes[b.ID] = core.HashToken(token)
+	}
+	return tokens, hashes, nil
+}
+
+func (e *Engine) remind(ctx context.Context, u store.User, now time.Time) error {
+	policy, err := LoadPolicy(ctx, e.store, u.ID)
+	if err != nil {
+		return err
+	}
+
+	deadline := Deadline(u)
+	step, ok := policy.ActiveStep(deadline, now)
+	if !ok || policy.InQuietHours(now) {
+		return nil
+	}
+
+	// Reminders from before the current window (or before the last check-in) don't count
+	windowStart := policy.WindowStart(deadline)
+	if windowStart.Before(u.LastCheckIn) {
+		windowStart = u.LastCheckIn
+	}
+	last, err := e.store.GetLatestNotificationByEvent(ctx, store.GetLatestNotificationByEventParams{
+		UserID: u.ID,
+		Event:  core.EventCheckInReminder,
+	})
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if err == nil && !last.CreatedAt.Before(windowStart) &&
+		now.Sub(last.CreatedAt) < time.Duration(step.Interval)*time.Second {
+		return nil
+	}
+
+	contacts, err := e.store.ListContactMethodsByUserID(ctx, sql.NullString{String: u.ID, Valid: true})
+	if err != nil {
+		return err
+	}
+	contacts = trustedContacts(u, contacts)
+	if !step.AllChannels {
+		contacts = primaryContact(policy, u, contacts)
+	}
+	if len(contacts) == 0 {
+		slog.WarnContext(ctx, "no contact method for reminders", "user_id", u.ID)
+		return nil
+	}
+
+	return e.notifyAll(ctx, u.ID, contacts, core.EventCheckInReminder, notify.TemplateData{
+		RecipientName: string(u.Name),
+		OwnerName:     string(u.Name),
+		Deadline:      deadline,
+		LastCheckIn:   u.LastCheckIn,
+		Location:      policy.Location,
+	})
+}
+
+// Only verified channels receive reminders. If none are verified yet, the account
+// email is used so the owner is never left without a warning before the deadline.
+func trustedContacts(u store.User, contacts []store.ContactMethod) []store.ContactMethod {
+	var verified []store.ContactMethod
+	var account []store.ContactMethod
+	for _, c := range contacts {
+		if c.VerifiedAt.Valid {
+			verified = append(verified, c)
+		} else if c.Channel == core.ChannelEmail && string(c.Destination) == u.Email {
+			account = append(account, c)
+		}
+	}
+	if len(verified) > 0 {
+		return verified
+	}
+	if len(account) > 0 {
+		slog.Warn("no verified contact method, falling back to account email", "user_id", u.ID)
+	}
+	return account
+}
+
+// The policy's chosen contact method, falling back to the account email
+func primaryContact(p Policy, u store.User, contacts []store.ContactMethod) []store.ContactMethod {
+	for _, c := range contacts {
+		if c.ID == p.PrimaryContactID {
+			return []store.ContactMethod{c}
+		}
+	}
+	for _, c := range contacts {
+		if c.Channel == core.ChannelEmail && string(c.Destination) == u.Email {
+			return []store.ContactMethod{c}
+		}
+	}
+	if len(contacts) > 0 {
+		return contacts[:1]
+	}
+	return nil
+}
+
+func (e *Engine) notifyAll(ctx context.Context, userID string, contacts []store.ContactMethod, event core.NotificationEvent, data notify.TemplateData) error {
+	for _, c := range contacts {
+		if err := e.notifier.Notify(ctx, userID, c, event, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,254 @@
+package liveness
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/vmpyr/afterlight/internal/core"
+	"github.com/vmpyr/afterlight/internal/keys"
+	"github.com/vmpyr/afterlight/internal/notify"
+	"github.com/vmpyr/afterlight/internal/seal"
+	"github.com/vmpyr/afterlight/internal/store"
+)
+
+type engineTest struct {
+	db     *sql.DB
+	store  *store.Store
+	engine *Engine
+	sealer *seal.Sealer
+}
+
+func newEngineTest(t *testing.T) engineTest {
+	t.Helper()
+	storage, err := store.NewStorage(filepath.Join(t.TempDir(), "afterlight.db"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { storage.Close() })
+
+	key, err := keys.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	master, err := keys.ParseKeyring([]byte(base64.StdEncoding.EncodeToString(key)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	templates, err := notify.NewTemplates("", notify.DefaultLocale)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := store.NewStore(storage.DB())
+	sealer := seal.NewSealer(s, master)
+	return engineTest{
+		db:     storage.DB(),
+		store:  s,
+		engine: NewEngine(s, notify.NewNotifier(s, templates, "https://afterlight.example"), sealer, time.Minute),
+		sealer: sealer,
+	}
+}
+
+// An owner who missed their only check-in three hours ago, with one sealed vault
+func (et engineTest) overdueOwner(t *testing.T, status core.UserStatus) (store.User, store.Vault) {
+	t.Helper()
+	ctx := context.Background()
+	u, err := et.store.CreateUserTx(ctx, core.RegisterRequest{
+		Name:     "Owner",
+		Email:    "owner@example.com",
+		Password: "Correct-horse-9",
+	}, store.UserDefaults{CheckInInterval: time.Hour, TriggerIntervals: 1}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := et.db.Exec("UPDATE users SET last_check_in = ?, current_status = ? WHERE id = ?",
+		time.Now().UTC().Add(-3*time.Hour), status, u.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := et.store.CreateVault(ctx, store.CreateVaultParams{
+		ID:        "vault",
+		UserID:    u.ID,
+		VaultName: "Vault",
+		KdfSalt:   "00",
+		KdfParams: core.DefaultKDFParams,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := et.store.CreateArtifact(ctx, store.CreateArtifactParams{
+		ID:            "artifact",
+		VaultID:       v.ID,
+		MessageType:   core.MsgText,
+		EncryptedBlob: []byte("ciphertext"),
+		Iv:            "iv",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := et.sealer.SealVault(ctx, v); err != nil {
+		t.Fatal(err)
+	}
+
+	u, err = et.store.GetUserByID(ctx, u.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err = et.store.GetVault(ctx, v.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u, v
+}
+
+func (et engineTest) vault(t *testing.T, id string) store.Vault {
+	t.Helper()
+	v, err := et.store.GetVault(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func TestTickUnsealsConfirmedDead(t *testing.T) {
+	et := newEngineTest(t)
+	ctx := context.Background()
+	u, v := et.overdueOwner(t, core.StatusWarning)
+
+	if err := et.engine.Tick(ctx, time.Now().UTC()); err != nil {
+		t.Fatal(err)
+	}
+
+	u, err := et.store.GetUserByID(ctx, u.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.CurrentStatus != core.StatusDead {
+		t.Fatalf("status = %s, want %s", u.CurrentStatus, core.StatusDead)
+	}
+	v = et.vault(t, v.ID)
+	if v.SealedKey.Valid || !v.UnsealedAt.Valid {
+		t.Fatal("vault of a confirmed dead owner is still sealed")
+	}
+	artifact, err := et.store.GetArtifact(ctx, store.GetArtifactParams{ID: "artifact", VaultID: v.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(artifact.EncryptedBlob) != "ciphertext" {
+		t.Fatalf("artifact after unseal = %q", artifact.EncryptedBlob)
+	}
+}
+
+func TestLostDeadTransitionKeepsVaultsSealed(t *testing.T) {
+	et := newEngineTest(t)
+	ctx := context.Background()
+	stale, v := et.overdueOwner(t, core.StatusWarning)
+
+	// The owner checks in after the engine loaded them but before it transitions
+	if _, err := et.store.CheckInTx(ctx, stale.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := et.engine.transition(ctx, stale, core.StatusDead); err != nil {
+		t.Fatal(err)
+	}
+	if err := et.engine.Tick(ctx, time.Now().UTC()); err != nil {
+		t.Fatal(err)
+	}
+
+	u, err := et.store.GetUserByID(ctx, stale.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.CurrentStatus != core.StatusAlive {
+		t.Fatalf("status = %s, want %s", u.CurrentStatus, core.StatusAlive)
+	}
+	if after := et.vault(t, v.ID); !after.SealedKey.Valid || after.UnsealedAt.Valid {
+		t.Fatal("vault of a living owner was unsealed")
+	}
+}
+
+// Adds a beneficiary of userID reachable by email
+func (et engineTest) beneficiary(t *testing.T, userID, id string, verifier bool) {
+	t.Helper()
+	ctx := context.Background()
+	if _, err := et.store.CreateBeneficiary(ctx, store.CreateBeneficiaryParams{
+		ID:              id,
+		UserID:          userID,
+		BeneficiaryName: core.SecretString(id),
+		IsVerifier:      sql.NullBool{Bool: verifier, Valid: true},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := et.store.CreateContactMethod(ctx, store.CreateContactMethodParams{
+		ID:            "contact-" + id,
+		BeneficiaryID: sql.NullString{String: id, Valid: true},
+		Channel:       core.ChannelEmail,
+		Destination:   core.SecretString(id + "@example.com"),
+		Metadata:      core.Metadata{},
+		CreatedAt:     time.Now().UTC(),
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func (et engineTest) notices(t *testing.T, userID string, event core.NotificationEvent) []store.NotificationOutbox {
+	t.Helper()
+	all, err := et.store.ListNotificationsByUser(context.Background(), userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out []store.NotificationOutbox
+	for _, n := range all {
+		if n.Event == event {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+func TestTickAsksVerifiersOnce(t *testing.T) {
+	et := newEngineTest(t)
+	ctx := context.Background()
+	u, v := et.overdueOwner(t, core.StatusWarning)
+	et.beneficiary(t, u.ID, "verifier", true)
+	et.beneficiary(t, u.ID, "heir", false)
+	if _, err := et.db.Exec("UPDATE users SET verifier_quorum = 1 WHERE id = ?", u.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	for range 2 {
+		if err := et.engine.Tick(ctx, time.Now().UTC()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := et.store.GetUserByID(ctx, u.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.CurrentStatus != core.StatusVerify {
+		t.Fatalf("status = %s, want %s", got.CurrentStatus, core.StatusVerify)
+	}
+	asked := et.notices(t, u.ID, core.EventVerificationRequested)
+	if len(asked) != 1 || asked[0].ContactMethodID != "contact-verifier" {
+		t.Fatalf("verification requests = %+v, want one to the verifier", asked)
+	}
+	if released := et.notices(t, u.ID, core.EventVaultReleased); len(released) != 0 {
+		t.Fatalf("release notices while awaiting verification: %+v", released)
+	}
+	beneficiaries, err := et.store.ListBeneficiariesByUser(ctx, u.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, b := range beneficiaries {
+		if b.VerifyTokenHash.Valid != b.IsVerifier.Bool || b.ReleaseTokenHash.Valid {
+			t.Fatalf("codes of %s = %+v", b.ID, b)
+		}
+	}
+	if after := et.vault(t, v.ID); !after.SealedKey.Valid {
+		t.Fatal("vault unsealed while awaiting verification")
+	}
+}
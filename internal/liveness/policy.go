@@ -0,0 +1,145 @@
+package liveness
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/vmpyr/afterlight/internal/core"
+	"github.com/vmpyr/afterlight/internal/store"
+)
+
+const (
+	hour = int64(time.Hour / time.Second)
+	day  = 24 * hour
+)
+
+// Used for users who never configured their own schedule:
+// daily on the primary channel from a week out, then fan out and speed up.
+var DefaultSteps = core.EscalationSteps{
+	{StartsBefore: 7 * day, Interval: day, AllChannels: false},
+	{StartsBefore: 3 * day, Interval: 12 * hour, AllChannels: true},
+	{StartsBefore: 1 * day, Interval: 4 * hour, AllChannels: true},
+}
+
+type Policy struct {
+	PrimaryContactID string
+	Steps            core.EscalationSteps
+	QuietHoursStart  string
+	QuietHoursEnd    string
+	Location         *time.Location
+	IsDefault        bool
+}
+
+func DefaultPolicy() Policy {
+	return Policy{Steps: DefaultSteps, Location: time.UTC, IsDefault: true}
+}
+
+func LoadPolicy(ctx context.Context, s *store.Store, userID string) (Policy, error) {
+	row, err := s.GetReminderPolicy(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return DefaultPolicy(), nil
+		}
+		return Policy{}, err
+	}
+
+	loc, err := time.LoadLocation(row.TimeZone)
+	if err != nil {
+		loc = time.UTC
+	}
+
+	return Policy{
+		PrimaryContactID: row.PrimaryContactID.String,
+		Steps:            row.Steps,
+		QuietHoursStart:  row.QuietHoursStart.String,
+		QuietHoursEnd:    row.QuietHoursEnd.String,
+		Location:         loc,
+	}, nil
+}
+
+func ValidatePolicy(req core.ReminderPolicyRequest) error {
+	if _, err := time.LoadLocation(req.TimeZone); err != nil || req.TimeZone == "" {
+		return core.ErrInvalidTimeZone
+	}
+
+	if (req.QuietHoursStart == "") != (req.QuietHoursEnd == "") {
+		return core.ErrInvalidQuietHours
+	}
+	if req.QuietHoursStart != "" {
+		if _, err := parseClock(req.QuietHoursStart); err != nil {
+			return core.ErrInvalidQuietHours
+		}
+		if _, err := parseClock(req.QuietHoursEnd); err != nil {
+			return core.ErrInvalidQuietHours
+		}
+	}
+
+	if len(req.Steps) == 0 {
+		return core.ErrInvalidEscalation
+	}
+	for i, step := range req.Steps {
+		if step.StartsBefore < 0 || step.Interval <= 0 {
+			return core.ErrInvalidEscalation
+		}
+		if i > 0 && step.StartsBefore >= req.Steps[i-1].StartsBefore {
+			return core.ErrInvalidEscalation
+		}
+	}
+
+	return nil
+}
+
+// Returns the escalation step in effect at now, if reminders have started
+func (p Policy) ActiveStep(deadline, now time.Time) (core.EscalationStep, bool) {
+	var active core.EscalationStep
+	found := false
+	for _, step := range p.Steps {
+		if !now.Before(deadline.Add(-time.Duration(step.StartsBefore) * time.Second)) {
+			active = step
+			found = true
+		}
+	}
+	return active, found
+}
+
+// When the first reminder for the given deadline may go out
+func (p Policy) WindowStart(deadline time.Time) time.Time {
+	if len(p.Steps) == 0 {
+		return deadline
+	}
+	return deadline.Add(-time.Duration(p.Steps[0].StartsBefore) * time.Second)
+}
+
+func (p Policy) InQuietHours(now time.Time) bool {
+	if p.QuietHoursStart == "" || p.QuietHoursEnd == "" {
+		return false
+	}
+	start, err := parseClock(p.QuietHoursStart)
+	if err != nil {
+		return false
+	}
+	end, err := parseClock(p.QuietHoursEnd)
+	if err != nil {
+		return false
+	}
+
+	local := now.In(p.Location)
+	minute := local.Hour()*60 + local.Minute()
+
+	// Quiet hours may wrap around midnight (e.g. 22:00 - 07:00)
+	if start <= end {
+		return minute >= start && minute < end
+	}
+	return minute >= start || minute < end
+}
+
+// Minutes since midnight for an HH:MM string
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, err
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
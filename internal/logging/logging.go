@@ -0,0 +1,92 @@
+// Package logging sets up structured logging: JSON or text output through
+// log/slog, request and user IDs taken from the context, and redaction of
+// emails, tokens and contact destinations.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// New returns a logger writing to w at level in "json" or "text" format.
+// Records logged with a request context carry its request_id and user_id.
+func New(w io.Writer, level slog.Level, format string) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: level}
+	var h slog.Handler
+	if format == "text" {
+		h = slog.NewTextHandler(w, opts)
+	} else {
+		h = slog.NewJSONHandler(w, opts)
+	}
+	return slog.New(&contextHandler{redactHandler{h}})
+}
+
+func ParseLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(s)); err != nil {
+		return 0, fmt.Errorf("unknown log level %q", s)
+	}
+	return level, nil
+}
+
+func IsValidFormat(s string) bool {
+	return s == "json" || s == "text"
+}
+
+type ctxKey struct{}
+
+// Request details shared between the middleware and whatever runs inside it.
+// A pointer is stored so the user can be attached after authentication.
+type requestInfo struct {
+	id     string
+	userID string
+}
+
+func withRequest(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, &requestInfo{id: id})
+}
+
+// SetUser tags the current request's log records with userID
+func SetUser(ctx context.Context, userID string) {
+	if info, ok := ctx.Value(ctxKey{}).(*requestInfo); ok {
+		info.userID = userID
+	}
+}
+
+// RequestID returns the ID of the request in ctx, if any
+func RequestID(ctx context.Context) string {
+	if info, ok := ctx.Value(ctxKey{}).(*requestInfo); ok {
+		return info.id
+	}
+	return ""
+}
+
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if info, ok := ctx.Value(ctxKey{}).(*requestInfo); ok {
+		r.AddAttrs(slog.String("request_id", info.id))
+		if info.userID != "" {
+			r.AddAttrs(slog.String("user_id", info.userID))
+		}
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{h.Handler.WithGroup(name)}
+}
+
+// Attribute keys are compared lowercased
+func normalizeKey(key string) string {
+	return strings.ToLower(strings.ReplaceAll(key, "-", "_"))
+}
@@ -0,0 +1,104 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+var emailPattern = regexp.MustCompile(`([A-Za-z0-9._%+\-])[A-Za-z0-9._%+\-]*@([A-Za-z0-9.\-]+\.[A-Za-z]{2,})`)
+var bearerPattern = regexp.MustCompile(`(?i)bearer\s+\S+`)
+
+// Telegram bot tokens are the bot ID, a colon and a 35 character secret
+var botTokenPattern = regexp.MustCompile(`\d{6,}:[A-Za-z0-9_\-]{30,}`)
+
+// Attributes whose values are dropped entirely
+var secretKeys = []string{"token", "password", "passphrase", "secret", "authorization", "cookie", "access_code", "api_key"}
+
+// Attributes that may identify a person; emails keep their first letter and domain
+var contactKeys = []string{"email", "destination", "to", "webhook_url", "chat_id"}
+
+// redactHandler masks sensitive values before they reach the output, both
+// in the attributes named above and in any email address in free text
+type redactHandler struct {
+	slog.Handler
+}
+
+func (h redactHandler) Handle(ctx context.Context, r slog.Record) error {
+	out := slog.NewRecord(r.Time, r.Level, Scrub(r.Message), r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		out.AddAttrs(redactAttr(a))
+		return true
+	})
+	return h.Handler.Handle(ctx, out)
+}
+
+func (h redactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clean := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		clean[i] = redactAttr(a)
+	}
+	return redactHandler{h.Handler.WithAttrs(clean)}
+}
+
+func (h redactHandler) WithGroup(name string) slog.Handler {
+	return redactHandler{h.Handler.WithGroup(name)}
+}
+
+func redactAttr(a slog.Attr) slog.Attr {
+	v := a.Value.Resolve()
+	key := normalizeKey(a.Key)
+
+	if v.Kind() == slog.KindGroup {
+		attrs := v.Group()
+		clean := make([]any, len(attrs))
+		for i, g := range attrs {
+			clean[i] = redactAttr(g)
+		}
+		return slog.Group(a.Key, clean...)
+	}
+
+	for _, k := range secretKeys {
+		if strings.Contains(key, k) {
+			return slog.String(a.Key, redacted)
+		}
+	}
+	for _, k := range contactKeys {
+		if key == k || strings.HasSuffix(key, "_"+k) {
+			return slog.String(a.Key, maskContact(v.String()))
+		}
+	}
+
+	switch v.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, Scrub(v.String()))
+	case slog.KindAny:
+		if err, ok := v.Any().(error); ok {
+			return slog.String(a.Key, Scrub(err.Error()))
+		}
+		if s, ok := v.Any().(interface{ String() string }); ok {
+			return slog.String(a.Key, Scrub(s.String()))
+		}
+	}
+	return slog.Attr{Key: a.Key, Value: v}
+}
+
+// Scrub masks email addresses, bearer tokens and bot tokens in free text
+func Scrub(s string) string {
+	s = emailPattern.ReplaceAllString(s, "$1***@$2")
+	s = botTokenPattern.ReplaceAllString(s, redacted)
+	return bearerPattern.ReplaceAllString(s, "Bearer "+redacted)
+}
+
+func maskContact(s string) string {
+	if s == "" {
+		return ""
+	}
+	if emailPattern.MatchString(s) {
+		return Scrub(s)
+	}
+	return redacted
+}
@@ -0,0 +1,21 @@
+package logging
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestScrub(t *testing.T) {
+	tests := []struct {
+		in, secret string
+	}{
+		{"mail to alice@example.com failed", "alice@"},
+		{"header Bearer abc.def.ghi", "abc.def.ghi"},
+		{`Post "https://api.telegram.org/bot123456789:AAHdqTcvCH1vGWJxfSeofSAs0K5PALDsaw/sendMessage": timeout`, "AAHdqTcvCH1vGWJxfSeofSAs0K5PALDsaw"},
+	}
+	for _, tt := range tests {
+		if out := Scrub(tt.in); strings.Contains(out, tt.secret) {
+			t.Errorf("Scrub(%q) = %q, still contains %q", tt.in, out, tt.secret)
+		}
+	}
+}
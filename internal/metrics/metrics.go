@@ -0,0 +1,111 @@
+// Package metrics exposes Prometheus metrics for HTTP traffic, notification
+// delivery and the state of the database.
+package metrics
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/vmpyr/afterlight/internal/core"
+	"github.com/vmpyr/afterlight/internal/store"
+)
+
+const namespace = "afterlight"
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by method, chi route pattern and status code.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method and chi route pattern.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	notificationSends = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "notification_sends_total",
+		Help:      "Notification delivery attempts by channel and result.",
+	}, []string{"channel", "result"})
+)
+
+// Registry holds every afterlight metric plus the Go runtime and process
+// collectors
+type Registry struct {
+	reg   *prometheus.Registry
+	token string
+}
+
+// An empty token leaves the endpoint open
+func NewRegistry(s *store.Store, db *sql.DB, token string) *Registry {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		collectors.NewDBStatsCollector(db, "afterlight"),
+		httpRequests,
+		httpDuration,
+		notificationSends,
+		newStoreCollector(s),
+	)
+	return &Registry{reg: reg, token: token}
+}
+
+// Handler serves /metrics, requiring "Authorization: Bearer <token>" if a token is set
+func (m *Registry) Handler() http.Handler {
+	metrics := promhttp.HandlerFor(m.reg, promhttp.HandlerOpts{})
+	if m.token == "" {
+		return metrics
+	}
+	want := []byte("Bearer " + m.token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), want) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="metrics"`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		metrics.ServeHTTP(w, r)
+	})
+}
+
+// Middleware records request counts and latencies. Routes are labelled by
+// their chi pattern so IDs in the path do not create new series.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+
+		route := "unmatched"
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			route = rctx.RoutePattern()
+		}
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		httpRequests.WithLabelValues(r.Method, route, strconv.Itoa(status)).Inc()
+		httpDuration.WithLabelValues(r.Method, route).Observe(time.Since(start).Seconds())
+	})
+}
+
+// ObserveNotification counts one delivery attempt on channel
+func ObserveNotification(channel core.Channel, err error) {
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	notificationSends.WithLabelValues(string(channel), result).Inc()
+}
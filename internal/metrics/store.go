@@ -0,0 +1,111 @@
+package metrics
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/vmpyr/afterlight/internal/core"
+	"github.com/vmpyr/afterlight/internal/liveness"
+	"github.com/vmpyr/afterlight/internal/store"
+)
+
+// How long a scrape may spend querying the database
+const scrapeTimeout = 5 * time.Second
+
+// Upper bounds, in seconds, for the time left until users' deadlines
+var deadlineBuckets = []float64{
+	(time.Hour).Seconds(),
+	(6 * time.Hour).Seconds(),
+	(24 * time.Hour).Seconds(),
+	(3 * 24 * time.Hour).Seconds(),
+	(7 * 24 * time.Hour).Seconds(),
+	(14 * 24 * time.Hour).Seconds(),
+	(30 * 24 * time.Hour).Seconds(),
+	(90 * 24 * time.Hour).Seconds(),
+}
+
+var (
+	usersDesc = prometheus.NewDesc(namespace+"_users",
+		"Users by liveness status.", []string{"status"}, nil)
+	deadlinesDesc = prometheus.NewDesc(namespace+"_liveness_deadline_seconds",
+		"Time left until the check-in deadline of active users; overdue users count as 0.", nil, nil)
+	outboxDesc = prometheus.NewDesc(namespace+"_notification_outbox_pending",
+		"Notifications waiting to be delivered.", nil, nil)
+)
+
+// storeCollector reads gauges from the database on every scrape
+type storeCollector struct {
+	store *store.Store
+}
+
+func newStoreCollector(s *store.Store) *storeCollector {
+	return &storeCollector{store: s}
+}
+
+func (c *storeCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- usersDesc
+	ch <- deadlinesDesc
+	ch <- outboxDesc
+}
+
+func (c *storeCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), scrapeTimeout)
+	defer cancel()
+
+	statuses, err := c.store.CountUsersByStatus(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "metrics: counting users failed", "error", err)
+		ch <- prometheus.NewInvalidMetric(usersDesc, err)
+	} else {
+		counts := map[core.UserStatus]int64{
+			core.StatusAlive:   0,
+			core.StatusWarning: 0,
+			core.StatusVerify:  0,
+			core.StatusDead:    0,
+		}
+		for _, s := range statuses {
+			counts[s.CurrentStatus] = s.Count
+		}
+		for status, n := range counts {
+			ch <- prometheus.MustNewConstMetric(usersDesc, prometheus.GaugeValue, float64(n), string(status))
+		}
+	}
+
+	if m, err := c.deadlines(ctx); err != nil {
+		slog.ErrorContext(ctx, "metrics: listing deadlines failed", "error", err)
+		ch <- prometheus.NewInvalidMetric(deadlinesDesc, err)
+	} else {
+		ch <- m
+	}
+
+	pending, err := c.store.CountPendingNotifications(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "metrics: counting outbox failed", "error", err)
+		ch <- prometheus.NewInvalidMetric(outboxDesc, err)
+	} else {
+		ch <- prometheus.MustNewConstMetric(outboxDesc, prometheus.GaugeValue, float64(pending))
+	}
+}
+
+func (c *storeCollector) deadlines(ctx context.Context) (prometheus.Metric, error) {
+	users, err := c.store.ListLivenessCandidates(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	buckets := make(map[float64]uint64, len(deadlineBuckets))
+	var sum float64
+	for _, u := range users {
+		left := max(liveness.Deadline(u).Sub(now).Seconds(), 0)
+		sum += left
+		for _, b := range deadlineBuckets {
+			if left <= b {
+				buckets[b]++
+			}
+		}
+	}
+	return prometheus.NewConstHistogram(deadlinesDesc, uint64(len(users)), sum, buckets)
+}
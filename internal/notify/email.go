@@ -0,0 +1,70 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+
+	"github.com/vmpyr/afterlight/internal/store"
+)
+
+var ErrEmailNotConfigured = errors.New("SMTP is not configured")
+
+// Without a Host nothing is sent: emails stay in the outbox until SMTP is
+// configured, so codes in them are not lost
+type EmailSender struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+func (e *EmailSender) Configured() bool {
+	return e.Host != ""
+}
+
+func (e *EmailSender) Send(ctx context.Context, to store.ContactMethod, msg Message) error {
+	if !e.Configured() {
+		return ErrEmailNotConfigured
+	}
+
+	var auth smtp.Auth
+	if e.Username != "" {
+		auth = smtp.PlainAuth("", e.Username, e.Password, e.Host)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", e.From)
+	fmt.Fprintf(&b, "To: %s\r\n", to.Destination)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	b.WriteString("MIME-Version: 1.0\r\n")
+
+	if msg.HTML == "" {
+		b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+		b.WriteString(msg.Body)
+	} else {
+		// Plain text first so clients that can't render HTML still show something
+		mw := multipart.NewWriter(&b)
+		fmt.Fprintf(&b, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", mw.Boundary())
+		for _, part := range []struct{ contentType, body string }{
+			{"text/plain; charset=UTF-8", msg.Body},
+			{"text/html; charset=UTF-8", msg.HTML},
+		} {
+			w, err := mw.CreatePart(textproto.MIMEHeader{"Content-Type": {part.contentType}})
+			if err != nil {
+				return err
+			}
+			w.Write([]byte(part.body))
+		}
+		mw.Close()
+	}
+
+	return smtp.SendMail(net.JoinHostPort(e.Host, e.Port), auth, e.From, []string{string(to.Destination)}, []byte(b.String()))
+}
@@ -0,0 +1,196 @@
+package notify
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vmpyr/afterlight/internal/core"
+	"github.com/vmpyr/afterlight/internal/store"
+)
+
+// Failed sends are retried on every flush until this many attempts have been made
+const maxAttempts = 5
+
+type Message struct {
+	Subject string
+	Body    string
+	HTML    string // Optional, used by channels that can render it
+}
+
+// A Sender delivers a message over one channel type (email, webhook, ...)
+type Sender interface {
+	Send(ctx context.Context, to store.ContactMethod, msg Message) error
+}
+
+// Outbox row for a message. Delivery happens asynchronously in the Dispatcher;
+// redact drops the body once it has been delivered or given up on.
+func newNotification(userID string, to store.ContactMethod, event core.NotificationEvent, msg Message, redact bool) store.CreateNotificationParams {
+	return store.CreateNotificationParams{
+		ID:              uuid.New().String(),
+		UserID:          userID,
+		ContactMethodID: to.ID,
+		Event:           event,
+		Subject:         core.SecretString(msg.Subject),
+		Body:            core.SecretString(msg.Body),
+		HtmlBody:        core.NullSecretString{String: msg.HTML, Valid: msg.HTML != ""},
+		Status:          core.NotificationPending,
+		CreatedAt:       time.Now().UTC(),
+		RedactAfterSend: redact,
+	}
+}
+
+// Notifier renders templated messages in each recipient's locale and queues them
+type Notifier struct {
+	store     *store.Store
+	templates *Templates
+	baseURL   string
+}
+
+func NewNotifier(s *store.Store, t *Templates, baseURL string) *Notifier {
+	return &Notifier{store: s, templates: t, baseURL: baseURL}
+}
+
+func (n *Notifier) Templates() *Templates {
+	return n.templates
+}
+
+// Notify renders the event's template for the recipient (locale taken from the
+// contact method's "locale" metadata) and adds it to the outbox
+func (n *Notifier) Notify(ctx context.Context, userID string, to store.ContactMethod, event core.NotificationEvent, data TemplateData) error {
+	params, err := n.Prepare(userID, to, event, data)
+	if err != nil {
+		return err
+	}
+	_, err = n.store.CreateNotification(ctx, params)
+	return err
+}
+
+// Prepare renders a message like Notify but leaves writing it to the caller, for
+// notices that must be queued in the same transaction as another change
+func (n *Notifier) Prepare(userID string, to store.ContactMethod, event core.NotificationEvent, data TemplateData) (store.CreateNotificationParams, error) {
+	if data.BaseURL == "" {
+		data.BaseURL = n.baseURL
+	}
+	msg, err := n.templates.Render(event, to.Metadata["locale"], data)
+	if err != nil {
+		return store.CreateNotificationParams{}, fmt.Errorf("rendering %s: %w", event, err)
+	}
+	return newNotification(userID, to, event, msg, data.HasSecret()), nil
+}
+
+// Dispatcher drains the notification outbox and hands messages to channel senders
+type Dispatcher struct {
+	store     *store.Store
+	senders   map[core.Channel]Sender
+	batchSize int64
+	observe   func(core.Channel, error)
+	skip      core.Channel // left pending, e.g. email while SMTP is not configured
+}
+
+func NewDispatcher(s *store.Store, email *EmailSender) *Dispatcher {
+	d := &Dispatcher{
+		store:     s,
+		senders:   make(map[core.Channel]Sender),
+		batchSize: 50,
+	}
+	d.Register(core.ChannelEmail, email)
+	if !email.Configured() {
+		d.skip = core.ChannelEmail
+	}
+	d.Register(core.ChannelDiscord, &WebhookSender{Format: discordPayload})
+	d.Register(core.ChannelSlack, &WebhookSender{Format: slackPayload})
+	d.Register(core.ChannelTelegram, &TelegramSender{})
+	return d
+}
+
+func (d *Dispatcher) Register(channel core.Channel, sender Sender) {
+	d.senders[channel] = sender
+}
+
+// Observe is called after every delivery attempt that reached a sender
+func (d *Dispatcher) Observe(fn func(channel core.Channel, err error)) {
+	d.observe = fn
+}
+
+// Run flushes the outbox every interval until ctx is cancelled
+func (d *Dispatcher) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := d.Flush(ctx); err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "notifier flush failed", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Flush attempts delivery of one batch of pending notifications
+func (d *Dispatcher) Flush(ctx context.Context) error {
+	pending, err := d.store.ListPendingNotifications(ctx, store.ListPendingNotificationsParams{
+		SkipChannel: d.skip,
+		Limit:       d.batchSize,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, n := range pending {
+		if err := d.deliver(ctx, n); err != nil {
+			status := core.NotificationPending
+			if n.Attempts+1 >= maxAttempts {
+				status = core.NotificationFailed
+			}
+			slog.WarnContext(ctx, "notification delivery failed", "notification_id", n.ID, "event", n.Event, "attempt", n.Attempts+1, "error", err)
+			if err := d.store.MarkNotificationFailed(ctx, store.MarkNotificationFailedParams{
+				Status:    status,
+				LastError: sql.NullString{String: err.Error(), Valid: true},
+				ID:        n.ID,
+			}); err != nil {
+				return err
+			}
+			if status == core.NotificationFailed {
+				if err := d.store.RedactNotification(ctx, n.ID); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		if err := d.store.MarkNotificationSent(ctx, store.MarkNotificationSentParams{
+			SentAt: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+			ID:     n.ID,
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, n store.NotificationOutbox) error {
+	to, err := d.store.GetContactMethodByID(ctx, n.ContactMethodID)
+	if err != nil {
+		return fmt.Errorf("loading contact method: %w", err)
+	}
+
+	sender, ok := d.senders[to.Channel]
+	if !ok {
+		return fmt.Errorf("no sender registered for channel %s", to.Channel)
+	}
+
+	err = sender.Send(ctx, to, Message{Subject: string(n.Subject), Body: string(n.Body), HTML: n.HtmlBody.String})
+	if d.observe != nil {
+		d.observe(to.Channel, err)
+	}
+	return err
+}
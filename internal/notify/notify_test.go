@@ -0,0 +1,162 @@
+package notify
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/vmpyr/afterlight/internal/core"
+	"github.com/vmpyr/afterlight/internal/store"
+)
+
+// Records what it was asked to send instead of sending it, failing with err if set
+type fakeSender struct {
+	sent []Message
+	err  error
+}
+
+func (f *fakeSender) Send(ctx context.Context, to store.ContactMethod, msg Message) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.sent = append(f.sent, msg)
+	return nil
+}
+
+type outboxTest struct {
+	store *store.Store
+	user  store.User
+}
+
+func newOutboxTest(t *testing.T) outboxTest {
+	t.Helper()
+	storage, err := store.NewStorage(filepath.Join(t.TempDir(), "afterlight.db"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { storage.Close() })
+	s := store.NewStore(storage.DB())
+	u, err := s.CreateUserTx(context.Background(), core.RegisterRequest{
+		Name:     "Owner",
+		Email:    "owner@example.com",
+		Password: "Correct-horse-9",
+	}, store.UserDefaults{CheckInInterval: time.Hour, TriggerIntervals: 1}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return outboxTest{store: s, user: u}
+}
+
+// Queues a message to a new contact method of the owner on channel
+func (ot outboxTest) queue(t *testing.T, channel core.Channel, redact bool) store.NotificationOutbox {
+	t.Helper()
+	ctx := context.Background()
+	contact, err := ot.store.CreateContactMethod(ctx, store.CreateContactMethodParams{
+		ID:          "contact-" + string(channel),
+		UserID:      sql.NullString{String: ot.user.ID, Valid: true},
+		Channel:     channel,
+		Destination: "owner@example.com",
+		Metadata:    core.Metadata{},
+		CreatedAt:   time.Now().UTC(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := ot.store.CreateNotification(ctx, newNotification(ot.user.ID, contact, core.EventVaultReleased,
+		Message{Subject: "Subject", Body: "code 1234"}, redact))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func (ot outboxTest) notification(t *testing.T, id string) store.NotificationOutbox {
+	t.Helper()
+	all, err := ot.store.ListNotificationsByUser(context.Background(), ot.user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range all {
+		if n.ID == id {
+			return n
+		}
+	}
+	t.Fatalf("notification %s not found", id)
+	return store.NotificationOutbox{}
+}
+
+func TestFlushKeepsEmailWithoutSMTP(t *testing.T) {
+	ot := newOutboxTest(t)
+	email := ot.queue(t, core.ChannelEmail, true)
+	webhook := ot.queue(t, core.ChannelDiscord, false)
+
+	d := NewDispatcher(ot.store, &EmailSender{})
+	d.batchSize = 1
+	discord := &fakeSender{}
+	d.Register(core.ChannelDiscord, discord)
+	if err := d.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	got := ot.notification(t, email.ID)
+	if got.Status != core.NotificationPending || got.Attempts != 0 || string(got.Body) != "code 1234" {
+		t.Fatalf("email without SMTP = %+v, want it pending and untouched", got)
+	}
+	// Queued email must not hold up other channels
+	if len(discord.sent) != 1 || ot.notification(t, webhook.ID).Status != core.NotificationSent {
+		t.Fatalf("webhook was not delivered past the queued email: %+v", discord.sent)
+	}
+}
+
+func TestFlushRedactsAfterSend(t *testing.T) {
+	ot := newOutboxTest(t)
+	secret := ot.queue(t, core.ChannelDiscord, true)
+	plain := ot.queue(t, core.ChannelSlack, false)
+
+	d := NewDispatcher(ot.store, &EmailSender{})
+	sender := &fakeSender{}
+	d.Register(core.ChannelDiscord, sender)
+	d.Register(core.ChannelSlack, sender)
+	if err := d.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sender.sent) != 2 || sender.sent[0].Body != "code 1234" {
+		t.Fatalf("sent = %+v, want both messages with their bodies", sender.sent)
+	}
+	got := ot.notification(t, secret.ID)
+	if got.Status != core.NotificationSent || !got.SentAt.Valid || got.Body != "" {
+		t.Fatalf("redacted message after send = %+v", got)
+	}
+	if got := ot.notification(t, plain.ID); got.Status != core.NotificationSent || got.Body != "code 1234" {
+		t.Fatalf("message after send = %+v", got)
+	}
+}
+
+func TestFlushGivesUpAndRedacts(t *testing.T) {
+	ot := newOutboxTest(t)
+	n := ot.queue(t, core.ChannelDiscord, true)
+
+	d := NewDispatcher(ot.store, &EmailSender{})
+	d.Register(core.ChannelDiscord, &fakeSender{err: errors.New("webhook responded with 500")})
+	for i := 1; i <= maxAttempts; i++ {
+		if err := d.Flush(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		got := ot.notification(t, n.ID)
+		if got.Attempts != int64(i) || got.LastError.String != "webhook responded with 500" {
+			t.Fatalf("after attempt %d: %+v", i, got)
+		}
+		if i < maxAttempts && (got.Status != core.NotificationPending || got.Body == "") {
+			t.Fatalf("gave up after attempt %d: %+v", i, got)
+		}
+	}
+
+	got := ot.notification(t, n.ID)
+	if got.Status != core.NotificationFailed || got.Body != "" {
+		t.Fatalf("message after the last attempt = %+v, want it failed and redacted", got)
+	}
+}
@@ -0,0 +1,252 @@
+package notify
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	htmltemplate "html/template"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/vmpyr/afterlight/internal/core"
+)
+
+//go:embed templates
+var embeddedTemplates embed.FS
+
+const DefaultLocale = "en"
+
+// Every event that has a template, in the order shown by the preview API
+var TemplateEvents = []core.NotificationEvent{
+	core.EventCheckInReminder,
+	core.EventVerificationRequested,
+	core.EventVaultReleased,
+	core.EventContactVerification,
+	core.EventTestNotification,
+	core.EventInvitation,
+	core.EventBeneficiaryRemoved,
+}
+
+// Values available to templates. Not every field is set for every event.
+type TemplateData struct {
+	Locale        string
+	RecipientName string    // Who the message is addressed to, if known
+	OwnerName     string    // The account holder the event is about
+	Deadline      time.Time // Trigger deadline, or when an invite expires
+	LastCheckIn   time.Time
+	Code          string // Contact verification code
+	ExpiresIn     int    // Minutes until Code expires
+	BaseURL       string
+	ReleaseToken  string         // Beneficiary access code for released vaults
+	VerifyToken   string         // Verifier's code for confirming a death
+	InviteToken   string         // Registration invite
+	Location      *time.Location // Time zone used by Date, defaults to UTC
+}
+
+// Messages carrying a code or link that grants access are not kept in the
+// outbox once they have gone out
+func (d TemplateData) HasSecret() bool {
+	return d.Code != "" || d.ReleaseToken != "" || d.VerifyToken != "" || d.InviteToken != ""
+}
+
+func (d TemplateData) Date(t time.Time) string {
+	loc := d.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	return t.In(loc).Format("Mon, 02 Jan 2006 15:04 MST")
+}
+
+// Templates renders per-event, per-locale messages. Files live at
+// <locale>/<event>.tmpl and define "subject", "text" and "html" blocks;
+// layout.html wraps the html block. Files in the override directory take
+// precedence over the embedded ones, so operators can change wording
+// without rebuilding.
+type Templates struct {
+	embedded      fs.FS
+	override      fs.FS
+	defaultLocale string
+}
+
+func NewTemplates(overrideDir, defaultLocale string) (*Templates, error) {
+	sub, err := fs.Sub(embeddedTemplates, "templates")
+	if err != nil {
+		return nil, err
+	}
+
+	t := &Templates{embedded: sub, defaultLocale: DefaultLocale}
+	if overrideDir != "" {
+		if _, err := os.Stat(overrideDir); err != nil {
+			return nil, err
+		}
+		t.override = os.DirFS(overrideDir)
+	}
+
+	if defaultLocale != "" {
+		if !slices.Contains(t.Locales(), defaultLocale) {
+			return nil, errors.New("no templates for default locale " + defaultLocale)
+		}
+		t.defaultLocale = defaultLocale
+	}
+
+	// Fail at startup rather than at the first send if a template is broken
+	for _, locale := range t.Locales() {
+		for _, event := range TemplateEvents {
+			if _, err := t.Render(event, locale, TemplateData{}); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return t, nil
+}
+
+// Locales with at least one template, embedded or overridden
+func (t *Templates) Locales() []string {
+	var locales []string
+	for _, fsys := range []fs.FS{t.embedded, t.override} {
+		if fsys == nil {
+			continue
+		}
+		entries, _ := fs.ReadDir(fsys, ".")
+		for _, e := range entries {
+			if e.IsDir() && !slices.Contains(locales, e.Name()) {
+				locales = append(locales, e.Name())
+			}
+		}
+	}
+	slices.Sort(locales)
+	return locales
+}
+
+// Picks the closest available locale: "pt-BR" falls back to "pt", then the default
+func (t *Templates) ResolveLocale(locale string) string {
+	available := t.Locales()
+	locale = strings.ReplaceAll(locale, "_", "-")
+	for locale != "" {
+		for _, l := range available {
+			if strings.EqualFold(l, locale) {
+				return l
+			}
+		}
+		i := strings.LastIndex(locale, "-")
+		if i < 0 {
+			break
+		}
+		locale = locale[:i]
+	}
+	return t.defaultLocale
+}
+
+func (t *Templates) Render(event core.NotificationEvent, locale string, data TemplateData) (Message, error) {
+	locale = t.ResolveLocale(locale)
+	data.Locale = locale
+
+	name := path.Join(locale, strings.ToLower(string(event))+".tmpl")
+	src, err := t.readFile(name)
+	if errors.Is(err, fs.ErrNotExist) && locale != t.defaultLocale {
+		name = path.Join(t.defaultLocale, strings.ToLower(string(event))+".tmpl")
+		src, err = t.readFile(name)
+	}
+	if err != nil {
+		return Message{}, err
+	}
+	layout, err := t.readFile("layout.html")
+	if err != nil {
+		return Message{}, err
+	}
+
+	text, err := texttemplate.New(name).Parse(string(src))
+	if err != nil {
+		return Message{}, err
+	}
+	var subject, body bytes.Buffer
+	if err := text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return Message{}, err
+	}
+	if err := text.ExecuteTemplate(&body, "text", data); err != nil {
+		return Message{}, err
+	}
+
+	html, err := htmltemplate.New(name).Parse(string(layout))
+	if err != nil {
+		return Message{}, err
+	}
+	if html, err = html.Parse(string(src)); err != nil {
+		return Message{}, err
+	}
+	var htmlBody bytes.Buffer
+	if err := html.ExecuteTemplate(&htmlBody, "layout", data); err != nil {
+		return Message{}, err
+	}
+
+	return Message{
+		Subject: strings.TrimSpace(subject.String()),
+		Body:    strings.TrimSpace(body.String()),
+		HTML:    htmlBody.String(),
+	}, nil
+}
+
+func (t *Templates) readFile(name string) ([]byte, error) {
+	if t.override != nil {
+		b, err := fs.ReadFile(t.override, name)
+		if err == nil {
+			return b, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+	return fs.ReadFile(t.embedded, name)
+}
+
+// Where a beneficiary collects released vaults; empty without a public URL
+func (d TemplateData) ReleaseURL() string {
+	if d.BaseURL == "" || d.ReleaseToken == "" {
+		return ""
+	}
+	return strings.TrimSuffix(d.BaseURL, "/") + "/api/v1/release/" + d.ReleaseToken
+}
+
+// Where a verifier confirms the owner's death; empty without a public URL
+func (d TemplateData) VerifyURL() string {
+	if d.BaseURL == "" || d.VerifyToken == "" {
+		return ""
+	}
+	return strings.TrimSuffix(d.BaseURL, "/") + "/verify/" + d.VerifyToken
+}
+
+func (d TemplateData) InviteURL() string {
+	return InviteURL(d.BaseURL, d.InviteToken)
+}
+
+// Where an invite is redeemed; empty without a public URL
+func InviteURL(baseURL, token string) string {
+	if baseURL == "" || token == "" {
+		return ""
+	}
+	return strings.TrimSuffix(baseURL, "/") + "/register?invite=" + url.QueryEscape(token)
+}
+
+// Placeholder values used by the preview endpoints
+func SampleData() TemplateData {
+	now := time.Now().UTC()
+	return TemplateData{
+		RecipientName: "Alex",
+		OwnerName:     "Sam Taylor",
+		Deadline:      now.Add(72 * time.Hour),
+		LastCheckIn:   now.Add(-45 * 24 * time.Hour),
+		Code:          "123456",
+		ExpiresIn:     15,
+		BaseURL:       "https://afterlight.example.com",
+		ReleaseToken:  "sample-release-token",
+		VerifyToken:   "sample-verify-token",
+		InviteToken:   "sample-invite-token",
+	}
+}
@@ -0,0 +1,83 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/vmpyr/afterlight/internal/store"
+)
+
+var httpClient = &http.Client{Timeout: 15 * time.Second}
+
+func discordPayload(msg Message) any {
+	return map[string]string{"content": "**" + msg.Subject + "**\n" + msg.Body}
+}
+
+func slackPayload(msg Message) any {
+	return map[string]string{"text": "*" + msg.Subject + "*\n" + msg.Body}
+}
+
+// WebhookSender posts a JSON payload to the destination URL (Discord, Slack)
+type WebhookSender struct {
+	Format func(Message) any
+}
+
+func (w *WebhookSender) Send(ctx context.Context, to store.ContactMethod, msg Message) error {
+	return postJSON(ctx, string(to.Destination), w.Format(msg))
+}
+
+// TelegramSender uses the bot token stored in the contact method metadata ("bot_token").
+// The destination is the chat ID.
+type TelegramSender struct{}
+
+func (t *TelegramSender) Send(ctx context.Context, to store.ContactMethod, msg Message) error {
+	token := to.Metadata["bot_token"]
+	if token == "" {
+		return fmt.Errorf("telegram contact method %s has no bot_token", to.ID)
+	}
+	return postJSON(ctx, "https://api.telegram.org/bot"+token+"/sendMessage", map[string]string{
+		"chat_id": string(to.Destination),
+		"text":    msg.Subject + "\n\n" + msg.Body,
+	})
+}
+
+// Errors never include target: it holds the Telegram bot token or the webhook
+// secret, and delivery errors end up in the outbox and the logs
+func postJSON(ctx context.Context, target string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("invalid webhook URL: %w", withoutURL(err))
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook request failed: %w", withoutURL(err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with %s", resp.Status)
+	}
+	return nil
+}
+
+// *url.Error repeats the full URL in its message
+func withoutURL(err error) error {
+	var uerr *url.Error
+	if errors.As(err, &uerr) {
+		return uerr.Err
+	}
+	return err
+}
@@ -0,0 +1,28 @@
+package notify
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/vmpyr/afterlight/internal/store"
+)
+
+const testBotToken = "123456789:AAHdqTcvCH1vGWJxfSeofSAs0K5PALDsaw"
+
+func TestTelegramErrorOmitsBotToken(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := (&TelegramSender{}).Send(ctx, store.ContactMethod{
+		ID:          "contact",
+		Destination: "42",
+		Metadata:    map[string]string{"bot_token": testBotToken},
+	}, Message{Subject: "Subject", Body: "Body"})
+	if err == nil {
+		t.Fatal("sending with a cancelled context succeeded")
+	}
+	if strings.Contains(err.Error(), testBotToken) {
+		t.Fatalf("error contains the bot token: %v", err)
+	}
+}
@@ -0,0 +1,219 @@
+// Package seal keeps release material of sealed vaults encrypted under a
+// server master key until the owner is confirmed dead.
+//
+// Every sealed vault has its own random data key. Material is encrypted with
+// the data key, and the data key is encrypted ("wrapped") with the master key,
+// so rotating the master key only re-wraps one small key per vault.
+package seal
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/vmpyr/afterlight/internal/keys"
+	"github.com/vmpyr/afterlight/internal/store"
+)
+
+var ErrAlreadySealed = errors.New("vault is already sealed")
+var ErrNoMasterKey = errors.New("no master key configured")
+
+// Sealer seals and unseals vault release material. It works without a
+// keyring, but then only vaults that were never sealed can be used.
+type Sealer struct {
+	store   *store.Store
+	keyring *keys.Keyring
+}
+
+func NewSealer(s *store.Store, k *keys.Keyring) *Sealer {
+	return &Sealer{store: s, keyring: k}
+}
+
+func (s *Sealer) Enabled() bool {
+	return s.keyring != nil
+}
+
+// NewVaultKey creates the data key for a vault that is sealed from the start.
+// The wrapped key goes into CreateVaultParams; the data key seals its material.
+func (s *Sealer) NewVaultKey(vaultID string) (dataKey []byte, keyID, wrapped string, err error) {
+	if s.keyring == nil {
+		return nil, "", "", ErrNoMasterKey
+	}
+	if dataKey, err = keys.GenerateKey(); err != nil {
+		return nil, "", "", err
+	}
+	keyID, wrapped, err = s.keyring.Wrap(dataKey, vaultKeyAAD(vaultID))
+	return dataKey, keyID, wrapped, err
+}
+
+// Seal encrypts a value stored in the given field of a sealed vault.
+// Values of unsealed vaults are returned unchanged.
+func (s *Sealer) Seal(v store.Vault, field string, plaintext []byte) ([]byte, error) {
+	if !v.SealedKey.Valid {
+		return plaintext, nil
+	}
+	dataKey, err := s.dataKey(v)
+	if err != nil {
+		return nil, err
+	}
+	return SealWith(dataKey, v.ID, field, plaintext)
+}
+
+// Open is the inverse of Seal, used to show the owner their own vault
+func (s *Sealer) Open(v store.Vault, field string, data []byte) ([]byte, error) {
+	if !v.SealedKey.Valid {
+		return data, nil
+	}
+	dataKey, err := s.dataKey(v)
+	if err != nil {
+		return nil, err
+	}
+	return OpenWith(dataKey, v.ID, field, data)
+}
+
+// Text columns hold sealed values as base64
+func (s *Sealer) SealString(v store.Vault, field string, value sql.NullString) (sql.NullString, error) {
+	if !value.Valid || !v.SealedKey.Valid {
+		return value, nil
+	}
+	out, err := s.Seal(v, field, []byte(value.String))
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: string(out), Valid: true}, nil
+}
+
+func (s *Sealer) OpenString(v store.Vault, field string, value sql.NullString) (sql.NullString, error) {
+	if !value.Valid || !v.SealedKey.Valid {
+		return value, nil
+	}
+	out, err := s.Open(v, field, []byte(value.String))
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: string(out), Valid: true}, nil
+}
+
+// SealVault turns on sealed release for an existing vault, encrypting the
+// material it already has
+func (s *Sealer) SealVault(ctx context.Context, v store.Vault) error {
+	if v.SealedKey.Valid {
+		return ErrAlreadySealed
+	}
+	dataKey, keyID, wrapped, err := s.NewVaultKey(v.ID)
+	if err != nil {
+		return err
+	}
+
+	return s.store.RewriteVaultMaterialTx(ctx, v.ID, func(field string, data []byte) ([]byte, error) {
+		return SealWith(dataKey, v.ID, field, data)
+	}, store.UpdateVaultSealParams{
+		SealedKey:   sql.NullString{String: wrapped, Valid: true},
+		SealedKeyID: sql.NullString{String: keyID, Valid: true},
+	})
+}
+
+// UnsealReleased decrypts the material of every sealed vault whose owner is
+// stored as CONFIRMED_DEAD. The liveness engine calls it on every tick, so a
+// vault that fails here, e.g. while a rotation the owner started is still
+// open, is retried later and the others are still unsealed.
+func (s *Sealer) UnsealReleased(ctx context.Context) (int, error) {
+	vaults, err := s.store.ListReleasedSealedVaults(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var errs []error
+	unsealed := 0
+	for _, v := range vaults {
+		if err := s.unseal(ctx, v); err != nil {
+			errs = append(errs, fmt.Errorf("unsealing vault %s: %w", v.ID, err))
+			continue
+		}
+		unsealed++
+		slog.InfoContext(ctx, "vault unsealed", "vault_id", v.ID, "user_id", v.UserID)
+	}
+	return unsealed, errors.Join(errs...)
+}
+
+func (s *Sealer) unseal(ctx context.Context, v store.Vault) error {
+	dataKey, err := s.dataKey(v)
+	if err != nil {
+		return err
+	}
+	return s.store.RewriteVaultMaterialTx(ctx, v.ID, func(field string, data []byte) ([]byte, error) {
+		return OpenWith(dataKey, v.ID, field, data)
+	}, store.UpdateVaultSealParams{
+		UnsealedAt: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+	})
+}
+
+// Rotate re-wraps every sealed vault's data key under next's primary key.
+// All vaults are updated in one transaction, so a failure leaves nothing half done,
+// and the transaction fails if the server sealed a vault in the meantime.
+func (s *Sealer) Rotate(ctx context.Context, next *keys.Keyring) (int, error) {
+	if s.keyring == nil {
+		return 0, ErrNoMasterKey
+	}
+
+	vaults, err := s.store.ListSealedVaults(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	rewraps := make([]store.RewrapVaultKeyParams, 0, len(vaults))
+	for _, v := range vaults {
+		dataKey, err := s.dataKey(v)
+		if err != nil {
+			return 0, fmt.Errorf("vault %s: %w", v.ID, err)
+		}
+		keyID, wrapped, err := next.Wrap(dataKey, vaultKeyAAD(v.ID))
+		if err != nil {
+			return 0, err
+		}
+		rewraps = append(rewraps, store.RewrapVaultKeyParams{
+			SealedKey:    sql.NullString{String: wrapped, Valid: true},
+			SealedKeyID:  sql.NullString{String: keyID, Valid: true},
+			ID:           v.ID,
+			OldSealedKey: v.SealedKey,
+		})
+	}
+
+	if err := s.store.RewrapVaultKeysTx(ctx, next.PrimaryID(), rewraps); err != nil {
+		return 0, err
+	}
+	return len(rewraps), nil
+}
+
+func (s *Sealer) dataKey(v store.Vault) ([]byte, error) {
+	if s.keyring == nil {
+		return nil, ErrNoMasterKey
+	}
+	return s.keyring.Unwrap(v.SealedKeyID.String, v.SealedKey.String, vaultKeyAAD(v.ID))
+}
+
+func vaultKeyAAD(vaultID string) string {
+	return "vault-key/" + vaultID
+}
+
+// SealWith encrypts with a vault data key, bound to the vault and field it is stored in.
+// The result is base64 so it fits text and blob columns alike.
+func SealWith(dataKey []byte, vaultID, field string, plaintext []byte) ([]byte, error) {
+	ct, err := keys.Encrypt(dataKey, plaintext, []byte(vaultID+"/"+field))
+	if err != nil {
+		return nil, err
+	}
+	return []byte(base64.StdEncoding.EncodeToString(ct)), nil
+}
+
+func OpenWith(dataKey []byte, vaultID, field string, data []byte) ([]byte, error) {
+	ct, err := base64.StdEncoding.DecodeString(string(data))
+	if err != nil {
+		return nil, err
+	}
+	return keys.Decrypt(dataKey, ct, []byte(vaultID+"/"+field))
+}
@@ -0,0 +1,170 @@
+package seal
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vmpyr/afterlight/internal/core"
+	"github.com/vmpyr/afterlight/internal/keys"
+	"github.com/vmpyr/afterlight/internal/store"
+)
+
+// A random master key in keyring file format
+func newKey(t *testing.T) string {
+	t.Helper()
+	key, err := keys.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(key) + "\n"
+}
+
+func newKeyring(t *testing.T, lines ...string) *keys.Keyring {
+	t.Helper()
+	k, err := keys.ParseKeyring([]byte(strings.Join(lines, "")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+func openTestStore(t *testing.T) *store.Store {
+	t.Helper()
+	storage, err := store.NewStorage(filepath.Join(t.TempDir(), "afterlight.db"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { storage.Close() })
+	return store.NewStore(storage.DB())
+}
+
+// An unsealed vault with one artifact, owned by a fresh user
+func createTestVault(t *testing.T, s *store.Store) store.Vault {
+	t.Helper()
+	ctx := context.Background()
+	u, err := s.CreateUserTx(ctx, core.RegisterRequest{
+		Name:     "Owner",
+		Email:    "owner@example.com",
+		Password: "Correct-horse-9",
+	}, store.UserDefaults{CheckInInterval: time.Hour, TriggerIntervals: 1}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := s.CreateVault(ctx, store.CreateVaultParams{
+		ID:        "vault",
+		UserID:    u.ID,
+		VaultName: "Vault",
+		Hint:      core.NullSecretString{String: "the usual", Valid: true},
+		KdfSalt:   "00",
+		KdfParams: core.DefaultKDFParams,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.CreateArtifact(ctx, store.CreateArtifactParams{
+		ID:            "artifact",
+		VaultID:       v.ID,
+		MessageType:   core.MsgText,
+		EncryptedBlob: []byte("ciphertext"),
+		Iv:            "iv",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func TestSealVaultRejectedDuringRotation(t *testing.T) {
+	s := openTestStore(t)
+	sealer := NewSealer(s, newKeyring(t, newKey(t)))
+	ctx := context.Background()
+	v := createTestVault(t, s)
+
+	if _, err := s.StartVaultRotationTx(ctx, store.CreateVaultRotationParams{
+		ID:        "rotation",
+		VaultID:   v.ID,
+		KdfSalt:   "01",
+		KdfParams: core.DefaultKDFParams,
+		ExpiresAt: time.Now().Add(time.Hour),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := sealer.SealVault(ctx, v); !errors.Is(err, store.ErrRotationInProgress) {
+		t.Fatalf("SealVault during a rotation = %v, want ErrRotationInProgress", err)
+	}
+	after, err := s.GetVault(ctx, v.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.SealedKey.Valid {
+		t.Fatal("vault was sealed during a rotation")
+	}
+}
+
+func artifactBlob(t *testing.T, s *store.Store, v store.Vault) string {
+	t.Helper()
+	a, err := s.GetArtifact(context.Background(), store.GetArtifactParams{ID: "artifact", VaultID: v.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(a.EncryptedBlob)
+}
+
+func TestSealRotateUnseal(t *testing.T) {
+	s := openTestStore(t)
+	ctx := context.Background()
+	oldLine, newLine := newKey(t), newKey(t)
+	sealer := NewSealer(s, newKeyring(t, oldLine))
+	v := createTestVault(t, s)
+
+	if err := sealer.SealVault(ctx, v); err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := s.GetVault(ctx, v.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sealed.SealedKey.Valid || sealed.Hint.String == "the usual" || artifactBlob(t, s, v) == "ciphertext" {
+		t.Fatalf("vault material left in the clear after sealing: %+v", sealed)
+	}
+	opened, err := sealer.Open(sealed, "artifact/artifact", []byte(artifactBlob(t, s, v)))
+	if err != nil || string(opened) != "ciphertext" {
+		t.Fatalf("Open = %q, %v", opened, err)
+	}
+	if err := sealer.SealVault(ctx, sealed); !errors.Is(err, ErrAlreadySealed) {
+		t.Fatalf("sealing twice = %v, want ErrAlreadySealed", err)
+	}
+
+	// Re-wrap under a new primary key; the old one stays for reading
+	next := newKeyring(t, newLine, oldLine)
+	if n, err := sealer.Rotate(ctx, next); err != nil || n != 1 {
+		t.Fatalf("Rotate = %d, %v; want 1 vault", n, err)
+	}
+	sealer = NewSealer(s, newKeyring(t, newLine))
+	if rewrapped, err := s.GetVault(ctx, v.ID); err != nil || rewrapped.SealedKeyID.String != next.PrimaryID() {
+		t.Fatalf("vault key after rotation = %+v, %v", rewrapped, err)
+	}
+
+	// Nothing is unsealed while the owner is alive
+	if n, err := sealer.UnsealReleased(ctx); err != nil || n != 0 {
+		t.Fatalf("UnsealReleased with a living owner = %d, %v", n, err)
+	}
+	if _, err := s.TransitionUserTx(ctx, store.StatusTransition{UserID: v.UserID, From: core.StatusAlive, To: core.StatusDead}); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := sealer.UnsealReleased(ctx); err != nil || n != 1 {
+		t.Fatalf("UnsealReleased = %d, %v; want 1 vault", n, err)
+	}
+	released, err := s.GetVault(ctx, v.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if released.SealedKey.Valid || released.Hint.String != "the usual" || artifactBlob(t, s, v) != "ciphertext" {
+		t.Fatalf("vault after unsealing = %+v", released)
+	}
+}
@@ -0,0 +1,161 @@
+// Package shamir implements Shamir's secret sharing over GF(2^8).
+//
+// Each byte of the secret is the constant term of its own random polynomial of
+// degree threshold-1. A share is the polynomial evaluated at a non-zero x for
+// every byte, followed by x itself, so a share is one byte longer than the
+// secret. Any threshold shares recover the secret; fewer reveal nothing about it.
+package shamir
+
+import (
+	"crypto/rand"
+	"errors"
+	"io"
+)
+
+const (
+	MinThreshold = 2
+	MaxShares    = 255
+)
+
+var (
+	ErrInvalidThreshold = errors.New("threshold must be at least 2 and no more than the number of shares")
+	ErrTooManyShares    = errors.New("at most 255 shares are supported")
+	ErrEmptySecret      = errors.New("secret must not be empty")
+	ErrNotEnoughShares  = errors.New("at least 2 shares are required")
+	ErrShareLength      = errors.New("shares must all be the same length")
+	ErrDuplicateShare   = errors.New("shares must have distinct indexes")
+	ErrInvalidIndex     = errors.New("share index must be between 1 and 255")
+)
+
+// Split divides secret into n shares, any k of which recover it
+func Split(secret []byte, n, k int) ([][]byte, error) {
+	return split(rand.Reader, secret, n, k)
+}
+
+func split(random io.Reader, secret []byte, n, k int) ([][]byte, error) {
+	if len(secret) == 0 {
+		return nil, ErrEmptySecret
+	}
+	if n > MaxShares {
+		return nil, ErrTooManyShares
+	}
+	if k < MinThreshold || k > n {
+		return nil, ErrInvalidThreshold
+	}
+
+	shares := make([][]byte, n)
+	for i := range shares {
+		shares[i] = make([]byte, len(secret)+1)
+		shares[i][len(secret)] = byte(i + 1)
+	}
+
+	coeffs := make([]byte, k)
+	for b, s := range secret {
+		coeffs[0] = s
+		if _, err := io.ReadFull(random, coeffs[1:]); err != nil {
+			return nil, err
+		}
+		for i := range shares {
+			shares[i][b] = evaluate(coeffs, byte(i+1))
+		}
+	}
+
+	return shares, nil
+}
+
+// Combine recovers the secret from at least threshold shares. Passing fewer
+// shares than the threshold yields a wrong secret, not an error; callers that
+// need to detect that must authenticate the result (e.g. by decrypting with it).
+func Combine(shares [][]byte) ([]byte, error) {
+	if len(shares) < MinThreshold {
+		return nil, ErrNotEnoughShares
+	}
+
+	size := len(shares[0])
+	if size < 2 {
+		return nil, ErrShareLength
+	}
+	xs := make([]byte, len(shares))
+	for i, s := range shares {
+		if len(s) != size {
+			return nil, ErrShareLength
+		}
+		x := s[size-1]
+		if x == 0 {
+			return nil, ErrInvalidIndex
+		}
+		for _, seen := range xs[:i] {
+			if seen == x {
+				return nil, ErrDuplicateShare
+			}
+		}
+		xs[i] = x
+	}
+
+	secret := make([]byte, size-1)
+	ys := make([]byte, len(shares))
+	for b := range secret {
+		for i, s := range shares {
+			ys[i] = s[b]
+		}
+		secret[b] = interpolateAtZero(xs, ys)
+	}
+
+	return secret, nil
+}
+
+// Index returns the x-coordinate a share was evaluated at
+func Index(share []byte) (byte, error) {
+	if len(share) < 2 || share[len(share)-1] == 0 {
+		return 0, ErrInvalidIndex
+	}
+	return share[len(share)-1], nil
+}
+
+// ValidateShareSet checks the metadata of a complete share set: n shares with
+// distinct indexes in 1..255 and a threshold the set can actually meet.
+func ValidateShareSet(threshold int, indexes []int) error {
+	if len(indexes) > MaxShares {
+		return ErrTooManyShares
+	}
+	if threshold < MinThreshold || threshold > len(indexes) {
+		return ErrInvalidThreshold
+	}
+	seen := make(map[int]bool, len(indexes))
+	for _, i := range indexes {
+		if i < 1 || i > MaxShares {
+			return ErrInvalidIndex
+		}
+		if seen[i] {
+			return ErrDuplicateShare
+		}
+		seen[i] = true
+	}
+	return nil
+}
+
+// Horner's method: coeffs[0] + x*(coeffs[1] + x*(...))
+func evaluate(coeffs []byte, x byte) byte {
+	result := coeffs[len(coeffs)-1]
+	for i := len(coeffs) - 2; i >= 0; i-- {
+		result = add(mul(result, x), coeffs[i])
+	}
+	return result
+}
+
+// Lagrange interpolation of the polynomial through (xs, ys), evaluated at 0
+func interpolateAtZero(xs, ys []byte) byte {
+	var result byte
+	for i := range xs {
+		basis := byte(1)
+		for j := range xs {
+			if i == j {
+				continue
+			}
+			// (0 - xj) / (xi - xj); subtraction is addition in GF(2^8)
+			basis = mul(basis, div(xs[j], add(xs[i], xs[j])))
+		}
+		result = add(result, mul(ys[i], basis))
+	}
+	return result
+}
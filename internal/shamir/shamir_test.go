@@ -0,0 +1,166 @@
+package shamir
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+// Vectors computed independently of this package. random is the stream of
+// coefficient bytes: k-1 per secret byte, lowest degree first.
+var knownAnswers = []struct {
+	name   string
+	secret string
+	n, k   int
+	random string
+	shares []string
+}{
+	{
+		name:   "2 of 3",
+		secret: "6869", // "hi"
+		n:      3, k: 2,
+		random: "9a3c",
+		shares: []string{"f25501", "471102", "dd2d03"},
+	},
+	{
+		name:   "3 of 5",
+		secret: "00ff80",
+		n:      5, k: 3,
+		random: "0102fe7f10c3",
+		shares: []string{"037e5301", "0aff8102", "097e5203", "249b4404", "271a9705"},
+	},
+}
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestMulKnownAnswers(t *testing.T) {
+	// FIPS-197 section 4.2
+	if got := mul(0x57, 0x83); got != 0xc1 {
+		t.Errorf("mul(0x57, 0x83) = %#x, want 0xc1", got)
+	}
+	if got := mul(0x57, 0x13); got != 0xfe {
+		t.Errorf("mul(0x57, 0x13) = %#x, want 0xfe", got)
+	}
+	for a := 1; a < 256; a++ {
+		for b := 1; b < 256; b++ {
+			if got := div(mul(byte(a), byte(b)), byte(b)); got != byte(a) {
+				t.Fatalf("div(mul(%d, %d), %d) = %d", a, b, b, got)
+			}
+		}
+	}
+}
+
+func TestSplitKnownAnswers(t *testing.T) {
+	for _, tc := range knownAnswers {
+		t.Run(tc.name, func(t *testing.T) {
+			shares, err := split(bytes.NewReader(mustHex(t, tc.random)), mustHex(t, tc.secret), tc.n, tc.k)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(shares) != len(tc.shares) {
+				t.Fatalf("got %d shares, want %d", len(shares), len(tc.shares))
+			}
+			for i, share := range shares {
+				if got := hex.EncodeToString(share); got != tc.shares[i] {
+					t.Errorf("share %d = %s, want %s", i+1, got, tc.shares[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCombineKnownAnswers(t *testing.T) {
+	for _, tc := range knownAnswers {
+		t.Run(tc.name, func(t *testing.T) {
+			all := make([][]byte, len(tc.shares))
+			for i, s := range tc.shares {
+				all[i] = mustHex(t, s)
+			}
+			want := mustHex(t, tc.secret)
+
+			// Every window of k consecutive shares, and all of them
+			subsets := [][][]byte{all}
+			for i := 0; i+tc.k <= len(all); i++ {
+				subsets = append(subsets, all[i:i+tc.k])
+			}
+			for _, subset := range subsets {
+				got, err := Combine(subset)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(got, want) {
+					t.Errorf("Combine(%d shares) = %x, want %x", len(subset), got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		t.Fatal(err)
+	}
+	shares, err := Split(secret, 5, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Combine([][]byte{shares[4], shares[0], shares[2]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, secret) {
+		t.Errorf("Combine = %x, want %x", got, secret)
+	}
+}
+
+func TestThresholdOneRejected(t *testing.T) {
+	if _, err := Split([]byte("secret"), 3, 1); !errors.Is(err, ErrInvalidThreshold) {
+		t.Errorf("Split with threshold 1: err = %v, want ErrInvalidThreshold", err)
+	}
+	if err := ValidateShareSet(1, []int{1, 2, 3}); !errors.Is(err, ErrInvalidThreshold) {
+		t.Errorf("ValidateShareSet with threshold 1: err = %v, want ErrInvalidThreshold", err)
+	}
+	if _, err := Combine([][]byte{mustHex(t, "f25501")}); !errors.Is(err, ErrNotEnoughShares) {
+		t.Errorf("Combine with one share: err = %v, want ErrNotEnoughShares", err)
+	}
+}
+
+func TestDuplicateIndexRejected(t *testing.T) {
+	a := mustHex(t, "f25501")
+	b := mustHex(t, "471102")
+	if _, err := Combine([][]byte{a, b, a}); !errors.Is(err, ErrDuplicateShare) {
+		t.Errorf("Combine with a repeated share: err = %v, want ErrDuplicateShare", err)
+	}
+	// Same x with different y values
+	if _, err := Combine([][]byte{a, mustHex(t, "000001")}); !errors.Is(err, ErrDuplicateShare) {
+		t.Errorf("Combine with a duplicate index: err = %v, want ErrDuplicateShare", err)
+	}
+	if err := ValidateShareSet(2, []int{1, 2, 2}); !errors.Is(err, ErrDuplicateShare) {
+		t.Errorf("ValidateShareSet with a duplicate index: err = %v, want ErrDuplicateShare", err)
+	}
+}
+
+func TestInvalidShares(t *testing.T) {
+	if _, err := Combine([][]byte{mustHex(t, "f25500"), mustHex(t, "471102")}); !errors.Is(err, ErrInvalidIndex) {
+		t.Errorf("Combine with index 0: err = %v, want ErrInvalidIndex", err)
+	}
+	if _, err := Combine([][]byte{mustHex(t, "f25501"), mustHex(t, "4702")}); !errors.Is(err, ErrShareLength) {
+		t.Errorf("Combine with mixed lengths: err = %v, want ErrShareLength", err)
+	}
+	if _, err := Split(nil, 3, 2); !errors.Is(err, ErrEmptySecret) {
+		t.Errorf("Split of an empty secret: err = %v, want ErrEmptySecret", err)
+	}
+	if _, err := Split([]byte("x"), 256, 2); !errors.Is(err, ErrTooManyShares) {
+		t.Errorf("Split into 256 shares: err = %v, want ErrTooManyShares", err)
+	}
+}
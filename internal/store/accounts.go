@@ -0,0 +1,89 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/vmpyr/afterlight/internal/core"
+)
+
+// The last active admin cannot schedule their own deletion
+func (s *Store) ScheduleDeletionTx(ctx context.Context, userID string, due time.Time) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	qTx := s.withTx(tx)
+	user, err := qTx.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := lastAdminGuard(ctx, qTx, user); err != nil {
+		return err
+	}
+
+	n, err := qTx.ScheduleUserDeletion(ctx, ScheduleUserDeletionParams{
+		DeletionDueAt: sql.NullTime{Time: due, Valid: true},
+		ID:            userID,
+	})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return core.ErrDeletionInProgress
+	}
+	return tx.Commit()
+}
+
+// Locks the account for good: it is disabled, which also stops its liveness
+// timer, and its sessions are ended. The removal notices are queued in the
+// same transaction. Returns false without changing anything if the deletion
+// was already started or is no longer due.
+func (s *Store) StartDeletionTx(ctx context.Context, userID string, now time.Time, notices []CreateNotificationParams) (bool, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	qTx := s.withTx(tx)
+	n, err := qTx.StartUserDeletion(ctx, StartUserDeletionParams{
+		DeletionStartedAt: sql.NullTime{Time: now, Valid: true},
+		DisabledAt:        sql.NullTime{Time: now, Valid: true},
+		ID:                userID,
+		DeletionDueAt:     sql.NullTime{Time: now, Valid: true},
+	})
+	if err != nil {
+		return false, err
+	}
+	if n == 0 {
+		return false, nil
+	}
+	if _, err := qTx.DeleteUserSessions(ctx, userID); err != nil {
+		return false, err
+	}
+	for _, params := range notices {
+		if _, err := qTx.CreateNotification(ctx, params); err != nil {
+			return false, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Deletes the user row; foreign keys cascade to everything the account owns.
+// With secure_delete the freed pages are zeroed, and the checkpoint keeps
+// the old pages from lingering in the WAL.
+func (s *Store) DeleteAccount(ctx context.Context, userID string) error {
+	if err := s.DeleteUser(ctx, userID); err != nil {
+		return err
+	}
+	_, err := s.db.ExecContext(ctx, "PRAGMA wal_checkpoint(TRUNCATE)")
+	return err
+}
@@ -0,0 +1,131 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/vmpyr/afterlight/internal/core"
+)
+
+const registrationModeKey = "registration_mode"
+
+// The mode set by an admin, or fallback (from the configuration) if none was set
+func (s *Store) RegistrationMode(ctx context.Context, fallback core.RegistrationMode) (core.RegistrationMode, error) {
+	value, err := s.GetInstanceSetting(ctx, registrationModeKey)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fallback, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return core.RegistrationMode(value), nil
+}
+
+func (s *Store) SetRegistrationMode(ctx context.Context, mode core.RegistrationMode) error {
+	if err := core.IsValidRegistrationMode(mode); err != nil {
+		return err
+	}
+	return s.UpsertInstanceSetting(ctx, UpsertInstanceSettingParams{Key: registrationModeKey, Value: string(mode)})
+}
+
+// Disabling an account ends its sessions and stops its liveness timer.
+// Returns false if the account was already disabled.
+func (s *Store) DisableUserTx(ctx context.Context, userID string) (bool, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	qTx := s.withTx(tx)
+	user, err := qTx.GetUserByID(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	if err := lastAdminGuard(ctx, qTx, user); err != nil {
+		return false, err
+	}
+
+	n, err := qTx.DisableUser(ctx, DisableUserParams{
+		DisabledAt: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+		ID:         userID,
+	})
+	if err != nil {
+		return false, err
+	}
+	if _, err := qTx.DeleteUserSessions(ctx, userID); err != nil {
+		return false, err
+	}
+	return n > 0, tx.Commit()
+}
+
+// Re-enabling counts as a check-in, so time spent disabled does not run
+// down the liveness timer; like any check-in it does not revive a released
+// account. Returns false if the account was not disabled, or is being deleted.
+func (s *Store) EnableUserAccountTx(ctx context.Context, userID string) (bool, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	qTx := s.withTx(tx)
+	n, err := qTx.EnableUser(ctx, userID)
+	if err != nil || n == 0 {
+		return false, err
+	}
+	n, err = qTx.UpdateUserCheckIn(ctx, UpdateUserCheckInParams{
+		LastCheckIn: time.Now().UTC(),
+		ID:          userID,
+	})
+	if err != nil {
+		return false, err
+	}
+	if n > 0 {
+		if err := qTx.ResetVerifierConfirmations(ctx, userID); err != nil {
+			return false, err
+		}
+	}
+	return true, tx.Commit()
+}
+
+func (s *Store) SetUserRoleTx(ctx context.Context, userID string, role core.UserRole) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	qTx := s.withTx(tx)
+	user, err := qTx.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if role != core.RoleAdmin {
+		if err := lastAdminGuard(ctx, qTx, user); err != nil {
+			return err
+		}
+	}
+	if _, err := qTx.SetUserRole(ctx, SetUserRoleParams{Role: role, ID: userID}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// An instance must keep at least one active admin. Admins whose account is
+// scheduled for deletion no longer count.
+func lastAdminGuard(ctx context.Context, q *Store, user User) error {
+	if user.Role != core.RoleAdmin || user.DisabledAt.Valid || user.DeletionDueAt.Valid {
+		return nil
+	}
+	admins, err := q.CountAdmins(ctx)
+	if err != nil {
+		return err
+	}
+	if admins <= 1 {
+		return core.ErrLastAdmin
+	}
+	return nil
+}
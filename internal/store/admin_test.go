@@ -0,0 +1,43 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestEnableUserSkipsAccountsBeingDeleted(t *testing.T) {
+	s, _ := openEncrypted(t)
+	ctx := context.Background()
+	createTestUser(t, s, "Admin", "admin@example.com")
+	deleting := createTestUser(t, s, "Erin", "erin@example.com")
+	disabled := createTestUser(t, s, "Frank", "frank@example.com")
+
+	now := time.Now().UTC()
+	if err := s.ScheduleDeletionTx(ctx, deleting.ID, now); err != nil {
+		t.Fatal(err)
+	}
+	if started, err := s.StartDeletionTx(ctx, deleting.ID, now, nil); err != nil || !started {
+		t.Fatalf("StartDeletionTx = %v, %v; want true", started, err)
+	}
+	if changed, err := s.EnableUserAccountTx(ctx, deleting.ID); err != nil || changed {
+		t.Fatalf("EnableUserAccountTx on an account being deleted = %v, %v; want false", changed, err)
+	}
+	if got, err := s.GetUserByID(ctx, deleting.ID); err != nil || !got.DisabledAt.Valid {
+		t.Fatalf("account being deleted was re-enabled: %+v, %v", got, err)
+	}
+
+	if _, err := s.DisableUserTx(ctx, disabled.ID); err != nil {
+		t.Fatal(err)
+	}
+	if changed, err := s.EnableUserAccountTx(ctx, disabled.ID); err != nil || !changed {
+		t.Fatalf("EnableUserAccountTx = %v, %v; want true", changed, err)
+	}
+	got, err := s.GetUserByID(ctx, disabled.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.DisabledAt.Valid || !got.LastCheckIn.After(disabled.LastCheckIn) {
+		t.Fatalf("enabled account = %+v", got)
+	}
+}
@@ -0,0 +1,29 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Replaces a beneficiary's public key. Keys wrapped to the old one can no longer
+// be opened by the beneficiary, so they are dropped and must be re-wrapped.
+func (s *Store) SetBeneficiaryPublicKeyTx(ctx context.Context, beneficiaryID, publicKey string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	qTx := s.withTx(tx)
+	if err := qTx.SetBeneficiaryPublicKey(ctx, SetBeneficiaryPublicKeyParams{
+		PublicKey: sql.NullString{String: publicKey, Valid: publicKey != ""},
+		ID:        beneficiaryID,
+	}); err != nil {
+		return err
+	}
+	if err := qTx.ClearWrappedKeysForBeneficiary(ctx, beneficiaryID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
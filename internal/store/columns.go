@@ -0,0 +1,302 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/vmpyr/afterlight/internal/core"
+)
+
+// Encrypted columns are bound to the table, column and row they are stored in,
+// which their Go types cannot see. So every generated query that writes or
+// reads one is wrapped here: arguments are sealed before the query runs and
+// results opened after it. Queries inside transactions use the same wrappers,
+// since withTx returns a Store.
+
+func ref(table, column, key string) core.ColumnRef {
+	return core.ColumnRef{Table: table, Column: column, Key: key}
+}
+
+func (u *User) open() error {
+	return u.Name.Open(ref("users", "name", u.ID))
+}
+
+func (b *Beneficiary) open() error {
+	return b.BeneficiaryName.Open(ref("beneficiaries", "beneficiary_name", b.ID))
+}
+
+func (c *ContactMethod) open() error {
+	if err := c.Destination.Open(ref("contact_methods", "destination", c.ID)); err != nil {
+		return err
+	}
+	return c.Metadata.Open(ref("contact_methods", "metadata", c.ID))
+}
+
+func (v *Vault) open() error {
+	return v.Hint.Open(ref("vaults", "hint", v.ID))
+}
+
+func (r *VaultRotation) open() error {
+	return r.Hint.Open(ref("vault_rotations", "hint", r.ID))
+}
+
+func (k *SigningKey) open() error {
+	return k.PrivateKey.Open(ref("signing_keys", "private_key", k.ID))
+}
+
+func (n *NotificationOutbox) open() error {
+	if err := n.Subject.Open(ref("notification_outbox", "subject", n.ID)); err != nil {
+		return err
+	}
+	if err := n.Body.Open(ref("notification_outbox", "body", n.ID)); err != nil {
+		return err
+	}
+	return n.HtmlBody.Open(ref("notification_outbox", "html_body", n.ID))
+}
+
+func (r *ListReleasedVaultsRow) open() error {
+	return r.Hint.Open(ref("vaults", "hint", r.ID))
+}
+
+func (r *ListVaultRecipientsRow) open() error {
+	return r.BeneficiaryName.Open(ref("beneficiaries", "beneficiary_name", r.ID))
+}
+
+func openOne[T any, P interface {
+	*T
+	open() error
+}](item T, err error) (T, error) {
+	if err != nil {
+		return item, err
+	}
+	return item, P(&item).open()
+}
+
+func openAll[T any, P interface {
+	*T
+	open() error
+}](items []T, err error) ([]T, error) {
+	if err != nil {
+		return nil, err
+	}
+	for i := range items {
+		if err := P(&items[i]).open(); err != nil {
+			return nil, err
+		}
+	}
+	return items, nil
+}
+
+// Users
+
+func (s *Store) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
+	if err := arg.Name.Seal(ref("users", "name", arg.ID)); err != nil {
+		return User{}, err
+	}
+	return openOne(s.Queries.CreateUser(ctx, arg))
+}
+
+func (s *Store) GetUserByEmail(ctx context.Context, email string) (User, error) {
+	return openOne(s.Queries.GetUserByEmail(ctx, email))
+}
+
+func (s *Store) GetUserByID(ctx context.Context, id string) (User, error) {
+	return openOne(s.Queries.GetUserByID(ctx, id))
+}
+
+func (s *Store) GetUserByIdentity(ctx context.Context, arg GetUserByIdentityParams) (User, error) {
+	return openOne(s.Queries.GetUserByIdentity(ctx, arg))
+}
+
+func (s *Store) GetUserBySessionToken(ctx context.Context, token string) (User, error) {
+	return openOne(s.Queries.GetUserBySessionToken(ctx, token))
+}
+
+func (s *Store) ListLivenessCandidates(ctx context.Context) ([]User, error) {
+	return openAll(s.Queries.ListLivenessCandidates(ctx))
+}
+
+func (s *Store) ListUsers(ctx context.Context) ([]User, error) {
+	return openAll(s.Queries.ListUsers(ctx))
+}
+
+func (s *Store) ListUsersBeingDeleted(ctx context.Context) ([]User, error) {
+	return openAll(s.Queries.ListUsersBeingDeleted(ctx))
+}
+
+func (s *Store) ListUsersDueForDeletion(ctx context.Context, deletionDueAt sql.NullTime) ([]User, error) {
+	return openAll(s.Queries.ListUsersDueForDeletion(ctx, deletionDueAt))
+}
+
+// Beneficiaries
+
+func (s *Store) CreateBeneficiary(ctx context.Context, arg CreateBeneficiaryParams) (Beneficiary, error) {
+	if err := arg.BeneficiaryName.Seal(ref("beneficiaries", "beneficiary_name", arg.ID)); err != nil {
+		return Beneficiary{}, err
+	}
+	return openOne(s.Queries.CreateBeneficiary(ctx, arg))
+}
+
+func (s *Store) GetBeneficiaryByID(ctx context.Context, arg GetBeneficiaryByIDParams) (Beneficiary, error) {
+	return openOne(s.Queries.GetBeneficiaryByID(ctx, arg))
+}
+
+func (s *Store) GetBeneficiaryByReleaseToken(ctx context.Context, releaseTokenHash sql.NullString) (Beneficiary, error) {
+	return openOne(s.Queries.GetBeneficiaryByReleaseToken(ctx, releaseTokenHash))
+}
+
+func (s *Store) GetBeneficiaryByVerifyToken(ctx context.Context, verifyTokenHash sql.NullString) (Beneficiary, error) {
+	return openOne(s.Queries.GetBeneficiaryByVerifyToken(ctx, verifyTokenHash))
+}
+
+func (s *Store) ListBeneficiariesByUser(ctx context.Context, userID string) ([]Beneficiary, error) {
+	return openAll(s.Queries.ListBeneficiariesByUser(ctx, userID))
+}
+
+func (s *Store) ListVaultRecipients(ctx context.Context, arg ListVaultRecipientsParams) ([]ListVaultRecipientsRow, error) {
+	return openAll(s.Queries.ListVaultRecipients(ctx, arg))
+}
+
+// Contact methods
+
+func (s *Store) CreateContactMethod(ctx context.Context, arg CreateContactMethodParams) (ContactMethod, error) {
+	if err := arg.Destination.Seal(ref("contact_methods", "destination", arg.ID)); err != nil {
+		return ContactMethod{}, err
+	}
+	if err := arg.Metadata.Seal(ref("contact_methods", "metadata", arg.ID)); err != nil {
+		return ContactMethod{}, err
+	}
+	return openOne(s.Queries.CreateContactMethod(ctx, arg))
+}
+
+func (s *Store) CreateInviteContactMethod(ctx context.Context, arg CreateInviteContactMethodParams) (ContactMethod, error) {
+	if err := arg.Destination.Seal(ref("contact_methods", "destination", arg.ID)); err != nil {
+		return ContactMethod{}, err
+	}
+	if err := arg.Metadata.Seal(ref("contact_methods", "metadata", arg.ID)); err != nil {
+		return ContactMethod{}, err
+	}
+	return openOne(s.Queries.CreateInviteContactMethod(ctx, arg))
+}
+
+func (s *Store) GetContactMethodByID(ctx context.Context, id string) (ContactMethod, error) {
+	return openOne(s.Queries.GetContactMethodByID(ctx, id))
+}
+
+func (s *Store) ListBeneficiaryContactMethods(ctx context.Context, userID string) ([]ContactMethod, error) {
+	return openAll(s.Queries.ListBeneficiaryContactMethods(ctx, userID))
+}
+
+func (s *Store) ListContactMethodsByBeneficiaryID(ctx context.Context, beneficiaryID sql.NullString) ([]ContactMethod, error) {
+	return openAll(s.Queries.ListContactMethodsByBeneficiaryID(ctx, beneficiaryID))
+}
+
+func (s *Store) ListContactMethodsByUserID(ctx context.Context, userID sql.NullString) ([]ContactMethod, error) {
+	return openAll(s.Queries.ListContactMethodsByUserID(ctx, userID))
+}
+
+// Vaults
+
+func (s *Store) CreateVault(ctx context.Context, arg CreateVaultParams) (Vault, error) {
+	if err := arg.Hint.Seal(ref("vaults", "hint", arg.ID)); err != nil {
+		return Vault{}, err
+	}
+	return openOne(s.Queries.CreateVault(ctx, arg))
+}
+
+func (s *Store) ImportVault(ctx context.Context, arg ImportVaultParams) error {
+	if err := arg.Hint.Seal(ref("vaults", "hint", arg.ID)); err != nil {
+		return err
+	}
+	return s.Queries.ImportVault(ctx, arg)
+}
+
+func (s *Store) UpdateVaultHint(ctx context.Context, arg UpdateVaultHintParams) error {
+	if err := arg.Hint.Seal(ref("vaults", "hint", arg.ID)); err != nil {
+		return err
+	}
+	return s.Queries.UpdateVaultHint(ctx, arg)
+}
+
+func (s *Store) GetReleasedVault(ctx context.Context, arg GetReleasedVaultParams) (Vault, error) {
+	return openOne(s.Queries.GetReleasedVault(ctx, arg))
+}
+
+func (s *Store) GetVault(ctx context.Context, id string) (Vault, error) {
+	return openOne(s.Queries.GetVault(ctx, id))
+}
+
+func (s *Store) GetVaultByID(ctx context.Context, arg GetVaultByIDParams) (Vault, error) {
+	return openOne(s.Queries.GetVaultByID(ctx, arg))
+}
+
+func (s *Store) GetVaultsByUser(ctx context.Context, userID string) ([]Vault, error) {
+	return openAll(s.Queries.GetVaultsByUser(ctx, userID))
+}
+
+func (s *Store) ListReleasedVaults(ctx context.Context, beneficiaryID string) ([]ListReleasedVaultsRow, error) {
+	return openAll(s.Queries.ListReleasedVaults(ctx, beneficiaryID))
+}
+
+func (s *Store) ListSealedVaults(ctx context.Context) ([]Vault, error) {
+	return openAll(s.Queries.ListSealedVaults(ctx))
+}
+
+func (s *Store) ListReleasedSealedVaults(ctx context.Context) ([]Vault, error) {
+	return openAll(s.Queries.ListReleasedSealedVaults(ctx))
+}
+
+func (s *Store) CreateVaultRotation(ctx context.Context, arg CreateVaultRotationParams) (VaultRotation, error) {
+	if err := arg.Hint.Seal(ref("vault_rotations", "hint", arg.ID)); err != nil {
+		return VaultRotation{}, err
+	}
+	return openOne(s.Queries.CreateVaultRotation(ctx, arg))
+}
+
+func (s *Store) GetVaultRotation(ctx context.Context, arg GetVaultRotationParams) (VaultRotation, error) {
+	return openOne(s.Queries.GetVaultRotation(ctx, arg))
+}
+
+func (s *Store) GetVaultRotationByVault(ctx context.Context, vaultID string) (VaultRotation, error) {
+	return openOne(s.Queries.GetVaultRotationByVault(ctx, vaultID))
+}
+
+// Signing keys
+
+func (s *Store) CreateSigningKey(ctx context.Context, arg CreateSigningKeyParams) error {
+	if err := arg.PrivateKey.Seal(ref("signing_keys", "private_key", arg.ID)); err != nil {
+		return err
+	}
+	return s.Queries.CreateSigningKey(ctx, arg)
+}
+
+func (s *Store) GetSigningKey(ctx context.Context, id string) (SigningKey, error) {
+	return openOne(s.Queries.GetSigningKey(ctx, id))
+}
+
+// Notifications
+
+func (s *Store) CreateNotification(ctx context.Context, arg CreateNotificationParams) (NotificationOutbox, error) {
+	if err := arg.Subject.Seal(ref("notification_outbox", "subject", arg.ID)); err != nil {
+		return NotificationOutbox{}, err
+	}
+	if err := arg.Body.Seal(ref("notification_outbox", "body", arg.ID)); err != nil {
+		return NotificationOutbox{}, err
+	}
+	if err := arg.HtmlBody.Seal(ref("notification_outbox", "html_body", arg.ID)); err != nil {
+		return NotificationOutbox{}, err
+	}
+	return openOne(s.Queries.CreateNotification(ctx, arg))
+}
+
+func (s *Store) GetLatestNotificationByEvent(ctx context.Context, arg GetLatestNotificationByEventParams) (NotificationOutbox, error) {
+	return openOne(s.Queries.GetLatestNotificationByEvent(ctx, arg))
+}
+
+func (s *Store) ListNotificationsByUser(ctx context.Context, userID string) ([]NotificationOutbox, error) {
+	return openAll(s.Queries.ListNotificationsByUser(ctx, userID))
+}
+
+func (s *Store) ListPendingNotifications(ctx context.Context, arg ListPendingNotificationsParams) ([]NotificationOutbox, error) {
+	return openAll(s.Queries.ListPendingNotifications(ctx, arg))
+}
@@ -0,0 +1,232 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vmpyr/afterlight/internal/core"
+	"github.com/vmpyr/afterlight/internal/keys"
+)
+
+func openEncrypted(t *testing.T) (*Store, *keys.Keyring) {
+	t.Helper()
+	key, err := keys.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	kek, err := keys.ParseKeyring([]byte(base64.StdEncoding.EncodeToString(key)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	storage, err := NewStorage(filepath.Join(t.TempDir(), "afterlight.db"), kek)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		storage.Close()
+		core.SetColumnCipher(nil)
+	})
+	return NewStore(storage.DB()), kek
+}
+
+func createTestUser(t *testing.T, s *Store, name, email string) User {
+	t.Helper()
+	u, err := s.CreateUserTx(context.Background(), core.RegisterRequest{
+		Name:     name,
+		Email:    email,
+		Password: "Correct-horse-9",
+	}, UserDefaults{CheckInInterval: time.Hour, TriggerIntervals: 1}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
+
+func rawName(t *testing.T, s *Store, id string) string {
+	t.Helper()
+	var name string
+	if err := s.db.QueryRow("SELECT name FROM users WHERE id = ?", id).Scan(&name); err != nil {
+		t.Fatal(err)
+	}
+	return name
+}
+
+func TestColumnsSealedToRow(t *testing.T) {
+	s, _ := openEncrypted(t)
+	ctx := context.Background()
+	alice := createTestUser(t, s, "Alice", "alice@example.com")
+	bob := createTestUser(t, s, "Bob", "bob@example.com")
+
+	if string(alice.Name) != "Alice" {
+		t.Fatalf("created user name = %q, want Alice", alice.Name)
+	}
+	raw := rawName(t, s, alice.ID)
+	if !core.IsSealedColumn(raw) || strings.Contains(raw, "Alice") {
+		t.Fatalf("stored name = %q, want a sealed value", raw)
+	}
+	got, err := s.GetUserByID(ctx, alice.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got.Name) != "Alice" {
+		t.Fatalf("read name = %q, want Alice", got.Name)
+	}
+
+	// The account email contact method is written inside the same transaction
+	contacts, err := s.ListContactMethodsByUserID(ctx, sql.NullString{String: alice.ID, Valid: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(contacts) != 1 || string(contacts[0].Destination) != "alice@example.com" || contacts[0].Metadata == nil {
+		t.Fatalf("contact methods = %+v", contacts)
+	}
+
+	// A value copied into another row no longer opens
+	if _, err := s.db.Exec("UPDATE users SET name = ? WHERE id = ?", raw, bob.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.GetUserByID(ctx, bob.ID); err == nil {
+		t.Fatal("name copied from another row was accepted")
+	}
+}
+
+func TestPlaintextWriteRejected(t *testing.T) {
+	s, _ := openEncrypted(t)
+
+	// Queries that bypass the store's sealing must not write plaintext
+	err := s.Queries.CreateSigningKey(context.Background(), CreateSigningKeyParams{
+		ID:         "k",
+		PrivateKey: "secret",
+	})
+	if err == nil {
+		t.Fatal("unsealed value was written")
+	}
+}
+
+func TestEncryptSensitiveColumnsBindsOldValues(t *testing.T) {
+	s, kek := openEncrypted(t)
+	ctx := context.Background()
+	plain := createTestUser(t, s, "Carol", "carol@example.com")
+	unbound := createTestUser(t, s, "Dave", "dave@example.com")
+
+	// Values written before encryption was enabled, and before it was bound to rows
+	stored, err := s.GetDataKey(ctx, columnDataKeyID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dataKey, err := kek.Unwrap(stored.KekID, stored.WrappedKey, columnDataKeyID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v1, err := columnCipher{key: dataKey}.Encrypt([]byte("Dave"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for id, value := range map[string]string{plain.ID: "Carol", unbound.ID: "enc:v1:" + v1} {
+		if _, err := s.db.Exec("UPDATE users SET name = ? WHERE id = ?", value, id); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := encryptSensitiveColumns(tx); err != nil {
+		tx.Rollback()
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	for id, want := range map[string]string{plain.ID: "Carol", unbound.ID: "Dave"} {
+		if raw := rawName(t, s, id); !core.IsSealedColumn(raw) {
+			t.Fatalf("stored name = %q, want a sealed value", raw)
+		}
+		got, err := s.GetUserByID(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got.Name) != want {
+			t.Fatalf("read name = %q, want %q", got.Name, want)
+		}
+	}
+}
+
+func TestPrefixedTextWithoutEncryption(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "afterlight.db")
+	storage, err := NewStorage(path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewStore(storage.DB())
+
+	// Text that looks like ciphertext, written escaped and by an older version unescaped
+	escaped := createTestUser(t, s, "enc:v2:x", "escaped@example.com")
+	legacy := createTestUser(t, s, "Legacy", "legacy@example.com")
+	if _, err := s.db.Exec("UPDATE users SET name = ? WHERE id = ?", "enc:v1:y", legacy.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.CreateBeneficiary(ctx, CreateBeneficiaryParams{
+		ID:              "beneficiary",
+		UserID:          escaped.ID,
+		BeneficiaryName: "enc:v0:z",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{escaped.ID: "enc:v2:x", legacy.ID: "enc:v1:y"}
+	checkNames := func(s *Store) {
+		t.Helper()
+		for id, name := range want {
+			got, err := s.GetUserByID(ctx, id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got.Name) != name {
+				t.Fatalf("read name = %q, want %q", got.Name, name)
+			}
+		}
+		beneficiaries, err := s.ListBeneficiariesByUser(ctx, escaped.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(beneficiaries) != 1 || string(beneficiaries[0].BeneficiaryName) != "enc:v0:z" {
+			t.Fatalf("beneficiaries = %+v", beneficiaries)
+		}
+	}
+	checkNames(s)
+	storage.Close()
+
+	// Turning encryption on later seals them as the plaintext they are
+	key, err := keys.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	kek, err := keys.ParseKeyring([]byte(base64.StdEncoding.EncodeToString(key)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	storage, err = NewStorage(path, kek)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		storage.Close()
+		core.SetColumnCipher(nil)
+	})
+	s = NewStore(storage.DB())
+	for id, name := range want {
+		if raw := rawName(t, s, id); !core.IsSealedColumn(raw) || strings.Contains(raw, name) {
+			t.Fatalf("stored name = %q, want a sealed value", raw)
+		}
+	}
+	checkNames(s)
+}
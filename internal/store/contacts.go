@@ -0,0 +1,40 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// Marks a contact method as verified and consumes its pending verification code
+func (s *Store) VerifyContactMethodTx(ctx context.Context, contactID string) (ContactMethod, error) {
+	now := time.Now().UTC()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return ContactMethod{}, err
+	}
+	defer tx.Rollback()
+
+	qTx := s.withTx(tx)
+	if err := qTx.MarkContactMethodVerified(ctx, MarkContactMethodVerifiedParams{
+		VerifiedAt: sql.NullTime{Time: now, Valid: true},
+		ID:         contactID,
+	}); err != nil {
+		return ContactMethod{}, err
+	}
+	if err := qTx.DeleteContactVerification(ctx, contactID); err != nil {
+		return ContactMethod{}, err
+	}
+
+	contact, err := qTx.GetContactMethodByID(ctx, contactID)
+	if err != nil {
+		return ContactMethod{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return ContactMethod{}, err
+	}
+
+	return contact, nil
+}
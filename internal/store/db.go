@@ -24,76 +24,519 @@ func New(db DBTX) *Queries {
 func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	q := Queries{db: db}
 	var err error
+	if q.cancelUserDeletionStmt, err = db.PrepareContext(ctx, cancelUserDeletion); err != nil {
+		return nil, fmt.Errorf("error preparing query CancelUserDeletion: %w", err)
+	}
+	if q.claimContactVerificationAttemptStmt, err = db.PrepareContext(ctx, claimContactVerificationAttempt); err != nil {
+		return nil, fmt.Errorf("error preparing query ClaimContactVerificationAttempt: %w", err)
+	}
+	if q.clearVaultSharesStmt, err = db.PrepareContext(ctx, clearVaultShares); err != nil {
+		return nil, fmt.Errorf("error preparing query ClearVaultShares: %w", err)
+	}
+	if q.clearVaultWrappedKeysStmt, err = db.PrepareContext(ctx, clearVaultWrappedKeys); err != nil {
+		return nil, fmt.Errorf("error preparing query ClearVaultWrappedKeys: %w", err)
+	}
+	if q.clearWrappedKeysForBeneficiaryStmt, err = db.PrepareContext(ctx, clearWrappedKeysForBeneficiary); err != nil {
+		return nil, fmt.Errorf("error preparing query ClearWrappedKeysForBeneficiary: %w", err)
+	}
+	if q.confirmVerifierStmt, err = db.PrepareContext(ctx, confirmVerifier); err != nil {
+		return nil, fmt.Errorf("error preparing query ConfirmVerifier: %w", err)
+	}
+	if q.countActiveSessionsByUserStmt, err = db.PrepareContext(ctx, countActiveSessionsByUser); err != nil {
+		return nil, fmt.Errorf("error preparing query CountActiveSessionsByUser: %w", err)
+	}
+	if q.countAdminsStmt, err = db.PrepareContext(ctx, countAdmins); err != nil {
+		return nil, fmt.Errorf("error preparing query CountAdmins: %w", err)
+	}
+	if q.countConfirmedVerifiersStmt, err = db.PrepareContext(ctx, countConfirmedVerifiers); err != nil {
+		return nil, fmt.Errorf("error preparing query CountConfirmedVerifiers: %w", err)
+	}
+	if q.countOpenInvitesByCreatorStmt, err = db.PrepareContext(ctx, countOpenInvitesByCreator); err != nil {
+		return nil, fmt.Errorf("error preparing query CountOpenInvitesByCreator: %w", err)
+	}
+	if q.countPendingNotificationsStmt, err = db.PrepareContext(ctx, countPendingNotifications); err != nil {
+		return nil, fmt.Errorf("error preparing query CountPendingNotifications: %w", err)
+	}
+	if q.countPendingNotificationsByUserStmt, err = db.PrepareContext(ctx, countPendingNotificationsByUser); err != nil {
+		return nil, fmt.Errorf("error preparing query CountPendingNotificationsByUser: %w", err)
+	}
+	if q.countUsersStmt, err = db.PrepareContext(ctx, countUsers); err != nil {
+		return nil, fmt.Errorf("error preparing query CountUsers: %w", err)
+	}
+	if q.countUsersByStatusStmt, err = db.PrepareContext(ctx, countUsersByStatus); err != nil {
+		return nil, fmt.Errorf("error preparing query CountUsersByStatus: %w", err)
+	}
+	if q.countVaultsSealedUnderOtherKeysStmt, err = db.PrepareContext(ctx, countVaultsSealedUnderOtherKeys); err != nil {
+		return nil, fmt.Errorf("error preparing query CountVaultsSealedUnderOtherKeys: %w", err)
+	}
 	if q.createArtifactStmt, err = db.PrepareContext(ctx, createArtifact); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateArtifact: %w", err)
 	}
+	if q.createBeneficiaryStmt, err = db.PrepareContext(ctx, createBeneficiary); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateBeneficiary: %w", err)
+	}
 	if q.createContactMethodStmt, err = db.PrepareContext(ctx, createContactMethod); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateContactMethod: %w", err)
 	}
+	if q.createInviteStmt, err = db.PrepareContext(ctx, createInvite); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateInvite: %w", err)
+	}
+	if q.createInviteContactMethodStmt, err = db.PrepareContext(ctx, createInviteContactMethod); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateInviteContactMethod: %w", err)
+	}
+	if q.createNotificationStmt, err = db.PrepareContext(ctx, createNotification); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateNotification: %w", err)
+	}
+	if q.createOIDCLoginStmt, err = db.PrepareContext(ctx, createOIDCLogin); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateOIDCLogin: %w", err)
+	}
 	if q.createSessionStmt, err = db.PrepareContext(ctx, createSession); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateSession: %w", err)
 	}
+	if q.createSigningKeyStmt, err = db.PrepareContext(ctx, createSigningKey); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateSigningKey: %w", err)
+	}
 	if q.createUserStmt, err = db.PrepareContext(ctx, createUser); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateUser: %w", err)
 	}
+	if q.createUserIdentityStmt, err = db.PrepareContext(ctx, createUserIdentity); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateUserIdentity: %w", err)
+	}
 	if q.createVaultStmt, err = db.PrepareContext(ctx, createVault); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateVault: %w", err)
 	}
 	if q.createVaultAccessStmt, err = db.PrepareContext(ctx, createVaultAccess); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateVaultAccess: %w", err)
 	}
+	if q.createVaultRotationStmt, err = db.PrepareContext(ctx, createVaultRotation); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateVaultRotation: %w", err)
+	}
+	if q.deleteContactMethodStmt, err = db.PrepareContext(ctx, deleteContactMethod); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteContactMethod: %w", err)
+	}
+	if q.deleteContactVerificationStmt, err = db.PrepareContext(ctx, deleteContactVerification); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteContactVerification: %w", err)
+	}
+	if q.deleteExpiredOIDCLoginsStmt, err = db.PrepareContext(ctx, deleteExpiredOIDCLogins); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteExpiredOIDCLogins: %w", err)
+	}
+	if q.deleteExpiredSessionsStmt, err = db.PrepareContext(ctx, deleteExpiredSessions); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteExpiredSessions: %w", err)
+	}
+	if q.deleteExpiredVaultRotationsStmt, err = db.PrepareContext(ctx, deleteExpiredVaultRotations); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteExpiredVaultRotations: %w", err)
+	}
+	if q.deleteInviteStmt, err = db.PrepareContext(ctx, deleteInvite); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteInvite: %w", err)
+	}
 	if q.deleteSessionStmt, err = db.PrepareContext(ctx, deleteSession); err != nil {
 		return nil, fmt.Errorf("error preparing query DeleteSession: %w", err)
 	}
+	if q.deleteUserStmt, err = db.PrepareContext(ctx, deleteUser); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteUser: %w", err)
+	}
+	if q.deleteUserIdentityStmt, err = db.PrepareContext(ctx, deleteUserIdentity); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteUserIdentity: %w", err)
+	}
+	if q.deleteUserQuotaStmt, err = db.PrepareContext(ctx, deleteUserQuota); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteUserQuota: %w", err)
+	}
+	if q.deleteUserSessionsStmt, err = db.PrepareContext(ctx, deleteUserSessions); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteUserSessions: %w", err)
+	}
+	if q.deleteVaultRotationStmt, err = db.PrepareContext(ctx, deleteVaultRotation); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteVaultRotation: %w", err)
+	}
+	if q.deleteWrappedKeyStmt, err = db.PrepareContext(ctx, deleteWrappedKey); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteWrappedKey: %w", err)
+	}
+	if q.disableUserStmt, err = db.PrepareContext(ctx, disableUser); err != nil {
+		return nil, fmt.Errorf("error preparing query DisableUser: %w", err)
+	}
+	if q.enableUserStmt, err = db.PrepareContext(ctx, enableUser); err != nil {
+		return nil, fmt.Errorf("error preparing query EnableUser: %w", err)
+	}
+	if q.getArtifactStmt, err = db.PrepareContext(ctx, getArtifact); err != nil {
+		return nil, fmt.Errorf("error preparing query GetArtifact: %w", err)
+	}
 	if q.getArtifactsByVaultStmt, err = db.PrepareContext(ctx, getArtifactsByVault); err != nil {
 		return nil, fmt.Errorf("error preparing query GetArtifactsByVault: %w", err)
 	}
+	if q.getBeneficiaryByIDStmt, err = db.PrepareContext(ctx, getBeneficiaryByID); err != nil {
+		return nil, fmt.Errorf("error preparing query GetBeneficiaryByID: %w", err)
+	}
+	if q.getBeneficiaryByReleaseTokenStmt, err = db.PrepareContext(ctx, getBeneficiaryByReleaseToken); err != nil {
+		return nil, fmt.Errorf("error preparing query GetBeneficiaryByReleaseToken: %w", err)
+	}
+	if q.getBeneficiaryByVerifyTokenStmt, err = db.PrepareContext(ctx, getBeneficiaryByVerifyToken); err != nil {
+		return nil, fmt.Errorf("error preparing query GetBeneficiaryByVerifyToken: %w", err)
+	}
+	if q.getContactMethodByIDStmt, err = db.PrepareContext(ctx, getContactMethodByID); err != nil {
+		return nil, fmt.Errorf("error preparing query GetContactMethodByID: %w", err)
+	}
+	if q.getContactVerificationStmt, err = db.PrepareContext(ctx, getContactVerification); err != nil {
+		return nil, fmt.Errorf("error preparing query GetContactVerification: %w", err)
+	}
+	if q.getDataKeyStmt, err = db.PrepareContext(ctx, getDataKey); err != nil {
+		return nil, fmt.Errorf("error preparing query GetDataKey: %w", err)
+	}
+	if q.getInstanceSettingStmt, err = db.PrepareContext(ctx, getInstanceSetting); err != nil {
+		return nil, fmt.Errorf("error preparing query GetInstanceSetting: %w", err)
+	}
+	if q.getInviteStmt, err = db.PrepareContext(ctx, getInvite); err != nil {
+		return nil, fmt.Errorf("error preparing query GetInvite: %w", err)
+	}
+	if q.getInviteByTokenHashStmt, err = db.PrepareContext(ctx, getInviteByTokenHash); err != nil {
+		return nil, fmt.Errorf("error preparing query GetInviteByTokenHash: %w", err)
+	}
+	if q.getLatestNotificationByEventStmt, err = db.PrepareContext(ctx, getLatestNotificationByEvent); err != nil {
+		return nil, fmt.Errorf("error preparing query GetLatestNotificationByEvent: %w", err)
+	}
+	if q.getReleasedVaultStmt, err = db.PrepareContext(ctx, getReleasedVault); err != nil {
+		return nil, fmt.Errorf("error preparing query GetReleasedVault: %w", err)
+	}
+	if q.getReminderPolicyStmt, err = db.PrepareContext(ctx, getReminderPolicy); err != nil {
+		return nil, fmt.Errorf("error preparing query GetReminderPolicy: %w", err)
+	}
+	if q.getSigningKeyStmt, err = db.PrepareContext(ctx, getSigningKey); err != nil {
+		return nil, fmt.Errorf("error preparing query GetSigningKey: %w", err)
+	}
 	if q.getUserByEmailStmt, err = db.PrepareContext(ctx, getUserByEmail); err != nil {
 		return nil, fmt.Errorf("error preparing query GetUserByEmail: %w", err)
 	}
 	if q.getUserByIDStmt, err = db.PrepareContext(ctx, getUserByID); err != nil {
 		return nil, fmt.Errorf("error preparing query GetUserByID: %w", err)
 	}
+	if q.getUserByIdentityStmt, err = db.PrepareContext(ctx, getUserByIdentity); err != nil {
+		return nil, fmt.Errorf("error preparing query GetUserByIdentity: %w", err)
+	}
 	if q.getUserBySessionTokenStmt, err = db.PrepareContext(ctx, getUserBySessionToken); err != nil {
 		return nil, fmt.Errorf("error preparing query GetUserBySessionToken: %w", err)
 	}
+	if q.getUserIdentityStmt, err = db.PrepareContext(ctx, getUserIdentity); err != nil {
+		return nil, fmt.Errorf("error preparing query GetUserIdentity: %w", err)
+	}
+	if q.getUserQuotaStmt, err = db.PrepareContext(ctx, getUserQuota); err != nil {
+		return nil, fmt.Errorf("error preparing query GetUserQuota: %w", err)
+	}
+	if q.getVaultStmt, err = db.PrepareContext(ctx, getVault); err != nil {
+		return nil, fmt.Errorf("error preparing query GetVault: %w", err)
+	}
 	if q.getVaultByIDStmt, err = db.PrepareContext(ctx, getVaultByID); err != nil {
 		return nil, fmt.Errorf("error preparing query GetVaultByID: %w", err)
 	}
+	if q.getVaultRotationStmt, err = db.PrepareContext(ctx, getVaultRotation); err != nil {
+		return nil, fmt.Errorf("error preparing query GetVaultRotation: %w", err)
+	}
+	if q.getVaultRotationByVaultStmt, err = db.PrepareContext(ctx, getVaultRotationByVault); err != nil {
+		return nil, fmt.Errorf("error preparing query GetVaultRotationByVault: %w", err)
+	}
 	if q.getVaultsByUserStmt, err = db.PrepareContext(ctx, getVaultsByUser); err != nil {
 		return nil, fmt.Errorf("error preparing query GetVaultsByUser: %w", err)
 	}
+	if q.importArtifactStmt, err = db.PrepareContext(ctx, importArtifact); err != nil {
+		return nil, fmt.Errorf("error preparing query ImportArtifact: %w", err)
+	}
+	if q.importVaultStmt, err = db.PrepareContext(ctx, importVault); err != nil {
+		return nil, fmt.Errorf("error preparing query ImportVault: %w", err)
+	}
+	if q.listArtifactsByVaultIDStmt, err = db.PrepareContext(ctx, listArtifactsByVaultID); err != nil {
+		return nil, fmt.Errorf("error preparing query ListArtifactsByVaultID: %w", err)
+	}
+	if q.listBeneficiariesByUserStmt, err = db.PrepareContext(ctx, listBeneficiariesByUser); err != nil {
+		return nil, fmt.Errorf("error preparing query ListBeneficiariesByUser: %w", err)
+	}
+	if q.listBeneficiaryContactMethodsStmt, err = db.PrepareContext(ctx, listBeneficiaryContactMethods); err != nil {
+		return nil, fmt.Errorf("error preparing query ListBeneficiaryContactMethods: %w", err)
+	}
+	if q.listContactMethodsByBeneficiaryIDStmt, err = db.PrepareContext(ctx, listContactMethodsByBeneficiaryID); err != nil {
+		return nil, fmt.Errorf("error preparing query ListContactMethodsByBeneficiaryID: %w", err)
+	}
 	if q.listContactMethodsByUserIDStmt, err = db.PrepareContext(ctx, listContactMethodsByUserID); err != nil {
 		return nil, fmt.Errorf("error preparing query ListContactMethodsByUserID: %w", err)
 	}
+	if q.listInvitesStmt, err = db.PrepareContext(ctx, listInvites); err != nil {
+		return nil, fmt.Errorf("error preparing query ListInvites: %w", err)
+	}
+	if q.listInvitesByCreatorStmt, err = db.PrepareContext(ctx, listInvitesByCreator); err != nil {
+		return nil, fmt.Errorf("error preparing query ListInvitesByCreator: %w", err)
+	}
+	if q.listLivenessCandidatesStmt, err = db.PrepareContext(ctx, listLivenessCandidates); err != nil {
+		return nil, fmt.Errorf("error preparing query ListLivenessCandidates: %w", err)
+	}
+	if q.listNotificationsByUserStmt, err = db.PrepareContext(ctx, listNotificationsByUser); err != nil {
+		return nil, fmt.Errorf("error preparing query ListNotificationsByUser: %w", err)
+	}
+	if q.listPendingNotificationsStmt, err = db.PrepareContext(ctx, listPendingNotifications); err != nil {
+		return nil, fmt.Errorf("error preparing query ListPendingNotifications: %w", err)
+	}
+	if q.listReleasedSealedVaultsStmt, err = db.PrepareContext(ctx, listReleasedSealedVaults); err != nil {
+		return nil, fmt.Errorf("error preparing query ListReleasedSealedVaults: %w", err)
+	}
+	if q.listReleasedVaultsStmt, err = db.PrepareContext(ctx, listReleasedVaults); err != nil {
+		return nil, fmt.Errorf("error preparing query ListReleasedVaults: %w", err)
+	}
+	if q.listRotationArtifactsStmt, err = db.PrepareContext(ctx, listRotationArtifacts); err != nil {
+		return nil, fmt.Errorf("error preparing query ListRotationArtifacts: %w", err)
+	}
+	if q.listSealedVaultsStmt, err = db.PrepareContext(ctx, listSealedVaults); err != nil {
+		return nil, fmt.Errorf("error preparing query ListSealedVaults: %w", err)
+	}
+	if q.listUserIdentitiesStmt, err = db.PrepareContext(ctx, listUserIdentities); err != nil {
+		return nil, fmt.Errorf("error preparing query ListUserIdentities: %w", err)
+	}
+	if q.listUsersStmt, err = db.PrepareContext(ctx, listUsers); err != nil {
+		return nil, fmt.Errorf("error preparing query ListUsers: %w", err)
+	}
+	if q.listUsersBeingDeletedStmt, err = db.PrepareContext(ctx, listUsersBeingDeleted); err != nil {
+		return nil, fmt.Errorf("error preparing query ListUsersBeingDeleted: %w", err)
+	}
+	if q.listUsersDueForDeletionStmt, err = db.PrepareContext(ctx, listUsersDueForDeletion); err != nil {
+		return nil, fmt.Errorf("error preparing query ListUsersDueForDeletion: %w", err)
+	}
+	if q.listVaultAccessStmt, err = db.PrepareContext(ctx, listVaultAccess); err != nil {
+		return nil, fmt.Errorf("error preparing query ListVaultAccess: %w", err)
+	}
+	if q.listVaultRecipientsStmt, err = db.PrepareContext(ctx, listVaultRecipients); err != nil {
+		return nil, fmt.Errorf("error preparing query ListVaultRecipients: %w", err)
+	}
+	if q.listVaultSharesStmt, err = db.PrepareContext(ctx, listVaultShares); err != nil {
+		return nil, fmt.Errorf("error preparing query ListVaultShares: %w", err)
+	}
+	if q.listVaultUsageStmt, err = db.PrepareContext(ctx, listVaultUsage); err != nil {
+		return nil, fmt.Errorf("error preparing query ListVaultUsage: %w", err)
+	}
+	if q.markContactMethodVerifiedStmt, err = db.PrepareContext(ctx, markContactMethodVerified); err != nil {
+		return nil, fmt.Errorf("error preparing query MarkContactMethodVerified: %w", err)
+	}
+	if q.markNotificationFailedStmt, err = db.PrepareContext(ctx, markNotificationFailed); err != nil {
+		return nil, fmt.Errorf("error preparing query MarkNotificationFailed: %w", err)
+	}
+	if q.markNotificationSentStmt, err = db.PrepareContext(ctx, markNotificationSent); err != nil {
+		return nil, fmt.Errorf("error preparing query MarkNotificationSent: %w", err)
+	}
+	if q.redactNotificationStmt, err = db.PrepareContext(ctx, redactNotification); err != nil {
+		return nil, fmt.Errorf("error preparing query RedactNotification: %w", err)
+	}
+	if q.redeemInviteStmt, err = db.PrepareContext(ctx, redeemInvite); err != nil {
+		return nil, fmt.Errorf("error preparing query RedeemInvite: %w", err)
+	}
+	if q.resetVerifierConfirmationsStmt, err = db.PrepareContext(ctx, resetVerifierConfirmations); err != nil {
+		return nil, fmt.Errorf("error preparing query ResetVerifierConfirmations: %w", err)
+	}
+	if q.rewrapVaultKeyStmt, err = db.PrepareContext(ctx, rewrapVaultKey); err != nil {
+		return nil, fmt.Errorf("error preparing query RewrapVaultKey: %w", err)
+	}
+	if q.scheduleUserDeletionStmt, err = db.PrepareContext(ctx, scheduleUserDeletion); err != nil {
+		return nil, fmt.Errorf("error preparing query ScheduleUserDeletion: %w", err)
+	}
+	if q.setBeneficiaryPublicKeyStmt, err = db.PrepareContext(ctx, setBeneficiaryPublicKey); err != nil {
+		return nil, fmt.Errorf("error preparing query SetBeneficiaryPublicKey: %w", err)
+	}
+	if q.setBeneficiaryReleaseTokenStmt, err = db.PrepareContext(ctx, setBeneficiaryReleaseToken); err != nil {
+		return nil, fmt.Errorf("error preparing query SetBeneficiaryReleaseToken: %w", err)
+	}
+	if q.setBeneficiaryVerifyTokenStmt, err = db.PrepareContext(ctx, setBeneficiaryVerifyToken); err != nil {
+		return nil, fmt.Errorf("error preparing query SetBeneficiaryVerifyToken: %w", err)
+	}
+	if q.setUserRoleStmt, err = db.PrepareContext(ctx, setUserRole); err != nil {
+		return nil, fmt.Errorf("error preparing query SetUserRole: %w", err)
+	}
+	if q.startUserDeletionStmt, err = db.PrepareContext(ctx, startUserDeletion); err != nil {
+		return nil, fmt.Errorf("error preparing query StartUserDeletion: %w", err)
+	}
+	if q.takeOIDCLoginStmt, err = db.PrepareContext(ctx, takeOIDCLogin); err != nil {
+		return nil, fmt.Errorf("error preparing query TakeOIDCLogin: %w", err)
+	}
+	if q.touchUserIdentityStmt, err = db.PrepareContext(ctx, touchUserIdentity); err != nil {
+		return nil, fmt.Errorf("error preparing query TouchUserIdentity: %w", err)
+	}
+	if q.transitionUserStatusStmt, err = db.PrepareContext(ctx, transitionUserStatus); err != nil {
+		return nil, fmt.Errorf("error preparing query TransitionUserStatus: %w", err)
+	}
+	if q.updateArtifactBlobStmt, err = db.PrepareContext(ctx, updateArtifactBlob); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateArtifactBlob: %w", err)
+	}
+	if q.updateArtifactCiphertextStmt, err = db.PrepareContext(ctx, updateArtifactCiphertext); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateArtifactCiphertext: %w", err)
+	}
 	if q.updateUserCheckInStmt, err = db.PrepareContext(ctx, updateUserCheckIn); err != nil {
 		return nil, fmt.Errorf("error preparing query UpdateUserCheckIn: %w", err)
 	}
+	if q.updateVaultAccessMaterialStmt, err = db.PrepareContext(ctx, updateVaultAccessMaterial); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateVaultAccessMaterial: %w", err)
+	}
+	if q.updateVaultHintStmt, err = db.PrepareContext(ctx, updateVaultHint); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateVaultHint: %w", err)
+	}
+	if q.updateVaultKDFStmt, err = db.PrepareContext(ctx, updateVaultKDF); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateVaultKDF: %w", err)
+	}
+	if q.updateVaultSealStmt, err = db.PrepareContext(ctx, updateVaultSeal); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateVaultSeal: %w", err)
+	}
+	if q.updateVaultSharesStmt, err = db.PrepareContext(ctx, updateVaultShares); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateVaultShares: %w", err)
+	}
+	if q.upsertContactVerificationStmt, err = db.PrepareContext(ctx, upsertContactVerification); err != nil {
+		return nil, fmt.Errorf("error preparing query UpsertContactVerification: %w", err)
+	}
+	if q.upsertDataKeyStmt, err = db.PrepareContext(ctx, upsertDataKey); err != nil {
+		return nil, fmt.Errorf("error preparing query UpsertDataKey: %w", err)
+	}
+	if q.upsertInstanceSettingStmt, err = db.PrepareContext(ctx, upsertInstanceSetting); err != nil {
+		return nil, fmt.Errorf("error preparing query UpsertInstanceSetting: %w", err)
+	}
+	if q.upsertReminderPolicyStmt, err = db.PrepareContext(ctx, upsertReminderPolicy); err != nil {
+		return nil, fmt.Errorf("error preparing query UpsertReminderPolicy: %w", err)
+	}
+	if q.upsertRotationArtifactStmt, err = db.PrepareContext(ctx, upsertRotationArtifact); err != nil {
+		return nil, fmt.Errorf("error preparing query UpsertRotationArtifact: %w", err)
+	}
+	if q.upsertUserQuotaStmt, err = db.PrepareContext(ctx, upsertUserQuota); err != nil {
+		return nil, fmt.Errorf("error preparing query UpsertUserQuota: %w", err)
+	}
+	if q.upsertVaultShareStmt, err = db.PrepareContext(ctx, upsertVaultShare); err != nil {
+		return nil, fmt.Errorf("error preparing query UpsertVaultShare: %w", err)
+	}
+	if q.upsertWrappedKeyStmt, err = db.PrepareContext(ctx, upsertWrappedKey); err != nil {
+		return nil, fmt.Errorf("error preparing query UpsertWrappedKey: %w", err)
+	}
 	return &q, nil
 }
 
 func (q *Queries) Close() error {
 	var err error
+	if q.cancelUserDeletionStmt != nil {
+		if cerr := q.cancelUserDeletionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing cancelUserDeletionStmt: %w", cerr)
+		}
+	}
+	if q.claimContactVerificationAttemptStmt != nil {
+		if cerr := q.claimContactVerificationAttemptStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing claimContactVerificationAttemptStmt: %w", cerr)
+		}
+	}
+	if q.clearVaultSharesStmt != nil {
+		if cerr := q.clearVaultSharesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing clearVaultSharesStmt: %w", cerr)
+		}
+	}
+	if q.clearVaultWrappedKeysStmt != nil {
+		if cerr := q.clearVaultWrappedKeysStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing clearVaultWrappedKeysStmt: %w", cerr)
+		}
+	}
+	if q.clearWrappedKeysForBeneficiaryStmt != nil {
+		if cerr := q.clearWrappedKeysForBeneficiaryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing clearWrappedKeysForBeneficiaryStmt: %w", cerr)
+		}
+	}
+	if q.confirmVerifierStmt != nil {
+		if cerr := q.confirmVerifierStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing confirmVerifierStmt: %w", cerr)
+		}
+	}
+	if q.countActiveSessionsByUserStmt != nil {
+		if cerr := q.countActiveSessionsByUserStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing countActiveSessionsByUserStmt: %w", cerr)
+		}
+	}
+	if q.countAdminsStmt != nil {
+		if cerr := q.countAdminsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing countAdminsStmt: %w", cerr)
+		}
+	}
+	if q.countConfirmedVerifiersStmt != nil {
+		if cerr := q.countConfirmedVerifiersStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing countConfirmedVerifiersStmt: %w", cerr)
+		}
+	}
+	if q.countOpenInvitesByCreatorStmt != nil {
+		if cerr := q.countOpenInvitesByCreatorStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing countOpenInvitesByCreatorStmt: %w", cerr)
+		}
+	}
+	if q.countPendingNotificationsStmt != nil {
+		if cerr := q.countPendingNotificationsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing countPendingNotificationsStmt: %w", cerr)
+		}
+	}
+	if q.countPendingNotificationsByUserStmt != nil {
+		if cerr := q.countPendingNotificationsByUserStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing countPendingNotificationsByUserStmt: %w", cerr)
+		}
+	}
+	if q.countUsersStmt != nil {
+		if cerr := q.countUsersStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing countUsersStmt: %w", cerr)
+		}
+	}
+	if q.countUsersByStatusStmt != nil {
+		if cerr := q.countUsersByStatusStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing countUsersByStatusStmt: %w", cerr)
+		}
+	}
+	if q.countVaultsSealedUnderOtherKeysStmt != nil {
+		if cerr := q.countVaultsSealedUnderOtherKeysStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing countVaultsSealedUnderOtherKeysStmt: %w", cerr)
+		}
+	}
 	if q.createArtifactStmt != nil {
 		if cerr := q.createArtifactStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing createArtifactStmt: %w", cerr)
 		}
 	}
+	if q.createBeneficiaryStmt != nil {
+		if cerr := q.createBeneficiaryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createBeneficiaryStmt: %w", cerr)
+		}
+	}
 	if q.createContactMethodStmt != nil {
 		if cerr := q.createContactMethodStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing createContactMethodStmt: %w", cerr)
 		}
 	}
+	if q.createInviteStmt != nil {
+		if cerr := q.createInviteStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createInviteStmt: %w", cerr)
+		}
+	}
+	if q.createInviteContactMethodStmt != nil {
+		if cerr := q.createInviteContactMethodStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createInviteContactMethodStmt: %w", cerr)
+		}
+	}
+	if q.createNotificationStmt != nil {
+		if cerr := q.createNotificationStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createNotificationStmt: %w", cerr)
+		}
+	}
+	if q.createOIDCLoginStmt != nil {
+		if cerr := q.createOIDCLoginStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createOIDCLoginStmt: %w", cerr)
+		}
+	}
 	if q.createSessionStmt != nil {
 		if cerr := q.createSessionStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing createSessionStmt: %w", cerr)
 		}
 	}
+	if q.createSigningKeyStmt != nil {
+		if cerr := q.createSigningKeyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createSigningKeyStmt: %w", cerr)
+		}
+	}
 	if q.createUserStmt != nil {
 		if cerr := q.createUserStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing createUserStmt: %w", cerr)
 		}
 	}
+	if q.createUserIdentityStmt != nil {
+		if cerr := q.createUserIdentityStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createUserIdentityStmt: %w", cerr)
+		}
+	}
 	if q.createVaultStmt != nil {
 		if cerr := q.createVaultStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing createVaultStmt: %w", cerr)
@@ -104,16 +547,161 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing createVaultAccessStmt: %w", cerr)
 		}
 	}
+	if q.createVaultRotationStmt != nil {
+		if cerr := q.createVaultRotationStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createVaultRotationStmt: %w", cerr)
+		}
+	}
+	if q.deleteContactMethodStmt != nil {
+		if cerr := q.deleteContactMethodStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteContactMethodStmt: %w", cerr)
+		}
+	}
+	if q.deleteContactVerificationStmt != nil {
+		if cerr := q.deleteContactVerificationStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteContactVerificationStmt: %w", cerr)
+		}
+	}
+	if q.deleteExpiredOIDCLoginsStmt != nil {
+		if cerr := q.deleteExpiredOIDCLoginsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteExpiredOIDCLoginsStmt: %w", cerr)
+		}
+	}
+	if q.deleteExpiredSessionsStmt != nil {
+		if cerr := q.deleteExpiredSessionsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteExpiredSessionsStmt: %w", cerr)
+		}
+	}
+	if q.deleteExpiredVaultRotationsStmt != nil {
+		if cerr := q.deleteExpiredVaultRotationsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteExpiredVaultRotationsStmt: %w", cerr)
+		}
+	}
+	if q.deleteInviteStmt != nil {
+		if cerr := q.deleteInviteStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteInviteStmt: %w", cerr)
+		}
+	}
 	if q.deleteSessionStmt != nil {
 		if cerr := q.deleteSessionStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing deleteSessionStmt: %w", cerr)
 		}
 	}
+	if q.deleteUserStmt != nil {
+		if cerr := q.deleteUserStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteUserStmt: %w", cerr)
+		}
+	}
+	if q.deleteUserIdentityStmt != nil {
+		if cerr := q.deleteUserIdentityStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteUserIdentityStmt: %w", cerr)
+		}
+	}
+	if q.deleteUserQuotaStmt != nil {
+		if cerr := q.deleteUserQuotaStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteUserQuotaStmt: %w", cerr)
+		}
+	}
+	if q.deleteUserSessionsStmt != nil {
+		if cerr := q.deleteUserSessionsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteUserSessionsStmt: %w", cerr)
+		}
+	}
+	if q.deleteVaultRotationStmt != nil {
+		if cerr := q.deleteVaultRotationStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteVaultRotationStmt: %w", cerr)
+		}
+	}
+	if q.deleteWrappedKeyStmt != nil {
+		if cerr := q.deleteWrappedKeyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteWrappedKeyStmt: %w", cerr)
+		}
+	}
+	if q.disableUserStmt != nil {
+		if cerr := q.disableUserStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing disableUserStmt: %w", cerr)
+		}
+	}
+	if q.enableUserStmt != nil {
+		if cerr := q.enableUserStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing enableUserStmt: %w", cerr)
+		}
+	}
+	if q.getArtifactStmt != nil {
+		if cerr := q.getArtifactStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getArtifactStmt: %w", cerr)
+		}
+	}
 	if q.getArtifactsByVaultStmt != nil {
 		if cerr := q.getArtifactsByVaultStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getArtifactsByVaultStmt: %w", cerr)
 		}
 	}
+	if q.getBeneficiaryByIDStmt != nil {
+		if cerr := q.getBeneficiaryByIDStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getBeneficiaryByIDStmt: %w", cerr)
+		}
+	}
+	if q.getBeneficiaryByReleaseTokenStmt != nil {
+		if cerr := q.getBeneficiaryByReleaseTokenStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getBeneficiaryByReleaseTokenStmt: %w", cerr)
+		}
+	}
+	if q.getBeneficiaryByVerifyTokenStmt != nil {
+		if cerr := q.getBeneficiaryByVerifyTokenStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getBeneficiaryByVerifyTokenStmt: %w", cerr)
+		}
+	}
+	if q.getContactMethodByIDStmt != nil {
+		if cerr := q.getContactMethodByIDStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getContactMethodByIDStmt: %w", cerr)
+		}
+	}
+	if q.getContactVerificationStmt != nil {
+		if cerr := q.getContactVerificationStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getContactVerificationStmt: %w", cerr)
+		}
+	}
+	if q.getDataKeyStmt != nil {
+		if cerr := q.getDataKeyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getDataKeyStmt: %w", cerr)
+		}
+	}
+	if q.getInstanceSettingStmt != nil {
+		if cerr := q.getInstanceSettingStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getInstanceSettingStmt: %w", cerr)
+		}
+	}
+	if q.getInviteStmt != nil {
+		if cerr := q.getInviteStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getInviteStmt: %w", cerr)
+		}
+	}
+	if q.getInviteByTokenHashStmt != nil {
+		if cerr := q.getInviteByTokenHashStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getInviteByTokenHashStmt: %w", cerr)
+		}
+	}
+	if q.getLatestNotificationByEventStmt != nil {
+		if cerr := q.getLatestNotificationByEventStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getLatestNotificationByEventStmt: %w", cerr)
+		}
+	}
+	if q.getReleasedVaultStmt != nil {
+		if cerr := q.getReleasedVaultStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getReleasedVaultStmt: %w", cerr)
+		}
+	}
+	if q.getReminderPolicyStmt != nil {
+		if cerr := q.getReminderPolicyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getReminderPolicyStmt: %w", cerr)
+		}
+	}
+	if q.getSigningKeyStmt != nil {
+		if cerr := q.getSigningKeyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getSigningKeyStmt: %w", cerr)
+		}
+	}
 	if q.getUserByEmailStmt != nil {
 		if cerr := q.getUserByEmailStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getUserByEmailStmt: %w", cerr)
@@ -124,31 +712,331 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing getUserByIDStmt: %w", cerr)
 		}
 	}
+	if q.getUserByIdentityStmt != nil {
+		if cerr := q.getUserByIdentityStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getUserByIdentityStmt: %w", cerr)
+		}
+	}
 	if q.getUserBySessionTokenStmt != nil {
 		if cerr := q.getUserBySessionTokenStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getUserBySessionTokenStmt: %w", cerr)
 		}
 	}
+	if q.getUserIdentityStmt != nil {
+		if cerr := q.getUserIdentityStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getUserIdentityStmt: %w", cerr)
+		}
+	}
+	if q.getUserQuotaStmt != nil {
+		if cerr := q.getUserQuotaStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getUserQuotaStmt: %w", cerr)
+		}
+	}
+	if q.getVaultStmt != nil {
+		if cerr := q.getVaultStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getVaultStmt: %w", cerr)
+		}
+	}
 	if q.getVaultByIDStmt != nil {
 		if cerr := q.getVaultByIDStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getVaultByIDStmt: %w", cerr)
 		}
 	}
+	if q.getVaultRotationStmt != nil {
+		if cerr := q.getVaultRotationStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getVaultRotationStmt: %w", cerr)
+		}
+	}
+	if q.getVaultRotationByVaultStmt != nil {
+		if cerr := q.getVaultRotationByVaultStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getVaultRotationByVaultStmt: %w", cerr)
+		}
+	}
 	if q.getVaultsByUserStmt != nil {
 		if cerr := q.getVaultsByUserStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getVaultsByUserStmt: %w", cerr)
 		}
 	}
+	if q.importArtifactStmt != nil {
+		if cerr := q.importArtifactStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing importArtifactStmt: %w", cerr)
+		}
+	}
+	if q.importVaultStmt != nil {
+		if cerr := q.importVaultStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing importVaultStmt: %w", cerr)
+		}
+	}
+	if q.listArtifactsByVaultIDStmt != nil {
+		if cerr := q.listArtifactsByVaultIDStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listArtifactsByVaultIDStmt: %w", cerr)
+		}
+	}
+	if q.listBeneficiariesByUserStmt != nil {
+		if cerr := q.listBeneficiariesByUserStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listBeneficiariesByUserStmt: %w", cerr)
+		}
+	}
+	if q.listBeneficiaryContactMethodsStmt != nil {
+		if cerr := q.listBeneficiaryContactMethodsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listBeneficiaryContactMethodsStmt: %w", cerr)
+		}
+	}
+	if q.listContactMethodsByBeneficiaryIDStmt != nil {
+		if cerr := q.listContactMethodsByBeneficiaryIDStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listContactMethodsByBeneficiaryIDStmt: %w", cerr)
+		}
+	}
 	if q.listContactMethodsByUserIDStmt != nil {
 		if cerr := q.listContactMethodsByUserIDStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing listContactMethodsByUserIDStmt: %w", cerr)
 		}
 	}
+	if q.listInvitesStmt != nil {
+		if cerr := q.listInvitesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listInvitesStmt: %w", cerr)
+		}
+	}
+	if q.listInvitesByCreatorStmt != nil {
+		if cerr := q.listInvitesByCreatorStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listInvitesByCreatorStmt: %w", cerr)
+		}
+	}
+	if q.listLivenessCandidatesStmt != nil {
+		if cerr := q.listLivenessCandidatesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listLivenessCandidatesStmt: %w", cerr)
+		}
+	}
+	if q.listNotificationsByUserStmt != nil {
+		if cerr := q.listNotificationsByUserStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listNotificationsByUserStmt: %w", cerr)
+		}
+	}
+	if q.listPendingNotificationsStmt != nil {
+		if cerr := q.listPendingNotificationsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listPendingNotificationsStmt: %w", cerr)
+		}
+	}
+	if q.listReleasedSealedVaultsStmt != nil {
+		if cerr := q.listReleasedSealedVaultsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listReleasedSealedVaultsStmt: %w", cerr)
+		}
+	}
+	if q.listReleasedVaultsStmt != nil {
+		if cerr := q.listReleasedVaultsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listReleasedVaultsStmt: %w", cerr)
+		}
+	}
+	if q.listRotationArtifactsStmt != nil {
+		if cerr := q.listRotationArtifactsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listRotationArtifactsStmt: %w", cerr)
+		}
+	}
+	if q.listSealedVaultsStmt != nil {
+		if cerr := q.listSealedVaultsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listSealedVaultsStmt: %w", cerr)
+		}
+	}
+	if q.listUserIdentitiesStmt != nil {
+		if cerr := q.listUserIdentitiesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listUserIdentitiesStmt: %w", cerr)
+		}
+	}
+	if q.listUsersStmt != nil {
+		if cerr := q.listUsersStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listUsersStmt: %w", cerr)
+		}
+	}
+	if q.listUsersBeingDeletedStmt != nil {
+		if cerr := q.listUsersBeingDeletedStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listUsersBeingDeletedStmt: %w", cerr)
+		}
+	}
+	if q.listUsersDueForDeletionStmt != nil {
+		if cerr := q.listUsersDueForDeletionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listUsersDueForDeletionStmt: %w", cerr)
+		}
+	}
+	if q.listVaultAccessStmt != nil {
+		if cerr := q.listVaultAccessStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listVaultAccessStmt: %w", cerr)
+		}
+	}
+	if q.listVaultRecipientsStmt != nil {
+		if cerr := q.listVaultRecipientsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listVaultRecipientsStmt: %w", cerr)
+		}
+	}
+	if q.listVaultSharesStmt != nil {
+		if cerr := q.listVaultSharesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listVaultSharesStmt: %w", cerr)
+		}
+	}
+	if q.listVaultUsageStmt != nil {
+		if cerr := q.listVaultUsageStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listVaultUsageStmt: %w", cerr)
+		}
+	}
+	if q.markContactMethodVerifiedStmt != nil {
+		if cerr := q.markContactMethodVerifiedStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing markContactMethodVerifiedStmt: %w", cerr)
+		}
+	}
+	if q.markNotificationFailedStmt != nil {
+		if cerr := q.markNotificationFailedStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing markNotificationFailedStmt: %w", cerr)
+		}
+	}
+	if q.markNotificationSentStmt != nil {
+		if cerr := q.markNotificationSentStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing markNotificationSentStmt: %w", cerr)
+		}
+	}
+	if q.redactNotificationStmt != nil {
+		if cerr := q.redactNotificationStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing redactNotificationStmt: %w", cerr)
+		}
+	}
+	if q.redeemInviteStmt != nil {
+		if cerr := q.redeemInviteStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing redeemInviteStmt: %w", cerr)
+		}
+	}
+	if q.resetVerifierConfirmationsStmt != nil {
+		if cerr := q.resetVerifierConfirmationsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing resetVerifierConfirmationsStmt: %w", cerr)
+		}
+	}
+	if q.rewrapVaultKeyStmt != nil {
+		if cerr := q.rewrapVaultKeyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing rewrapVaultKeyStmt: %w", cerr)
+		}
+	}
+	if q.scheduleUserDeletionStmt != nil {
+		if cerr := q.scheduleUserDeletionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing scheduleUserDeletionStmt: %w", cerr)
+		}
+	}
+	if q.setBeneficiaryPublicKeyStmt != nil {
+		if cerr := q.setBeneficiaryPublicKeyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setBeneficiaryPublicKeyStmt: %w", cerr)
+		}
+	}
+	if q.setBeneficiaryReleaseTokenStmt != nil {
+		if cerr := q.setBeneficiaryReleaseTokenStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setBeneficiaryReleaseTokenStmt: %w", cerr)
+		}
+	}
+	if q.setBeneficiaryVerifyTokenStmt != nil {
+		if cerr := q.setBeneficiaryVerifyTokenStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setBeneficiaryVerifyTokenStmt: %w", cerr)
+		}
+	}
+	if q.setUserRoleStmt != nil {
+		if cerr := q.setUserRoleStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setUserRoleStmt: %w", cerr)
+		}
+	}
+	if q.startUserDeletionStmt != nil {
+		if cerr := q.startUserDeletionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing startUserDeletionStmt: %w", cerr)
+		}
+	}
+	if q.takeOIDCLoginStmt != nil {
+		if cerr := q.takeOIDCLoginStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing takeOIDCLoginStmt: %w", cerr)
+		}
+	}
+	if q.touchUserIdentityStmt != nil {
+		if cerr := q.touchUserIdentityStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing touchUserIdentityStmt: %w", cerr)
+		}
+	}
+	if q.transitionUserStatusStmt != nil {
+		if cerr := q.transitionUserStatusStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing transitionUserStatusStmt: %w", cerr)
+		}
+	}
+	if q.updateArtifactBlobStmt != nil {
+		if cerr := q.updateArtifactBlobStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateArtifactBlobStmt: %w", cerr)
+		}
+	}
+	if q.updateArtifactCiphertextStmt != nil {
+		if cerr := q.updateArtifactCiphertextStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateArtifactCiphertextStmt: %w", cerr)
+		}
+	}
 	if q.updateUserCheckInStmt != nil {
 		if cerr := q.updateUserCheckInStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing updateUserCheckInStmt: %w", cerr)
 		}
 	}
+	if q.updateVaultAccessMaterialStmt != nil {
+		if cerr := q.updateVaultAccessMaterialStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateVaultAccessMaterialStmt: %w", cerr)
+		}
+	}
+	if q.updateVaultHintStmt != nil {
+		if cerr := q.updateVaultHintStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateVaultHintStmt: %w", cerr)
+		}
+	}
+	if q.updateVaultKDFStmt != nil {
+		if cerr := q.updateVaultKDFStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateVaultKDFStmt: %w", cerr)
+		}
+	}
+	if q.updateVaultSealStmt != nil {
+		if cerr := q.updateVaultSealStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateVaultSealStmt: %w", cerr)
+		}
+	}
+	if q.updateVaultSharesStmt != nil {
+		if cerr := q.updateVaultSharesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateVaultSharesStmt: %w", cerr)
+		}
+	}
+	if q.upsertContactVerificationStmt != nil {
+		if cerr := q.upsertContactVerificationStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing upsertContactVerificationStmt: %w", cerr)
+		}
+	}
+	if q.upsertDataKeyStmt != nil {
+		if cerr := q.upsertDataKeyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing upsertDataKeyStmt: %w", cerr)
+		}
+	}
+	if q.upsertInstanceSettingStmt != nil {
+		if cerr := q.upsertInstanceSettingStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing upsertInstanceSettingStmt: %w", cerr)
+		}
+	}
+	if q.upsertReminderPolicyStmt != nil {
+		if cerr := q.upsertReminderPolicyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing upsertReminderPolicyStmt: %w", cerr)
+		}
+	}
+	if q.upsertRotationArtifactStmt != nil {
+		if cerr := q.upsertRotationArtifactStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing upsertRotationArtifactStmt: %w", cerr)
+		}
+	}
+	if q.upsertUserQuotaStmt != nil {
+		if cerr := q.upsertUserQuotaStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing upsertUserQuotaStmt: %w", cerr)
+		}
+	}
+	if q.upsertVaultShareStmt != nil {
+		if cerr := q.upsertVaultShareStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing upsertVaultShareStmt: %w", cerr)
+		}
+	}
+	if q.upsertWrappedKeyStmt != nil {
+		if cerr := q.upsertWrappedKeyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing upsertWrappedKeyStmt: %w", cerr)
+		}
+	}
 	return err
 }
 
@@ -186,43 +1074,265 @@ func (q *Queries) queryRow(ctx context.Context, stmt *sql.Stmt, query string, ar
 }
 
 type Queries struct {
-	db                             DBTX
-	tx                             *sql.Tx
-	createArtifactStmt             *sql.Stmt
-	createContactMethodStmt        *sql.Stmt
-	createSessionStmt              *sql.Stmt
-	createUserStmt                 *sql.Stmt
-	createVaultStmt                *sql.Stmt
-	createVaultAccessStmt          *sql.Stmt
-	deleteSessionStmt              *sql.Stmt
-	getArtifactsByVaultStmt        *sql.Stmt
-	getUserByEmailStmt             *sql.Stmt
-	getUserByIDStmt                *sql.Stmt
-	getUserBySessionTokenStmt      *sql.Stmt
-	getVaultByIDStmt               *sql.Stmt
-	getVaultsByUserStmt            *sql.Stmt
-	listContactMethodsByUserIDStmt *sql.Stmt
-	updateUserCheckInStmt          *sql.Stmt
+	db                                    DBTX
+	tx                                    *sql.Tx
+	cancelUserDeletionStmt                *sql.Stmt
+	claimContactVerificationAttemptStmt   *sql.Stmt
+	clearVaultSharesStmt                  *sql.Stmt
+	clearVaultWrappedKeysStmt             *sql.Stmt
+	clearWrappedKeysForBeneficiaryStmt    *sql.Stmt
+	confirmVerifierStmt                   *sql.Stmt
+	countActiveSessionsByUserStmt         *sql.Stmt
+	countAdminsStmt                       *sql.Stmt
+	countConfirmedVerifiersStmt           *sql.Stmt
+	countOpenInvitesByCreatorStmt         *sql.Stmt
+	countPendingNotificationsStmt         *sql.Stmt
+	countPendingNotificationsByUserStmt   *sql.Stmt
+	countUsersStmt                        *sql.Stmt
+	countUsersByStatusStmt                *sql.Stmt
+	countVaultsSealedUnderOtherKeysStmt   *sql.Stmt
+	createArtifactStmt                    *sql.Stmt
+	createBeneficiaryStmt                 *sql.Stmt
+	createContactMethodStmt               *sql.Stmt
+	createInviteStmt                      *sql.Stmt
+	createInviteContactMethodStmt         *sql.Stmt
+	createNotificationStmt                *sql.Stmt
+	createOIDCLoginStmt                   *sql.Stmt
+	createSessionStmt                     *sql.Stmt
+	createSigningKeyStmt                  *sql.Stmt
+	createUserStmt                        *sql.Stmt
+	createUserIdentityStmt                *sql.Stmt
+	createVaultStmt                       *sql.Stmt
+	createVaultAccessStmt                 *sql.Stmt
+	createVaultRotationStmt               *sql.Stmt
+	deleteContactMethodStmt               *sql.Stmt
+	deleteContactVerificationStmt         *sql.Stmt
+	deleteExpiredOIDCLoginsStmt           *sql.Stmt
+	deleteExpiredSessionsStmt             *sql.Stmt
+	deleteExpiredVaultRotationsStmt       *sql.Stmt
+	deleteInviteStmt                      *sql.Stmt
+	deleteSessionStmt                     *sql.Stmt
+	deleteUserStmt                        *sql.Stmt
+	deleteUserIdentityStmt                *sql.Stmt
+	deleteUserQuotaStmt                   *sql.Stmt
+	deleteUserSessionsStmt                *sql.Stmt
+	deleteVaultRotationStmt               *sql.Stmt
+	deleteWrappedKeyStmt                  *sql.Stmt
+	disableUserStmt                       *sql.Stmt
+	enableUserStmt                        *sql.Stmt
+	getArtifactStmt                       *sql.Stmt
+	getArtifactsByVaultStmt               *sql.Stmt
+	getBeneficiaryByIDStmt                *sql.Stmt
+	getBeneficiaryByReleaseTokenStmt      *sql.Stmt
+	getBeneficiaryByVerifyTokenStmt       *sql.Stmt
+	getContactMethodByIDStmt              *sql.Stmt
+	getContactVerificationStmt            *sql.Stmt
+	getDataKeyStmt                        *sql.Stmt
+	getInstanceSettingStmt                *sql.Stmt
+	getInviteStmt                         *sql.Stmt
+	getInviteByTokenHashStmt              *sql.Stmt
+	getLatestNotificationByEventStmt      *sql.Stmt
+	getReleasedVaultStmt                  *sql.Stmt
+	getReminderPolicyStmt                 *sql.Stmt
+	getSigningKeyStmt                     *sql.Stmt
+	getUserByEmailStmt                    *sql.Stmt
+	getUserByIDStmt                       *sql.Stmt
+	getUserByIdentityStmt                 *sql.Stmt
+	getUserBySessionTokenStmt             *sql.Stmt
+	getUserIdentityStmt                   *sql.Stmt
+	getUserQuotaStmt                      *sql.Stmt
+	getVaultStmt                          *sql.Stmt
+	getVaultByIDStmt                      *sql.Stmt
+	getVaultRotationStmt                  *sql.Stmt
+	getVaultRotationByVaultStmt           *sql.Stmt
+	getVaultsByUserStmt                   *sql.Stmt
+	importArtifactStmt                    *sql.Stmt
+	importVaultStmt                       *sql.Stmt
+	listArtifactsByVaultIDStmt            *sql.Stmt
+	listBeneficiariesByUserStmt           *sql.Stmt
+	listBeneficiaryContactMethodsStmt     *sql.Stmt
+	listContactMethodsByBeneficiaryIDStmt *sql.Stmt
+	listContactMethodsByUserIDStmt        *sql.Stmt
+	listInvitesStmt                       *sql.Stmt
+	listInvitesByCreatorStmt              *sql.Stmt
+	listLivenessCandidatesStmt            *sql.Stmt
+	listNotificationsByUserStmt           *sql.Stmt
+	listPendingNotificationsStmt          *sql.Stmt
+	listReleasedSealedVaultsStmt          *sql.Stmt
+	listReleasedVaultsStmt                *sql.Stmt
+	listRotationArtifactsStmt             *sql.Stmt
+	listSealedVaultsStmt                  *sql.Stmt
+	listUserIdentitiesStmt                *sql.Stmt
+	listUsersStmt                         *sql.Stmt
+	listUsersBeingDeletedStmt             *sql.Stmt
+	listUsersDueForDeletionStmt           *sql.Stmt
+	listVaultAccessStmt                   *sql.Stmt
+	listVaultRecipientsStmt               *sql.Stmt
+	listVaultSharesStmt                   *sql.Stmt
+	listVaultUsageStmt                    *sql.Stmt
+	markContactMethodVerifiedStmt         *sql.Stmt
+	markNotificationFailedStmt            *sql.Stmt
+	markNotificationSentStmt              *sql.Stmt
+	redactNotificationStmt                *sql.Stmt
+	redeemInviteStmt                      *sql.Stmt
+	resetVerifierConfirmationsStmt        *sql.Stmt
+	rewrapVaultKeyStmt                    *sql.Stmt
+	scheduleUserDeletionStmt              *sql.Stmt
+	setBeneficiaryPublicKeyStmt           *sql.Stmt
+	setBeneficiaryReleaseTokenStmt        *sql.Stmt
+	setBeneficiaryVerifyTokenStmt         *sql.Stmt
+	setUserRoleStmt                       *sql.Stmt
+	startUserDeletionStmt                 *sql.Stmt
+	takeOIDCLoginStmt                     *sql.Stmt
+	touchUserIdentityStmt                 *sql.Stmt
+	transitionUserStatusStmt              *sql.Stmt
+	updateArtifactBlobStmt                *sql.Stmt
+	updateArtifactCiphertextStmt          *sql.Stmt
+	updateUserCheckInStmt                 *sql.Stmt
+	updateVaultAccessMaterialStmt         *sql.Stmt
+	updateVaultHintStmt                   *sql.Stmt
+	updateVaultKDFStmt                    *sql.Stmt
+	updateVaultSealStmt                   *sql.Stmt
+	updateVaultSharesStmt                 *sql.Stmt
+	upsertContactVerificationStmt         *sql.Stmt
+	upsertDataKeyStmt                     *sql.Stmt
+	upsertInstanceSettingStmt             *sql.Stmt
+	upsertReminderPolicyStmt              *sql.Stmt
+	upsertRotationArtifactStmt            *sql.Stmt
+	upsertUserQuotaStmt                   *sql.Stmt
+	upsertVaultShareStmt                  *sql.Stmt
+	upsertWrappedKeyStmt                  *sql.Stmt
 }
 
 func (q *Queries) WithTx(tx *sql.Tx) *Queries {
 	return &Queries{
-		db:                             tx,
-		tx:                             tx,
-		createArtifactStmt:             q.createArtifactStmt,
-		createContactMethodStmt:        q.createContactMethodStmt,
-		createSessionStmt:              q.createSessionStmt,
-		createUserStmt:                 q.createUserStmt,
-		createVaultStmt:                q.createVaultStmt,
-		createVaultAccessStmt:          q.createVaultAccessStmt,
-		deleteSessionStmt:              q.deleteSessionStmt,
-		getArtifactsByVaultStmt:        q.getArtifactsByVaultStmt,
-		getUserByEmailStmt:             q.getUserByEmailStmt,
-		getUserByIDStmt:                q.getUserByIDStmt,
-		getUserBySessionTokenStmt:      q.getUserBySessionTokenStmt,
-		getVaultByIDStmt:               q.getVaultByIDStmt,
-		getVaultsByUserStmt:            q.getVaultsByUserStmt,
-		listContactMethodsByUserIDStmt: q.listContactMethodsByUserIDStmt,
-		updateUserCheckInStmt:          q.updateUserCheckInStmt,
+		db:                                    tx,
+		tx:                                    tx,
+		cancelUserDeletionStmt:                q.cancelUserDeletionStmt,
+		claimContactVerificationAttemptStmt:   q.claimContactVerificationAttemptStmt,
+		clearVaultSharesStmt:                  q.clearVaultSharesStmt,
+		clearVaultWrappedKeysStmt:             q.clearVaultWrappedKeysStmt,
+		clearWrappedKeysForBeneficiaryStmt:    q.clearWrappedKeysForBeneficiaryStmt,
+		confirmVerifierStmt:                   q.confirmVerifierStmt,
+		countActiveSessionsByUserStmt:         q.countActiveSessionsByUserStmt,
+		countAdminsStmt:                       q.countAdminsStmt,
+		countConfirmedVerifiersStmt:           q.countConfirmedVerifiersStmt,
+		countOpenInvitesByCreatorStmt:         q.countOpenInvitesByCreatorStmt,
+		countPendingNotificationsStmt:         q.countPendingNotificationsStmt,
+		countPendingNotificationsByUserStmt:   q.countPendingNotificationsByUserStmt,
+		countUsersStmt:                        q.countUsersStmt,
+		countUsersByStatusStmt:                q.countUsersByStatusStmt,
+		countVaultsSealedUnderOtherKeysStmt:   q.countVaultsSealedUnderOtherKeysStmt,
+		createArtifactStmt:                    q.createArtifactStmt,
+		createBeneficiaryStmt:                 q.createBeneficiaryStmt,
+		createContactMethodStmt:               q.createContactMethodStmt,
+		createInviteStmt:                      q.createInviteStmt,
+		createInviteContactMethodStmt:         q.createInviteContactMethodStmt,
+		createNotificationStmt:                q.createNotificationStmt,
+		createOIDCLoginStmt:                   q.createOIDCLoginStmt,
+		createSessionStmt:                     q.createSessionStmt,
+		createSigningKeyStmt:                  q.createSigningKeyStmt,
+		createUserStmt:                        q.createUserStmt,
+		createUserIdentityStmt:                q.createUserIdentityStmt,
+		createVaultStmt:                       q.createVaultStmt,
+		createVaultAccessStmt:                 q.createVaultAccessStmt,
+		createVaultRotationStmt:               q.createVaultRotationStmt,
+		deleteContactMethodStmt:               q.deleteContactMethodStmt,
+		deleteContactVerificationStmt:         q.deleteContactVerificationStmt,
+		deleteExpiredOIDCLoginsStmt:           q.deleteExpiredOIDCLoginsStmt,
+		deleteExpiredSessionsStmt:             q.deleteExpiredSessionsStmt,
+		deleteExpiredVaultRotationsStmt:       q.deleteExpiredVaultRotationsStmt,
+		deleteInviteStmt:                      q.deleteInviteStmt,
+		deleteSessionStmt:                     q.deleteSessionStmt,
+		deleteUserStmt:                        q.deleteUserStmt,
+		deleteUserIdentityStmt:                q.deleteUserIdentityStmt,
+		deleteUserQuotaStmt:                   q.deleteUserQuotaStmt,
+		deleteUserSessionsStmt:                q.deleteUserSessionsStmt,
+		deleteVaultRotationStmt:               q.deleteVaultRotationStmt,
+		deleteWrappedKeyStmt:                  q.deleteWrappedKeyStmt,
+		disableUserStmt:                       q.disableUserStmt,
+		enableUserStmt:                        q.enableUserStmt,
+		getArtifactStmt:                       q.getArtifactStmt,
+		getArtifactsByVaultStmt:               q.getArtifactsByVaultStmt,
+		getBeneficiaryByIDStmt:                q.getBeneficiaryByIDStmt,
+		getBeneficiaryByReleaseTokenStmt:      q.getBeneficiaryByReleaseTokenStmt,
+		getBeneficiaryByVerifyTokenStmt:       q.getBeneficiaryByVerifyTokenStmt,
+		getContactMethodByIDStmt:              q.getContactMethodByIDStmt,
+		getContactVerificationStmt:            q.getContactVerificationStmt,
+		getDataKeyStmt:                        q.getDataKeyStmt,
+		getInstanceSettingStmt:                q.getInstanceSettingStmt,
+		getInviteStmt:                         q.getInviteStmt,
+		getInviteByTokenHashStmt:              q.getInviteByTokenHashStmt,
+		getLatestNotificationByEventStmt:      q.getLatestNotificationByEventStmt,
+		getReleasedVaultStmt:                  q.getReleasedVaultStmt,
+		getReminderPolicyStmt:                 q.getReminderPolicyStmt,
+		getSigningKeyStmt:                     q.getSigningKeyStmt,
+		getUserByEmailStmt:                    q.getUserByEmailStmt,
+		getUserByIDStmt:                       q.getUserByIDStmt,
+		getUserByIdentityStmt:                 q.getUserByIdentityStmt,
+		getUserBySessionTokenStmt:             q.getUserBySessionTokenStmt,
+		getUserIdentityStmt:                   q.getUserIdentityStmt,
+		getUserQuotaStmt:                      q.getUserQuotaStmt,
+		getVaultStmt:                          q.getVaultStmt,
+		getVaultByIDStmt:                      q.getVaultByIDStmt,
+		getVaultRotationStmt:                  q.getVaultRotationStmt,
+		getVaultRotationByVaultStmt:           q.getVaultRotationByVaultStmt,
+		getVaultsByUserStmt:                   q.getVaultsByUserStmt,
+		importArtifactStmt:                    q.importArtifactStmt,
+		importVaultStmt:                       q.importVaultStmt,
+		listArtifactsByVaultIDStmt:            q.listArtifactsByVaultIDStmt,
+		listBeneficiariesByUserStmt:           q.listBeneficiariesByUserStmt,
+		listBeneficiaryContactMethodsStmt:     q.listBeneficiaryContactMethodsStmt,
+		listContactMethodsByBeneficiaryIDStmt: q.listContactMethodsByBeneficiaryIDStmt,
+		listContactMethodsByUserIDStmt:        q.listContactMethodsByUserIDStmt,
+		listInvitesStmt:                       q.listInvitesStmt,
+		listInvitesByCreatorStmt:              q.listInvitesByCreatorStmt,
+		listLivenessCandidatesStmt:            q.listLivenessCandidatesStmt,
+		listNotificationsByUserStmt:           q.listNotificationsByUserStmt,
+		listPendingNotificationsStmt:          q.listPendingNotificationsStmt,
+		listReleasedSealedVaultsStmt:          q.listReleasedSealedVaultsStmt,
+		listReleasedVaultsStmt:                q.listReleasedVaultsStmt,
+		listRotationArtifactsStmt:             q.listRotationArtifactsStmt,
+		listSealedVaultsStmt:                  q.listSealedVaultsStmt,
+		listUserIdentitiesStmt:                q.listUserIdentitiesStmt,
+		listUsersStmt:                         q.listUsersStmt,
+		listUsersBeingDeletedStmt:             q.listUsersBeingDeletedStmt,
+		listUsersDueForDeletionStmt:           q.listUsersDueForDeletionStmt,
+		listVaultAccessStmt:                   q.listVaultAccessStmt,
+		listVaultRecipientsStmt:               q.listVaultRecipientsStmt,
+		listVaultSharesStmt:                   q.listVaultSharesStmt,
+		listVaultUsageStmt:                    q.listVaultUsageStmt,
+		markContactMethodVerifiedStmt:         q.markContactMethodVerifiedStmt,
+		markNotificationFailedStmt:            q.markNotificationFailedStmt,
+		markNotificationSentStmt:              q.markNotificationSentStmt,
+		redactNotificationStmt:                q.redactNotificationStmt,
+		redeemInviteStmt:                      q.redeemInviteStmt,
+		resetVerifierConfirmationsStmt:        q.resetVerifierConfirmationsStmt,
+		rewrapVaultKeyStmt:                    q.rewrapVaultKeyStmt,
+		scheduleUserDeletionStmt:              q.scheduleUserDeletionStmt,
+		setBeneficiaryPublicKeyStmt:           q.setBeneficiaryPublicKeyStmt,
+		setBeneficiaryReleaseTokenStmt:        q.setBeneficiaryReleaseTokenStmt,
+		setBeneficiaryVerifyTokenStmt:         q.setBeneficiaryVerifyTokenStmt,
+		setUserRoleStmt:                       q.setUserRoleStmt,
+		startUserDeletionStmt:                 q.startUserDeletionStmt,
+		takeOIDCLoginStmt:                     q.takeOIDCLoginStmt,
+		touchUserIdentityStmt:                 q.touchUserIdentityStmt,
+		transitionUserStatusStmt:              q.transitionUserStatusStmt,
+		updateArtifactBlobStmt:                q.updateArtifactBlobStmt,
+		updateArtifactCiphertextStmt:          q.updateArtifactCiphertextStmt,
+		updateUserCheckInStmt:                 q.updateUserCheckInStmt,
+		updateVaultAccessMaterialStmt:         q.updateVaultAccessMaterialStmt,
+		updateVaultHintStmt:                   q.updateVaultHintStmt,
+		updateVaultKDFStmt:                    q.updateVaultKDFStmt,
+		updateVaultSealStmt:                   q.updateVaultSealStmt,
+		updateVaultSharesStmt:                 q.updateVaultSharesStmt,
+		upsertContactVerificationStmt:         q.upsertContactVerificationStmt,
+		upsertDataKeyStmt:                     q.upsertDataKeyStmt,
+		upsertInstanceSettingStmt:             q.upsertInstanceSettingStmt,
+		upsertReminderPolicyStmt:              q.upsertReminderPolicyStmt,
+		upsertRotationArtifactStmt:            q.upsertRotationArtifactStmt,
+		upsertUserQuotaStmt:                   q.upsertUserQuotaStmt,
+		upsertVaultShareStmt:                  q.upsertVaultShareStmt,
+		upsertWrappedKeyStmt:                  q.upsertWrappedKeyStmt,
 	}
 }
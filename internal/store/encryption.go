@@ -0,0 +1,151 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/vmpyr/afterlight/internal/core"
+	"github.com/vmpyr/afterlight/internal/keys"
+)
+
+const columnDataKeyID = "columns"
+
+// Columns encrypted at rest, by table. Their Go types (core.SecretString,
+// core.NullSecretString, core.Metadata) refuse to write plaintext; the store
+// seals values to their row before writing and opens them after reading
+// (columns.go).
+var encryptedColumns = []struct{ table, key, column string }{
+	{"users", "id", "name"},
+	{"beneficiaries", "id", "beneficiary_name"},
+	{"vaults", "id", "hint"},
+	{"vault_rotations", "id", "hint"},
+	{"contact_methods", "id", "destination"},
+	{"contact_methods", "id", "metadata"},
+	{"signing_keys", "id", "private_key"},
+	{"notification_outbox", "id", "subject"},
+	{"notification_outbox", "id", "body"},
+	{"notification_outbox", "id", "html_body"},
+}
+
+type columnCipher struct {
+	key []byte
+}
+
+func (c columnCipher) Encrypt(plaintext, aad []byte) (string, error) {
+	ct, err := keys.Encrypt(c.key, plaintext, aad)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(ct), nil
+}
+
+func (c columnCipher) Decrypt(ciphertext string, aad []byte) ([]byte, error) {
+	ct, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	return keys.Decrypt(c.key, ct, aad)
+}
+
+// Unwraps the column data key with the KEK and installs it, creating the data
+// key on first use. A data key wrapped by an older KEK still in the keyring is
+// re-wrapped under the primary one, which is how the KEK is rotated.
+func loadColumnKey(db *sql.DB, kek *keys.Keyring) error {
+	ctx := context.Background()
+	q := New(db)
+
+	stored, err := q.GetDataKey(ctx, columnDataKeyID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	exists := err == nil
+
+	if kek == nil {
+		if exists {
+			return errors.New("database has encrypted columns but no encryption key is configured")
+		}
+		return nil
+	}
+
+	var dataKey []byte
+	if exists {
+		if dataKey, err = kek.Unwrap(stored.KekID, stored.WrappedKey, columnDataKeyID); err != nil {
+			return fmt.Errorf("unwrapping column data key: %w", err)
+		}
+	} else if dataKey, err = keys.GenerateKey(); err != nil {
+		return err
+	}
+
+	if !exists || stored.KekID != kek.PrimaryID() {
+		kekID, wrapped, err := kek.Wrap(dataKey, columnDataKeyID)
+		if err != nil {
+			return err
+		}
+		if err := q.UpsertDataKey(ctx, UpsertDataKeyParams{
+			ID:         columnDataKeyID,
+			WrappedKey: wrapped,
+			KekID:      kekID,
+		}); err != nil {
+			return err
+		}
+		slog.Info("column data key wrapped", "encryption_key_id", kekID)
+	}
+
+	core.SetColumnCipher(columnCipher{key: dataKey})
+	return nil
+}
+
+// Code migration: encrypts sensitive values written before encryption was
+// turned on, and binds values encrypted before they were tied to their row.
+// Values that carry a prefix but do not decrypt are taken as plaintext.
+// Deferred until an encryption key is configured.
+func encryptSensitiveColumns(tx *sql.Tx) error {
+	if !core.ColumnEncryptionEnabled() {
+		return errMigrationDeferred
+	}
+
+	for _, c := range encryptedColumns {
+		rows, err := tx.Query(fmt.Sprintf("SELECT %s, %s FROM %s WHERE %s IS NOT NULL", c.key, c.column, c.table, c.column))
+		if err != nil {
+			return err
+		}
+
+		updates := map[string]any{}
+		for rows.Next() {
+			var id, value string
+			if err := rows.Scan(&id, &value); err != nil {
+				rows.Close()
+				return err
+			}
+			ref := core.ColumnRef{Table: c.table, Column: c.column, Key: id}
+			plain, err := core.OpenColumn(ref, value)
+			if err != nil {
+				// User text that only looks encrypted, written before such
+				// text was escaped
+				plain = []byte(value)
+			} else if core.IsSealedColumn(value) {
+				continue
+			}
+			if updates[id], err = core.SealColumn(ref, plain); err != nil {
+				rows.Close()
+				return err
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		for id, value := range updates {
+			if _, err := tx.Exec(fmt.Sprintf("UPDATE %s SET %s = ? WHERE %s = ?", c.table, c.column, c.key), value, id); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
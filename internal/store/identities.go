@@ -0,0 +1,103 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vmpyr/afterlight/internal/core"
+)
+
+// A login asserted by the OIDC provider
+type IdentityClaims struct {
+	Issuer        string
+	Subject       string
+	Email         string
+	EmailVerified bool
+}
+
+// Finds the user an identity logs in as. An unknown identity is linked to the
+// account with the same email if linkByEmail is set and the provider has
+// verified that address; otherwise it fails with core.ErrIdentityNotLinked.
+func (s *Store) LoginIdentityTx(ctx context.Context, claims IdentityClaims, linkByEmail bool, now time.Time) (User, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return User{}, err
+	}
+	defer tx.Rollback()
+
+	qTx := s.withTx(tx)
+	identity, err := qTx.GetUserIdentity(ctx, GetUserIdentityParams{Issuer: claims.Issuer, Subject: claims.Subject})
+	switch {
+	case err == nil:
+		err = qTx.TouchUserIdentity(ctx, TouchUserIdentityParams{
+			Email:       sql.NullString{String: claims.Email, Valid: claims.Email != ""},
+			LastLoginAt: sql.NullTime{Time: now, Valid: true},
+			ID:          identity.ID,
+		})
+	case errors.Is(err, sql.ErrNoRows):
+		if !linkByEmail || !claims.EmailVerified || claims.Email == "" {
+			return User{}, core.ErrIdentityNotLinked
+		}
+		user, lookupErr := qTx.GetUserByEmail(ctx, claims.Email)
+		if errors.Is(lookupErr, sql.ErrNoRows) {
+			return User{}, core.ErrIdentityNotLinked
+		}
+		if lookupErr != nil {
+			return User{}, lookupErr
+		}
+		identity, err = createIdentity(ctx, qTx, user.ID, claims, now)
+	}
+	if err != nil {
+		return User{}, err
+	}
+
+	user, err := qTx.GetUserByID(ctx, identity.UserID)
+	if err != nil {
+		return User{}, err
+	}
+	return user, tx.Commit()
+}
+
+// Links an identity to a logged-in user. Linking it again to the same user
+// only refreshes it; an identity linked elsewhere is core.ErrIdentityLinked.
+func (s *Store) LinkIdentityTx(ctx context.Context, userID string, claims IdentityClaims, now time.Time) (UserIdentity, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return UserIdentity{}, err
+	}
+	defer tx.Rollback()
+
+	qTx := s.withTx(tx)
+	identity, err := qTx.GetUserIdentity(ctx, GetUserIdentityParams{Issuer: claims.Issuer, Subject: claims.Subject})
+	switch {
+	case err == nil && identity.UserID != userID:
+		return UserIdentity{}, core.ErrIdentityLinked
+	case err == nil:
+		err = qTx.TouchUserIdentity(ctx, TouchUserIdentityParams{
+			Email:       sql.NullString{String: claims.Email, Valid: claims.Email != ""},
+			LastLoginAt: identity.LastLoginAt,
+			ID:          identity.ID,
+		})
+	case errors.Is(err, sql.ErrNoRows):
+		identity, err = createIdentity(ctx, qTx, userID, claims, time.Time{})
+	}
+	if err != nil {
+		return UserIdentity{}, err
+	}
+	return identity, tx.Commit()
+}
+
+// A zero lastLogin is stored as never
+func createIdentity(ctx context.Context, q *Store, userID string, claims IdentityClaims, lastLogin time.Time) (UserIdentity, error) {
+	return q.CreateUserIdentity(ctx, CreateUserIdentityParams{
+		ID:          uuid.New().String(),
+		UserID:      userID,
+		Issuer:      claims.Issuer,
+		Subject:     claims.Subject,
+		Email:       sql.NullString{String: claims.Email, Valid: claims.Email != ""},
+		LastLoginAt: sql.NullTime{Time: lastLogin, Valid: !lastLogin.IsZero()},
+	})
+}
@@ -0,0 +1,93 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vmpyr/afterlight/internal/core"
+)
+
+// Creates an invite for the given token. quota caps the open invites of the
+// creator, 0 meaning no cap. With an email address, an email contact method
+// is created alongside for delivering it.
+func (s *Store) CreateInviteTx(ctx context.Context, createdBy string, token string, req core.CreateInviteRequest, quota int64) (Invite, *ContactMethod, error) {
+	now := time.Now().UTC()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Invite{}, nil, err
+	}
+	defer tx.Rollback()
+
+	qTx := s.withTx(tx)
+	if quota > 0 {
+		open, err := qTx.CountOpenInvitesByCreator(ctx, CountOpenInvitesByCreatorParams{CreatedBy: createdBy, ExpiresAt: now})
+		if err != nil {
+			return Invite{}, nil, err
+		}
+		if open >= quota {
+			return Invite{}, nil, core.ErrInviteQuotaExceeded
+		}
+	}
+
+	invite, err := qTx.CreateInvite(ctx, CreateInviteParams{
+		ID:        uuid.New().String(),
+		TokenHash: core.HashToken(token),
+		CreatedBy: createdBy,
+		Email:     sql.NullString{String: req.Email, Valid: req.Email != ""},
+		MaxUses:   req.MaxUses,
+		ExpiresAt: now.Add(time.Duration(req.ExpiresIn) * time.Second),
+	})
+	if err != nil {
+		return Invite{}, nil, err
+	}
+
+	var contact *ContactMethod
+	if req.Email != "" {
+		metadata := core.Metadata{}
+		if req.Locale != "" {
+			metadata["locale"] = req.Locale
+		}
+		cm, err := qTx.CreateInviteContactMethod(ctx, CreateInviteContactMethodParams{
+			ID:          uuid.New().String(),
+			UserID:      sql.NullString{String: createdBy, Valid: true},
+			InviteID:    sql.NullString{String: invite.ID, Valid: true},
+			Channel:     core.ChannelEmail,
+			Destination: core.SecretString(req.Email),
+			Metadata:    metadata,
+			CreatedAt:   now,
+		})
+		if err != nil {
+			return Invite{}, nil, err
+		}
+		contact = &cm
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Invite{}, nil, err
+	}
+	return invite, contact, nil
+}
+
+// Finds the invite for a token and checks it can be used to register email.
+// It is only redeemed together with the account, in CreateUserTx.
+func (s *Store) LookupInvite(ctx context.Context, token, email string) (*Invite, error) {
+	invite, err := s.GetInviteByTokenHash(ctx, core.HashToken(token))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, core.ErrInvalidInvite
+	}
+	if err != nil {
+		return nil, err
+	}
+	if invite.Uses >= invite.MaxUses || !time.Now().Before(invite.ExpiresAt) {
+		return nil, core.ErrInvalidInvite
+	}
+	if invite.Email.Valid && !strings.EqualFold(invite.Email.String, email) {
+		return nil, core.ErrInviteEmailMismatch
+	}
+	return &invite, nil
+}
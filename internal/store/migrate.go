@@ -0,0 +1,145 @@
+package store
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+//go:embed migrations/versions/*.sql
+var migrationFS embed.FS
+
+type migration struct {
+	Version int64
+	Name    string
+	SQL     string
+	Run     func(tx *sql.Tx) error // Set for migrations that need Go code instead of SQL
+}
+
+// Returned by a code migration that cannot run yet; it is retried on the next start
+var errMigrationDeferred = errors.New("migration deferred")
+
+// Migrations that transform data in ways SQL cannot. Versions share the
+// numbering of the files in migrations/versions.
+var codeMigrations = []migration{
+	{Version: 6, Name: "encrypt_sensitive_columns", Run: encryptSensitiveColumns},
+	{Version: 15, Name: "bind_encrypted_columns", Run: encryptSensitiveColumns},
+}
+
+// Embedded migrations ordered by version, parsed from "NNNN_name.sql" file names
+func loadMigrations() ([]migration, error) {
+	entries, err := fs.ReadDir(migrationFS, "migrations/versions")
+	if err != nil {
+		return nil, err
+	}
+
+	var migrations []migration
+	for _, e := range entries {
+		prefix, name, ok := strings.Cut(strings.TrimSuffix(e.Name(), ".sql"), "_")
+		if !ok {
+			return nil, fmt.Errorf("migration %s is not named NNNN_name.sql", e.Name())
+		}
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s has an invalid version: %w", e.Name(), err)
+		}
+		body, err := migrationFS.ReadFile(path.Join("migrations/versions", e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, migration{Version: version, Name: name, SQL: string(body)})
+	}
+
+	migrations = append(migrations, codeMigrations...)
+	slices.SortFunc(migrations, func(a, b migration) int { return cmp.Compare(a.Version, b.Version) })
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].Version == migrations[i-1].Version {
+			return nil, fmt.Errorf("duplicate migration version %04d", migrations[i].Version)
+		}
+	}
+	return migrations, nil
+}
+
+// Applies every migration not yet recorded in schema_migrations, each in its own transaction
+func migrate(db *sql.DB) error {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		var applied int
+		if err := db.QueryRow("SELECT COUNT(*) FROM schema_migrations WHERE version = ?", m.Version).Scan(&applied); err != nil {
+			return err
+		}
+		if applied > 0 {
+			continue
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		if m.Run != nil {
+			err = m.Run(tx)
+		} else {
+			_, err = tx.Exec(m.SQL)
+		}
+		if errors.Is(err, errMigrationDeferred) {
+			tx.Rollback()
+			slog.Info("migration deferred", "migration", fmt.Sprintf("%04d_%s", m.Version, m.Name))
+			continue
+		}
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %04d_%s failed: %w", m.Version, m.Name, err)
+		}
+		if _, err := tx.Exec("INSERT INTO schema_migrations (version, name) VALUES (?, ?)", m.Version, m.Name); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+		slog.Info("applied migration", "migration", fmt.Sprintf("%04d_%s", m.Version, m.Name))
+	}
+	return nil
+}
+
+// PendingMigration is a migration that has not been applied. Deferrable ones
+// are code migrations that wait for something, like an encryption key.
+type PendingMigration struct {
+	Name       string
+	Deferrable bool
+}
+
+func (s *SQLiteStorage) PendingMigrations(ctx context.Context) ([]PendingMigration, error) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []PendingMigration
+	for _, m := range migrations {
+		var applied int
+		if err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM schema_migrations WHERE version = ?", m.Version).Scan(&applied); err != nil {
+			return nil, err
+		}
+		if applied == 0 {
+			pending = append(pending, PendingMigration{
+				Name:       fmt.Sprintf("%04d_%s", m.Version, m.Name),
+				Deferrable: m.Run != nil,
+			})
+		}
+	}
+	return pending, nil
+}
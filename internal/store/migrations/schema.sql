@@ -133,3 +133,207 @@ CREATE TABLE IF NOT EXISTS sessions (
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
 );
+
+-- =================================================================================
+-- 7. REMINDER POLICIES
+-- Per-user escalation schedule for check-in reminders ahead of the trigger deadline.
+-- Users without a row fall back to the built-in default policy.
+-- =================================================================================
+CREATE TABLE IF NOT EXISTS reminder_policies (
+    user_id            TEXT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    primary_contact_id TEXT REFERENCES contact_methods(id) ON DELETE SET NULL, -- Channel used before fan-out (NULL = account email)
+
+    steps              TEXT NOT NULL, -- JSON escalation steps, e.g. [{"starts_before": 604800, "interval": 86400, "all_channels": false}]
+
+    -- Quiet Hours (local wall clock in time_zone, 'HH:MM'). Reminders falling inside are deferred.
+    quiet_hours_start  TEXT,
+    quiet_hours_end    TEXT,
+    time_zone          TEXT NOT NULL DEFAULT 'UTC', -- IANA zone name, e.g. 'Europe/Berlin'
+
+    updated_at         DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- =================================================================================
+-- 8. NOTIFICATION OUTBOX
+-- Every outgoing message is queued here first and delivered by the notifier,
+-- which keeps a record of what was sent and lets failed sends be retried.
+-- =================================================================================
+CREATE TABLE IF NOT EXISTS notification_outbox (
+    id                TEXT PRIMARY KEY, -- UUID v4
+    user_id           TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE, -- Account the event is about
+    contact_method_id TEXT NOT NULL REFERENCES contact_methods(id) ON DELETE CASCADE,
+
+    event             TEXT NOT NULL, -- Enum: 'CHECK_IN_REMINDER', 'VERIFICATION_REQUESTED', 'VAULT_RELEASED'
+    subject           TEXT NOT NULL,
+    body              TEXT NOT NULL,
+
+    status            TEXT NOT NULL DEFAULT 'PENDING', -- Enum: 'PENDING', 'SENT', 'FAILED'
+    attempts          INTEGER NOT NULL DEFAULT 0,
+    last_error        TEXT,
+
+    created_at        DATETIME NOT NULL,
+    sent_at           DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_outbox_status ON notification_outbox(status, created_at);
+CREATE INDEX IF NOT EXISTS idx_notification_outbox_user_event ON notification_outbox(user_id, event, created_at);
+
+-- =================================================================================
+-- 9. CONTACT VERIFICATIONS
+-- Pending one-time codes sent to a contact method to prove the destination is reachable.
+-- =================================================================================
+CREATE TABLE IF NOT EXISTS contact_verifications (
+    contact_method_id TEXT PRIMARY KEY REFERENCES contact_methods(id) ON DELETE CASCADE,
+    code_hash         TEXT NOT NULL,    -- SHA-256 of the code, never the code itself
+    attempts          INTEGER NOT NULL DEFAULT 0,
+    expires_at        DATETIME NOT NULL,
+    created_at        DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- =================================================================================
+-- 10. SCHEMA MIGRATIONS
+-- Tracks which numbered files under migrations/versions have been applied.
+-- This file only creates missing tables; changes to existing tables go there.
+-- =================================================================================
+CREATE TABLE IF NOT EXISTS schema_migrations (
+    version    INTEGER PRIMARY KEY,
+    name       TEXT NOT NULL,
+    applied_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- =================================================================================
+-- 11. DATA KEYS
+-- Random keys used to encrypt sensitive columns at rest, each wrapped by the
+-- key-encryption key (KEK) from the server configuration. Only the wrapped form
+-- is stored; without the KEK the encrypted columns cannot be read.
+-- =================================================================================
+CREATE TABLE IF NOT EXISTS data_keys (
+    id          TEXT PRIMARY KEY, -- Purpose, e.g. 'columns'
+    wrapped_key TEXT NOT NULL,    -- Base64 AES-GCM ciphertext of the data key
+    kek_id      TEXT NOT NULL,    -- Fingerprint of the KEK it is wrapped with
+    created_at  DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- =================================================================================
+-- 12. SIGNING KEYS
+-- Ed25519 keys the server signs exported vault bundles with, so an import can
+-- tell whether a bundle was produced (and left untouched) by this server.
+-- =================================================================================
+CREATE TABLE IF NOT EXISTS signing_keys (
+    id          TEXT PRIMARY KEY, -- Purpose, e.g. 'bundles'
+    private_key TEXT NOT NULL,    -- Base64 Ed25519 seed, encrypted at rest when column encryption is on
+    created_at  DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- =================================================================================
+-- 13. VAULT ROTATIONS
+-- Two-phase passphrase rotation. The owner opens a session with the new salt
+-- and KDF, uploads a re-encrypted replacement for every artifact, then commits,
+-- which swaps everything in one transaction. Until then the vault is untouched.
+-- =================================================================================
+CREATE TABLE IF NOT EXISTS vault_rotations (
+    id           TEXT PRIMARY KEY, -- UUID v4
+    vault_id     TEXT NOT NULL UNIQUE REFERENCES vaults(id) ON DELETE CASCADE, -- One open session per vault
+    kdf_salt     TEXT NOT NULL,
+    kdf_params   TEXT NOT NULL,    -- core.KDFParams as JSON
+    hint         TEXT,             -- Replacement hint (sealed like the vault's), used if replace_hint
+    replace_hint BOOLEAN NOT NULL DEFAULT 0,
+    expires_at   DATETIME NOT NULL,
+    created_at   DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS vault_rotation_artifacts (
+    rotation_id      TEXT NOT NULL REFERENCES vault_rotations(id) ON DELETE CASCADE,
+    artifact_id      TEXT NOT NULL REFERENCES artifacts(id) ON DELETE CASCADE,
+    encrypted_blob   BLOB NOT NULL,    -- Replacement ciphertext (sealed like the vault's)
+    iv               TEXT NOT NULL,
+    envelope_version INTEGER NOT NULL,
+    created_at       DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (rotation_id, artifact_id)
+);
+
+-- =================================================================================
+-- 14. HEALTH PROBE
+-- A single row rewritten by the readiness check to prove the database accepts writes.
+-- =================================================================================
+CREATE TABLE IF NOT EXISTS health_probe (
+    id         INTEGER PRIMARY KEY CHECK (id = 1),
+    checked_at DATETIME NOT NULL
+);
+
+-- =================================================================================
+-- 15. INSTANCE SETTINGS
+-- Settings an admin changes at runtime. A missing row falls back to the server
+-- configuration.
+-- =================================================================================
+CREATE TABLE IF NOT EXISTS instance_settings (
+    key        TEXT PRIMARY KEY,
+    value      TEXT NOT NULL,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- =================================================================================
+-- 16. INVITES
+-- Tokens that let someone register while registration is invite-only. Created
+-- by admins, or by users within their quota. Only the token's hash is stored.
+-- =================================================================================
+CREATE TABLE IF NOT EXISTS invites (
+    id          TEXT PRIMARY KEY, -- UUID v4
+    token_hash  TEXT UNIQUE NOT NULL,  -- SHA-256 of the token, never the token itself
+    created_by  TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    email       TEXT,                  -- If set, only this address can register with the invite
+    max_uses    INTEGER NOT NULL DEFAULT 1,
+    uses        INTEGER NOT NULL DEFAULT 0,
+    expires_at  DATETIME NOT NULL,
+    created_at  DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_invites_created_by ON invites(created_by);
+
+-- =================================================================================
+-- 17. USER QUOTAS
+-- Per-user overrides of the storage limits in the server configuration, set by
+-- an admin. NULL keeps the configured default; 0 means unlimited.
+-- =================================================================================
+CREATE TABLE IF NOT EXISTS user_quotas (
+    user_id            TEXT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    max_vaults         INTEGER,
+    max_artifacts      INTEGER, -- Across all of the user's vaults
+    max_artifact_bytes INTEGER, -- Size of a single encrypted blob
+    max_storage_bytes  INTEGER, -- Total size of the user's encrypted blobs
+    updated_at         DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- =================================================================================
+-- 18. OIDC IDENTITIES
+-- Accounts at the configured OpenID Connect provider that log in as a user,
+-- keyed by issuer and subject. Linked on first login by verified email, or
+-- explicitly by the logged-in user.
+-- =================================================================================
+CREATE TABLE IF NOT EXISTS user_identities (
+    id            TEXT PRIMARY KEY, -- UUID v4
+    user_id       TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    issuer        TEXT NOT NULL,
+    subject       TEXT NOT NULL,
+    email         TEXT,             -- As last reported by the provider
+    created_at    DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_login_at DATETIME,
+    UNIQUE (issuer, subject)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_identities_user ON user_identities(user_id);
+
+-- =================================================================================
+-- 19. OIDC LOGINS
+-- Authorization requests on their way through the provider. The state is also
+-- kept in a cookie, so a callback only completes in the browser that started
+-- it. Each row is used once; expired rows are removed by the sweeper.
+-- =================================================================================
+CREATE TABLE IF NOT EXISTS oidc_logins (
+    state_hash    TEXT PRIMARY KEY, -- SHA-256 of the state parameter
+    code_verifier TEXT NOT NULL,    -- PKCE
+    nonce         TEXT NOT NULL,
+    user_id       TEXT REFERENCES users(id) ON DELETE CASCADE, -- Set when linking to a logged-in account
+    redirect_to   TEXT NOT NULL,    -- Local path to return to
+    expires_at    DATETIME NOT NULL
+);
@@ -0,0 +1,2 @@
+-- Contact methods must be verified before they are trusted for liveness reminders.
+ALTER TABLE contact_methods ADD COLUMN verified_at DATETIME;
@@ -0,0 +1,2 @@
+-- Rendered HTML alternative for channels that support it (email).
+ALTER TABLE notification_outbox ADD COLUMN html_body TEXT;
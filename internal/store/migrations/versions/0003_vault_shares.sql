@@ -0,0 +1,14 @@
+-- Optional Shamir mode: the client splits the vault key into share_count shares,
+-- any share_threshold of which recover it. NULL threshold = single passphrase mode.
+ALTER TABLE vaults ADD COLUMN share_threshold INTEGER;
+ALTER TABLE vaults ADD COLUMN share_count INTEGER;
+
+-- Each beneficiary's share, encrypted client-side for that beneficiary.
+-- share_index is the x-coordinate the share was evaluated at (1..255).
+ALTER TABLE vault_access ADD COLUMN share_index INTEGER;
+ALTER TABLE vault_access ADD COLUMN encrypted_share TEXT;
+
+-- SHA-256 of the token a beneficiary uses to collect released shares.
+-- Only issued once the owner reaches CONFIRMED_DEAD.
+ALTER TABLE beneficiaries ADD COLUMN release_token_hash TEXT;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_beneficiaries_release_token ON beneficiaries(release_token_hash);
@@ -0,0 +1,6 @@
+-- Beneficiary's age X25519 recipient ("age1..."). Vault keys are wrapped to it client-side.
+ALTER TABLE beneficiaries ADD COLUMN public_key TEXT;
+
+-- The vault key encrypted to the beneficiary's public_key (base64 age file).
+-- The server only stores it; the plaintext key never leaves the client.
+ALTER TABLE vault_access ADD COLUMN wrapped_key TEXT;
@@ -0,0 +1,7 @@
+-- Sealed release: the vault's data key, encrypted under the server master key
+-- identified by sealed_key_id. While set, the hint, wrapped keys, shares and
+-- artifact blobs of the vault are stored encrypted under that data key.
+-- The liveness engine unseals (decrypts and clears) them on CONFIRMED_DEAD.
+ALTER TABLE vaults ADD COLUMN sealed_key TEXT;
+ALTER TABLE vaults ADD COLUMN sealed_key_id TEXT;
+ALTER TABLE vaults ADD COLUMN unsealed_at DATETIME;
@@ -0,0 +1,3 @@
+-- Envelope version the client declared for the artifact (see core.ArtifactEnvelopes).
+-- Existing artifacts stay at 0: uploaded before envelopes were checked.
+ALTER TABLE artifacts ADD COLUMN envelope_version INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,3 @@
+-- KDF the client derives the vault key with (core.KDFParams as JSON).
+-- Existing vaults were all created with the frontend's implicit PBKDF2 settings.
+ALTER TABLE vaults ADD COLUMN kdf_params TEXT NOT NULL DEFAULT '{"algorithm":"PBKDF2-SHA256","iterations":600000}';
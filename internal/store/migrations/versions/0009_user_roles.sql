@@ -0,0 +1,4 @@
+-- Instance administration. Every existing account starts as a regular user;
+-- promote one with `afterlight set-role`.
+ALTER TABLE users ADD COLUMN role TEXT NOT NULL DEFAULT 'USER'; -- Enum: 'USER', 'ADMIN'
+ALTER TABLE users ADD COLUMN disabled_at DATETIME;               -- Set while an admin has disabled the account
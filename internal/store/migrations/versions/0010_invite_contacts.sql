@@ -0,0 +1,4 @@
+-- Invitation emails go through the notification outbox, which needs a contact
+-- method. Those are owned by the inviter (contact methods must have an owner),
+-- removed with the invite, and never listed among the inviter's own.
+ALTER TABLE contact_methods ADD COLUMN invite_id TEXT REFERENCES invites(id) ON DELETE CASCADE;
@@ -0,0 +1,6 @@
+-- Self-service account deletion. deletion_due_at is set when the owner asks
+-- for it and cleared if they cancel. Once it has passed, the account is locked
+-- and its beneficiaries are told (deletion_started_at); the row is deleted,
+-- cascading to everything else, when those messages have gone out.
+ALTER TABLE users ADD COLUMN deletion_due_at DATETIME;
+ALTER TABLE users ADD COLUMN deletion_started_at DATETIME;
@@ -0,0 +1,4 @@
+-- Verifiers confirm a death through a link in the VERIFICATION_REQUESTED
+-- notice. Like release tokens, only the hash is kept; it is issued when the
+-- owner enters VERIFY and cleared when they check in.
+ALTER TABLE beneficiaries ADD COLUMN verify_token_hash TEXT;
@@ -0,0 +1,3 @@
+-- Messages carrying an access code (release, verification, invite) lose their
+-- body once delivered, so the code does not stay readable in the outbox.
+ALTER TABLE notification_outbox ADD COLUMN redact_after_send BOOLEAN NOT NULL DEFAULT FALSE;
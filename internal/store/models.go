@@ -12,32 +12,116 @@ import (
 )
 
 type Artifact struct {
-	ID            string             `json:"id"`
-	VaultID       string             `json:"vault_id"`
-	MessageType   core.MessageType   `json:"message_type"`
-	EncryptedBlob core.EncryptedBlob `json:"encrypted_blob"`
-	Iv            string             `json:"iv"`
-	CreatedAt     time.Time          `json:"created_at"`
+	ID              string               `json:"id"`
+	VaultID         string               `json:"vault_id"`
+	MessageType     core.MessageType     `json:"message_type"`
+	EncryptedBlob   core.EncryptedBlob   `json:"encrypted_blob"`
+	Iv              string               `json:"iv"`
+	CreatedAt       time.Time            `json:"created_at"`
+	EnvelopeVersion core.EnvelopeVersion `json:"envelope_version"`
 }
 
 type Beneficiary struct {
-	ID              string       `json:"id"`
-	UserID          string       `json:"user_id"`
-	BeneficiaryName string       `json:"beneficiary_name"`
-	IsVerifier      sql.NullBool `json:"is_verifier"`
-	HasConfirmed    sql.NullBool `json:"has_confirmed"`
-	ConfirmedAt     sql.NullTime `json:"confirmed_at"`
-	CreatedAt       time.Time    `json:"created_at"`
+	ID               string            `json:"id"`
+	UserID           string            `json:"user_id"`
+	BeneficiaryName  core.SecretString `json:"beneficiary_name"`
+	IsVerifier       sql.NullBool      `json:"is_verifier"`
+	HasConfirmed     sql.NullBool      `json:"has_confirmed"`
+	ConfirmedAt      sql.NullTime      `json:"confirmed_at"`
+	CreatedAt        time.Time         `json:"created_at"`
+	ReleaseTokenHash sql.NullString    `json:"release_token_hash"`
+	PublicKey        sql.NullString    `json:"public_key"`
+	VerifyTokenHash  sql.NullString    `json:"verify_token_hash"`
 }
 
 type ContactMethod struct {
-	ID            string         `json:"id"`
-	UserID        sql.NullString `json:"user_id"`
-	BeneficiaryID sql.NullString `json:"beneficiary_id"`
-	Channel       string         `json:"channel"`
-	Destination   string         `json:"destination"`
-	Metadata      core.Metadata  `json:"metadata"`
-	CreatedAt     time.Time      `json:"created_at"`
+	ID            string            `json:"id"`
+	UserID        sql.NullString    `json:"user_id"`
+	BeneficiaryID sql.NullString    `json:"beneficiary_id"`
+	Channel       core.Channel      `json:"channel"`
+	Destination   core.SecretString `json:"destination"`
+	Metadata      core.Metadata     `json:"metadata"`
+	CreatedAt     time.Time         `json:"created_at"`
+	VerifiedAt    sql.NullTime      `json:"verified_at"`
+	InviteID      sql.NullString    `json:"invite_id"`
+}
+
+type ContactVerification struct {
+	ContactMethodID string    `json:"contact_method_id"`
+	CodeHash        string    `json:"code_hash"`
+	Attempts        int64     `json:"attempts"`
+	ExpiresAt       time.Time `json:"expires_at"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+type DataKey struct {
+	ID         string    `json:"id"`
+	WrappedKey string    `json:"wrapped_key"`
+	KekID      string    `json:"kek_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type HealthProbe struct {
+	ID        int64     `json:"id"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+type InstanceSetting struct {
+	Key       string    `json:"key"`
+	Value     string    `json:"value"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type Invite struct {
+	ID        string         `json:"id"`
+	TokenHash string         `json:"token_hash"`
+	CreatedBy string         `json:"created_by"`
+	Email     sql.NullString `json:"email"`
+	MaxUses   int64          `json:"max_uses"`
+	Uses      int64          `json:"uses"`
+	ExpiresAt time.Time      `json:"expires_at"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+type NotificationOutbox struct {
+	ID              string                  `json:"id"`
+	UserID          string                  `json:"user_id"`
+	ContactMethodID string                  `json:"contact_method_id"`
+	Event           core.NotificationEvent  `json:"event"`
+	Subject         core.SecretString       `json:"subject"`
+	Body            core.SecretString       `json:"body"`
+	Status          core.NotificationStatus `json:"status"`
+	Attempts        int64                   `json:"attempts"`
+	LastError       sql.NullString          `json:"last_error"`
+	CreatedAt       time.Time               `json:"created_at"`
+	SentAt          sql.NullTime            `json:"sent_at"`
+	HtmlBody        core.NullSecretString   `json:"html_body"`
+	RedactAfterSend bool                    `json:"redact_after_send"`
+}
+
+type OidcLogin struct {
+	StateHash    string         `json:"state_hash"`
+	CodeVerifier string         `json:"code_verifier"`
+	Nonce        string         `json:"nonce"`
+	UserID       sql.NullString `json:"user_id"`
+	RedirectTo   string         `json:"redirect_to"`
+	ExpiresAt    time.Time      `json:"expires_at"`
+}
+
+type ReminderPolicy struct {
+	UserID           string               `json:"user_id"`
+	PrimaryContactID sql.NullString       `json:"primary_contact_id"`
+	Steps            core.EscalationSteps `json:"steps"`
+	QuietHoursStart  sql.NullString       `json:"quiet_hours_start"`
+	QuietHoursEnd    sql.NullString       `json:"quiet_hours_end"`
+	TimeZone         string               `json:"time_zone"`
+	UpdatedAt        time.Time            `json:"updated_at"`
+}
+
+type SchemaMigration struct {
+	Version   int64     `json:"version"`
+	Name      string    `json:"name"`
+	AppliedAt time.Time `json:"applied_at"`
 }
 
 type Session struct {
@@ -47,32 +131,90 @@ type Session struct {
 	CreatedAt sql.NullTime `json:"created_at"`
 }
 
+type SigningKey struct {
+	ID         string            `json:"id"`
+	PrivateKey core.SecretString `json:"private_key"`
+	CreatedAt  time.Time         `json:"created_at"`
+}
+
 type User struct {
-	ID                 string          `json:"id"`
-	Name               string          `json:"name"`
-	Email              string          `json:"email"`
-	PasswordHash       string          `json:"password_hash"`
-	IsPaused           bool            `json:"is_paused"`
-	CheckInInterval    int64           `json:"check_in_interval"`
-	TriggerIntervalNum int64           `json:"trigger_interval_num"`
-	BufferPeriod       int64           `json:"buffer_period"`
-	VerifierQuorum     sql.NullInt64   `json:"verifier_quorum"`
-	LastCheckIn        time.Time       `json:"last_check_in"`
-	CurrentStatus      core.UserStatus `json:"current_status"`
-	CreatedAt          time.Time       `json:"created_at"`
+	ID                 string            `json:"id"`
+	Name               core.SecretString `json:"name"`
+	Email              string            `json:"email"`
+	PasswordHash       string            `json:"password_hash"`
+	IsPaused           bool              `json:"is_paused"`
+	CheckInInterval    int64             `json:"check_in_interval"`
+	TriggerIntervalNum int64             `json:"trigger_interval_num"`
+	BufferPeriod       int64             `json:"buffer_period"`
+	VerifierQuorum     sql.NullInt64     `json:"verifier_quorum"`
+	LastCheckIn        time.Time         `json:"last_check_in"`
+	CurrentStatus      core.UserStatus   `json:"current_status"`
+	CreatedAt          time.Time         `json:"created_at"`
+	Role               core.UserRole     `json:"role"`
+	DisabledAt         sql.NullTime      `json:"disabled_at"`
+	DeletionDueAt      sql.NullTime      `json:"deletion_due_at"`
+	DeletionStartedAt  sql.NullTime      `json:"deletion_started_at"`
+}
+
+type UserIdentity struct {
+	ID          string         `json:"id"`
+	UserID      string         `json:"user_id"`
+	Issuer      string         `json:"issuer"`
+	Subject     string         `json:"subject"`
+	Email       sql.NullString `json:"email"`
+	CreatedAt   time.Time      `json:"created_at"`
+	LastLoginAt sql.NullTime   `json:"last_login_at"`
+}
+
+type UserQuota struct {
+	UserID           string        `json:"user_id"`
+	MaxVaults        sql.NullInt64 `json:"max_vaults"`
+	MaxArtifacts     sql.NullInt64 `json:"max_artifacts"`
+	MaxArtifactBytes sql.NullInt64 `json:"max_artifact_bytes"`
+	MaxStorageBytes  sql.NullInt64 `json:"max_storage_bytes"`
+	UpdatedAt        time.Time     `json:"updated_at"`
 }
 
 type Vault struct {
-	ID        string         `json:"id"`
-	UserID    string         `json:"user_id"`
-	VaultName string         `json:"vault_name"`
-	Hint      sql.NullString `json:"hint"`
-	KdfSalt   string         `json:"kdf_salt"`
-	CreatedAt time.Time      `json:"created_at"`
+	ID             string                `json:"id"`
+	UserID         string                `json:"user_id"`
+	VaultName      string                `json:"vault_name"`
+	Hint           core.NullSecretString `json:"hint"`
+	KdfSalt        string                `json:"kdf_salt"`
+	CreatedAt      time.Time             `json:"created_at"`
+	ShareThreshold sql.NullInt64         `json:"share_threshold"`
+	ShareCount     sql.NullInt64         `json:"share_count"`
+	SealedKey      sql.NullString        `json:"sealed_key"`
+	SealedKeyID    sql.NullString        `json:"sealed_key_id"`
+	UnsealedAt     sql.NullTime          `json:"unsealed_at"`
+	KdfParams      core.KDFParams        `json:"kdf_params"`
 }
 
 type VaultAccess struct {
-	VaultID       string    `json:"vault_id"`
-	BeneficiaryID string    `json:"beneficiary_id"`
-	GrantedAt     time.Time `json:"granted_at"`
+	VaultID        string         `json:"vault_id"`
+	BeneficiaryID  string         `json:"beneficiary_id"`
+	GrantedAt      time.Time      `json:"granted_at"`
+	ShareIndex     sql.NullInt64  `json:"share_index"`
+	EncryptedShare sql.NullString `json:"encrypted_share"`
+	WrappedKey     sql.NullString `json:"wrapped_key"`
+}
+
+type VaultRotation struct {
+	ID          string                `json:"id"`
+	VaultID     string                `json:"vault_id"`
+	KdfSalt     string                `json:"kdf_salt"`
+	KdfParams   core.KDFParams        `json:"kdf_params"`
+	Hint        core.NullSecretString `json:"hint"`
+	ReplaceHint bool                  `json:"replace_hint"`
+	ExpiresAt   time.Time             `json:"expires_at"`
+	CreatedAt   time.Time             `json:"created_at"`
+}
+
+type VaultRotationArtifact struct {
+	RotationID      string               `json:"rotation_id"`
+	ArtifactID      string               `json:"artifact_id"`
+	EncryptedBlob   core.EncryptedBlob   `json:"encrypted_blob"`
+	Iv              string               `json:"iv"`
+	EnvelopeVersion core.EnvelopeVersion `json:"envelope_version"`
+	CreatedAt       time.Time            `json:"created_at"`
 }
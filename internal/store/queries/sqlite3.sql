@@ -2,11 +2,11 @@
 INSERT INTO users (
     id, name, email, password_hash,
     is_paused, check_in_interval, trigger_interval_num, buffer_period, verifier_quorum,
-    last_check_in, current_status
+    last_check_in, current_status, role
 ) VALUES (
     ?, ?, ?, ?,
     ?, ?, ?, ?, ?,
-    ?, ?
+    ?, ?, ?
 ) RETURNING *;
 
 -- name: CreateContactMethod :one
@@ -26,12 +26,16 @@ WHERE id = ? LIMIT 1;
 
 -- name: ListContactMethodsByUserID :many
 SELECT * FROM contact_methods
-WHERE user_id = ?;
+WHERE user_id = ? AND invite_id IS NULL;
+
+-- name: CountUsersByStatus :many
+SELECT current_status, COUNT(*) AS count FROM users
+GROUP BY current_status;
 
--- name: UpdateUserCheckIn :exec
+-- name: UpdateUserCheckIn :execrows
 UPDATE users
 SET last_check_in = ?, current_status = 'ALIVE'
-WHERE id = ?;
+WHERE id = ? AND current_status != 'CONFIRMED_DEAD';
 
 -- name: CreateSession :one
 INSERT INTO sessions (token, user_id, expires_at)
@@ -46,9 +50,15 @@ WHERE s.token = ? AND s.expires_at > CURRENT_TIMESTAMP;
 -- name: DeleteSession :exec
 DELETE FROM sessions WHERE token = ?;
 
+-- name: DeleteUserSessions :execrows
+DELETE FROM sessions WHERE user_id = ?;
+
+-- name: DeleteExpiredSessions :execrows
+DELETE FROM sessions WHERE expires_at <= CURRENT_TIMESTAMP;
+
 -- name: CreateVault :one
-INSERT INTO vaults (id, user_id, vault_name, hint, kdf_salt)
-VALUES (?, ?, ?, ?, ?)
+INSERT INTO vaults (id, user_id, vault_name, hint, kdf_salt, kdf_params, sealed_key, sealed_key_id)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)
 RETURNING *;
 
 -- name: GetVaultsByUser :many
@@ -61,8 +71,8 @@ SELECT * FROM vaults
 WHERE id = ? AND user_id = ?;
 
 -- name: CreateArtifact :one
-INSERT INTO artifacts (id, vault_id, message_type, encrypted_blob, iv)
-VALUES (?, ?, ?, ?, ?)
+INSERT INTO artifacts (id, vault_id, message_type, encrypted_blob, iv, envelope_version)
+VALUES (?, ?, ?, ?, ?, ?)
 RETURNING *;
 
 -- name: GetArtifactsByVault :many
@@ -75,3 +85,519 @@ ORDER BY a.created_at DESC;
 INSERT INTO vault_access (vault_id, beneficiary_id)
 VALUES (?, ?)
 RETURNING *;
+
+-- name: GetContactMethodByID :one
+SELECT * FROM contact_methods
+WHERE id = ? LIMIT 1;
+
+-- name: ListLivenessCandidates :many
+SELECT * FROM users
+WHERE is_paused = FALSE AND current_status != 'CONFIRMED_DEAD' AND disabled_at IS NULL;
+
+-- name: TransitionUserStatus :execrows
+UPDATE users
+SET current_status = sqlc.arg(to_status)
+WHERE id = sqlc.arg(id) AND current_status = sqlc.arg(from_status);
+
+-- name: CountConfirmedVerifiers :one
+SELECT COUNT(*) FROM beneficiaries
+WHERE user_id = ? AND is_verifier = TRUE AND has_confirmed = TRUE;
+
+-- name: ListBeneficiaryContactMethods :many
+SELECT c.* FROM contact_methods c
+JOIN beneficiaries b ON c.beneficiary_id = b.id
+WHERE b.user_id = ?;
+
+-- name: GetReminderPolicy :one
+SELECT * FROM reminder_policies
+WHERE user_id = ?;
+
+-- name: UpsertReminderPolicy :one
+INSERT INTO reminder_policies (
+    user_id, primary_contact_id, steps, quiet_hours_start, quiet_hours_end, time_zone, updated_at
+) VALUES (
+    ?, ?, ?, ?, ?, ?, ?
+)
+ON CONFLICT(user_id) DO UPDATE SET
+    primary_contact_id = excluded.primary_contact_id,
+    steps = excluded.steps,
+    quiet_hours_start = excluded.quiet_hours_start,
+    quiet_hours_end = excluded.quiet_hours_end,
+    time_zone = excluded.time_zone,
+    updated_at = excluded.updated_at
+RETURNING *;
+
+-- name: CreateNotification :one
+INSERT INTO notification_outbox (
+    id, user_id, contact_method_id, event, subject, body, html_body, status, created_at, redact_after_send
+) VALUES (
+    ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+) RETURNING *;
+
+-- name: GetLatestNotificationByEvent :one
+SELECT * FROM notification_outbox
+WHERE user_id = ? AND event = ?
+ORDER BY created_at DESC
+LIMIT 1;
+
+-- name: ListPendingNotifications :many
+SELECT n.* FROM notification_outbox n
+JOIN contact_methods c ON c.id = n.contact_method_id
+WHERE n.status = 'PENDING' AND c.channel != sqlc.arg(skip_channel)
+ORDER BY n.created_at ASC
+LIMIT sqlc.arg(limit);
+
+-- name: CountPendingNotifications :one
+SELECT COUNT(*) FROM notification_outbox
+WHERE status = 'PENDING';
+
+-- name: MarkNotificationSent :exec
+UPDATE notification_outbox
+SET status = 'SENT', attempts = attempts + 1, sent_at = ?, last_error = NULL,
+    body = CASE WHEN redact_after_send THEN '' ELSE body END,
+    html_body = CASE WHEN redact_after_send THEN NULL ELSE html_body END
+WHERE id = ?;
+
+-- name: MarkNotificationFailed :exec
+UPDATE notification_outbox
+SET status = ?, attempts = attempts + 1, last_error = ?
+WHERE id = ?;
+
+-- name: RedactNotification :exec
+UPDATE notification_outbox
+SET body = '', html_body = NULL
+WHERE id = ? AND redact_after_send;
+
+-- name: ResetVerifierConfirmations :exec
+UPDATE beneficiaries
+SET has_confirmed = FALSE, confirmed_at = NULL, verify_token_hash = NULL
+WHERE user_id = ?;
+
+-- name: SetBeneficiaryVerifyToken :exec
+UPDATE beneficiaries
+SET verify_token_hash = ?
+WHERE id = ?;
+
+-- name: GetBeneficiaryByVerifyToken :one
+SELECT * FROM beneficiaries
+WHERE verify_token_hash = ? AND is_verifier = TRUE;
+
+-- name: ConfirmVerifier :execrows
+UPDATE beneficiaries
+SET has_confirmed = TRUE, confirmed_at = ?
+WHERE id = ? AND is_verifier = TRUE AND has_confirmed = FALSE;
+
+-- name: ListContactMethodsByBeneficiaryID :many
+SELECT * FROM contact_methods
+WHERE beneficiary_id = ?;
+
+-- name: DeleteContactMethod :exec
+DELETE FROM contact_methods WHERE id = ?;
+
+-- name: MarkContactMethodVerified :exec
+UPDATE contact_methods
+SET verified_at = ?
+WHERE id = ?;
+
+-- name: UpsertContactVerification :exec
+INSERT INTO contact_verifications (contact_method_id, code_hash, attempts, expires_at)
+VALUES (?, ?, 0, ?)
+ON CONFLICT(contact_method_id) DO UPDATE SET
+    code_hash = excluded.code_hash,
+    attempts = 0,
+    expires_at = excluded.expires_at,
+    created_at = CURRENT_TIMESTAMP;
+
+-- name: GetContactVerification :one
+SELECT * FROM contact_verifications
+WHERE contact_method_id = ?;
+
+-- name: ClaimContactVerificationAttempt :one
+UPDATE contact_verifications
+SET attempts = attempts + 1
+WHERE contact_method_id = ? AND attempts < ? AND expires_at > ?
+RETURNING *;
+
+-- name: DeleteContactVerification :exec
+DELETE FROM contact_verifications WHERE contact_method_id = ?;
+
+-- name: CreateBeneficiary :one
+INSERT INTO beneficiaries (id, user_id, beneficiary_name, is_verifier)
+VALUES (?, ?, ?, ?)
+RETURNING *;
+
+-- name: ListBeneficiariesByUser :many
+SELECT * FROM beneficiaries
+WHERE user_id = ?
+ORDER BY created_at ASC;
+
+-- name: GetBeneficiaryByID :one
+SELECT * FROM beneficiaries
+WHERE id = ? AND user_id = ?;
+
+-- name: UpdateVaultShares :exec
+UPDATE vaults
+SET share_threshold = ?, share_count = ?
+WHERE id = ?;
+
+-- name: ClearVaultShares :exec
+UPDATE vault_access
+SET share_index = NULL, encrypted_share = NULL
+WHERE vault_id = ?;
+
+-- name: UpsertVaultShare :exec
+INSERT INTO vault_access (vault_id, beneficiary_id, share_index, encrypted_share)
+VALUES (?, ?, ?, ?)
+ON CONFLICT(vault_id, beneficiary_id) DO UPDATE SET
+    share_index = excluded.share_index,
+    encrypted_share = excluded.encrypted_share;
+
+-- name: ListVaultShares :many
+SELECT * FROM vault_access
+WHERE vault_id = ? AND share_index IS NOT NULL
+ORDER BY share_index ASC;
+
+-- name: SetBeneficiaryReleaseToken :exec
+UPDATE beneficiaries
+SET release_token_hash = ?
+WHERE id = ?;
+
+-- name: GetBeneficiaryByReleaseToken :one
+SELECT * FROM beneficiaries
+WHERE release_token_hash = ?;
+
+-- name: ListReleasedVaults :many
+SELECT v.id, v.vault_name, v.hint, v.kdf_salt, v.kdf_params, v.share_threshold, v.share_count, a.share_index, a.encrypted_share, a.wrapped_key
+FROM vault_access a
+JOIN vaults v ON a.vault_id = v.id
+WHERE a.beneficiary_id = ? AND v.sealed_key IS NULL
+ORDER BY v.created_at ASC;
+
+-- name: GetReleasedVault :one
+SELECT v.* FROM vaults v
+JOIN vault_access a ON a.vault_id = v.id
+WHERE v.id = ? AND a.beneficiary_id = ? AND v.sealed_key IS NULL;
+
+-- name: SetBeneficiaryPublicKey :exec
+UPDATE beneficiaries
+SET public_key = ?
+WHERE id = ?;
+
+-- name: ClearWrappedKeysForBeneficiary :exec
+UPDATE vault_access
+SET wrapped_key = NULL
+WHERE beneficiary_id = ?;
+
+-- name: UpsertWrappedKey :exec
+INSERT INTO vault_access (vault_id, beneficiary_id, wrapped_key)
+VALUES (?, ?, ?)
+ON CONFLICT(vault_id, beneficiary_id) DO UPDATE SET
+    wrapped_key = excluded.wrapped_key;
+
+-- name: DeleteWrappedKey :exec
+UPDATE vault_access
+SET wrapped_key = NULL
+WHERE vault_id = ? AND beneficiary_id = ?;
+
+-- name: ListVaultRecipients :many
+SELECT b.id, b.beneficiary_name, b.public_key, a.wrapped_key
+FROM beneficiaries b
+LEFT JOIN vault_access a ON a.beneficiary_id = b.id AND a.vault_id = sqlc.arg(vault_id)
+WHERE b.user_id = sqlc.arg(user_id)
+ORDER BY b.created_at ASC;
+
+-- name: UpdateVaultSeal :exec
+UPDATE vaults
+SET sealed_key = ?, sealed_key_id = ?, unsealed_at = ?
+WHERE id = ?;
+
+-- name: UpdateVaultHint :exec
+UPDATE vaults
+SET hint = ?
+WHERE id = ?;
+
+-- name: RewrapVaultKey :execrows
+UPDATE vaults
+SET sealed_key = sqlc.arg(sealed_key), sealed_key_id = sqlc.arg(sealed_key_id)
+WHERE id = sqlc.arg(id) AND sealed_key = sqlc.arg(old_sealed_key);
+
+-- name: CountVaultsSealedUnderOtherKeys :one
+SELECT COUNT(*) FROM vaults
+WHERE sealed_key IS NOT NULL AND sealed_key_id != ?;
+
+-- name: ListSealedVaults :many
+SELECT * FROM vaults
+WHERE sealed_key IS NOT NULL;
+
+-- name: ListReleasedSealedVaults :many
+SELECT v.* FROM vaults v
+JOIN users u ON u.id = v.user_id
+WHERE v.sealed_key IS NOT NULL AND u.current_status = 'CONFIRMED_DEAD';
+
+-- name: ListVaultAccess :many
+SELECT * FROM vault_access
+WHERE vault_id = ?;
+
+-- name: UpdateVaultAccessMaterial :exec
+UPDATE vault_access
+SET wrapped_key = ?, encrypted_share = ?
+WHERE vault_id = ? AND beneficiary_id = ?;
+
+-- name: ListArtifactsByVaultID :many
+SELECT * FROM artifacts
+WHERE vault_id = ?;
+
+-- name: UpdateArtifactBlob :exec
+UPDATE artifacts
+SET encrypted_blob = ?
+WHERE id = ?;
+
+-- name: GetVault :one
+SELECT * FROM vaults
+WHERE id = ?;
+
+-- name: GetDataKey :one
+SELECT * FROM data_keys
+WHERE id = ?;
+
+-- name: UpsertDataKey :exec
+INSERT INTO data_keys (id, wrapped_key, kek_id)
+VALUES (?, ?, ?)
+ON CONFLICT(id) DO UPDATE SET
+    wrapped_key = excluded.wrapped_key,
+    kek_id = excluded.kek_id;
+
+-- name: GetSigningKey :one
+SELECT * FROM signing_keys
+WHERE id = ?;
+
+-- name: CreateSigningKey :exec
+INSERT INTO signing_keys (id, private_key)
+VALUES (?, ?)
+ON CONFLICT(id) DO NOTHING;
+
+-- name: ImportVault :exec
+INSERT INTO vaults (id, user_id, vault_name, hint, kdf_salt, kdf_params, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?);
+
+-- name: ImportArtifact :exec
+INSERT INTO artifacts (id, vault_id, message_type, encrypted_blob, iv, envelope_version, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?);
+
+-- name: UpdateVaultKDF :exec
+UPDATE vaults
+SET kdf_salt = ?, kdf_params = ?
+WHERE id = ?;
+
+-- name: UpdateArtifactCiphertext :exec
+UPDATE artifacts
+SET encrypted_blob = ?, iv = ?, envelope_version = ?
+WHERE id = ? AND vault_id = ?;
+
+-- name: GetArtifact :one
+SELECT * FROM artifacts
+WHERE id = ? AND vault_id = ?;
+
+-- name: ClearVaultWrappedKeys :exec
+UPDATE vault_access
+SET wrapped_key = NULL
+WHERE vault_id = ?;
+
+-- name: CreateVaultRotation :one
+INSERT INTO vault_rotations (id, vault_id, kdf_salt, kdf_params, hint, replace_hint, expires_at)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: GetVaultRotation :one
+SELECT * FROM vault_rotations
+WHERE id = ? AND vault_id = ?;
+
+-- name: GetVaultRotationByVault :one
+SELECT * FROM vault_rotations
+WHERE vault_id = ?;
+
+-- name: DeleteVaultRotation :exec
+DELETE FROM vault_rotations
+WHERE id = ?;
+
+-- name: DeleteExpiredVaultRotations :execrows
+DELETE FROM vault_rotations
+WHERE expires_at <= ?;
+
+-- name: UpsertRotationArtifact :exec
+INSERT INTO vault_rotation_artifacts (rotation_id, artifact_id, encrypted_blob, iv, envelope_version)
+VALUES (?, ?, ?, ?, ?)
+ON CONFLICT(rotation_id, artifact_id) DO UPDATE SET
+    encrypted_blob = excluded.encrypted_blob,
+    iv = excluded.iv,
+    envelope_version = excluded.envelope_version;
+
+-- name: ListRotationArtifacts :many
+SELECT * FROM vault_rotation_artifacts
+WHERE rotation_id = ?;
+
+-- name: CountUsers :one
+SELECT COUNT(*) FROM users;
+
+-- name: CountAdmins :one
+SELECT COUNT(*) FROM users WHERE role = 'ADMIN' AND disabled_at IS NULL AND deletion_due_at IS NULL;
+
+-- name: ListUsers :many
+SELECT * FROM users
+ORDER BY created_at;
+
+-- name: CountActiveSessionsByUser :many
+SELECT user_id, COUNT(*) AS count FROM sessions
+WHERE expires_at > CURRENT_TIMESTAMP
+GROUP BY user_id;
+
+-- name: SetUserRole :execrows
+UPDATE users SET role = ? WHERE id = ?;
+
+-- name: DisableUser :execrows
+UPDATE users SET disabled_at = ? WHERE id = ? AND disabled_at IS NULL;
+
+-- name: EnableUser :execrows
+UPDATE users SET disabled_at = NULL
+WHERE id = ? AND disabled_at IS NOT NULL AND deletion_started_at IS NULL;
+
+-- name: GetInstanceSetting :one
+SELECT value FROM instance_settings WHERE key = ?;
+
+-- name: UpsertInstanceSetting :exec
+INSERT INTO instance_settings (key, value, updated_at)
+VALUES (?, ?, CURRENT_TIMESTAMP)
+ON CONFLICT(key) DO UPDATE SET value = excluded.value, updated_at = excluded.updated_at;
+
+-- name: CreateInvite :one
+INSERT INTO invites (id, token_hash, created_by, email, max_uses, expires_at)
+VALUES (?, ?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: GetInvite :one
+SELECT * FROM invites WHERE id = ?;
+
+-- name: GetInviteByTokenHash :one
+SELECT * FROM invites WHERE token_hash = ?;
+
+-- name: ListInvites :many
+SELECT * FROM invites
+ORDER BY created_at DESC;
+
+-- name: ListInvitesByCreator :many
+SELECT * FROM invites
+WHERE created_by = ?
+ORDER BY created_at DESC;
+
+-- name: CountOpenInvitesByCreator :one
+SELECT COUNT(*) FROM invites
+WHERE created_by = ? AND uses < max_uses AND expires_at > ?;
+
+-- name: RedeemInvite :execrows
+UPDATE invites SET uses = uses + 1
+WHERE id = ? AND uses < max_uses AND expires_at > ?;
+
+-- name: DeleteInvite :exec
+DELETE FROM invites WHERE id = ?;
+
+-- name: CreateInviteContactMethod :one
+INSERT INTO contact_methods (id, user_id, invite_id, channel, destination, metadata, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: GetUserQuota :one
+SELECT * FROM user_quotas WHERE user_id = ?;
+
+-- name: UpsertUserQuota :exec
+INSERT INTO user_quotas (user_id, max_vaults, max_artifacts, max_artifact_bytes, max_storage_bytes, updated_at)
+VALUES (?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+ON CONFLICT(user_id) DO UPDATE SET
+    max_vaults = excluded.max_vaults,
+    max_artifacts = excluded.max_artifacts,
+    max_artifact_bytes = excluded.max_artifact_bytes,
+    max_storage_bytes = excluded.max_storage_bytes,
+    updated_at = excluded.updated_at;
+
+-- name: DeleteUserQuota :exec
+DELETE FROM user_quotas WHERE user_id = ?;
+
+-- name: ListVaultUsage :many
+SELECT
+    v.id, v.vault_name,
+    CAST(COUNT(a.id) AS INTEGER) AS artifacts,
+    CAST(COALESCE(SUM(LENGTH(a.encrypted_blob)), 0) AS INTEGER) AS bytes
+FROM vaults v
+LEFT JOIN artifacts a ON a.vault_id = v.id
+WHERE v.user_id = ?
+GROUP BY v.id
+ORDER BY v.created_at;
+
+-- name: ScheduleUserDeletion :execrows
+UPDATE users SET deletion_due_at = ?
+WHERE id = ? AND deletion_started_at IS NULL;
+
+-- name: CancelUserDeletion :execrows
+UPDATE users SET deletion_due_at = NULL
+WHERE id = ? AND deletion_due_at IS NOT NULL AND deletion_started_at IS NULL;
+
+-- name: ListUsersDueForDeletion :many
+SELECT * FROM users
+WHERE deletion_due_at <= ? AND deletion_started_at IS NULL;
+
+-- name: StartUserDeletion :execrows
+UPDATE users SET deletion_started_at = ?, disabled_at = ?
+WHERE id = ? AND deletion_due_at <= ? AND deletion_started_at IS NULL;
+
+-- name: ListUsersBeingDeleted :many
+SELECT * FROM users
+WHERE deletion_started_at IS NOT NULL;
+
+-- name: CountPendingNotificationsByUser :one
+SELECT COUNT(*) FROM notification_outbox
+WHERE user_id = ? AND status = 'PENDING';
+
+-- name: DeleteUser :exec
+DELETE FROM users WHERE id = ?;
+
+-- name: ListNotificationsByUser :many
+SELECT * FROM notification_outbox
+WHERE user_id = ?
+ORDER BY created_at;
+
+-- name: CreateOIDCLogin :exec
+INSERT INTO oidc_logins (state_hash, code_verifier, nonce, user_id, redirect_to, expires_at)
+VALUES (?, ?, ?, ?, ?, ?);
+
+-- name: TakeOIDCLogin :one
+DELETE FROM oidc_logins
+WHERE state_hash = ? AND expires_at > ?
+RETURNING *;
+
+-- name: DeleteExpiredOIDCLogins :execrows
+DELETE FROM oidc_logins WHERE expires_at <= ?;
+
+-- name: GetUserByIdentity :one
+SELECT u.* FROM users u
+JOIN user_identities i ON i.user_id = u.id
+WHERE i.issuer = ? AND i.subject = ?;
+
+-- name: GetUserIdentity :one
+SELECT * FROM user_identities
+WHERE issuer = ? AND subject = ?;
+
+-- name: CreateUserIdentity :one
+INSERT INTO user_identities (id, user_id, issuer, subject, email, last_login_at)
+VALUES (?, ?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: TouchUserIdentity :exec
+UPDATE user_identities SET email = ?, last_login_at = ?
+WHERE id = ?;
+
+-- name: ListUserIdentities :many
+SELECT * FROM user_identities
+WHERE user_id = ?
+ORDER BY created_at;
+
+-- name: DeleteUserIdentity :execrows
+DELETE FROM user_identities WHERE id = ? AND user_id = ?;
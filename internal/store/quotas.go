@@ -0,0 +1,130 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/vmpyr/afterlight/internal/core"
+)
+
+// The user's limits: defaults with any admin overrides applied. The
+// overrides are nil if none are set.
+func (s *Store) UserQuotaLimits(ctx context.Context, userID string, defaults core.QuotaLimits) (core.QuotaLimits, *core.QuotaOverrides, error) {
+	return quotaLimits(ctx, s, userID, defaults)
+}
+
+func quotaLimits(ctx context.Context, q *Store, userID string, defaults core.QuotaLimits) (core.QuotaLimits, *core.QuotaOverrides, error) {
+	row, err := q.GetUserQuota(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return defaults, nil, nil
+	}
+	if err != nil {
+		return core.QuotaLimits{}, nil, err
+	}
+	o := &core.QuotaOverrides{
+		MaxVaults:        nullInt(row.MaxVaults),
+		MaxArtifacts:     nullInt(row.MaxArtifacts),
+		MaxArtifactBytes: nullInt(row.MaxArtifactBytes),
+		MaxStorageBytes:  nullInt(row.MaxStorageBytes),
+	}
+	return o.Apply(defaults), o, nil
+}
+
+func (s *Store) SetUserQuota(ctx context.Context, userID string, o core.QuotaOverrides) error {
+	if err := core.IsValidQuotaOverrides(o); err != nil {
+		return err
+	}
+	return s.UpsertUserQuota(ctx, UpsertUserQuotaParams{
+		UserID:           userID,
+		MaxVaults:        toNullInt(o.MaxVaults),
+		MaxArtifacts:     toNullInt(o.MaxArtifacts),
+		MaxArtifactBytes: toNullInt(o.MaxArtifactBytes),
+		MaxStorageBytes:  toNullInt(o.MaxStorageBytes),
+	})
+}
+
+// Totals and per-vault usage. Bytes are what is stored, so sealed vaults
+// count their sealing overhead too.
+func (s *Store) UserUsage(ctx context.Context, userID string) (core.Usage, []core.VaultUsage, error) {
+	return userUsage(ctx, s, userID)
+}
+
+func userUsage(ctx context.Context, q *Store, userID string) (core.Usage, []core.VaultUsage, error) {
+	rows, err := q.ListVaultUsage(ctx, userID)
+	if err != nil {
+		return core.Usage{}, nil, err
+	}
+	usage := core.Usage{Vaults: int64(len(rows))}
+	vaults := make([]core.VaultUsage, 0, len(rows))
+	for _, r := range rows {
+		usage.Artifacts += r.Artifacts
+		usage.Bytes += r.Bytes
+		vaults = append(vaults, core.VaultUsage{VaultID: r.ID, VaultName: r.VaultName, Artifacts: r.Artifacts, Bytes: r.Bytes})
+	}
+	return usage, vaults, nil
+}
+
+// Checks the quota and creates the vault in one transaction, so concurrent
+// requests cannot both slip under the limit
+func (s *Store) CreateVaultTx(ctx context.Context, params CreateVaultParams, defaults core.QuotaLimits) (Vault, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Vault{}, err
+	}
+	defer tx.Rollback()
+
+	qTx := s.withTx(tx)
+	if err := checkQuota(ctx, qTx, params.UserID, defaults, 1, 0, 0); err != nil {
+		return Vault{}, err
+	}
+	vault, err := qTx.CreateVault(ctx, params)
+	if err != nil {
+		return Vault{}, err
+	}
+	return vault, tx.Commit()
+}
+
+func (s *Store) CreateArtifactTx(ctx context.Context, userID string, params CreateArtifactParams, defaults core.QuotaLimits) (Artifact, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Artifact{}, err
+	}
+	defer tx.Rollback()
+
+	qTx := s.withTx(tx)
+	if err := checkQuota(ctx, qTx, userID, defaults, 0, 1, int64(len(params.EncryptedBlob))); err != nil {
+		return Artifact{}, err
+	}
+	artifact, err := qTx.CreateArtifact(ctx, params)
+	if err != nil {
+		return Artifact{}, err
+	}
+	return artifact, tx.Commit()
+}
+
+func checkQuota(ctx context.Context, q *Store, userID string, defaults core.QuotaLimits, vaults, artifacts, bytes int64) error {
+	limits, _, err := quotaLimits(ctx, q, userID, defaults)
+	if err != nil {
+		return err
+	}
+	usage, _, err := userUsage(ctx, q, userID)
+	if err != nil {
+		return err
+	}
+	return limits.Allow(usage, vaults, artifacts, bytes)
+}
+
+func nullInt(v sql.NullInt64) *int64 {
+	if !v.Valid {
+		return nil
+	}
+	return &v.Int64
+}
+
+func toNullInt(v *int64) sql.NullInt64 {
+	if v == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: *v, Valid: true}
+}
@@ -0,0 +1,212 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/vmpyr/afterlight/internal/core"
+)
+
+var ErrRotationInProgress = errors.New("a rotation is already in progress for this vault")
+
+// Lists how a set of replacements differs from a vault's artifacts.
+// Unwraps to core.ErrArtifactSetMismatch.
+type ArtifactSetMismatchError struct {
+	Missing    []string // Artifacts without a replacement
+	Unexpected []string // Replacements for artifacts the vault does not have, or duplicates
+}
+
+func (e *ArtifactSetMismatchError) Error() string {
+	var parts []string
+	if len(e.Missing) > 0 {
+		parts = append(parts, "missing: "+strings.Join(e.Missing, ", "))
+	}
+	if len(e.Unexpected) > 0 {
+		parts = append(parts, "unexpected: "+strings.Join(e.Unexpected, ", "))
+	}
+	return fmt.Sprintf("%v (%s)", core.ErrArtifactSetMismatch, strings.Join(parts, "; "))
+}
+
+func (e *ArtifactSetMismatchError) Unwrap() error {
+	return core.ErrArtifactSetMismatch
+}
+
+func diffArtifactSets(current []Artifact, replaced []string) error {
+	var mismatch ArtifactSetMismatchError
+	pending := make(map[string]bool, len(current))
+	for _, a := range current {
+		pending[a.ID] = true
+	}
+	for _, id := range replaced {
+		if !pending[id] {
+			mismatch.Unexpected = append(mismatch.Unexpected, id)
+		}
+		delete(pending, id)
+	}
+	for id := range pending {
+		mismatch.Missing = append(mismatch.Missing, id)
+	}
+	if len(mismatch.Missing) == 0 && len(mismatch.Unexpected) == 0 {
+		return nil
+	}
+	slices.Sort(mismatch.Missing)
+	return &mismatch
+}
+
+// Opens a rotation session, replacing one that has expired
+func (s *Store) StartVaultRotationTx(ctx context.Context, params CreateVaultRotationParams) (VaultRotation, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return VaultRotation{}, err
+	}
+	defer tx.Rollback()
+
+	qTx := s.withTx(tx)
+	existing, err := qTx.GetVaultRotationByVault(ctx, params.VaultID)
+	switch {
+	case err == nil && existing.ExpiresAt.After(time.Now()):
+		return VaultRotation{}, ErrRotationInProgress
+	case err == nil:
+		if err := qTx.DeleteVaultRotation(ctx, existing.ID); err != nil {
+			return VaultRotation{}, err
+		}
+	case !errors.Is(err, sql.ErrNoRows):
+		return VaultRotation{}, err
+	}
+
+	rotation, err := qTx.CreateVaultRotation(ctx, params)
+	if err != nil {
+		return VaultRotation{}, err
+	}
+	return rotation, tx.Commit()
+}
+
+// Key material that replaces a vault's shares and wrapped keys when its
+// passphrase changes (a rotation commit or a re-key). The old material protects
+// the old passphrase, so it is always dropped; whatever is listed here takes
+// its place.
+type VaultKeyMaterial struct {
+	ShareThreshold int64
+	Shares         []UpsertVaultShareParams
+	WrappedKeys    []UpsertWrappedKeyParams
+}
+
+func replaceKeyMaterial(ctx context.Context, q *Store, vaultID string, material VaultKeyMaterial) error {
+	if err := q.ClearVaultShares(ctx, vaultID); err != nil {
+		return err
+	}
+	if err := q.ClearVaultWrappedKeys(ctx, vaultID); err != nil {
+		return err
+	}
+	shares := UpdateVaultSharesParams{ID: vaultID}
+	if len(material.Shares) > 0 {
+		shares.ShareThreshold = sql.NullInt64{Int64: material.ShareThreshold, Valid: true}
+		shares.ShareCount = sql.NullInt64{Int64: int64(len(material.Shares)), Valid: true}
+	}
+	for _, share := range material.Shares {
+		share.VaultID = vaultID
+		if err := q.UpsertVaultShare(ctx, share); err != nil {
+			return err
+		}
+	}
+	if err := q.UpdateVaultShares(ctx, shares); err != nil {
+		return err
+	}
+	for _, key := range material.WrappedKeys {
+		key.VaultID = vaultID
+		if err := q.UpsertWrappedKey(ctx, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Returns ErrRotationInProgress if the vault has a rotation session that has not expired
+func checkNoRotation(ctx context.Context, q *Store, vaultID string) error {
+	rotation, err := q.GetVaultRotationByVault(ctx, vaultID)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return nil
+	case err != nil:
+		return err
+	case rotation.ExpiresAt.After(time.Now()):
+		return ErrRotationInProgress
+	}
+	return nil
+}
+
+// Applies a rotation session in one transaction: every artifact's ciphertext,
+// the salt and KDF, the hint and the key material, then closes the session.
+// If the uploaded replacements do not match the vault's artifacts nothing
+// changes and an *ArtifactSetMismatchError is returned; if the session is gone,
+// sql.ErrNoRows.
+func (s *Store) CommitVaultRotationTx(ctx context.Context, rotation VaultRotation, material VaultKeyMaterial) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	qTx := s.withTx(tx)
+	vaultID := rotation.VaultID
+
+	// The session may have been aborted or committed since it was loaded
+	if _, err := qTx.GetVaultRotation(ctx, GetVaultRotationParams{ID: rotation.ID, VaultID: vaultID}); err != nil {
+		return err
+	}
+
+	current, err := qTx.ListArtifactsByVaultID(ctx, vaultID)
+	if err != nil {
+		return err
+	}
+	replacements, err := qTx.ListRotationArtifacts(ctx, rotation.ID)
+	if err != nil {
+		return err
+	}
+	ids := make([]string, 0, len(replacements))
+	for _, r := range replacements {
+		ids = append(ids, r.ArtifactID)
+	}
+	if err := diffArtifactSets(current, ids); err != nil {
+		return err
+	}
+
+	for _, r := range replacements {
+		if err := qTx.UpdateArtifactCiphertext(ctx, UpdateArtifactCiphertextParams{
+			EncryptedBlob:   r.EncryptedBlob,
+			Iv:              r.Iv,
+			EnvelopeVersion: r.EnvelopeVersion,
+			ID:              r.ArtifactID,
+			VaultID:         vaultID,
+		}); err != nil {
+			return err
+		}
+	}
+	if err := qTx.UpdateVaultKDF(ctx, UpdateVaultKDFParams{
+		KdfSalt:   rotation.KdfSalt,
+		KdfParams: rotation.KdfParams,
+		ID:        vaultID,
+	}); err != nil {
+		return err
+	}
+	if rotation.ReplaceHint {
+		if err := qTx.UpdateVaultHint(ctx, UpdateVaultHintParams{Hint: rotation.Hint, ID: vaultID}); err != nil {
+			return err
+		}
+	}
+
+	if err := replaceKeyMaterial(ctx, qTx, vaultID, material); err != nil {
+		return err
+	}
+
+	if err := qTx.DeleteVaultRotation(ctx, rotation.ID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,111 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/vmpyr/afterlight/internal/core"
+)
+
+func startTestRotation(t *testing.T, s *Store, v testVault) VaultRotation {
+	t.Helper()
+	ctx := context.Background()
+	rotation, err := s.StartVaultRotationTx(ctx, CreateVaultRotationParams{
+		ID:        "rotation",
+		VaultID:   v.Vault.ID,
+		KdfSalt:   "02",
+		KdfParams: core.DefaultKDFParams,
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.UpsertRotationArtifact(ctx, UpsertRotationArtifactParams{
+		RotationID:    rotation.ID,
+		ArtifactID:    v.Artifact,
+		EncryptedBlob: []byte("new"),
+		Iv:            "new",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return rotation
+}
+
+func TestCommitRotationReplacesKeyMaterial(t *testing.T) {
+	s, _ := openEncrypted(t)
+	ctx := context.Background()
+	v := createTestVault(t, s)
+	rotation := startTestRotation(t, s, v)
+
+	material := VaultKeyMaterial{
+		ShareThreshold: 1,
+		Shares: []UpsertVaultShareParams{{
+			BeneficiaryID:  v.Beneficiary,
+			ShareIndex:     sql.NullInt64{Int64: 1, Valid: true},
+			EncryptedShare: sql.NullString{String: "new share", Valid: true},
+		}},
+	}
+	if err := s.CommitVaultRotationTx(ctx, rotation, material); err != nil {
+		t.Fatal(err)
+	}
+
+	access := vaultAccess(t, s, v.Vault.ID)
+	if access.EncryptedShare.String != "new share" {
+		t.Fatalf("share = %q, want the replacement", access.EncryptedShare.String)
+	}
+	if access.WrappedKey.Valid {
+		t.Fatalf("wrapped key under the old passphrase kept: %+v", access)
+	}
+
+	// A second commit of the same session changes nothing
+	if err := s.CommitVaultRotationTx(ctx, rotation, VaultKeyMaterial{}); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("committing a closed rotation = %v, want sql.ErrNoRows", err)
+	}
+	if access := vaultAccess(t, s, v.Vault.ID); access.EncryptedShare.String != "new share" {
+		t.Fatalf("share changed by a repeated commit: %+v", access)
+	}
+}
+
+func TestKeyMaterialLockedDuringRotation(t *testing.T) {
+	s, _ := openEncrypted(t)
+	ctx := context.Background()
+	v := createTestVault(t, s)
+	startTestRotation(t, s, v)
+
+	err := s.SetVaultSharesTx(ctx, v.Vault.ID, 1, []UpsertVaultShareParams{{
+		BeneficiaryID:  v.Beneficiary,
+		ShareIndex:     sql.NullInt64{Int64: 1, Valid: true},
+		EncryptedShare: sql.NullString{String: "new share", Valid: true},
+	}})
+	if !errors.Is(err, ErrRotationInProgress) {
+		t.Fatalf("SetVaultSharesTx during a rotation = %v, want ErrRotationInProgress", err)
+	}
+	err = s.SetWrappedKeysTx(ctx, v.Vault.ID, []UpsertWrappedKeyParams{{
+		BeneficiaryID: v.Beneficiary,
+		WrappedKey:    sql.NullString{String: "new key", Valid: true},
+	}})
+	if !errors.Is(err, ErrRotationInProgress) {
+		t.Fatalf("SetWrappedKeysTx during a rotation = %v, want ErrRotationInProgress", err)
+	}
+	if err := s.ClearVaultSharesTx(ctx, v.Vault.ID); !errors.Is(err, ErrRotationInProgress) {
+		t.Fatalf("ClearVaultSharesTx during a rotation = %v, want ErrRotationInProgress", err)
+	}
+	err = s.DeleteWrappedKeyTx(ctx, DeleteWrappedKeyParams{VaultID: v.Vault.ID, BeneficiaryID: v.Beneficiary})
+	if !errors.Is(err, ErrRotationInProgress) {
+		t.Fatalf("DeleteWrappedKeyTx during a rotation = %v, want ErrRotationInProgress", err)
+	}
+	err = s.RewriteVaultMaterialTx(ctx, v.Vault.ID, func(field string, data []byte) ([]byte, error) {
+		return append([]byte("sealed "), data...), nil
+	}, UpdateVaultSealParams{SealedKey: sql.NullString{String: "key", Valid: true}, SealedKeyID: sql.NullString{String: "k1", Valid: true}})
+	if !errors.Is(err, ErrRotationInProgress) {
+		t.Fatalf("RewriteVaultMaterialTx during a rotation = %v, want ErrRotationInProgress", err)
+	}
+
+	access := vaultAccess(t, s, v.Vault.ID)
+	if access.EncryptedShare.String != "old share" || access.WrappedKey.String != "old key" {
+		t.Fatalf("key material changed during a rotation: %+v", access)
+	}
+}
@@ -0,0 +1,42 @@
+package store
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+
+	"github.com/vmpyr/afterlight/internal/core"
+)
+
+const bundleSigningKeyID = "bundles"
+
+// Returns the key exported vault bundles are signed with, creating it on first use
+func (s *Store) BundleSigningKey(ctx context.Context) (ed25519.PrivateKey, error) {
+	stored, err := s.GetSigningKey(ctx, bundleSigningKeyID)
+	if errors.Is(err, sql.ErrNoRows) {
+		seed := make([]byte, ed25519.SeedSize)
+		if _, err := rand.Read(seed); err != nil {
+			return nil, err
+		}
+		// Another request may have won the race; the stored key is the one to use
+		if err := s.CreateSigningKey(ctx, CreateSigningKeyParams{
+			ID:         bundleSigningKeyID,
+			PrivateKey: core.SecretString(base64.StdEncoding.EncodeToString(seed)),
+		}); err != nil {
+			return nil, err
+		}
+		stored, err = s.GetSigningKey(ctx, bundleSigningKeyID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	seed, err := base64.StdEncoding.DecodeString(string(stored.PrivateKey))
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return nil, errors.New("stored bundle signing key is invalid")
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
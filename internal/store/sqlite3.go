@@ -1,12 +1,15 @@
 package store
 
 import (
+	"context"
 	"database/sql"
 	_ "embed"
 	"fmt"
-	"log"
+	"log/slog"
+	"time"
 
 	_ "github.com/mattn/go-sqlite3"
+	"github.com/vmpyr/afterlight/internal/keys"
 )
 
 //go:embed migrations/schema.sql
@@ -17,8 +20,11 @@ type SQLiteStorage struct {
 	db *sql.DB
 }
 
-func NewStorage(dbPath string) (*SQLiteStorage, error) {
-	dsn := fmt.Sprintf("file:%s?mode=rwc&_journal_mode=WAL&_busy_timeout=5000", dbPath)
+// kek encrypts the key used for sensitive columns; nil leaves them in plaintext
+func NewStorage(dbPath string, kek *keys.Keyring) (*SQLiteStorage, error) {
+	// Foreign keys are per connection, so they are set in the DSN for every
+	// connection in the pool; the PRAGMA in the schema only covers the first
+	dsn := fmt.Sprintf("file:%s?mode=rwc&_journal_mode=WAL&_busy_timeout=5000&_foreign_keys=on&_secure_delete=on", dbPath)
 
 	db, err := sql.Open("sqlite3", dsn)
 	if err != nil {
@@ -33,7 +39,15 @@ func NewStorage(dbPath string) (*SQLiteStorage, error) {
 		return nil, fmt.Errorf("failed to apply schema: %w", err)
 	}
 
-	log.Println("Database connected and schema applied")
+	if err := loadColumnKey(db, kek); err != nil {
+		return nil, fmt.Errorf("failed to load encryption key: %w", err)
+	}
+
+	if err := migrate(db); err != nil {
+		return nil, fmt.Errorf("failed to migrate schema: %w", err)
+	}
+
+	slog.Info("database connected and schema applied", "path", dbPath)
 	return &SQLiteStorage{db: db}, nil
 }
 
@@ -41,6 +55,13 @@ func (s *SQLiteStorage) Close() error {
 	return s.db.Close()
 }
 
+// ProbeWrite rewrites the health_probe row to check the database accepts writes
+func (s *SQLiteStorage) ProbeWrite(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, `INSERT INTO health_probe (id, checked_at) VALUES (1, ?)
+		ON CONFLICT(id) DO UPDATE SET checked_at = excluded.checked_at`, time.Now().UTC())
+	return err
+}
+
 func (s *SQLiteStorage) DB() *sql.DB {
 	return s.db
 }
@@ -53,7 +74,7 @@ type Store struct {
 
 func NewStore(db *sql.DB) *Store {
 	return &Store{
-		Queries: New(db),
+		Queries: New(tracedDB{db}),
 		db:      db,
 	}
 }
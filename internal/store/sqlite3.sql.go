@@ -13,18 +13,264 @@ import (
 	"github.com/vmpyr/afterlight/internal/core"
 )
 
+const cancelUserDeletion = `-- name: CancelUserDeletion :execrows
+UPDATE users SET deletion_due_at = NULL
+WHERE id = ? AND deletion_due_at IS NOT NULL AND deletion_started_at IS NULL
+`
+
+func (q *Queries) CancelUserDeletion(ctx context.Context, id string) (int64, error) {
+	result, err := q.exec(ctx, q.cancelUserDeletionStmt, cancelUserDeletion, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const claimContactVerificationAttempt = `-- name: ClaimContactVerificationAttempt :one
+UPDATE contact_verifications
+SET attempts = attempts + 1
+WHERE contact_method_id = ? AND attempts < ? AND expires_at > ?
+RETURNING contact_method_id, code_hash, attempts, expires_at, created_at
+`
+
+type ClaimContactVerificationAttemptParams struct {
+	ContactMethodID string    `json:"contact_method_id"`
+	Attempts        int64     `json:"attempts"`
+	ExpiresAt       time.Time `json:"expires_at"`
+}
+
+func (q *Queries) ClaimContactVerificationAttempt(ctx context.Context, arg ClaimContactVerificationAttemptParams) (ContactVerification, error) {
+	row := q.queryRow(ctx, q.claimContactVerificationAttemptStmt, claimContactVerificationAttempt, arg.ContactMethodID, arg.Attempts, arg.ExpiresAt)
+	var i ContactVerification
+	err := row.Scan(
+		&i.ContactMethodID,
+		&i.CodeHash,
+		&i.Attempts,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const clearVaultShares = `-- name: ClearVaultShares :exec
+UPDATE vault_access
+SET share_index = NULL, encrypted_share = NULL
+WHERE vault_id = ?
+`
+
+func (q *Queries) ClearVaultShares(ctx context.Context, vaultID string) error {
+	_, err := q.exec(ctx, q.clearVaultSharesStmt, clearVaultShares, vaultID)
+	return err
+}
+
+const clearVaultWrappedKeys = `-- name: ClearVaultWrappedKeys :exec
+UPDATE vault_access
+SET wrapped_key = NULL
+WHERE vault_id = ?
+`
+
+func (q *Queries) ClearVaultWrappedKeys(ctx context.Context, vaultID string) error {
+	_, err := q.exec(ctx, q.clearVaultWrappedKeysStmt, clearVaultWrappedKeys, vaultID)
+	return err
+}
+
+const clearWrappedKeysForBeneficiary = `-- name: ClearWrappedKeysForBeneficiary :exec
+UPDATE vault_access
+SET wrapped_key = NULL
+WHERE beneficiary_id = ?
+`
+
+func (q *Queries) ClearWrappedKeysForBeneficiary(ctx context.Context, beneficiaryID string) error {
+	_, err := q.exec(ctx, q.clearWrappedKeysForBeneficiaryStmt, clearWrappedKeysForBeneficiary, beneficiaryID)
+	return err
+}
+
+const confirmVerifier = `-- name: ConfirmVerifier :execrows
+UPDATE beneficiaries
+SET has_confirmed = TRUE, confirmed_at = ?
+WHERE id = ? AND is_verifier = TRUE AND has_confirmed = FALSE
+`
+
+type ConfirmVerifierParams struct {
+	ConfirmedAt sql.NullTime `json:"confirmed_at"`
+	ID          string       `json:"id"`
+}
+
+func (q *Queries) ConfirmVerifier(ctx context.Context, arg ConfirmVerifierParams) (int64, error) {
+	result, err := q.exec(ctx, q.confirmVerifierStmt, confirmVerifier, arg.ConfirmedAt, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const countActiveSessionsByUser = `-- name: CountActiveSessionsByUser :many
+SELECT user_id, COUNT(*) AS count FROM sessions
+WHERE expires_at > CURRENT_TIMESTAMP
+GROUP BY user_id
+`
+
+type CountActiveSessionsByUserRow struct {
+	UserID string `json:"user_id"`
+	Count  int64  `json:"count"`
+}
+
+func (q *Queries) CountActiveSessionsByUser(ctx context.Context) ([]CountActiveSessionsByUserRow, error) {
+	rows, err := q.query(ctx, q.countActiveSessionsByUserStmt, countActiveSessionsByUser)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CountActiveSessionsByUserRow
+	for rows.Next() {
+		var i CountActiveSessionsByUserRow
+		if err := rows.Scan(&i.UserID, &i.Count); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const countAdmins = `-- name: CountAdmins :one
+SELECT COUNT(*) FROM users WHERE role = 'ADMIN' AND disabled_at IS NULL AND deletion_due_at IS NULL
+`
+
+func (q *Queries) CountAdmins(ctx context.Context) (int64, error) {
+	row := q.queryRow(ctx, q.countAdminsStmt, countAdmins)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countConfirmedVerifiers = `-- name: CountConfirmedVerifiers :one
+SELECT COUNT(*) FROM beneficiaries
+WHERE user_id = ? AND is_verifier = TRUE AND has_confirmed = TRUE
+`
+
+func (q *Queries) CountConfirmedVerifiers(ctx context.Context, userID string) (int64, error) {
+	row := q.queryRow(ctx, q.countConfirmedVerifiersStmt, countConfirmedVerifiers, userID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countOpenInvitesByCreator = `-- name: CountOpenInvitesByCreator :one
+SELECT COUNT(*) FROM invites
+WHERE created_by = ? AND uses < max_uses AND expires_at > ?
+`
+
+type CountOpenInvitesByCreatorParams struct {
+	CreatedBy string    `json:"created_by"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func (q *Queries) CountOpenInvitesByCreator(ctx context.Context, arg CountOpenInvitesByCreatorParams) (int64, error) {
+	row := q.queryRow(ctx, q.countOpenInvitesByCreatorStmt, countOpenInvitesByCreator, arg.CreatedBy, arg.ExpiresAt)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countPendingNotifications = `-- name: CountPendingNotifications :one
+SELECT COUNT(*) FROM notification_outbox
+WHERE status = 'PENDING'
+`
+
+func (q *Queries) CountPendingNotifications(ctx context.Context) (int64, error) {
+	row := q.queryRow(ctx, q.countPendingNotificationsStmt, countPendingNotifications)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countPendingNotificationsByUser = `-- name: CountPendingNotificationsByUser :one
+SELECT COUNT(*) FROM notification_outbox
+WHERE user_id = ? AND status = 'PENDING'
+`
+
+func (q *Queries) CountPendingNotificationsByUser(ctx context.Context, userID string) (int64, error) {
+	row := q.queryRow(ctx, q.countPendingNotificationsByUserStmt, countPendingNotificationsByUser, userID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countUsers = `-- name: CountUsers :one
+SELECT COUNT(*) FROM users
+`
+
+func (q *Queries) CountUsers(ctx context.Context) (int64, error) {
+	row := q.queryRow(ctx, q.countUsersStmt, countUsers)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countUsersByStatus = `-- name: CountUsersByStatus :many
+SELECT current_status, COUNT(*) AS count FROM users
+GROUP BY current_status
+`
+
+type CountUsersByStatusRow struct {
+	CurrentStatus core.UserStatus `json:"current_status"`
+	Count         int64           `json:"count"`
+}
+
+func (q *Queries) CountUsersByStatus(ctx context.Context) ([]CountUsersByStatusRow, error) {
+	rows, err := q.query(ctx, q.countUsersByStatusStmt, countUsersByStatus)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CountUsersByStatusRow
+	for rows.Next() {
+		var i CountUsersByStatusRow
+		if err := rows.Scan(&i.CurrentStatus, &i.Count); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const countVaultsSealedUnderOtherKeys = `-- name: CountVaultsSealedUnderOtherKeys :one
+SELECT COUNT(*) FROM vaults
+WHERE sealed_key IS NOT NULL AND sealed_key_id != ?
+`
+
+func (q *Queries) CountVaultsSealedUnderOtherKeys(ctx context.Context, sealedKeyID sql.NullString) (int64, error) {
+	row := q.queryRow(ctx, q.countVaultsSealedUnderOtherKeysStmt, countVaultsSealedUnderOtherKeys, sealedKeyID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createArtifact = `-- name: CreateArtifact :one
-INSERT INTO artifacts (id, vault_id, message_type, encrypted_blob, iv)
-VALUES (?, ?, ?, ?, ?)
-RETURNING id, vault_id, message_type, encrypted_blob, iv, created_at
+INSERT INTO artifacts (id, vault_id, message_type, encrypted_blob, iv, envelope_version)
+VALUES (?, ?, ?, ?, ?, ?)
+RETURNING id, vault_id, message_type, encrypted_blob, iv, created_at, envelope_version
 `
 
 type CreateArtifactParams struct {
-	ID            string             `json:"id"`
-	VaultID       string             `json:"vault_id"`
-	MessageType   core.MessageType   `json:"message_type"`
-	EncryptedBlob core.EncryptedBlob `json:"encrypted_blob"`
-	Iv            string             `json:"iv"`
+	ID              string               `json:"id"`
+	VaultID         string               `json:"vault_id"`
+	MessageType     core.MessageType     `json:"message_type"`
+	EncryptedBlob   core.EncryptedBlob   `json:"encrypted_blob"`
+	Iv              string               `json:"iv"`
+	EnvelopeVersion core.EnvelopeVersion `json:"envelope_version"`
 }
 
 func (q *Queries) CreateArtifact(ctx context.Context, arg CreateArtifactParams) (Artifact, error) {
@@ -34,6 +280,7 @@ func (q *Queries) CreateArtifact(ctx context.Context, arg CreateArtifactParams)
 		arg.MessageType,
 		arg.EncryptedBlob,
 		arg.Iv,
+		arg.EnvelopeVersion,
 	)
 	var i Artifact
 	err := row.Scan(
@@ -43,6 +290,43 @@ func (q *Queries) CreateArtifact(ctx context.Context, arg CreateArtifactParams)
 		&i.EncryptedBlob,
 		&i.Iv,
 		&i.CreatedAt,
+		&i.EnvelopeVersion,
+	)
+	return i, err
+}
+
+const createBeneficiary = `-- name: CreateBeneficiary :one
+INSERT INTO beneficiaries (id, user_id, beneficiary_name, is_verifier)
+VALUES (?, ?, ?, ?)
+RETURNING id, user_id, beneficiary_name, is_verifier, has_confirmed, confirmed_at, created_at, release_token_hash, public_key, verify_token_hash
+`
+
+type CreateBeneficiaryParams struct {
+	ID              string            `json:"id"`
+	UserID          string            `json:"user_id"`
+	BeneficiaryName core.SecretString `json:"beneficiary_name"`
+	IsVerifier      sql.NullBool      `json:"is_verifier"`
+}
+
+func (q *Queries) CreateBeneficiary(ctx context.Context, arg CreateBeneficiaryParams) (Beneficiary, error) {
+	row := q.queryRow(ctx, q.createBeneficiaryStmt, createBeneficiary,
+		arg.ID,
+		arg.UserID,
+		arg.BeneficiaryName,
+		arg.IsVerifier,
+	)
+	var i Beneficiary
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.BeneficiaryName,
+		&i.IsVerifier,
+		&i.HasConfirmed,
+		&i.ConfirmedAt,
+		&i.CreatedAt,
+		&i.ReleaseTokenHash,
+		&i.PublicKey,
+		&i.VerifyTokenHash,
 	)
 	return i, err
 }
@@ -52,17 +336,17 @@ INSERT INTO contact_methods (
     id, user_id, beneficiary_id, channel, destination, metadata, created_at
 ) VALUES (
     ?, ?, ?, ?, ?, ?, ?
-) RETURNING id, user_id, beneficiary_id, channel, destination, metadata, created_at
+) RETURNING id, user_id, beneficiary_id, channel, destination, metadata, created_at, verified_at, invite_id
 `
 
 type CreateContactMethodParams struct {
-	ID            string         `json:"id"`
-	UserID        sql.NullString `json:"user_id"`
-	BeneficiaryID sql.NullString `json:"beneficiary_id"`
-	Channel       string         `json:"channel"`
-	Destination   string         `json:"destination"`
-	Metadata      core.Metadata  `json:"metadata"`
-	CreatedAt     time.Time      `json:"created_at"`
+	ID            string            `json:"id"`
+	UserID        sql.NullString    `json:"user_id"`
+	BeneficiaryID sql.NullString    `json:"beneficiary_id"`
+	Channel       core.Channel      `json:"channel"`
+	Destination   core.SecretString `json:"destination"`
+	Metadata      core.Metadata     `json:"metadata"`
+	CreatedAt     time.Time         `json:"created_at"`
 }
 
 func (q *Queries) CreateContactMethod(ctx context.Context, arg CreateContactMethodParams) (ContactMethod, error) {
@@ -84,10 +368,170 @@ func (q *Queries) CreateContactMethod(ctx context.Context, arg CreateContactMeth
 		&i.Destination,
 		&i.Metadata,
 		&i.CreatedAt,
+		&i.VerifiedAt,
+		&i.InviteID,
+	)
+	return i, err
+}
+
+const createInvite = `-- name: CreateInvite :one
+INSERT INTO invites (id, token_hash, created_by, email, max_uses, expires_at)
+VALUES (?, ?, ?, ?, ?, ?)
+RETURNING id, token_hash, created_by, email, max_uses, uses, expires_at, created_at
+`
+
+type CreateInviteParams struct {
+	ID        string         `json:"id"`
+	TokenHash string         `json:"token_hash"`
+	CreatedBy string         `json:"created_by"`
+	Email     sql.NullString `json:"email"`
+	MaxUses   int64          `json:"max_uses"`
+	ExpiresAt time.Time      `json:"expires_at"`
+}
+
+func (q *Queries) CreateInvite(ctx context.Context, arg CreateInviteParams) (Invite, error) {
+	row := q.queryRow(ctx, q.createInviteStmt, createInvite,
+		arg.ID,
+		arg.TokenHash,
+		arg.CreatedBy,
+		arg.Email,
+		arg.MaxUses,
+		arg.ExpiresAt,
+	)
+	var i Invite
+	err := row.Scan(
+		&i.ID,
+		&i.TokenHash,
+		&i.CreatedBy,
+		&i.Email,
+		&i.MaxUses,
+		&i.Uses,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createInviteContactMethod = `-- name: CreateInviteContactMethod :one
+INSERT INTO contact_methods (id, user_id, invite_id, channel, destination, metadata, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+RETURNING id, user_id, beneficiary_id, channel, destination, metadata, created_at, verified_at, invite_id
+`
+
+type CreateInviteContactMethodParams struct {
+	ID          string            `json:"id"`
+	UserID      sql.NullString    `json:"user_id"`
+	InviteID    sql.NullString    `json:"invite_id"`
+	Channel     core.Channel      `json:"channel"`
+	Destination core.SecretString `json:"destination"`
+	Metadata    core.Metadata     `json:"metadata"`
+	CreatedAt   time.Time         `json:"created_at"`
+}
+
+func (q *Queries) CreateInviteContactMethod(ctx context.Context, arg CreateInviteContactMethodParams) (ContactMethod, error) {
+	row := q.queryRow(ctx, q.createInviteContactMethodStmt, createInviteContactMethod,
+		arg.ID,
+		arg.UserID,
+		arg.InviteID,
+		arg.Channel,
+		arg.Destination,
+		arg.Metadata,
+		arg.CreatedAt,
+	)
+	var i ContactMethod
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.BeneficiaryID,
+		&i.Channel,
+		&i.Destination,
+		&i.Metadata,
+		&i.CreatedAt,
+		&i.VerifiedAt,
+		&i.InviteID,
+	)
+	return i, err
+}
+
+const createNotification = `-- name: CreateNotification :one
+INSERT INTO notification_outbox (
+    id, user_id, contact_method_id, event, subject, body, html_body, status, created_at, redact_after_send
+) VALUES (
+    ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+) RETURNING id, user_id, contact_method_id, event, subject, body, status, attempts, last_error, created_at, sent_at, html_body, redact_after_send
+`
+
+type CreateNotificationParams struct {
+	ID              string                  `json:"id"`
+	UserID          string                  `json:"user_id"`
+	ContactMethodID string                  `json:"contact_method_id"`
+	Event           core.NotificationEvent  `json:"event"`
+	Subject         core.SecretString       `json:"subject"`
+	Body            core.SecretString       `json:"body"`
+	HtmlBody        core.NullSecretString   `json:"html_body"`
+	Status          core.NotificationStatus `json:"status"`
+	CreatedAt       time.Time               `json:"created_at"`
+	RedactAfterSend bool                    `json:"redact_after_send"`
+}
+
+func (q *Queries) CreateNotification(ctx context.Context, arg CreateNotificationParams) (NotificationOutbox, error) {
+	row := q.queryRow(ctx, q.createNotificationStmt, createNotification,
+		arg.ID,
+		arg.UserID,
+		arg.ContactMethodID,
+		arg.Event,
+		arg.Subject,
+		arg.Body,
+		arg.HtmlBody,
+		arg.Status,
+		arg.CreatedAt,
+		arg.RedactAfterSend,
+	)
+	var i NotificationOutbox
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.ContactMethodID,
+		&i.Event,
+		&i.Subject,
+		&i.Body,
+		&i.Status,
+		&i.Attempts,
+		&i.LastError,
+		&i.CreatedAt,
+		&i.SentAt,
+		&i.HtmlBody,
+		&i.RedactAfterSend,
 	)
 	return i, err
 }
 
+const createOIDCLogin = `-- name: CreateOIDCLogin :exec
+INSERT INTO oidc_logins (state_hash, code_verifier, nonce, user_id, redirect_to, expires_at)
+VALUES (?, ?, ?, ?, ?, ?)
+`
+
+type CreateOIDCLoginParams struct {
+	StateHash    string         `json:"state_hash"`
+	CodeVerifier string         `json:"code_verifier"`
+	Nonce        string         `json:"nonce"`
+	UserID       sql.NullString `json:"user_id"`
+	RedirectTo   string         `json:"redirect_to"`
+	ExpiresAt    time.Time      `json:"expires_at"`
+}
+
+func (q *Queries) CreateOIDCLogin(ctx context.Context, arg CreateOIDCLoginParams) error {
+	_, err := q.exec(ctx, q.createOIDCLoginStmt, createOIDCLogin,
+		arg.StateHash,
+		arg.CodeVerifier,
+		arg.Nonce,
+		arg.UserID,
+		arg.RedirectTo,
+		arg.ExpiresAt,
+	)
+	return err
+}
+
 const createSession = `-- name: CreateSession :one
 INSERT INTO sessions (token, user_id, expires_at)
 VALUES (?, ?, ?)
@@ -112,30 +556,47 @@ func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) (S
 	return i, err
 }
 
+const createSigningKey = `-- name: CreateSigningKey :exec
+INSERT INTO signing_keys (id, private_key)
+VALUES (?, ?)
+ON CONFLICT(id) DO NOTHING
+`
+
+type CreateSigningKeyParams struct {
+	ID         string            `json:"id"`
+	PrivateKey core.SecretString `json:"private_key"`
+}
+
+func (q *Queries) CreateSigningKey(ctx context.Context, arg CreateSigningKeyParams) error {
+	_, err := q.exec(ctx, q.createSigningKeyStmt, createSigningKey, arg.ID, arg.PrivateKey)
+	return err
+}
+
 const createUser = `-- name: CreateUser :one
 INSERT INTO users (
     id, name, email, password_hash,
     is_paused, check_in_interval, trigger_interval_num, buffer_period, verifier_quorum,
-    last_check_in, current_status
+    last_check_in, current_status, role
 ) VALUES (
     ?, ?, ?, ?,
     ?, ?, ?, ?, ?,
-    ?, ?
-) RETURNING id, name, email, password_hash, is_paused, check_in_interval, trigger_interval_num, buffer_period, verifier_quorum, last_check_in, current_status, created_at
+    ?, ?, ?
+) RETURNING id, name, email, password_hash, is_paused, check_in_interval, trigger_interval_num, buffer_period, verifier_quorum, last_check_in, current_status, created_at, role, disabled_at, deletion_due_at, deletion_started_at
 `
 
 type CreateUserParams struct {
-	ID                 string          `json:"id"`
-	Name               string          `json:"name"`
-	Email              string          `json:"email"`
-	PasswordHash       string          `json:"password_hash"`
-	IsPaused           bool            `json:"is_paused"`
-	CheckInInterval    int64           `json:"check_in_interval"`
-	TriggerIntervalNum int64           `json:"trigger_interval_num"`
-	BufferPeriod       int64           `json:"buffer_period"`
-	VerifierQuorum     sql.NullInt64   `json:"verifier_quorum"`
-	LastCheckIn        time.Time       `json:"last_check_in"`
-	CurrentStatus      core.UserStatus `json:"current_status"`
+	ID                 string            `json:"id"`
+	Name               core.SecretString `json:"name"`
+	Email              string            `json:"email"`
+	PasswordHash       string            `json:"password_hash"`
+	IsPaused           bool              `json:"is_paused"`
+	CheckInInterval    int64             `json:"check_in_interval"`
+	TriggerIntervalNum int64             `json:"trigger_interval_num"`
+	BufferPeriod       int64             `json:"buffer_period"`
+	VerifierQuorum     sql.NullInt64     `json:"verifier_quorum"`
+	LastCheckIn        time.Time         `json:"last_check_in"`
+	CurrentStatus      core.UserStatus   `json:"current_status"`
+	Role               core.UserRole     `json:"role"`
 }
 
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
@@ -151,6 +612,7 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		arg.VerifierQuorum,
 		arg.LastCheckIn,
 		arg.CurrentStatus,
+		arg.Role,
 	)
 	var i User
 	err := row.Scan(
@@ -166,22 +628,66 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.LastCheckIn,
 		&i.CurrentStatus,
 		&i.CreatedAt,
+		&i.Role,
+		&i.DisabledAt,
+		&i.DeletionDueAt,
+		&i.DeletionStartedAt,
+	)
+	return i, err
+}
+
+const createUserIdentity = `-- name: CreateUserIdentity :one
+INSERT INTO user_identities (id, user_id, issuer, subject, email, last_login_at)
+VALUES (?, ?, ?, ?, ?, ?)
+RETURNING id, user_id, issuer, subject, email, created_at, last_login_at
+`
+
+type CreateUserIdentityParams struct {
+	ID          string         `json:"id"`
+	UserID      string         `json:"user_id"`
+	Issuer      string         `json:"issuer"`
+	Subject     string         `json:"subject"`
+	Email       sql.NullString `json:"email"`
+	LastLoginAt sql.NullTime   `json:"last_login_at"`
+}
+
+func (q *Queries) CreateUserIdentity(ctx context.Context, arg CreateUserIdentityParams) (UserIdentity, error) {
+	row := q.queryRow(ctx, q.createUserIdentityStmt, createUserIdentity,
+		arg.ID,
+		arg.UserID,
+		arg.Issuer,
+		arg.Subject,
+		arg.Email,
+		arg.LastLoginAt,
+	)
+	var i UserIdentity
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Issuer,
+		&i.Subject,
+		&i.Email,
+		&i.CreatedAt,
+		&i.LastLoginAt,
 	)
 	return i, err
 }
 
 const createVault = `-- name: CreateVault :one
-INSERT INTO vaults (id, user_id, vault_name, hint, kdf_salt)
-VALUES (?, ?, ?, ?, ?)
-RETURNING id, user_id, vault_name, hint, kdf_salt, created_at
+INSERT INTO vaults (id, user_id, vault_name, hint, kdf_salt, kdf_params, sealed_key, sealed_key_id)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING id, user_id, vault_name, hint, kdf_salt, created_at, share_threshold, share_count, sealed_key, sealed_key_id, unsealed_at, kdf_params
 `
 
 type CreateVaultParams struct {
-	ID        string         `json:"id"`
-	UserID    string         `json:"user_id"`
-	VaultName string         `json:"vault_name"`
-	Hint      sql.NullString `json:"hint"`
-	KdfSalt   string         `json:"kdf_salt"`
+	ID          string                `json:"id"`
+	UserID      string                `json:"user_id"`
+	VaultName   string                `json:"vault_name"`
+	Hint        core.NullSecretString `json:"hint"`
+	KdfSalt     string                `json:"kdf_salt"`
+	KdfParams   core.KDFParams        `json:"kdf_params"`
+	SealedKey   sql.NullString        `json:"sealed_key"`
+	SealedKeyID sql.NullString        `json:"sealed_key_id"`
 }
 
 func (q *Queries) CreateVault(ctx context.Context, arg CreateVaultParams) (Vault, error) {
@@ -191,6 +697,9 @@ func (q *Queries) CreateVault(ctx context.Context, arg CreateVaultParams) (Vault
 		arg.VaultName,
 		arg.Hint,
 		arg.KdfSalt,
+		arg.KdfParams,
+		arg.SealedKey,
+		arg.SealedKeyID,
 	)
 	var i Vault
 	err := row.Scan(
@@ -200,6 +709,12 @@ func (q *Queries) CreateVault(ctx context.Context, arg CreateVaultParams) (Vault
 		&i.Hint,
 		&i.KdfSalt,
 		&i.CreatedAt,
+		&i.ShareThreshold,
+		&i.ShareCount,
+		&i.SealedKey,
+		&i.SealedKeyID,
+		&i.UnsealedAt,
+		&i.KdfParams,
 	)
 	return i, err
 }
@@ -207,7 +722,7 @@ func (q *Queries) CreateVault(ctx context.Context, arg CreateVaultParams) (Vault
 const createVaultAccess = `-- name: CreateVaultAccess :one
 INSERT INTO vault_access (vault_id, beneficiary_id)
 VALUES (?, ?)
-RETURNING vault_id, beneficiary_id, granted_at
+RETURNING vault_id, beneficiary_id, granted_at, share_index, encrypted_share, wrapped_key
 `
 
 type CreateVaultAccessParams struct {
@@ -218,38 +733,277 @@ type CreateVaultAccessParams struct {
 func (q *Queries) CreateVaultAccess(ctx context.Context, arg CreateVaultAccessParams) (VaultAccess, error) {
 	row := q.queryRow(ctx, q.createVaultAccessStmt, createVaultAccess, arg.VaultID, arg.BeneficiaryID)
 	var i VaultAccess
-	err := row.Scan(&i.VaultID, &i.BeneficiaryID, &i.GrantedAt)
+	err := row.Scan(
+		&i.VaultID,
+		&i.BeneficiaryID,
+		&i.GrantedAt,
+		&i.ShareIndex,
+		&i.EncryptedShare,
+		&i.WrappedKey,
+	)
 	return i, err
 }
 
-const deleteSession = `-- name: DeleteSession :exec
-DELETE FROM sessions WHERE token = ?
-`
-
-func (q *Queries) DeleteSession(ctx context.Context, token string) error {
-	_, err := q.exec(ctx, q.deleteSessionStmt, deleteSession, token)
-	return err
-}
-
-const getArtifactsByVault = `-- name: GetArtifactsByVault :many
-SELECT a.id, a.vault_id, a.message_type, a.encrypted_blob, a.iv, a.created_at FROM artifacts a
-JOIN vaults v ON a.vault_id = v.id
-WHERE a.vault_id = ? AND v.user_id = ?
-ORDER BY a.created_at DESC
+const createVaultRotation = `-- name: CreateVaultRotation :one
+INSERT INTO vault_rotations (id, vault_id, kdf_salt, kdf_params, hint, replace_hint, expires_at)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+RETURNING id, vault_id, kdf_salt, kdf_params, hint, replace_hint, expires_at, created_at
 `
 
-type GetArtifactsByVaultParams struct {
-	VaultID string `json:"vault_id"`
-	UserID  string `json:"user_id"`
+type CreateVaultRotationParams struct {
+	ID          string                `json:"id"`
+	VaultID     string                `json:"vault_id"`
+	KdfSalt     string                `json:"kdf_salt"`
+	KdfParams   core.KDFParams        `json:"kdf_params"`
+	Hint        core.NullSecretString `json:"hint"`
+	ReplaceHint bool                  `json:"replace_hint"`
+	ExpiresAt   time.Time             `json:"expires_at"`
 }
 
-func (q *Queries) GetArtifactsByVault(ctx context.Context, arg GetArtifactsByVaultParams) ([]Artifact, error) {
-	rows, err := q.query(ctx, q.getArtifactsByVaultStmt, getArtifactsByVault, arg.VaultID, arg.UserID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []Artifact
+func (q *Queries) CreateVaultRotation(ctx context.Context, arg CreateVaultRotationParams) (VaultRotation, error) {
+	row := q.queryRow(ctx, q.createVaultRotationStmt, createVaultRotation,
+		arg.ID,
+		arg.VaultID,
+		arg.KdfSalt,
+		arg.KdfParams,
+		arg.Hint,
+		arg.ReplaceHint,
+		arg.ExpiresAt,
+	)
+	var i VaultRotation
+	err := row.Scan(
+		&i.ID,
+		&i.VaultID,
+		&i.KdfSalt,
+		&i.KdfParams,
+		&i.Hint,
+		&i.ReplaceHint,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteContactMethod = `-- name: DeleteContactMethod :exec
+DELETE FROM contact_methods WHERE id = ?
+`
+
+func (q *Queries) DeleteContactMethod(ctx context.Context, id string) error {
+	_, err := q.exec(ctx, q.deleteContactMethodStmt, deleteContactMethod, id)
+	return err
+}
+
+const deleteContactVerification = `-- name: DeleteContactVerification :exec
+DELETE FROM contact_verifications WHERE contact_method_id = ?
+`
+
+func (q *Queries) DeleteContactVerification(ctx context.Context, contactMethodID string) error {
+	_, err := q.exec(ctx, q.deleteContactVerificationStmt, deleteContactVerification, contactMethodID)
+	return err
+}
+
+const deleteExpiredOIDCLogins = `-- name: DeleteExpiredOIDCLogins :execrows
+DELETE FROM oidc_logins WHERE expires_at <= ?
+`
+
+func (q *Queries) DeleteExpiredOIDCLogins(ctx context.Context, expiresAt time.Time) (int64, error) {
+	result, err := q.exec(ctx, q.deleteExpiredOIDCLoginsStmt, deleteExpiredOIDCLogins, expiresAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteExpiredSessions = `-- name: DeleteExpiredSessions :execrows
+DELETE FROM sessions WHERE expires_at <= CURRENT_TIMESTAMP
+`
+
+func (q *Queries) DeleteExpiredSessions(ctx context.Context) (int64, error) {
+	result, err := q.exec(ctx, q.deleteExpiredSessionsStmt, deleteExpiredSessions)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteExpiredVaultRotations = `-- name: DeleteExpiredVaultRotations :execrows
+DELETE FROM vault_rotations
+WHERE expires_at <= ?
+`
+
+func (q *Queries) DeleteExpiredVaultRotations(ctx context.Context, expiresAt time.Time) (int64, error) {
+	result, err := q.exec(ctx, q.deleteExpiredVaultRotationsStmt, deleteExpiredVaultRotations, expiresAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteInvite = `-- name: DeleteInvite :exec
+DELETE FROM invites WHERE id = ?
+`
+
+func (q *Queries) DeleteInvite(ctx context.Context, id string) error {
+	_, err := q.exec(ctx, q.deleteInviteStmt, deleteInvite, id)
+	return err
+}
+
+const deleteSession = `-- name: DeleteSession :exec
+DELETE FROM sessions WHERE token = ?
+`
+
+func (q *Queries) DeleteSession(ctx context.Context, token string) error {
+	_, err := q.exec(ctx, q.deleteSessionStmt, deleteSession, token)
+	return err
+}
+
+const deleteUser = `-- name: DeleteUser :exec
+DELETE FROM users WHERE id = ?
+`
+
+func (q *Queries) DeleteUser(ctx context.Context, id string) error {
+	_, err := q.exec(ctx, q.deleteUserStmt, deleteUser, id)
+	return err
+}
+
+const deleteUserIdentity = `-- name: DeleteUserIdentity :execrows
+DELETE FROM user_identities WHERE id = ? AND user_id = ?
+`
+
+type DeleteUserIdentityParams struct {
+	ID     string `json:"id"`
+	UserID string `json:"user_id"`
+}
+
+func (q *Queries) DeleteUserIdentity(ctx context.Context, arg DeleteUserIdentityParams) (int64, error) {
+	result, err := q.exec(ctx, q.deleteUserIdentityStmt, deleteUserIdentity, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteUserQuota = `-- name: DeleteUserQuota :exec
+DELETE FROM user_quotas WHERE user_id = ?
+`
+
+func (q *Queries) DeleteUserQuota(ctx context.Context, userID string) error {
+	_, err := q.exec(ctx, q.deleteUserQuotaStmt, deleteUserQuota, userID)
+	return err
+}
+
+const deleteUserSessions = `-- name: DeleteUserSessions :execrows
+DELETE FROM sessions WHERE user_id = ?
+`
+
+func (q *Queries) DeleteUserSessions(ctx context.Context, userID string) (int64, error) {
+	result, err := q.exec(ctx, q.deleteUserSessionsStmt, deleteUserSessions, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteVaultRotation = `-- name: DeleteVaultRotation :exec
+DELETE FROM vault_rotations
+WHERE id = ?
+`
+
+func (q *Queries) DeleteVaultRotation(ctx context.Context, id string) error {
+	_, err := q.exec(ctx, q.deleteVaultRotationStmt, deleteVaultRotation, id)
+	return err
+}
+
+const deleteWrappedKey = `-- name: DeleteWrappedKey :exec
+UPDATE vault_access
+SET wrapped_key = NULL
+WHERE vault_id = ? AND beneficiary_id = ?
+`
+
+type DeleteWrappedKeyParams struct {
+	VaultID       string `json:"vault_id"`
+	BeneficiaryID string `json:"beneficiary_id"`
+}
+
+func (q *Queries) DeleteWrappedKey(ctx context.Context, arg DeleteWrappedKeyParams) error {
+	_, err := q.exec(ctx, q.deleteWrappedKeyStmt, deleteWrappedKey, arg.VaultID, arg.BeneficiaryID)
+	return err
+}
+
+const disableUser = `-- name: DisableUser :execrows
+UPDATE users SET disabled_at = ? WHERE id = ? AND disabled_at IS NULL
+`
+
+type DisableUserParams struct {
+	DisabledAt sql.NullTime `json:"disabled_at"`
+	ID         string       `json:"id"`
+}
+
+func (q *Queries) DisableUser(ctx context.Context, arg DisableUserParams) (int64, error) {
+	result, err := q.exec(ctx, q.disableUserStmt, disableUser, arg.DisabledAt, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const enableUser = `-- name: EnableUser :execrows
+UPDATE users SET disabled_at = NULL
+WHERE id = ? AND disabled_at IS NOT NULL AND deletion_started_at IS NULL
+`
+
+func (q *Queries) EnableUser(ctx context.Context, id string) (int64, error) {
+	result, err := q.exec(ctx, q.enableUserStmt, enableUser, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getArtifact = `-- name: GetArtifact :one
+SELECT id, vault_id, message_type, encrypted_blob, iv, created_at, envelope_version FROM artifacts
+WHERE id = ? AND vault_id = ?
+`
+
+type GetArtifactParams struct {
+	ID      string `json:"id"`
+	VaultID string `json:"vault_id"`
+}
+
+func (q *Queries) GetArtifact(ctx context.Context, arg GetArtifactParams) (Artifact, error) {
+	row := q.queryRow(ctx, q.getArtifactStmt, getArtifact, arg.ID, arg.VaultID)
+	var i Artifact
+	err := row.Scan(
+		&i.ID,
+		&i.VaultID,
+		&i.MessageType,
+		&i.EncryptedBlob,
+		&i.Iv,
+		&i.CreatedAt,
+		&i.EnvelopeVersion,
+	)
+	return i, err
+}
+
+const getArtifactsByVault = `-- name: GetArtifactsByVault :many
+SELECT a.id, a.vault_id, a.message_type, a.encrypted_blob, a.iv, a.created_at, a.envelope_version FROM artifacts a
+JOIN vaults v ON a.vault_id = v.id
+WHERE a.vault_id = ? AND v.user_id = ?
+ORDER BY a.created_at DESC
+`
+
+type GetArtifactsByVaultParams struct {
+	VaultID string `json:"vault_id"`
+	UserID  string `json:"user_id"`
+}
+
+func (q *Queries) GetArtifactsByVault(ctx context.Context, arg GetArtifactsByVaultParams) ([]Artifact, error) {
+	rows, err := q.query(ctx, q.getArtifactsByVaultStmt, getArtifactsByVault, arg.VaultID, arg.UserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Artifact
 	for rows.Next() {
 		var i Artifact
 		if err := rows.Scan(
@@ -259,6 +1013,7 @@ func (q *Queries) GetArtifactsByVault(ctx context.Context, arg GetArtifactsByVau
 			&i.EncryptedBlob,
 			&i.Iv,
 			&i.CreatedAt,
+			&i.EnvelopeVersion,
 		); err != nil {
 			return nil, err
 		}
@@ -273,114 +1028,560 @@ func (q *Queries) GetArtifactsByVault(ctx context.Context, arg GetArtifactsByVau
 	return items, nil
 }
 
-const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, name, email, password_hash, is_paused, check_in_interval, trigger_interval_num, buffer_period, verifier_quorum, last_check_in, current_status, created_at FROM users
-WHERE email = ? LIMIT 1
+const getBeneficiaryByID = `-- name: GetBeneficiaryByID :one
+SELECT id, user_id, beneficiary_name, is_verifier, has_confirmed, confirmed_at, created_at, release_token_hash, public_key, verify_token_hash FROM beneficiaries
+WHERE id = ? AND user_id = ?
 `
 
-func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error) {
-	row := q.queryRow(ctx, q.getUserByEmailStmt, getUserByEmail, email)
-	var i User
+type GetBeneficiaryByIDParams struct {
+	ID     string `json:"id"`
+	UserID string `json:"user_id"`
+}
+
+func (q *Queries) GetBeneficiaryByID(ctx context.Context, arg GetBeneficiaryByIDParams) (Beneficiary, error) {
+	row := q.queryRow(ctx, q.getBeneficiaryByIDStmt, getBeneficiaryByID, arg.ID, arg.UserID)
+	var i Beneficiary
 	err := row.Scan(
 		&i.ID,
-		&i.Name,
-		&i.Email,
-		&i.PasswordHash,
-		&i.IsPaused,
-		&i.CheckInInterval,
-		&i.TriggerIntervalNum,
-		&i.BufferPeriod,
-		&i.VerifierQuorum,
-		&i.LastCheckIn,
-		&i.CurrentStatus,
+		&i.UserID,
+		&i.BeneficiaryName,
+		&i.IsVerifier,
+		&i.HasConfirmed,
+		&i.ConfirmedAt,
 		&i.CreatedAt,
+		&i.ReleaseTokenHash,
+		&i.PublicKey,
+		&i.VerifyTokenHash,
 	)
 	return i, err
 }
 
-const getUserByID = `-- name: GetUserByID :one
-SELECT id, name, email, password_hash, is_paused, check_in_interval, trigger_interval_num, buffer_period, verifier_quorum, last_check_in, current_status, created_at FROM users
+const getBeneficiaryByReleaseToken = `-- name: GetBeneficiaryByReleaseToken :one
+SELECT id, user_id, beneficiary_name, is_verifier, has_confirmed, confirmed_at, created_at, release_token_hash, public_key, verify_token_hash FROM beneficiaries
+WHERE release_token_hash = ?
+`
+
+func (q *Queries) GetBeneficiaryByReleaseToken(ctx context.Context, releaseTokenHash sql.NullString) (Beneficiary, error) {
+	row := q.queryRow(ctx, q.getBeneficiaryByReleaseTokenStmt, getBeneficiaryByReleaseToken, releaseTokenHash)
+	var i Beneficiary
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.BeneficiaryName,
+		&i.IsVerifier,
+		&i.HasConfirmed,
+		&i.ConfirmedAt,
+		&i.CreatedAt,
+		&i.ReleaseTokenHash,
+		&i.PublicKey,
+		&i.VerifyTokenHash,
+	)
+	return i, err
+}
+
+const getBeneficiaryByVerifyToken = `-- name: GetBeneficiaryByVerifyToken :one
+SELECT id, user_id, beneficiary_name, is_verifier, has_confirmed, confirmed_at, created_at, release_token_hash, public_key, verify_token_hash FROM beneficiaries
+WHERE verify_token_hash = ? AND is_verifier = TRUE
+`
+
+func (q *Queries) GetBeneficiaryByVerifyToken(ctx context.Context, verifyTokenHash sql.NullString) (Beneficiary, error) {
+	row := q.queryRow(ctx, q.getBeneficiaryByVerifyTokenStmt, getBeneficiaryByVerifyToken, verifyTokenHash)
+	var i Beneficiary
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.BeneficiaryName,
+		&i.IsVerifier,
+		&i.HasConfirmed,
+		&i.ConfirmedAt,
+		&i.CreatedAt,
+		&i.ReleaseTokenHash,
+		&i.PublicKey,
+		&i.VerifyTokenHash,
+	)
+	return i, err
+}
+
+const getContactMethodByID = `-- name: GetContactMethodByID :one
+SELECT id, user_id, beneficiary_id, channel, destination, metadata, created_at, verified_at, invite_id FROM contact_methods
 WHERE id = ? LIMIT 1
 `
 
-func (q *Queries) GetUserByID(ctx context.Context, id string) (User, error) {
-	row := q.queryRow(ctx, q.getUserByIDStmt, getUserByID, id)
-	var i User
+func (q *Queries) GetContactMethodByID(ctx context.Context, id string) (ContactMethod, error) {
+	row := q.queryRow(ctx, q.getContactMethodByIDStmt, getContactMethodByID, id)
+	var i ContactMethod
 	err := row.Scan(
 		&i.ID,
-		&i.Name,
-		&i.Email,
-		&i.PasswordHash,
-		&i.IsPaused,
-		&i.CheckInInterval,
-		&i.TriggerIntervalNum,
-		&i.BufferPeriod,
-		&i.VerifierQuorum,
-		&i.LastCheckIn,
-		&i.CurrentStatus,
+		&i.UserID,
+		&i.BeneficiaryID,
+		&i.Channel,
+		&i.Destination,
+		&i.Metadata,
 		&i.CreatedAt,
+		&i.VerifiedAt,
+		&i.InviteID,
 	)
 	return i, err
 }
 
-const getUserBySessionToken = `-- name: GetUserBySessionToken :one
-SELECT u.id, u.name, u.email, u.password_hash, u.is_paused, u.check_in_interval, u.trigger_interval_num, u.buffer_period, u.verifier_quorum, u.last_check_in, u.current_status, u.created_at FROM sessions s
-JOIN users u ON s.user_id = u.id
-WHERE s.token = ? AND s.expires_at > CURRENT_TIMESTAMP
+const getContactVerification = `-- name: GetContactVerification :one
+SELECT contact_method_id, code_hash, attempts, expires_at, created_at FROM contact_verifications
+WHERE contact_method_id = ?
 `
 
-func (q *Queries) GetUserBySessionToken(ctx context.Context, token string) (User, error) {
-	row := q.queryRow(ctx, q.getUserBySessionTokenStmt, getUserBySessionToken, token)
-	var i User
+func (q *Queries) GetContactVerification(ctx context.Context, contactMethodID string) (ContactVerification, error) {
+	row := q.queryRow(ctx, q.getContactVerificationStmt, getContactVerification, contactMethodID)
+	var i ContactVerification
+	err := row.Scan(
+		&i.ContactMethodID,
+		&i.CodeHash,
+		&i.Attempts,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getDataKey = `-- name: GetDataKey :one
+SELECT id, wrapped_key, kek_id, created_at FROM data_keys
+WHERE id = ?
+`
+
+func (q *Queries) GetDataKey(ctx context.Context, id string) (DataKey, error) {
+	row := q.queryRow(ctx, q.getDataKeyStmt, getDataKey, id)
+	var i DataKey
 	err := row.Scan(
 		&i.ID,
-		&i.Name,
-		&i.Email,
-		&i.PasswordHash,
-		&i.IsPaused,
-		&i.CheckInInterval,
-		&i.TriggerIntervalNum,
-		&i.BufferPeriod,
-		&i.VerifierQuorum,
-		&i.LastCheckIn,
-		&i.CurrentStatus,
+		&i.WrappedKey,
+		&i.KekID,
 		&i.CreatedAt,
 	)
 	return i, err
 }
 
-const getVaultByID = `-- name: GetVaultByID :one
-SELECT id, user_id, vault_name, hint, kdf_salt, created_at FROM vaults
-WHERE id = ? AND user_id = ?
+const getInstanceSetting = `-- name: GetInstanceSetting :one
+SELECT value FROM instance_settings WHERE key = ?
 `
 
-type GetVaultByIDParams struct {
-	ID     string `json:"id"`
-	UserID string `json:"user_id"`
+func (q *Queries) GetInstanceSetting(ctx context.Context, key string) (string, error) {
+	row := q.queryRow(ctx, q.getInstanceSettingStmt, getInstanceSetting, key)
+	var value string
+	err := row.Scan(&value)
+	return value, err
 }
 
-func (q *Queries) GetVaultByID(ctx context.Context, arg GetVaultByIDParams) (Vault, error) {
-	row := q.queryRow(ctx, q.getVaultByIDStmt, getVaultByID, arg.ID, arg.UserID)
-	var i Vault
+const getInvite = `-- name: GetInvite :one
+SELECT id, token_hash, created_by, email, max_uses, uses, expires_at, created_at FROM invites WHERE id = ?
+`
+
+func (q *Queries) GetInvite(ctx context.Context, id string) (Invite, error) {
+	row := q.queryRow(ctx, q.getInviteStmt, getInvite, id)
+	var i Invite
 	err := row.Scan(
 		&i.ID,
-		&i.UserID,
-		&i.VaultName,
-		&i.Hint,
-		&i.KdfSalt,
+		&i.TokenHash,
+		&i.CreatedBy,
+		&i.Email,
+		&i.MaxUses,
+		&i.Uses,
+		&i.ExpiresAt,
 		&i.CreatedAt,
 	)
 	return i, err
 }
 
-const getVaultsByUser = `-- name: GetVaultsByUser :many
-SELECT id, user_id, vault_name, hint, kdf_salt, created_at FROM vaults
-WHERE user_id = ?
-ORDER BY created_at DESC
+const getInviteByTokenHash = `-- name: GetInviteByTokenHash :one
+SELECT id, token_hash, created_by, email, max_uses, uses, expires_at, created_at FROM invites WHERE token_hash = ?
 `
 
-func (q *Queries) GetVaultsByUser(ctx context.Context, userID string) ([]Vault, error) {
-	rows, err := q.query(ctx, q.getVaultsByUserStmt, getVaultsByUser, userID)
+func (q *Queries) GetInviteByTokenHash(ctx context.Context, tokenHash string) (Invite, error) {
+	row := q.queryRow(ctx, q.getInviteByTokenHashStmt, getInviteByTokenHash, tokenHash)
+	var i Invite
+	err := row.Scan(
+		&i.ID,
+		&i.TokenHash,
+		&i.CreatedBy,
+		&i.Email,
+		&i.MaxUses,
+		&i.Uses,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getLatestNotificationByEvent = `-- name: GetLatestNotificationByEvent :one
+SELECT id, user_id, contact_method_id, event, subject, body, status, attempts, last_error, created_at, sent_at, html_body, redact_after_send FROM notification_outbox
+WHERE user_id = ? AND event = ?
+ORDER BY created_at DESC
+LIMIT 1
+`
+
+type GetLatestNotificationByEventParams struct {
+	UserID string                 `json:"user_id"`
+	Event  core.NotificationEvent `json:"event"`
+}
+
+func (q *Queries) GetLatestNotificationByEvent(ctx context.Context, arg GetLatestNotificationByEventParams) (NotificationOutbox, error) {
+	row := q.queryRow(ctx, q.getLatestNotificationByEventStmt, getLatestNotificationByEvent, arg.UserID, arg.Event)
+	var i NotificationOutbox
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.ContactMethodID,
+		&i.Event,
+		&i.Subject,
+		&i.Body,
+		&i.Status,
+		&i.Attempts,
+		&i.LastError,
+		&i.CreatedAt,
+		&i.SentAt,
+		&i.HtmlBody,
+		&i.RedactAfterSend,
+	)
+	return i, err
+}
+
+const getReleasedVault = `-- name: GetReleasedVault :one
+SELECT v.id, v.user_id, v.vault_name, v.hint, v.kdf_salt, v.created_at, v.share_threshold, v.share_count, v.sealed_key, v.sealed_key_id, v.unsealed_at, v.kdf_params FROM vaults v
+JOIN vault_access a ON a.vault_id = v.id
+WHERE v.id = ? AND a.beneficiary_id = ? AND v.sealed_key IS NULL
+`
+
+type GetReleasedVaultParams struct {
+	ID            string `json:"id"`
+	BeneficiaryID string `json:"beneficiary_id"`
+}
+
+func (q *Queries) GetReleasedVault(ctx context.Context, arg GetReleasedVaultParams) (Vault, error) {
+	row := q.queryRow(ctx, q.getReleasedVaultStmt, getReleasedVault, arg.ID, arg.BeneficiaryID)
+	var i Vault
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.VaultName,
+		&i.Hint,
+		&i.KdfSalt,
+		&i.CreatedAt,
+		&i.ShareThreshold,
+		&i.ShareCount,
+		&i.SealedKey,
+		&i.SealedKeyID,
+		&i.UnsealedAt,
+		&i.KdfParams,
+	)
+	return i, err
+}
+
+const getReminderPolicy = `-- name: GetReminderPolicy :one
+SELECT user_id, primary_contact_id, steps, quiet_hours_start, quiet_hours_end, time_zone, updated_at FROM reminder_policies
+WHERE user_id = ?
+`
+
+func (q *Queries) GetReminderPolicy(ctx context.Context, userID string) (ReminderPolicy, error) {
+	row := q.queryRow(ctx, q.getReminderPolicyStmt, getReminderPolicy, userID)
+	var i ReminderPolicy
+	err := row.Scan(
+		&i.UserID,
+		&i.PrimaryContactID,
+		&i.Steps,
+		&i.QuietHoursStart,
+		&i.QuietHoursEnd,
+		&i.TimeZone,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getSigningKey = `-- name: GetSigningKey :one
+SELECT id, private_key, created_at FROM signing_keys
+WHERE id = ?
+`
+
+func (q *Queries) GetSigningKey(ctx context.Context, id string) (SigningKey, error) {
+	row := q.queryRow(ctx, q.getSigningKeyStmt, getSigningKey, id)
+	var i SigningKey
+	err := row.Scan(&i.ID, &i.PrivateKey, &i.CreatedAt)
+	return i, err
+}
+
+const getUserByEmail = `-- name: GetUserByEmail :one
+SELECT id, name, email, password_hash, is_paused, check_in_interval, trigger_interval_num, buffer_period, verifier_quorum, last_check_in, current_status, created_at, role, disabled_at, deletion_due_at, deletion_started_at FROM users
+WHERE email = ? LIMIT 1
+`
+
+func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error) {
+	row := q.queryRow(ctx, q.getUserByEmailStmt, getUserByEmail, email)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Email,
+		&i.PasswordHash,
+		&i.IsPaused,
+		&i.CheckInInterval,
+		&i.TriggerIntervalNum,
+		&i.BufferPeriod,
+		&i.VerifierQuorum,
+		&i.LastCheckIn,
+		&i.CurrentStatus,
+		&i.CreatedAt,
+		&i.Role,
+		&i.DisabledAt,
+		&i.DeletionDueAt,
+		&i.DeletionStartedAt,
+	)
+	return i, err
+}
+
+const getUserByID = `-- name: GetUserByID :one
+SELECT id, name, email, password_hash, is_paused, check_in_interval, trigger_interval_num, buffer_period, verifier_quorum, last_check_in, current_status, created_at, role, disabled_at, deletion_due_at, deletion_started_at FROM users
+WHERE id = ? LIMIT 1
+`
+
+func (q *Queries) GetUserByID(ctx context.Context, id string) (User, error) {
+	row := q.queryRow(ctx, q.getUserByIDStmt, getUserByID, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Email,
+		&i.PasswordHash,
+		&i.IsPaused,
+		&i.CheckInInterval,
+		&i.TriggerIntervalNum,
+		&i.BufferPeriod,
+		&i.VerifierQuorum,
+		&i.LastCheckIn,
+		&i.CurrentStatus,
+		&i.CreatedAt,
+		&i.Role,
+		&i.DisabledAt,
+		&i.DeletionDueAt,
+		&i.DeletionStartedAt,
+	)
+	return i, err
+}
+
+const getUserByIdentity = `-- name: GetUserByIdentity :one
+SELECT u.id, u.name, u.email, u.password_hash, u.is_paused, u.check_in_interval, u.trigger_interval_num, u.buffer_period, u.verifier_quorum, u.last_check_in, u.current_status, u.created_at, u.role, u.disabled_at, u.deletion_due_at, u.deletion_started_at FROM users u
+JOIN user_identities i ON i.user_id = u.id
+WHERE i.issuer = ? AND i.subject = ?
+`
+
+type GetUserByIdentityParams struct {
+	Issuer  string `json:"issuer"`
+	Subject string `json:"subject"`
+}
+
+func (q *Queries) GetUserByIdentity(ctx context.Context, arg GetUserByIdentityParams) (User, error) {
+	row := q.queryRow(ctx, q.getUserByIdentityStmt, getUserByIdentity, arg.Issuer, arg.Subject)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Email,
+		&i.PasswordHash,
+		&i.IsPaused,
+		&i.CheckInInterval,
+		&i.TriggerIntervalNum,
+		&i.BufferPeriod,
+		&i.VerifierQuorum,
+		&i.LastCheckIn,
+		&i.CurrentStatus,
+		&i.CreatedAt,
+		&i.Role,
+		&i.DisabledAt,
+		&i.DeletionDueAt,
+		&i.DeletionStartedAt,
+	)
+	return i, err
+}
+
+const getUserBySessionToken = `-- name: GetUserBySessionToken :one
+SELECT u.id, u.name, u.email, u.password_hash, u.is_paused, u.check_in_interval, u.trigger_interval_num, u.buffer_period, u.verifier_quorum, u.last_check_in, u.current_status, u.created_at, u.role, u.disabled_at, u.deletion_due_at, u.deletion_started_at FROM sessions s
+JOIN users u ON s.user_id = u.id
+WHERE s.token = ? AND s.expires_at > CURRENT_TIMESTAMP
+`
+
+func (q *Queries) GetUserBySessionToken(ctx context.Context, token string) (User, error) {
+	row := q.queryRow(ctx, q.getUserBySessionTokenStmt, getUserBySessionToken, token)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Email,
+		&i.PasswordHash,
+		&i.IsPaused,
+		&i.CheckInInterval,
+		&i.TriggerIntervalNum,
+		&i.BufferPeriod,
+		&i.VerifierQuorum,
+		&i.LastCheckIn,
+		&i.CurrentStatus,
+		&i.CreatedAt,
+		&i.Role,
+		&i.DisabledAt,
+		&i.DeletionDueAt,
+		&i.DeletionStartedAt,
+	)
+	return i, err
+}
+
+const getUserIdentity = `-- name: GetUserIdentity :one
+SELECT id, user_id, issuer, subject, email, created_at, last_login_at FROM user_identities
+WHERE issuer = ? AND subject = ?
+`
+
+type GetUserIdentityParams struct {
+	Issuer  string `json:"issuer"`
+	Subject string `json:"subject"`
+}
+
+func (q *Queries) GetUserIdentity(ctx context.Context, arg GetUserIdentityParams) (UserIdentity, error) {
+	row := q.queryRow(ctx, q.getUserIdentityStmt, getUserIdentity, arg.Issuer, arg.Subject)
+	var i UserIdentity
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Issuer,
+		&i.Subject,
+		&i.Email,
+		&i.CreatedAt,
+		&i.LastLoginAt,
+	)
+	return i, err
+}
+
+const getUserQuota = `-- name: GetUserQuota :one
+SELECT user_id, max_vaults, max_artifacts, max_artifact_bytes, max_storage_bytes, updated_at FROM user_quotas WHERE user_id = ?
+`
+
+func (q *Queries) GetUserQuota(ctx context.Context, userID string) (UserQuota, error) {
+	row := q.queryRow(ctx, q.getUserQuotaStmt, getUserQuota, userID)
+	var i UserQuota
+	err := row.Scan(
+		&i.UserID,
+		&i.MaxVaults,
+		&i.MaxArtifacts,
+		&i.MaxArtifactBytes,
+		&i.MaxStorageBytes,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getVault = `-- name: GetVault :one
+SELECT id, user_id, vault_name, hint, kdf_salt, created_at, share_threshold, share_count, sealed_key, sealed_key_id, unsealed_at, kdf_params FROM vaults
+WHERE id = ?
+`
+
+func (q *Queries) GetVault(ctx context.Context, id string) (Vault, error) {
+	row := q.queryRow(ctx, q.getVaultStmt, getVault, id)
+	var i Vault
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.VaultName,
+		&i.Hint,
+		&i.KdfSalt,
+		&i.CreatedAt,
+		&i.ShareThreshold,
+		&i.ShareCount,
+		&i.SealedKey,
+		&i.SealedKeyID,
+		&i.UnsealedAt,
+		&i.KdfParams,
+	)
+	return i, err
+}
+
+const getVaultByID = `-- name: GetVaultByID :one
+SELECT id, user_id, vault_name, hint, kdf_salt, created_at, share_threshold, share_count, sealed_key, sealed_key_id, unsealed_at, kdf_params FROM vaults
+WHERE id = ? AND user_id = ?
+`
+
+type GetVaultByIDParams struct {
+	ID     string `json:"id"`
+	UserID string `json:"user_id"`
+}
+
+func (q *Queries) GetVaultByID(ctx context.Context, arg GetVaultByIDParams) (Vault, error) {
+	row := q.queryRow(ctx, q.getVaultByIDStmt, getVaultByID, arg.ID, arg.UserID)
+	var i Vault
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.VaultName,
+		&i.Hint,
+		&i.KdfSalt,
+		&i.CreatedAt,
+		&i.ShareThreshold,
+		&i.ShareCount,
+		&i.SealedKey,
+		&i.SealedKeyID,
+		&i.UnsealedAt,
+		&i.KdfParams,
+	)
+	return i, err
+}
+
+const getVaultRotation = `-- name: GetVaultRotation :one
+SELECT id, vault_id, kdf_salt, kdf_params, hint, replace_hint, expires_at, created_at FROM vault_rotations
+WHERE id = ? AND vault_id = ?
+`
+
+type GetVaultRotationParams struct {
+	ID      string `json:"id"`
+	VaultID string `json:"vault_id"`
+}
+
+func (q *Queries) GetVaultRotation(ctx context.Context, arg GetVaultRotationParams) (VaultRotation, error) {
+	row := q.queryRow(ctx, q.getVaultRotationStmt, getVaultRotation, arg.ID, arg.VaultID)
+	var i VaultRotation
+	err := row.Scan(
+		&i.ID,
+		&i.VaultID,
+		&i.KdfSalt,
+		&i.KdfParams,
+		&i.Hint,
+		&i.ReplaceHint,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getVaultRotationByVault = `-- name: GetVaultRotationByVault :one
+SELECT id, vault_id, kdf_salt, kdf_params, hint, replace_hint, expires_at, created_at FROM vault_rotations
+WHERE vault_id = ?
+`
+
+func (q *Queries) GetVaultRotationByVault(ctx context.Context, vaultID string) (VaultRotation, error) {
+	row := q.queryRow(ctx, q.getVaultRotationByVaultStmt, getVaultRotationByVault, vaultID)
+	var i VaultRotation
+	err := row.Scan(
+		&i.ID,
+		&i.VaultID,
+		&i.KdfSalt,
+		&i.KdfParams,
+		&i.Hint,
+		&i.ReplaceHint,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getVaultsByUser = `-- name: GetVaultsByUser :many
+SELECT id, user_id, vault_name, hint, kdf_salt, created_at, share_threshold, share_count, sealed_key, sealed_key_id, unsealed_at, kdf_params FROM vaults
+WHERE user_id = ?
+ORDER BY created_at DESC
+`
+
+func (q *Queries) GetVaultsByUser(ctx context.Context, userID string) ([]Vault, error) {
+	rows, err := q.query(ctx, q.getVaultsByUserStmt, getVaultsByUser, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -395,6 +1596,12 @@ func (q *Queries) GetVaultsByUser(ctx context.Context, userID string) ([]Vault,
 			&i.Hint,
 			&i.KdfSalt,
 			&i.CreatedAt,
+			&i.ShareThreshold,
+			&i.ShareCount,
+			&i.SealedKey,
+			&i.SealedKeyID,
+			&i.UnsealedAt,
+			&i.KdfParams,
 		); err != nil {
 			return nil, err
 		}
@@ -409,21 +1616,154 @@ func (q *Queries) GetVaultsByUser(ctx context.Context, userID string) ([]Vault,
 	return items, nil
 }
 
-const listContactMethodsByUserID = `-- name: ListContactMethodsByUserID :many
-SELECT id, user_id, beneficiary_id, channel, destination, metadata, created_at FROM contact_methods
-WHERE user_id = ?
+const importArtifact = `-- name: ImportArtifact :exec
+INSERT INTO artifacts (id, vault_id, message_type, encrypted_blob, iv, envelope_version, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?)
 `
 
-func (q *Queries) ListContactMethodsByUserID(ctx context.Context, userID sql.NullString) ([]ContactMethod, error) {
-	rows, err := q.query(ctx, q.listContactMethodsByUserIDStmt, listContactMethodsByUserID, userID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []ContactMethod
-	for rows.Next() {
-		var i ContactMethod
-		if err := rows.Scan(
+type ImportArtifactParams struct {
+	ID              string               `json:"id"`
+	VaultID         string               `json:"vault_id"`
+	MessageType     core.MessageType     `json:"message_type"`
+	EncryptedBlob   core.EncryptedBlob   `json:"encrypted_blob"`
+	Iv              string               `json:"iv"`
+	EnvelopeVersion core.EnvelopeVersion `json:"envelope_version"`
+	CreatedAt       time.Time            `json:"created_at"`
+}
+
+func (q *Queries) ImportArtifact(ctx context.Context, arg ImportArtifactParams) error {
+	_, err := q.exec(ctx, q.importArtifactStmt, importArtifact,
+		arg.ID,
+		arg.VaultID,
+		arg.MessageType,
+		arg.EncryptedBlob,
+		arg.Iv,
+		arg.EnvelopeVersion,
+		arg.CreatedAt,
+	)
+	return err
+}
+
+const importVault = `-- name: ImportVault :exec
+INSERT INTO vaults (id, user_id, vault_name, hint, kdf_salt, kdf_params, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+`
+
+type ImportVaultParams struct {
+	ID        string                `json:"id"`
+	UserID    string                `json:"user_id"`
+	VaultName string                `json:"vault_name"`
+	Hint      core.NullSecretString `json:"hint"`
+	KdfSalt   string                `json:"kdf_salt"`
+	KdfParams core.KDFParams        `json:"kdf_params"`
+	CreatedAt time.Time             `json:"created_at"`
+}
+
+func (q *Queries) ImportVault(ctx context.Context, arg ImportVaultParams) error {
+	_, err := q.exec(ctx, q.importVaultStmt, importVault,
+		arg.ID,
+		arg.UserID,
+		arg.VaultName,
+		arg.Hint,
+		arg.KdfSalt,
+		arg.KdfParams,
+		arg.CreatedAt,
+	)
+	return err
+}
+
+const listArtifactsByVaultID = `-- name: ListArtifactsByVaultID :many
+SELECT id, vault_id, message_type, encrypted_blob, iv, created_at, envelope_version FROM artifacts
+WHERE vault_id = ?
+`
+
+func (q *Queries) ListArtifactsByVaultID(ctx context.Context, vaultID string) ([]Artifact, error) {
+	rows, err := q.query(ctx, q.listArtifactsByVaultIDStmt, listArtifactsByVaultID, vaultID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Artifact
+	for rows.Next() {
+		var i Artifact
+		if err := rows.Scan(
+			&i.ID,
+			&i.VaultID,
+			&i.MessageType,
+			&i.EncryptedBlob,
+			&i.Iv,
+			&i.CreatedAt,
+			&i.EnvelopeVersion,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listBeneficiariesByUser = `-- name: ListBeneficiariesByUser :many
+SELECT id, user_id, beneficiary_name, is_verifier, has_confirmed, confirmed_at, created_at, release_token_hash, public_key, verify_token_hash FROM beneficiaries
+WHERE user_id = ?
+ORDER BY created_at ASC
+`
+
+func (q *Queries) ListBeneficiariesByUser(ctx context.Context, userID string) ([]Beneficiary, error) {
+	rows, err := q.query(ctx, q.listBeneficiariesByUserStmt, listBeneficiariesByUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Beneficiary
+	for rows.Next() {
+		var i Beneficiary
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.BeneficiaryName,
+			&i.IsVerifier,
+			&i.HasConfirmed,
+			&i.ConfirmedAt,
+			&i.CreatedAt,
+			&i.ReleaseTokenHash,
+			&i.PublicKey,
+			&i.VerifyTokenHash,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listBeneficiaryContactMethods = `-- name: ListBeneficiaryContactMethods :many
+SELECT c.id, c.user_id, c.beneficiary_id, c.channel, c.destination, c.metadata, c.created_at, c.verified_at, c.invite_id FROM contact_methods c
+JOIN beneficiaries b ON c.beneficiary_id = b.id
+WHERE b.user_id = ?
+`
+
+func (q *Queries) ListBeneficiaryContactMethods(ctx context.Context, userID string) ([]ContactMethod, error) {
+	rows, err := q.query(ctx, q.listBeneficiaryContactMethodsStmt, listBeneficiaryContactMethods, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ContactMethod
+	for rows.Next() {
+		var i ContactMethod
+		if err := rows.Scan(
 			&i.ID,
 			&i.UserID,
 			&i.BeneficiaryID,
@@ -431,6 +1771,8 @@ func (q *Queries) ListContactMethodsByUserID(ctx context.Context, userID sql.Nul
 			&i.Destination,
 			&i.Metadata,
 			&i.CreatedAt,
+			&i.VerifiedAt,
+			&i.InviteID,
 		); err != nil {
 			return nil, err
 		}
@@ -445,18 +1787,1450 @@ func (q *Queries) ListContactMethodsByUserID(ctx context.Context, userID sql.Nul
 	return items, nil
 }
 
-const updateUserCheckIn = `-- name: UpdateUserCheckIn :exec
-UPDATE users
-SET last_check_in = ?, current_status = 'ALIVE'
-WHERE id = ?
+const listContactMethodsByBeneficiaryID = `-- name: ListContactMethodsByBeneficiaryID :many
+SELECT id, user_id, beneficiary_id, channel, destination, metadata, created_at, verified_at, invite_id FROM contact_methods
+WHERE beneficiary_id = ?
 `
 
-type UpdateUserCheckInParams struct {
-	LastCheckIn time.Time `json:"last_check_in"`
-	ID          string    `json:"id"`
+func (q *Queries) ListContactMethodsByBeneficiaryID(ctx context.Context, beneficiaryID sql.NullString) ([]ContactMethod, error) {
+	rows, err := q.query(ctx, q.listContactMethodsByBeneficiaryIDStmt, listContactMethodsByBeneficiaryID, beneficiaryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ContactMethod
+	for rows.Next() {
+		var i ContactMethod
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.BeneficiaryID,
+			&i.Channel,
+			&i.Destination,
+			&i.Metadata,
+			&i.CreatedAt,
+			&i.VerifiedAt,
+			&i.InviteID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listContactMethodsByUserID = `-- name: ListContactMethodsByUserID :many
+SELECT id, user_id, beneficiary_id, channel, destination, metadata, created_at, verified_at, invite_id FROM contact_methods
+WHERE user_id = ? AND invite_id IS NULL
+`
+
+func (q *Queries) ListContactMethodsByUserID(ctx context.Context, userID sql.NullString) ([]ContactMethod, error) {
+	rows, err := q.query(ctx, q.listContactMethodsByUserIDStmt, listContactMethodsByUserID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ContactMethod
+	for rows.Next() {
+		var i ContactMethod
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.BeneficiaryID,
+			&i.Channel,
+			&i.Destination,
+			&i.Metadata,
+			&i.CreatedAt,
+			&i.VerifiedAt,
+			&i.InviteID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listInvites = `-- name: ListInvites :many
+SELECT id, token_hash, created_by, email, max_uses, uses, expires_at, created_at FROM invites
+ORDER BY created_at DESC
+`
+
+func (q *Queries) ListInvites(ctx context.Context) ([]Invite, error) {
+	rows, err := q.query(ctx, q.listInvitesStmt, listInvites)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Invite
+	for rows.Next() {
+		var i Invite
+		if err := rows.Scan(
+			&i.ID,
+			&i.TokenHash,
+			&i.CreatedBy,
+			&i.Email,
+			&i.MaxUses,
+			&i.Uses,
+			&i.ExpiresAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listInvitesByCreator = `-- name: ListInvitesByCreator :many
+SELECT id, token_hash, created_by, email, max_uses, uses, expires_at, created_at FROM invites
+WHERE created_by = ?
+ORDER BY created_at DESC
+`
+
+func (q *Queries) ListInvitesByCreator(ctx context.Context, createdBy string) ([]Invite, error) {
+	rows, err := q.query(ctx, q.listInvitesByCreatorStmt, listInvitesByCreator, createdBy)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Invite
+	for rows.Next() {
+		var i Invite
+		if err := rows.Scan(
+			&i.ID,
+			&i.TokenHash,
+			&i.CreatedBy,
+			&i.Email,
+			&i.MaxUses,
+			&i.Uses,
+			&i.ExpiresAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listLivenessCandidates = `-- name: ListLivenessCandidates :many
+SELECT id, name, email, password_hash, is_paused, check_in_interval, trigger_interval_num, buffer_period, verifier_quorum, last_check_in, current_status, created_at, role, disabled_at, deletion_due_at, deletion_started_at FROM users
+WHERE is_paused = FALSE AND current_status != 'CONFIRMED_DEAD' AND disabled_at IS NULL
+`
+
+func (q *Queries) ListLivenessCandidates(ctx context.Context) ([]User, error) {
+	rows, err := q.query(ctx, q.listLivenessCandidatesStmt, listLivenessCandidates)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Email,
+			&i.PasswordHash,
+			&i.IsPaused,
+			&i.CheckInInterval,
+			&i.TriggerIntervalNum,
+			&i.BufferPeriod,
+			&i.VerifierQuorum,
+			&i.LastCheckIn,
+			&i.CurrentStatus,
+			&i.CreatedAt,
+			&i.Role,
+			&i.DisabledAt,
+			&i.DeletionDueAt,
+			&i.DeletionStartedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listNotificationsByUser = `-- name: ListNotificationsByUser :many
+SELECT id, user_id, contact_method_id, event, subject, body, status, attempts, last_error, created_at, sent_at, html_body, redact_after_send FROM notification_outbox
+WHERE user_id = ?
+ORDER BY created_at
+`
+
+func (q *Queries) ListNotificationsByUser(ctx context.Context, userID string) ([]NotificationOutbox, error) {
+	rows, err := q.query(ctx, q.listNotificationsByUserStmt, listNotificationsByUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []NotificationOutbox
+	for rows.Next() {
+		var i NotificationOutbox
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.ContactMethodID,
+			&i.Event,
+			&i.Subject,
+			&i.Body,
+			&i.Status,
+			&i.Attempts,
+			&i.LastError,
+			&i.CreatedAt,
+			&i.SentAt,
+			&i.HtmlBody,
+			&i.RedactAfterSend,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPendingNotifications = `-- name: ListPendingNotifications :many
+SELECT n.id, n.user_id, n.contact_method_id, n.event, n.subject, n.body, n.status, n.attempts, n.last_error, n.created_at, n.sent_at, n.html_body, n.redact_after_send FROM notification_outbox n
+JOIN contact_methods c ON c.id = n.contact_method_id
+WHERE n.status = 'PENDING' AND c.channel != ?
+ORDER BY n.created_at ASC
+LIMIT ?
+`
+
+type ListPendingNotificationsParams struct {
+	SkipChannel core.Channel `json:"skip_channel"`
+	Limit       int64        `json:"limit"`
+}
+
+func (q *Queries) ListPendingNotifications(ctx context.Context, arg ListPendingNotificationsParams) ([]NotificationOutbox, error) {
+	rows, err := q.query(ctx, q.listPendingNotificationsStmt, listPendingNotifications, arg.SkipChannel, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []NotificationOutbox
+	for rows.Next() {
+		var i NotificationOutbox
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.ContactMethodID,
+			&i.Event,
+			&i.Subject,
+			&i.Body,
+			&i.Status,
+			&i.Attempts,
+			&i.LastError,
+			&i.CreatedAt,
+			&i.SentAt,
+			&i.HtmlBody,
+			&i.RedactAfterSend,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listReleasedSealedVaults = `-- name: ListReleasedSealedVaults :many
+SELECT v.id, v.user_id, v.vault_name, v.hint, v.kdf_salt, v.created_at, v.share_threshold, v.share_count, v.sealed_key, v.sealed_key_id, v.unsealed_at, v.kdf_params FROM vaults v
+JOIN users u ON u.id = v.user_id
+WHERE v.sealed_key IS NOT NULL AND u.current_status = 'CONFIRMED_DEAD'
+`
+
+func (q *Queries) ListReleasedSealedVaults(ctx context.Context) ([]Vault, error) {
+	rows, err := q.query(ctx, q.listReleasedSealedVaultsStmt, listReleasedSealedVaults)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Vault
+	for rows.Next() {
+		var i Vault
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.VaultName,
+			&i.Hint,
+			&i.KdfSalt,
+			&i.CreatedAt,
+			&i.ShareThreshold,
+			&i.ShareCount,
+			&i.SealedKey,
+			&i.SealedKeyID,
+			&i.UnsealedAt,
+			&i.KdfParams,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listReleasedVaults = `-- name: ListReleasedVaults :many
+SELECT v.id, v.vault_name, v.hint, v.kdf_salt, v.kdf_params, v.share_threshold, v.share_count, a.share_index, a.encrypted_share, a.wrapped_key
+FROM vault_access a
+JOIN vaults v ON a.vault_id = v.id
+WHERE a.beneficiary_id = ? AND v.sealed_key IS NULL
+ORDER BY v.created_at ASC
+`
+
+type ListReleasedVaultsRow struct {
+	ID             string                `json:"id"`
+	VaultName      string                `json:"vault_name"`
+	Hint           core.NullSecretString `json:"hint"`
+	KdfSalt        string                `json:"kdf_salt"`
+	KdfParams      core.KDFParams        `json:"kdf_params"`
+	ShareThreshold sql.NullInt64         `json:"share_threshold"`
+	ShareCount     sql.NullInt64         `json:"share_count"`
+	ShareIndex     sql.NullInt64         `json:"share_index"`
+	EncryptedShare sql.NullString        `json:"encrypted_share"`
+	WrappedKey     sql.NullString        `json:"wrapped_key"`
+}
+
+func (q *Queries) ListReleasedVaults(ctx context.Context, beneficiaryID string) ([]ListReleasedVaultsRow, error) {
+	rows, err := q.query(ctx, q.listReleasedVaultsStmt, listReleasedVaults, beneficiaryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListReleasedVaultsRow
+	for rows.Next() {
+		var i ListReleasedVaultsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.VaultName,
+			&i.Hint,
+			&i.KdfSalt,
+			&i.KdfParams,
+			&i.ShareThreshold,
+			&i.ShareCount,
+			&i.ShareIndex,
+			&i.EncryptedShare,
+			&i.WrappedKey,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRotationArtifacts = `-- name: ListRotationArtifacts :many
+SELECT rotation_id, artifact_id, encrypted_blob, iv, envelope_version, created_at FROM vault_rotation_artifacts
+WHERE rotation_id = ?
+`
+
+func (q *Queries) ListRotationArtifacts(ctx context.Context, rotationID string) ([]VaultRotationArtifact, error) {
+	rows, err := q.query(ctx, q.listRotationArtifactsStmt, listRotationArtifacts, rotationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []VaultRotationArtifact
+	for rows.Next() {
+		var i VaultRotationArtifact
+		if err := rows.Scan(
+			&i.RotationID,
+			&i.ArtifactID,
+			&i.EncryptedBlob,
+			&i.Iv,
+			&i.EnvelopeVersion,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSealedVaults = `-- name: ListSealedVaults :many
+SELECT id, user_id, vault_name, hint, kdf_salt, created_at, share_threshold, share_count, sealed_key, sealed_key_id, unsealed_at, kdf_params FROM vaults
+WHERE sealed_key IS NOT NULL
+`
+
+func (q *Queries) ListSealedVaults(ctx context.Context) ([]Vault, error) {
+	rows, err := q.query(ctx, q.listSealedVaultsStmt, listSealedVaults)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Vault
+	for rows.Next() {
+		var i Vault
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.VaultName,
+			&i.Hint,
+			&i.KdfSalt,
+			&i.CreatedAt,
+			&i.ShareThreshold,
+			&i.ShareCount,
+			&i.SealedKey,
+			&i.SealedKeyID,
+			&i.UnsealedAt,
+			&i.KdfParams,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserIdentities = `-- name: ListUserIdentities :many
+SELECT id, user_id, issuer, subject, email, created_at, last_login_at FROM user_identities
+WHERE user_id = ?
+ORDER BY created_at
+`
+
+func (q *Queries) ListUserIdentities(ctx context.Context, userID string) ([]UserIdentity, error) {
+	rows, err := q.query(ctx, q.listUserIdentitiesStmt, listUserIdentities, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []UserIdentity
+	for rows.Next() {
+		var i UserIdentity
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Issuer,
+			&i.Subject,
+			&i.Email,
+			&i.CreatedAt,
+			&i.LastLoginAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUsers = `-- name: ListUsers :many
+SELECT id, name, email, password_hash, is_paused, check_in_interval, trigger_interval_num, buffer_period, verifier_quorum, last_check_in, current_status, created_at, role, disabled_at, deletion_due_at, deletion_started_at FROM users
+ORDER BY created_at
+`
+
+func (q *Queries) ListUsers(ctx context.Context) ([]User, error) {
+	rows, err := q.query(ctx, q.listUsersStmt, listUsers)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Email,
+			&i.PasswordHash,
+			&i.IsPaused,
+			&i.CheckInInterval,
+			&i.TriggerIntervalNum,
+			&i.BufferPeriod,
+			&i.VerifierQuorum,
+			&i.LastCheckIn,
+			&i.CurrentStatus,
+			&i.CreatedAt,
+			&i.Role,
+			&i.DisabledAt,
+			&i.DeletionDueAt,
+			&i.DeletionStartedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUsersBeingDeleted = `-- name: ListUsersBeingDeleted :many
+SELECT id, name, email, password_hash, is_paused, check_in_interval, trigger_interval_num, buffer_period, verifier_quorum, last_check_in, current_status, created_at, role, disabled_at, deletion_due_at, deletion_started_at FROM users
+WHERE deletion_started_at IS NOT NULL
+`
+
+func (q *Queries) ListUsersBeingDeleted(ctx context.Context) ([]User, error) {
+	rows, err := q.query(ctx, q.listUsersBeingDeletedStmt, listUsersBeingDeleted)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Email,
+			&i.PasswordHash,
+			&i.IsPaused,
+			&i.CheckInInterval,
+			&i.TriggerIntervalNum,
+			&i.BufferPeriod,
+			&i.VerifierQuorum,
+			&i.LastCheckIn,
+			&i.CurrentStatus,
+			&i.CreatedAt,
+			&i.Role,
+			&i.DisabledAt,
+			&i.DeletionDueAt,
+			&i.DeletionStartedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUsersDueForDeletion = `-- name: ListUsersDueForDeletion :many
+SELECT id, name, email, password_hash, is_paused, check_in_interval, trigger_interval_num, buffer_period, verifier_quorum, last_check_in, current_status, created_at, role, disabled_at, deletion_due_at, deletion_started_at FROM users
+WHERE deletion_due_at <= ? AND deletion_started_at IS NULL
+`
+
+func (q *Queries) ListUsersDueForDeletion(ctx context.Context, deletionDueAt sql.NullTime) ([]User, error) {
+	rows, err := q.query(ctx, q.listUsersDueForDeletionStmt, listUsersDueForDeletion, deletionDueAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Email,
+			&i.PasswordHash,
+			&i.IsPaused,
+			&i.CheckInInterval,
+			&i.TriggerIntervalNum,
+			&i.BufferPeriod,
+			&i.VerifierQuorum,
+			&i.LastCheckIn,
+			&i.CurrentStatus,
+			&i.CreatedAt,
+			&i.Role,
+			&i.DisabledAt,
+			&i.DeletionDueAt,
+			&i.DeletionStartedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listVaultAccess = `-- name: ListVaultAccess :many
+SELECT vault_id, beneficiary_id, granted_at, share_index, encrypted_share, wrapped_key FROM vault_access
+WHERE vault_id = ?
+`
+
+func (q *Queries) ListVaultAccess(ctx context.Context, vaultID string) ([]VaultAccess, error) {
+	rows, err := q.query(ctx, q.listVaultAccessStmt, listVaultAccess, vaultID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []VaultAccess
+	for rows.Next() {
+		var i VaultAccess
+		if err := rows.Scan(
+			&i.VaultID,
+			&i.BeneficiaryID,
+			&i.GrantedAt,
+			&i.ShareIndex,
+			&i.EncryptedShare,
+			&i.WrappedKey,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listVaultRecipients = `-- name: ListVaultRecipients :many
+SELECT b.id, b.beneficiary_name, b.public_key, a.wrapped_key
+FROM beneficiaries b
+LEFT JOIN vault_access a ON a.beneficiary_id = b.id AND a.vault_id = ?
+WHERE b.user_id = ?
+ORDER BY b.created_at ASC
+`
+
+type ListVaultRecipientsParams struct {
+	VaultID string `json:"vault_id"`
+	UserID  string `json:"user_id"`
+}
+
+type ListVaultRecipientsRow struct {
+	ID              string            `json:"id"`
+	BeneficiaryName core.SecretString `json:"beneficiary_name"`
+	PublicKey       sql.NullString    `json:"public_key"`
+	WrappedKey      sql.NullString    `json:"wrapped_key"`
+}
+
+func (q *Queries) ListVaultRecipients(ctx context.Context, arg ListVaultRecipientsParams) ([]ListVaultRecipientsRow, error) {
+	rows, err := q.query(ctx, q.listVaultRecipientsStmt, listVaultRecipients, arg.VaultID, arg.UserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListVaultRecipientsRow
+	for rows.Next() {
+		var i ListVaultRecipientsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.BeneficiaryName,
+			&i.PublicKey,
+			&i.WrappedKey,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listVaultShares = `-- name: ListVaultShares :many
+SELECT vault_id, beneficiary_id, granted_at, share_index, encrypted_share, wrapped_key FROM vault_access
+WHERE vault_id = ? AND share_index IS NOT NULL
+ORDER BY share_index ASC
+`
+
+func (q *Queries) ListVaultShares(ctx context.Context, vaultID string) ([]VaultAccess, error) {
+	rows, err := q.query(ctx, q.listVaultSharesStmt, listVaultShares, vaultID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []VaultAccess
+	for rows.Next() {
+		var i VaultAccess
+		if err := rows.Scan(
+			&i.VaultID,
+			&i.BeneficiaryID,
+			&i.GrantedAt,
+			&i.ShareIndex,
+			&i.EncryptedShare,
+			&i.WrappedKey,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listVaultUsage = `-- name: ListVaultUsage :many
+SELECT
+    v.id, v.vault_name,
+    CAST(COUNT(a.id) AS INTEGER) AS artifacts,
+    CAST(COALESCE(SUM(LENGTH(a.encrypted_blob)), 0) AS INTEGER) AS bytes
+FROM vaults v
+LEFT JOIN artifacts a ON a.vault_id = v.id
+WHERE v.user_id = ?
+GROUP BY v.id
+ORDER BY v.created_at
+`
+
+type ListVaultUsageRow struct {
+	ID        string `json:"id"`
+	VaultName string `json:"vault_name"`
+	Artifacts int64  `json:"artifacts"`
+	Bytes     int64  `json:"bytes"`
+}
+
+func (q *Queries) ListVaultUsage(ctx context.Context, userID string) ([]ListVaultUsageRow, error) {
+	rows, err := q.query(ctx, q.listVaultUsageStmt, listVaultUsage, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListVaultUsageRow
+	for rows.Next() {
+		var i ListVaultUsageRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.VaultName,
+			&i.Artifacts,
+			&i.Bytes,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markContactMethodVerified = `-- name: MarkContactMethodVerified :exec
+UPDATE contact_methods
+SET verified_at = ?
+WHERE id = ?
+`
+
+type MarkContactMethodVerifiedParams struct {
+	VerifiedAt sql.NullTime `json:"verified_at"`
+	ID         string       `json:"id"`
+}
+
+func (q *Queries) MarkContactMethodVerified(ctx context.Context, arg MarkContactMethodVerifiedParams) error {
+	_, err := q.exec(ctx, q.markContactMethodVerifiedStmt, markContactMethodVerified, arg.VerifiedAt, arg.ID)
+	return err
+}
+
+const markNotificationFailed = `-- name: MarkNotificationFailed :exec
+UPDATE notification_outbox
+SET status = ?, attempts = attempts + 1, last_error = ?
+WHERE id = ?
+`
+
+type MarkNotificationFailedParams struct {
+	Status    core.NotificationStatus `json:"status"`
+	LastError sql.NullString          `json:"last_error"`
+	ID        string                  `json:"id"`
+}
+
+func (q *Queries) MarkNotificationFailed(ctx context.Context, arg MarkNotificationFailedParams) error {
+	_, err := q.exec(ctx, q.markNotificationFailedStmt, markNotificationFailed, arg.Status, arg.LastError, arg.ID)
+	return err
+}
+
+const markNotificationSent = `-- name: MarkNotificationSent :exec
+UPDATE notification_outbox
+SET status = 'SENT', attempts = attempts + 1, sent_at = ?, last_error = NULL,
+    body = CASE WHEN redact_after_send THEN '' ELSE body END,
+    html_body = CASE WHEN redact_after_send THEN NULL ELSE html_body END
+WHERE id = ?
+`
+
+type MarkNotificationSentParams struct {
+	SentAt sql.NullTime `json:"sent_at"`
+	ID     string       `json:"id"`
+}
+
+func (q *Queries) MarkNotificationSent(ctx context.Context, arg MarkNotificationSentParams) error {
+	_, err := q.exec(ctx, q.markNotificationSentStmt, markNotificationSent, arg.SentAt, arg.ID)
+	return err
+}
+
+const redactNotification = `-- name: RedactNotification :exec
+UPDATE notification_outbox
+SET body = '', html_body = NULL
+WHERE id = ? AND redact_after_send
+`
+
+func (q *Queries) RedactNotification(ctx context.Context, id string) error {
+	_, err := q.exec(ctx, q.redactNotificationStmt, redactNotification, id)
+	return err
+}
+
+const redeemInvite = `-- name: RedeemInvite :execrows
+UPDATE invites SET uses = uses + 1
+WHERE id = ? AND uses < max_uses AND expires_at > ?
+`
+
+type RedeemInviteParams struct {
+	ID        string    `json:"id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func (q *Queries) RedeemInvite(ctx context.Context, arg RedeemInviteParams) (int64, error) {
+	result, err := q.exec(ctx, q.redeemInviteStmt, redeemInvite, arg.ID, arg.ExpiresAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const resetVerifierConfirmations = `-- name: ResetVerifierConfirmations :exec
+UPDATE beneficiaries
+SET has_confirmed = FALSE, confirmed_at = NULL, verify_token_hash = NULL
+WHERE user_id = ?
+`
+
+func (q *Queries) ResetVerifierConfirmations(ctx context.Context, userID string) error {
+	_, err := q.exec(ctx, q.resetVerifierConfirmationsStmt, resetVerifierConfirmations, userID)
+	return err
+}
+
+const rewrapVaultKey = `-- name: RewrapVaultKey :execrows
+UPDATE vaults
+SET sealed_key = ?, sealed_key_id = ?
+WHERE id = ? AND sealed_key = ?
+`
+
+type RewrapVaultKeyParams struct {
+	SealedKey    sql.NullString `json:"sealed_key"`
+	SealedKeyID  sql.NullString `json:"sealed_key_id"`
+	ID           string         `json:"id"`
+	OldSealedKey sql.NullString `json:"old_sealed_key"`
+}
+
+func (q *Queries) RewrapVaultKey(ctx context.Context, arg RewrapVaultKeyParams) (int64, error) {
+	result, err := q.exec(ctx, q.rewrapVaultKeyStmt, rewrapVaultKey,
+		arg.SealedKey,
+		arg.SealedKeyID,
+		arg.ID,
+		arg.OldSealedKey,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const scheduleUserDeletion = `-- name: ScheduleUserDeletion :execrows
+UPDATE users SET deletion_due_at = ?
+WHERE id = ? AND deletion_started_at IS NULL
+`
+
+type ScheduleUserDeletionParams struct {
+	DeletionDueAt sql.NullTime `json:"deletion_due_at"`
+	ID            string       `json:"id"`
+}
+
+func (q *Queries) ScheduleUserDeletion(ctx context.Context, arg ScheduleUserDeletionParams) (int64, error) {
+	result, err := q.exec(ctx, q.scheduleUserDeletionStmt, scheduleUserDeletion, arg.DeletionDueAt, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setBeneficiaryPublicKey = `-- name: SetBeneficiaryPublicKey :exec
+UPDATE beneficiaries
+SET public_key = ?
+WHERE id = ?
+`
+
+type SetBeneficiaryPublicKeyParams struct {
+	PublicKey sql.NullString `json:"public_key"`
+	ID        string         `json:"id"`
+}
+
+func (q *Queries) SetBeneficiaryPublicKey(ctx context.Context, arg SetBeneficiaryPublicKeyParams) error {
+	_, err := q.exec(ctx, q.setBeneficiaryPublicKeyStmt, setBeneficiaryPublicKey, arg.PublicKey, arg.ID)
+	return err
+}
+
+const setBeneficiaryReleaseToken = `-- name: SetBeneficiaryReleaseToken :exec
+UPDATE beneficiaries
+SET release_token_hash = ?
+WHERE id = ?
+`
+
+type SetBeneficiaryReleaseTokenParams struct {
+	ReleaseTokenHash sql.NullString `json:"release_token_hash"`
+	ID               string         `json:"id"`
+}
+
+func (q *Queries) SetBeneficiaryReleaseToken(ctx context.Context, arg SetBeneficiaryReleaseTokenParams) error {
+	_, err := q.exec(ctx, q.setBeneficiaryReleaseTokenStmt, setBeneficiaryReleaseToken, arg.ReleaseTokenHash, arg.ID)
+	return err
+}
+
+const setBeneficiaryVerifyToken = `-- name: SetBeneficiaryVerifyToken :exec
+UPDATE beneficiaries
+SET verify_token_hash = ?
+WHERE id = ?
+`
+
+type SetBeneficiaryVerifyTokenParams struct {
+	VerifyTokenHash sql.NullString `json:"verify_token_hash"`
+	ID              string         `json:"id"`
+}
+
+func (q *Queries) SetBeneficiaryVerifyToken(ctx context.Context, arg SetBeneficiaryVerifyTokenParams) error {
+	_, err := q.exec(ctx, q.setBeneficiaryVerifyTokenStmt, setBeneficiaryVerifyToken, arg.VerifyTokenHash, arg.ID)
+	return err
+}
+
+const setUserRole = `-- name: SetUserRole :execrows
+UPDATE users SET role = ? WHERE id = ?
+`
+
+type SetUserRoleParams struct {
+	Role core.UserRole `json:"role"`
+	ID   string        `json:"id"`
+}
+
+func (q *Queries) SetUserRole(ctx context.Context, arg SetUserRoleParams) (int64, error) {
+	result, err := q.exec(ctx, q.setUserRoleStmt, setUserRole, arg.Role, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const startUserDeletion = `-- name: StartUserDeletion :execrows
+UPDATE users SET deletion_started_at = ?, disabled_at = ?
+WHERE id = ? AND deletion_due_at <= ? AND deletion_started_at IS NULL
+`
+
+type StartUserDeletionParams struct {
+	DeletionStartedAt sql.NullTime `json:"deletion_started_at"`
+	DisabledAt        sql.NullTime `json:"disabled_at"`
+	ID                string       `json:"id"`
+	DeletionDueAt     sql.NullTime `json:"deletion_due_at"`
+}
+
+func (q *Queries) StartUserDeletion(ctx context.Context, arg StartUserDeletionParams) (int64, error) {
+	result, err := q.exec(ctx, q.startUserDeletionStmt, startUserDeletion,
+		arg.DeletionStartedAt,
+		arg.DisabledAt,
+		arg.ID,
+		arg.DeletionDueAt,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const takeOIDCLogin = `-- name: TakeOIDCLogin :one
+DELETE FROM oidc_logins
+WHERE state_hash = ? AND expires_at > ?
+RETURNING state_hash, code_verifier, nonce, user_id, redirect_to, expires_at
+`
+
+type TakeOIDCLoginParams struct {
+	StateHash string    `json:"state_hash"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func (q *Queries) TakeOIDCLogin(ctx context.Context, arg TakeOIDCLoginParams) (OidcLogin, error) {
+	row := q.queryRow(ctx, q.takeOIDCLoginStmt, takeOIDCLogin, arg.StateHash, arg.ExpiresAt)
+	var i OidcLogin
+	err := row.Scan(
+		&i.StateHash,
+		&i.CodeVerifier,
+		&i.Nonce,
+		&i.UserID,
+		&i.RedirectTo,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
+
+const touchUserIdentity = `-- name: TouchUserIdentity :exec
+UPDATE user_identities SET email = ?, last_login_at = ?
+WHERE id = ?
+`
+
+type TouchUserIdentityParams struct {
+	Email       sql.NullString `json:"email"`
+	LastLoginAt sql.NullTime   `json:"last_login_at"`
+	ID          string         `json:"id"`
+}
+
+func (q *Queries) TouchUserIdentity(ctx context.Context, arg TouchUserIdentityParams) error {
+	_, err := q.exec(ctx, q.touchUserIdentityStmt, touchUserIdentity, arg.Email, arg.LastLoginAt, arg.ID)
+	return err
+}
+
+const transitionUserStatus = `-- name: TransitionUserStatus :execrows
+UPDATE users
+SET current_status = ?
+WHERE id = ? AND current_status = ?
+`
+
+type TransitionUserStatusParams struct {
+	ToStatus   core.UserStatus `json:"to_status"`
+	ID         string          `json:"id"`
+	FromStatus core.UserStatus `json:"from_status"`
+}
+
+func (q *Queries) TransitionUserStatus(ctx context.Context, arg TransitionUserStatusParams) (int64, error) {
+	result, err := q.exec(ctx, q.transitionUserStatusStmt, transitionUserStatus, arg.ToStatus, arg.ID, arg.FromStatus)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const updateArtifactBlob = `-- name: UpdateArtifactBlob :exec
+UPDATE artifacts
+SET encrypted_blob = ?
+WHERE id = ?
+`
+
+type UpdateArtifactBlobParams struct {
+	EncryptedBlob core.EncryptedBlob `json:"encrypted_blob"`
+	ID            string             `json:"id"`
+}
+
+func (q *Queries) UpdateArtifactBlob(ctx context.Context, arg UpdateArtifactBlobParams) error {
+	_, err := q.exec(ctx, q.updateArtifactBlobStmt, updateArtifactBlob, arg.EncryptedBlob, arg.ID)
+	return err
+}
+
+const updateArtifactCiphertext = `-- name: UpdateArtifactCiphertext :exec
+UPDATE artifacts
+SET encrypted_blob = ?, iv = ?, envelope_version = ?
+WHERE id = ? AND vault_id = ?
+`
+
+type UpdateArtifactCiphertextParams struct {
+	EncryptedBlob   core.EncryptedBlob   `json:"encrypted_blob"`
+	Iv              string               `json:"iv"`
+	EnvelopeVersion core.EnvelopeVersion `json:"envelope_version"`
+	ID              string               `json:"id"`
+	VaultID         string               `json:"vault_id"`
+}
+
+func (q *Queries) UpdateArtifactCiphertext(ctx context.Context, arg UpdateArtifactCiphertextParams) error {
+	_, err := q.exec(ctx, q.updateArtifactCiphertextStmt, updateArtifactCiphertext,
+		arg.EncryptedBlob,
+		arg.Iv,
+		arg.EnvelopeVersion,
+		arg.ID,
+		arg.VaultID,
+	)
+	return err
+}
+
+const updateUserCheckIn = `-- name: UpdateUserCheckIn :execrows
+UPDATE users
+SET last_check_in = ?, current_status = 'ALIVE'
+WHERE id = ? AND current_status != 'CONFIRMED_DEAD'
+`
+
+type UpdateUserCheckInParams struct {
+	LastCheckIn time.Time `json:"last_check_in"`
+	ID          string    `json:"id"`
+}
+
+func (q *Queries) UpdateUserCheckIn(ctx context.Context, arg UpdateUserCheckInParams) (int64, error) {
+	result, err := q.exec(ctx, q.updateUserCheckInStmt, updateUserCheckIn, arg.LastCheckIn, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const updateVaultAccessMaterial = `-- name: UpdateVaultAccessMaterial :exec
+UPDATE vault_access
+SET wrapped_key = ?, encrypted_share = ?
+WHERE vault_id = ? AND beneficiary_id = ?
+`
+
+type UpdateVaultAccessMaterialParams struct {
+	WrappedKey     sql.NullString `json:"wrapped_key"`
+	EncryptedShare sql.NullString `json:"encrypted_share"`
+	VaultID        string         `json:"vault_id"`
+	BeneficiaryID  string         `json:"beneficiary_id"`
+}
+
+func (q *Queries) UpdateVaultAccessMaterial(ctx context.Context, arg UpdateVaultAccessMaterialParams) error {
+	_, err := q.exec(ctx, q.updateVaultAccessMaterialStmt, updateVaultAccessMaterial,
+		arg.WrappedKey,
+		arg.EncryptedShare,
+		arg.VaultID,
+		arg.BeneficiaryID,
+	)
+	return err
+}
+
+const updateVaultHint = `-- name: UpdateVaultHint :exec
+UPDATE vaults
+SET hint = ?
+WHERE id = ?
+`
+
+type UpdateVaultHintParams struct {
+	Hint core.NullSecretString `json:"hint"`
+	ID   string                `json:"id"`
+}
+
+func (q *Queries) UpdateVaultHint(ctx context.Context, arg UpdateVaultHintParams) error {
+	_, err := q.exec(ctx, q.updateVaultHintStmt, updateVaultHint, arg.Hint, arg.ID)
+	return err
+}
+
+const updateVaultKDF = `-- name: UpdateVaultKDF :exec
+UPDATE vaults
+SET kdf_salt = ?, kdf_params = ?
+WHERE id = ?
+`
+
+type UpdateVaultKDFParams struct {
+	KdfSalt   string         `json:"kdf_salt"`
+	KdfParams core.KDFParams `json:"kdf_params"`
+	ID        string         `json:"id"`
+}
+
+func (q *Queries) UpdateVaultKDF(ctx context.Context, arg UpdateVaultKDFParams) error {
+	_, err := q.exec(ctx, q.updateVaultKDFStmt, updateVaultKDF, arg.KdfSalt, arg.KdfParams, arg.ID)
+	return err
+}
+
+const updateVaultSeal = `-- name: UpdateVaultSeal :exec
+UPDATE vaults
+SET sealed_key = ?, sealed_key_id = ?, unsealed_at = ?
+WHERE id = ?
+`
+
+type UpdateVaultSealParams struct {
+	SealedKey   sql.NullString `json:"sealed_key"`
+	SealedKeyID sql.NullString `json:"sealed_key_id"`
+	UnsealedAt  sql.NullTime   `json:"unsealed_at"`
+	ID          string         `json:"id"`
+}
+
+func (q *Queries) UpdateVaultSeal(ctx context.Context, arg UpdateVaultSealParams) error {
+	_, err := q.exec(ctx, q.updateVaultSealStmt, updateVaultSeal,
+		arg.SealedKey,
+		arg.SealedKeyID,
+		arg.UnsealedAt,
+		arg.ID,
+	)
+	return err
+}
+
+const updateVaultShares = `-- name: UpdateVaultShares :exec
+UPDATE vaults
+SET share_threshold = ?, share_count = ?
+WHERE id = ?
+`
+
+type UpdateVaultSharesParams struct {
+	ShareThreshold sql.NullInt64 `json:"share_threshold"`
+	ShareCount     sql.NullInt64 `json:"share_count"`
+	ID             string        `json:"id"`
+}
+
+func (q *Queries) UpdateVaultShares(ctx context.Context, arg UpdateVaultSharesParams) error {
+	_, err := q.exec(ctx, q.updateVaultSharesStmt, updateVaultShares, arg.ShareThreshold, arg.ShareCount, arg.ID)
+	return err
+}
+
+const upsertContactVerification = `-- name: UpsertContactVerification :exec
+INSERT INTO contact_verifications (contact_method_id, code_hash, attempts, expires_at)
+VALUES (?, ?, 0, ?)
+ON CONFLICT(contact_method_id) DO UPDATE SET
+    code_hash = excluded.code_hash,
+    attempts = 0,
+    expires_at = excluded.expires_at,
+    created_at = CURRENT_TIMESTAMP
+`
+
+type UpsertContactVerificationParams struct {
+	ContactMethodID string    `json:"contact_method_id"`
+	CodeHash        string    `json:"code_hash"`
+	ExpiresAt       time.Time `json:"expires_at"`
+}
+
+func (q *Queries) UpsertContactVerification(ctx context.Context, arg UpsertContactVerificationParams) error {
+	_, err := q.exec(ctx, q.upsertContactVerificationStmt, upsertContactVerification, arg.ContactMethodID, arg.CodeHash, arg.ExpiresAt)
+	return err
+}
+
+const upsertDataKey = `-- name: UpsertDataKey :exec
+INSERT INTO data_keys (id, wrapped_key, kek_id)
+VALUES (?, ?, ?)
+ON CONFLICT(id) DO UPDATE SET
+    wrapped_key = excluded.wrapped_key,
+    kek_id = excluded.kek_id
+`
+
+type UpsertDataKeyParams struct {
+	ID         string `json:"id"`
+	WrappedKey string `json:"wrapped_key"`
+	KekID      string `json:"kek_id"`
+}
+
+func (q *Queries) UpsertDataKey(ctx context.Context, arg UpsertDataKeyParams) error {
+	_, err := q.exec(ctx, q.upsertDataKeyStmt, upsertDataKey, arg.ID, arg.WrappedKey, arg.KekID)
+	return err
+}
+
+const upsertInstanceSetting = `-- name: UpsertInstanceSetting :exec
+INSERT INTO instance_settings (key, value, updated_at)
+VALUES (?, ?, CURRENT_TIMESTAMP)
+ON CONFLICT(key) DO UPDATE SET value = excluded.value, updated_at = excluded.updated_at
+`
+
+type UpsertInstanceSettingParams struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+func (q *Queries) UpsertInstanceSetting(ctx context.Context, arg UpsertInstanceSettingParams) error {
+	_, err := q.exec(ctx, q.upsertInstanceSettingStmt, upsertInstanceSetting, arg.Key, arg.Value)
+	return err
+}
+
+const upsertReminderPolicy = `-- name: UpsertReminderPolicy :one
+INSERT INTO reminder_policies (
+    user_id, primary_contact_id, steps, quiet_hours_start, quiet_hours_end, time_zone, updated_at
+) VALUES (
+    ?, ?, ?, ?, ?, ?, ?
+)
+ON CONFLICT(user_id) DO UPDATE SET
+    primary_contact_id = excluded.primary_contact_id,
+    steps = excluded.steps,
+    quiet_hours_start = excluded.quiet_hours_start,
+    quiet_hours_end = excluded.quiet_hours_end,
+    time_zone = excluded.time_zone,
+    updated_at = excluded.updated_at
+RETURNING user_id, primary_contact_id, steps, quiet_hours_start, quiet_hours_end, time_zone, updated_at
+`
+
+type UpsertReminderPolicyParams struct {
+	UserID           string               `json:"user_id"`
+	PrimaryContactID sql.NullString       `json:"primary_contact_id"`
+	Steps            core.EscalationSteps `json:"steps"`
+	QuietHoursStart  sql.NullString       `json:"quiet_hours_start"`
+	QuietHoursEnd    sql.NullString       `json:"quiet_hours_end"`
+	TimeZone         string               `json:"time_zone"`
+	UpdatedAt        time.Time            `json:"updated_at"`
+}
+
+func (q *Queries) UpsertReminderPolicy(ctx context.Context, arg UpsertReminderPolicyParams) (ReminderPolicy, error) {
+	row := q.queryRow(ctx, q.upsertReminderPolicyStmt, upsertReminderPolicy,
+		arg.UserID,
+		arg.PrimaryContactID,
+		arg.Steps,
+		arg.QuietHoursStart,
+		arg.QuietHoursEnd,
+		arg.TimeZone,
+		arg.UpdatedAt,
+	)
+	var i ReminderPolicy
+	err := row.Scan(
+		&i.UserID,
+		&i.PrimaryContactID,
+		&i.Steps,
+		&i.QuietHoursStart,
+		&i.QuietHoursEnd,
+		&i.TimeZone,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const upsertRotationArtifact = `-- name: UpsertRotationArtifact :exec
+INSERT INTO vault_rotation_artifacts (rotation_id, artifact_id, encrypted_blob, iv, envelope_version)
+VALUES (?, ?, ?, ?, ?)
+ON CONFLICT(rotation_id, artifact_id) DO UPDATE SET
+    encrypted_blob = excluded.encrypted_blob,
+    iv = excluded.iv,
+    envelope_version = excluded.envelope_version
+`
+
+type UpsertRotationArtifactParams struct {
+	RotationID      string               `json:"rotation_id"`
+	ArtifactID      string               `json:"artifact_id"`
+	EncryptedBlob   core.EncryptedBlob   `json:"encrypted_blob"`
+	Iv              string               `json:"iv"`
+	EnvelopeVersion core.EnvelopeVersion `json:"envelope_version"`
+}
+
+func (q *Queries) UpsertRotationArtifact(ctx context.Context, arg UpsertRotationArtifactParams) error {
+	_, err := q.exec(ctx, q.upsertRotationArtifactStmt, upsertRotationArtifact,
+		arg.RotationID,
+		arg.ArtifactID,
+		arg.EncryptedBlob,
+		arg.Iv,
+		arg.EnvelopeVersion,
+	)
+	return err
+}
+
+const upsertUserQuota = `-- name: UpsertUserQuota :exec
+INSERT INTO user_quotas (user_id, max_vaults, max_artifacts, max_artifact_bytes, max_storage_bytes, updated_at)
+VALUES (?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+ON CONFLICT(user_id) DO UPDATE SET
+    max_vaults = excluded.max_vaults,
+    max_artifacts = excluded.max_artifacts,
+    max_artifact_bytes = excluded.max_artifact_bytes,
+    max_storage_bytes = excluded.max_storage_bytes,
+    updated_at = excluded.updated_at
+`
+
+type UpsertUserQuotaParams struct {
+	UserID           string        `json:"user_id"`
+	MaxVaults        sql.NullInt64 `json:"max_vaults"`
+	MaxArtifacts     sql.NullInt64 `json:"max_artifacts"`
+	MaxArtifactBytes sql.NullInt64 `json:"max_artifact_bytes"`
+	MaxStorageBytes  sql.NullInt64 `json:"max_storage_bytes"`
+}
+
+func (q *Queries) UpsertUserQuota(ctx context.Context, arg UpsertUserQuotaParams) error {
+	_, err := q.exec(ctx, q.upsertUserQuotaStmt, upsertUserQuota,
+		arg.UserID,
+		arg.MaxVaults,
+		arg.MaxArtifacts,
+		arg.MaxArtifactBytes,
+		arg.MaxStorageBytes,
+	)
+	return err
+}
+
+const upsertVaultShare = `-- name: UpsertVaultShare :exec
+INSERT INTO vault_access (vault_id, beneficiary_id, share_index, encrypted_share)
+VALUES (?, ?, ?, ?)
+ON CONFLICT(vault_id, beneficiary_id) DO UPDATE SET
+    share_index = excluded.share_index,
+    encrypted_share = excluded.encrypted_share
+`
+
+type UpsertVaultShareParams struct {
+	VaultID        string         `json:"vault_id"`
+	BeneficiaryID  string         `json:"beneficiary_id"`
+	ShareIndex     sql.NullInt64  `json:"share_index"`
+	EncryptedShare sql.NullString `json:"encrypted_share"`
+}
+
+func (q *Queries) UpsertVaultShare(ctx context.Context, arg UpsertVaultShareParams) error {
+	_, err := q.exec(ctx, q.upsertVaultShareStmt, upsertVaultShare,
+		arg.VaultID,
+		arg.BeneficiaryID,
+		arg.ShareIndex,
+		arg.EncryptedShare,
+	)
+	return err
+}
+
+const upsertWrappedKey = `-- name: UpsertWrappedKey :exec
+INSERT INTO vault_access (vault_id, beneficiary_id, wrapped_key)
+VALUES (?, ?, ?)
+ON CONFLICT(vault_id, beneficiary_id) DO UPDATE SET
+    wrapped_key = excluded.wrapped_key
+`
+
+type UpsertWrappedKeyParams struct {
+	VaultID       string         `json:"vault_id"`
+	BeneficiaryID string         `json:"beneficiary_id"`
+	WrappedKey    sql.NullString `json:"wrapped_key"`
 }
 
-func (q *Queries) UpdateUserCheckIn(ctx context.Context, arg UpdateUserCheckInParams) error {
-	_, err := q.exec(ctx, q.updateUserCheckInStmt, updateUserCheckIn, arg.LastCheckIn, arg.ID)
+func (q *Queries) UpsertWrappedKey(ctx context.Context, arg UpsertWrappedKeyParams) error {
+	_, err := q.exec(ctx, q.upsertWrappedKeyStmt, upsertWrappedKey, arg.VaultID, arg.BeneficiaryID, arg.WrappedKey)
 	return err
 }
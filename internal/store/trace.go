@@ -0,0 +1,74 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// Queries slower than this are logged as warnings
+const slowQuery = 250 * time.Millisecond
+
+// tracedDB logs every query at debug level with the caller's context, so
+// store calls show up under the request ID that made them
+type tracedDB struct {
+	DBTX
+}
+
+func (d tracedDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	start := time.Now()
+	res, err := d.DBTX.ExecContext(ctx, query, args...)
+	logQuery(ctx, query, start, err)
+	return res, err
+}
+
+func (d tracedDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	start := time.Now()
+	rows, err := d.DBTX.QueryContext(ctx, query, args...)
+	logQuery(ctx, query, start, err)
+	return rows, err
+}
+
+func (d tracedDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	start := time.Now()
+	row := d.DBTX.QueryRowContext(ctx, query, args...)
+	logQuery(ctx, query, start, row.Err())
+	return row
+}
+
+// Arguments are never logged; they hold names, blobs and token hashes
+func logQuery(ctx context.Context, query string, start time.Time, err error) {
+	elapsed := time.Since(start)
+	level := slog.LevelDebug
+	if elapsed >= slowQuery {
+		level = slog.LevelWarn
+	}
+	if !slog.Default().Enabled(ctx, level) {
+		return
+	}
+
+	attrs := []slog.Attr{slog.String("query", queryName(query)), slog.Duration("duration", elapsed)}
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		attrs = append(attrs, slog.Any("error", err))
+	}
+	slog.LogAttrs(ctx, level, "store query", attrs...)
+}
+
+// sqlc prefixes each query with "-- name: Name :kind"
+func queryName(query string) string {
+	if rest, ok := strings.CutPrefix(query, "-- name: "); ok {
+		if name, _, ok := strings.Cut(rest, " "); ok {
+			return name
+		}
+	}
+	return "adhoc"
+}
+
+// withTx runs queries inside tx, traced and with encrypted columns handled
+// like the rest of the store
+func (s *Store) withTx(tx *sql.Tx) *Store {
+	return &Store{Queries: New(tracedDB{tx})}
+}
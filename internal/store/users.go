@@ -2,7 +2,9 @@ package store
 
 import (
 	"context"
+	"crypto/rand"
 	"database/sql"
+	"encoding/base64"
 	"fmt"
 	"time"
 
@@ -11,7 +13,17 @@ import (
 	"github.com/vmpyr/afterlight/internal/core"
 )
 
-func (s *Store) CreateUserTx(ctx context.Context, input core.RegisterRequest) (User, error) {
+// Liveness settings a new account starts with
+type UserDefaults struct {
+	CheckInInterval  time.Duration
+	TriggerIntervals int64
+	BufferPeriod     time.Duration
+	VerifierQuorum   int64
+}
+
+// invite, if set, is redeemed in the same transaction; the account is not
+// created if it has been used up in the meantime
+func (s *Store) CreateUserTx(ctx context.Context, input core.RegisterRequest, defaults UserDefaults, invite *Invite) (User, error) {
 	if err := core.IsValidPassword(input.Password); err != nil {
 		return User{}, fmt.Errorf("password does not meet complexity requirements: %w", err)
 	}
@@ -19,7 +31,26 @@ func (s *Store) CreateUserTx(ctx context.Context, input core.RegisterRequest) (U
 	if err != nil {
 		return User{}, fmt.Errorf("hashing failed: %w", err)
 	}
+	return s.insertUserTx(ctx, input.Name, input.Email, hash, defaults, invite, true)
+}
+
+// Creates an account for a user authenticated by a trusted reverse proxy. It
+// gets a random password nobody knows, so it can only be used through the proxy,
+// and is never made an admin, not even as the instance's first account.
+func (s *Store) ProvisionUserTx(ctx context.Context, name, email string, defaults UserDefaults) (User, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return User{}, err
+	}
+	hash, err := argon2id.CreateHash(base64.RawStdEncoding.EncodeToString(raw), argon2id.DefaultParams)
+	if err != nil {
+		return User{}, fmt.Errorf("hashing failed: %w", err)
+	}
+	return s.insertUserTx(ctx, name, email, hash, defaults, nil, false)
+}
 
+// firstIsAdmin makes the account an admin if it is the instance's first
+func (s *Store) insertUserTx(ctx context.Context, name, email, passwordHash string, defaults UserDefaults, invite *Invite, firstIsAdmin bool) (User, error) {
 	userID := uuid.New().String()
 	now := time.Now().UTC()
 
@@ -30,19 +61,43 @@ func (s *Store) CreateUserTx(ctx context.Context, input core.RegisterRequest) (U
 	}
 	defer tx.Rollback()
 
-	qTx := s.Queries.WithTx(tx)
+	qTx := s.withTx(tx)
+
+	if invite != nil {
+		n, err := qTx.RedeemInvite(ctx, RedeemInviteParams{ID: invite.ID, ExpiresAt: now})
+		if err != nil {
+			return User{}, err
+		}
+		if n == 0 {
+			return User{}, core.ErrInvalidInvite
+		}
+	}
+
+	// The first account registered on a fresh instance administers it
+	role := core.RoleUser
+	if firstIsAdmin {
+		existing, err := qTx.CountUsers(ctx)
+		if err != nil {
+			return User{}, err
+		}
+		if existing == 0 {
+			role = core.RoleAdmin
+		}
+	}
+
 	user, err := qTx.CreateUser(ctx, CreateUserParams{
 		ID:                 userID,
-		Name:               input.Name,
-		Email:              input.Email,
-		PasswordHash:       hash,
+		Name:               core.SecretString(name),
+		Email:              email,
+		PasswordHash:       passwordHash,
 		IsPaused:           false,
-		CheckInInterval:    2592000, // 30 days
-		TriggerIntervalNum: 4,
-		BufferPeriod:       604800, // 7 days
-		VerifierQuorum:     sql.NullInt64{Int64: 1, Valid: true},
+		CheckInInterval:    int64(defaults.CheckInInterval.Seconds()),
+		TriggerIntervalNum: defaults.TriggerIntervals,
+		BufferPeriod:       int64(defaults.BufferPeriod.Seconds()),
+		VerifierQuorum:     sql.NullInt64{Int64: defaults.VerifierQuorum, Valid: true},
 		LastCheckIn:        now,
 		CurrentStatus:      core.StatusAlive,
+		Role:               role,
 	})
 	if err != nil {
 		return User{}, err
@@ -52,7 +107,7 @@ func (s *Store) CreateUserTx(ctx context.Context, input core.RegisterRequest) (U
 		ID:          uuid.New().String(),
 		UserID:      sql.NullString{String: userID, Valid: true},
 		Channel:     "EMAIL",
-		Destination: input.Email,
+		Destination: core.SecretString(email),
 		Metadata:    core.Metadata{},
 		CreatedAt:   now,
 	})
@@ -66,3 +121,99 @@ func (s *Store) CreateUserTx(ctx context.Context, input core.RegisterRequest) (U
 
 	return user, nil
 }
+
+// Records a check-in and clears verifier confirmations and links left over
+// from the previous cycle. A released account cannot check in.
+func (s *Store) CheckInTx(ctx context.Context, userID string) (time.Time, error) {
+	now := time.Now().UTC()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer tx.Rollback()
+
+	qTx := s.withTx(tx)
+	n, err := qTx.UpdateUserCheckIn(ctx, UpdateUserCheckInParams{
+		LastCheckIn: now,
+		ID:          userID,
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+	// The engine may have released the account since the caller loaded it
+	if n == 0 {
+		return time.Time{}, core.ErrAccountReleased
+	}
+	if err := qTx.ResetVerifierConfirmations(ctx, userID); err != nil {
+		return time.Time{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return time.Time{}, err
+	}
+
+	return now, nil
+}
+
+// A liveness status change and the codes and notices that go out with it.
+// Token maps hold hashes by beneficiary ID.
+type StatusTransition struct {
+	UserID        string
+	From          core.UserStatus
+	To            core.UserStatus
+	VerifyTokens  map[string]string
+	ReleaseTokens map[string]string
+	Notifications []CreateNotificationParams
+}
+
+// Applies a status change together with its tokens and notices, so the owner is
+// never released without the beneficiaries being told. Returns false without
+// changing anything if the user is no longer in the From status.
+func (s *Store) TransitionUserTx(ctx context.Context, t StatusTransition) (bool, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	qTx := s.withTx(tx)
+	n, err := qTx.TransitionUserStatus(ctx, TransitionUserStatusParams{
+		ToStatus:   t.To,
+		ID:         t.UserID,
+		FromStatus: t.From,
+	})
+	if err != nil {
+		return false, err
+	}
+	if n == 0 {
+		return false, nil
+	}
+
+	for id, hash := range t.VerifyTokens {
+		if err := qTx.SetBeneficiaryVerifyToken(ctx, SetBeneficiaryVerifyTokenParams{
+			VerifyTokenHash: sql.NullString{String: hash, Valid: true},
+			ID:              id,
+		}); err != nil {
+			return false, err
+		}
+	}
+	for id, hash := range t.ReleaseTokens {
+		if err := qTx.SetBeneficiaryReleaseToken(ctx, SetBeneficiaryReleaseTokenParams{
+			ReleaseTokenHash: sql.NullString{String: hash, Valid: true},
+			ID:               id,
+		}); err != nil {
+			return false, err
+		}
+	}
+	for _, params := range t.Notifications {
+		if _, err := qTx.CreateNotification(ctx, params); err != nil {
+			return false, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -1,10 +1,309 @@
 package store
 
-import "time"
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/vmpyr/afterlight/internal/core"
+)
 
 type ListArtifactsResponse struct {
-	VaultName string     `json:"vault_name"`
-	Hint      string     `json:"hint,omitempty"`
-	Artifacts []Artifact `json:"artifacts"`
-	CreatedAt time.Time  `json:"created_at"`
+	VaultName              string               `json:"vault_name"`
+	Hint                   string               `json:"hint,omitempty"`
+	KdfSalt                string               `json:"kdf_salt"`
+	KDF                    core.KDFParams       `json:"kdf"`
+	Artifacts              []Artifact           `json:"artifacts"`
+	CurrentEnvelopeVersion core.EnvelopeVersion `json:"current_envelope_version"` // Artifacts below it should be re-encrypted
+	CreatedAt              time.Time            `json:"created_at"`
+}
+
+// Replaces a vault's share set; rows for beneficiaries not in shares lose their share.
+// Fails with ErrRotationInProgress while a rotation is open, since the new shares
+// go in with its commit.
+func (s *Store) SetVaultSharesTx(ctx context.Context, vaultID string, threshold int64, shares []UpsertVaultShareParams) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	qTx := s.withTx(tx)
+	if err := checkNoRotation(ctx, qTx, vaultID); err != nil {
+		return err
+	}
+	if err := qTx.ClearVaultShares(ctx, vaultID); err != nil {
+		return err
+	}
+	for _, share := range shares {
+		share.VaultID = vaultID
+		if err := qTx.UpsertVaultShare(ctx, share); err != nil {
+			return err
+		}
+	}
+	if err := qTx.UpdateVaultShares(ctx, UpdateVaultSharesParams{
+		ShareThreshold: sql.NullInt64{Int64: threshold, Valid: true},
+		ShareCount:     sql.NullInt64{Int64: int64(len(shares)), Valid: true},
+		ID:             vaultID,
+	}); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Stores wrapped keys, fails with ErrRotationInProgress like SetVaultSharesTx
+func (s *Store) SetWrappedKeysTx(ctx context.Context, vaultID string, keys []UpsertWrappedKeyParams) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	qTx := s.withTx(tx)
+	if err := checkNoRotation(ctx, qTx, vaultID); err != nil {
+		return err
+	}
+	for _, key := range keys {
+		key.VaultID = vaultID
+		if err := qTx.UpsertWrappedKey(ctx, key); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Switches a vault back to single passphrase mode
+func (s *Store) ClearVaultSharesTx(ctx context.Context, vaultID string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	qTx := s.withTx(tx)
+	if err := checkNoRotation(ctx, qTx, vaultID); err != nil {
+		return err
+	}
+	if err := qTx.ClearVaultShares(ctx, vaultID); err != nil {
+		return err
+	}
+	if err := qTx.UpdateVaultShares(ctx, UpdateVaultSharesParams{ID: vaultID}); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Removes one beneficiary's wrapped key, unless a rotation is open
+func (s *Store) DeleteWrappedKeyTx(ctx context.Context, arg DeleteWrappedKeyParams) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	qTx := s.withTx(tx)
+	if err := checkNoRotation(ctx, qTx, arg.VaultID); err != nil {
+		return err
+	}
+	if err := qTx.DeleteWrappedKey(ctx, arg); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Passes every piece of a vault's release material through transform (the hint,
+// each beneficiary's wrapped key and share, each artifact blob) and stores the
+// result together with the new seal state, all in one transaction. field names
+// the value so callers can bind ciphertexts to where they are stored.
+// Fails with ErrRotationInProgress while a rotation is open, because its
+// replacements were sealed under the seal state the vault had when uploaded.
+func (s *Store) RewriteVaultMaterialTx(ctx context.Context, vaultID string, transform func(field string, data []byte) ([]byte, error), seal UpdateVaultSealParams) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	qTx := s.withTx(tx)
+	if err := checkNoRotation(ctx, qTx, vaultID); err != nil {
+		return err
+	}
+
+	nullString := func(field string, v sql.NullString) (sql.NullString, error) {
+		if !v.Valid {
+			return v, nil
+		}
+		out, err := transform(field, []byte(v.String))
+		if err != nil {
+			return sql.NullString{}, err
+		}
+		return sql.NullString{String: string(out), Valid: true}, nil
+	}
+
+	vault, err := qTx.GetVault(ctx, vaultID)
+	if err != nil {
+		return err
+	}
+	hint, err := nullString("hint", sql.NullString(vault.Hint))
+	if err != nil {
+		return err
+	}
+	if err := qTx.UpdateVaultHint(ctx, UpdateVaultHintParams{Hint: core.NullSecretString(hint), ID: vaultID}); err != nil {
+		return err
+	}
+
+	access, err := qTx.ListVaultAccess(ctx, vaultID)
+	if err != nil {
+		return err
+	}
+	for _, a := range access {
+		wrapped, err := nullString("wrapped_key/"+a.BeneficiaryID, a.WrappedKey)
+		if err != nil {
+			return err
+		}
+		share, err := nullString("encrypted_share/"+a.BeneficiaryID, a.EncryptedShare)
+		if err != nil {
+			return err
+		}
+		if err := qTx.UpdateVaultAccessMaterial(ctx, UpdateVaultAccessMaterialParams{
+			WrappedKey:     wrapped,
+			EncryptedShare: share,
+			VaultID:        vaultID,
+			BeneficiaryID:  a.BeneficiaryID,
+		}); err != nil {
+			return err
+		}
+	}
+
+	artifacts, err := qTx.ListArtifactsByVaultID(ctx, vaultID)
+	if err != nil {
+		return err
+	}
+	for _, a := range artifacts {
+		blob, err := transform("artifact/"+a.ID, a.EncryptedBlob)
+		if err != nil {
+			return err
+		}
+		if err := qTx.UpdateArtifactBlob(ctx, UpdateArtifactBlobParams{
+			EncryptedBlob: blob,
+			ID:            a.ID,
+		}); err != nil {
+			return err
+		}
+	}
+
+	seal.ID = vaultID
+	if err := qTx.UpdateVaultSeal(ctx, seal); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Re-wraps the data keys of sealed vaults under the key keyID. Fails with
+// core.ErrSealsChanged, leaving everything as it was, if a vault was sealed,
+// unsealed or re-wrapped since the rewraps were prepared.
+func (s *Store) RewrapVaultKeysTx(ctx context.Context, keyID string, rewraps []RewrapVaultKeyParams) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	qTx := s.withTx(tx)
+	for _, r := range rewraps {
+		n, err := qTx.RewrapVaultKey(ctx, r)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return core.ErrSealsChanged
+		}
+	}
+
+	left, err := qTx.CountVaultsSealedUnderOtherKeys(ctx, sql.NullString{String: keyID, Valid: true})
+	if err != nil {
+		return err
+	}
+	if left > 0 {
+		return core.ErrSealsChanged
+	}
+
+	return tx.Commit()
+}
+
+// Creates a vault from an imported bundle together with all of its artifacts
+func (s *Store) ImportVaultTx(ctx context.Context, vault ImportVaultParams, artifacts []ImportArtifactParams, quota core.QuotaLimits) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	qTx := s.withTx(tx)
+	var size int64
+	for _, a := range artifacts {
+		size += int64(len(a.EncryptedBlob))
+	}
+	if err := checkQuota(ctx, qTx, vault.UserID, quota, 1, int64(len(artifacts)), size); err != nil {
+		return err
+	}
+	if err := qTx.ImportVault(ctx, vault); err != nil {
+		return err
+	}
+	for _, a := range artifacts {
+		a.VaultID = vault.ID
+		if err := qTx.ImportArtifact(ctx, a); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Swaps a vault's salt and KDF together with the ciphertext of every artifact
+// and the key material. Fails with an *ArtifactSetMismatchError unless
+// artifacts covers exactly the vault's current artifacts, so a vault is never
+// left half re-keyed, and with ErrRotationInProgress while a rotation is open.
+func (s *Store) RekeyVaultTx(ctx context.Context, kdf UpdateVaultKDFParams, artifacts []UpdateArtifactCiphertextParams, material VaultKeyMaterial) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	qTx := s.withTx(tx)
+	if err := checkNoRotation(ctx, qTx, kdf.ID); err != nil {
+		return err
+	}
+	current, err := qTx.ListArtifactsByVaultID(ctx, kdf.ID)
+	if err != nil {
+		return err
+	}
+	ids := make([]string, 0, len(artifacts))
+	for _, a := range artifacts {
+		ids = append(ids, a.ID)
+	}
+	if err := diffArtifactSets(current, ids); err != nil {
+		return err
+	}
+
+	for _, a := range artifacts {
+		a.VaultID = kdf.ID
+		if err := qTx.UpdateArtifactCiphertext(ctx, a); err != nil {
+			return err
+		}
+	}
+	if err := qTx.UpdateVaultKDF(ctx, kdf); err != nil {
+		return err
+	}
+	if err := replaceKeyMaterial(ctx, qTx, kdf.ID, material); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
@@ -0,0 +1,135 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/vmpyr/afterlight/internal/core"
+)
+
+type testVault struct {
+	Vault       Vault
+	Beneficiary string
+	Artifact    string
+}
+
+// A vault with one artifact and one beneficiary holding a share and a wrapped key
+func createTestVault(t *testing.T, s *Store) testVault {
+	t.Helper()
+	ctx := context.Background()
+	owner := createTestUser(t, s, "Owner", "owner@example.com")
+	vault, err := s.CreateVault(ctx, CreateVaultParams{
+		ID:        "vault",
+		UserID:    owner.ID,
+		VaultName: "Vault",
+		KdfSalt:   "00",
+		KdfParams: core.DefaultKDFParams,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	beneficiary, err := s.CreateBeneficiary(ctx, CreateBeneficiaryParams{
+		ID:              "beneficiary",
+		UserID:          owner.ID,
+		BeneficiaryName: "Beneficiary",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	artifact, err := s.CreateArtifact(ctx, CreateArtifactParams{
+		ID:            "artifact",
+		VaultID:       vault.ID,
+		MessageType:   core.MsgText,
+		EncryptedBlob: []byte("old"),
+		Iv:            "old",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetVaultSharesTx(ctx, vault.ID, 1, []UpsertVaultShareParams{{
+		BeneficiaryID:  beneficiary.ID,
+		ShareIndex:     sql.NullInt64{Int64: 1, Valid: true},
+		EncryptedShare: sql.NullString{String: "old share", Valid: true},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.UpsertWrappedKey(ctx, UpsertWrappedKeyParams{
+		VaultID:       vault.ID,
+		BeneficiaryID: beneficiary.ID,
+		WrappedKey:    sql.NullString{String: "old key", Valid: true},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return testVault{Vault: vault, Beneficiary: beneficiary.ID, Artifact: artifact.ID}
+}
+
+func vaultAccess(t *testing.T, s *Store, vaultID string) VaultAccess {
+	t.Helper()
+	access, err := s.ListVaultAccess(context.Background(), vaultID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(access) != 1 {
+		t.Fatalf("vault access rows = %d, want 1", len(access))
+	}
+	return access[0]
+}
+
+func TestRekeyVaultReplacesKeyMaterial(t *testing.T) {
+	s, _ := openEncrypted(t)
+	ctx := context.Background()
+	v := createTestVault(t, s)
+
+	kdf := UpdateVaultKDFParams{KdfSalt: "01", KdfParams: core.DefaultKDFParams, ID: v.Vault.ID}
+	artifacts := []UpdateArtifactCiphertextParams{{ID: v.Artifact, EncryptedBlob: []byte("new"), Iv: "new"}}
+	material := VaultKeyMaterial{WrappedKeys: []UpsertWrappedKeyParams{{
+		BeneficiaryID: v.Beneficiary,
+		WrappedKey:    sql.NullString{String: "new key", Valid: true},
+	}}}
+	if err := s.RekeyVaultTx(ctx, kdf, artifacts, material); err != nil {
+		t.Fatal(err)
+	}
+
+	access := vaultAccess(t, s, v.Vault.ID)
+	if access.EncryptedShare.Valid || access.ShareIndex.Valid {
+		t.Fatalf("share under the old passphrase kept: %+v", access)
+	}
+	if access.WrappedKey.String != "new key" {
+		t.Fatalf("wrapped key = %q, want the replacement", access.WrappedKey.String)
+	}
+	vault, err := s.GetVault(ctx, v.Vault.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vault.KdfSalt != "01" || vault.ShareThreshold.Valid {
+		t.Fatalf("vault after re-key = %+v", vault)
+	}
+}
+
+func TestRekeyVaultRejectedDuringRotation(t *testing.T) {
+	s, _ := openEncrypted(t)
+	ctx := context.Background()
+	v := createTestVault(t, s)
+
+	if _, err := s.StartVaultRotationTx(ctx, CreateVaultRotationParams{
+		ID:        "rotation",
+		VaultID:   v.Vault.ID,
+		KdfSalt:   "02",
+		KdfParams: core.DefaultKDFParams,
+		ExpiresAt: time.Now().Add(time.Hour),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	kdf := UpdateVaultKDFParams{KdfSalt: "01", KdfParams: core.DefaultKDFParams, ID: v.Vault.ID}
+	artifacts := []UpdateArtifactCiphertextParams{{ID: v.Artifact, EncryptedBlob: []byte("new"), Iv: "new"}}
+	if err := s.RekeyVaultTx(ctx, kdf, artifacts, VaultKeyMaterial{}); !errors.Is(err, ErrRotationInProgress) {
+		t.Fatalf("RekeyVaultTx during a rotation = %v, want ErrRotationInProgress", err)
+	}
+	if access := vaultAccess(t, s, v.Vault.ID); access.WrappedKey.String != "old key" {
+		t.Fatalf("wrapped key changed by a rejected re-key: %+v", access)
+	}
+}
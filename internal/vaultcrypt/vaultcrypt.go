@@ -0,0 +1,114 @@
+// Package vaultcrypt mirrors the browser's artifact encryption, so vaults can
+// be opened without the server.
+//
+// A vault key is derived from the vault passphrase and the hex decoded
+// kdf_salt with the vault's KDF (PBKDF2-HMAC-SHA256 or Argon2id). Each
+// artifact is AES-256-GCM ciphertext with the 16 byte tag appended (the
+// WebCrypto layout) under a 12 byte IV stored as hex.
+package vaultcrypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/vmpyr/afterlight/internal/core"
+	"golang.org/x/crypto/argon2"
+)
+
+const (
+	KeySize = 32
+	IVSize  = 12
+)
+
+var ErrInvalidSalt = errors.New("kdf_salt is not valid hex")
+var ErrInvalidIV = errors.New("iv must be 12 bytes of hex")
+var ErrDecrypt = errors.New("decryption failed: wrong passphrase or corrupted artifact")
+
+// Bundle is a vault as returned by the artifacts endpoints of the owner and
+// release APIs. Saving either response to a file gives a bundle the
+// decrypt command can open.
+type Bundle struct {
+	VaultName string           `json:"vault_name"`
+	Hint      string           `json:"hint,omitempty"`
+	KdfSalt   string           `json:"kdf_salt"`
+	KDF       core.KDFParams   `json:"kdf"`
+	Artifacts []BundleArtifact `json:"artifacts"`
+	CreatedAt time.Time        `json:"created_at"`
+}
+
+type BundleArtifact struct {
+	ID            string             `json:"id"`
+	MessageType   core.MessageType   `json:"message_type"`
+	EncryptedBlob core.EncryptedBlob `json:"encrypted_blob"`
+	IV            string             `json:"iv"`
+	CreatedAt     time.Time          `json:"created_at"`
+}
+
+// An unset KDF means the vault predates stored KDFs and used the default
+func DeriveKey(passphrase, kdfSalt string, kdf core.KDFParams) ([]byte, error) {
+	salt, err := hex.DecodeString(kdfSalt)
+	if err != nil || len(salt) == 0 {
+		return nil, ErrInvalidSalt
+	}
+	if kdf.Algorithm == "" {
+		kdf = core.DefaultKDFParams
+	}
+	if err := core.IsValidKDFParams(kdf); err != nil {
+		return nil, err
+	}
+
+	switch kdf.Algorithm {
+	case core.KDFArgon2id:
+		return argon2.IDKey([]byte(passphrase), salt, uint32(kdf.Iterations), uint32(kdf.MemoryKiB), uint8(kdf.Parallelism), KeySize), nil
+	default:
+		return pbkdf2.Key(sha256.New, passphrase, salt, kdf.Iterations, KeySize)
+	}
+}
+
+func Decrypt(key []byte, iv string, blob []byte) ([]byte, error) {
+	aead, nonce, err := open(key, iv)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := aead.Open(nil, nonce, blob, nil)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plaintext, nil
+}
+
+// Encrypt is the inverse of Decrypt, returning the hex IV and the ciphertext
+func Encrypt(key, plaintext []byte) (string, []byte, error) {
+	nonce := make([]byte, IVSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", nil, err
+	}
+	iv := hex.EncodeToString(nonce)
+	aead, _, err := open(key, iv)
+	if err != nil {
+		return "", nil, err
+	}
+	return iv, aead.Seal(nil, nonce, plaintext, nil), nil
+}
+
+func open(key []byte, iv string) (cipher.AEAD, []byte, error) {
+	nonce, err := hex.DecodeString(iv)
+	if err != nil || len(nonce) != IVSize {
+		return nil, nil, ErrInvalidIV
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, nil, err
+	}
+	return aead, nonce, nil
+}
@@ -0,0 +1,83 @@
+package vaultcrypt
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+)
+
+// testdata/web-client.json was encrypted in the browser code path
+// (web/src/lib/vaultcrypto.ts) under the passphrase in web-client.passphrase
+func readWebFixture(t *testing.T) (Bundle, string) {
+	t.Helper()
+	raw, err := os.ReadFile("testdata/web-client.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b Bundle
+	if err := json.Unmarshal(raw, &b); err != nil {
+		t.Fatal(err)
+	}
+	passphrase, err := os.ReadFile("testdata/web-client.passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b, strings.TrimRight(string(passphrase), "\n")
+}
+
+func webFixturePlaintexts() map[string][]byte {
+	binary := make([]byte, 300)
+	for i := range binary {
+		binary[i] = byte(i*37 + 11)
+	}
+	return map[string][]byte{
+		"text":   []byte("The seed phrase is in the blue folder.\nÜmlauts and emoji 🔑 survive."),
+		"binary": binary,
+	}
+}
+
+func TestDecryptWebClientFixture(t *testing.T) {
+	b, passphrase := readWebFixture(t)
+	key, err := DeriveKey(passphrase, b.KdfSalt, b.KDF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := webFixturePlaintexts()
+	if len(b.Artifacts) != len(want) {
+		t.Fatalf("fixture has %d artifacts, want %d", len(b.Artifacts), len(want))
+	}
+	for _, a := range b.Artifacts {
+		got, err := Decrypt(key, a.IV, a.EncryptedBlob)
+		if err != nil {
+			t.Fatalf("artifact %s: %v", a.ID, err)
+		}
+		if !bytes.Equal(got, want[a.ID]) {
+			t.Fatalf("artifact %s = %q, want %q", a.ID, got, want[a.ID])
+		}
+	}
+}
+
+func TestDecryptWebClientFixtureWrongPassphrase(t *testing.T) {
+	b, passphrase := readWebFixture(t)
+	key, err := DeriveKey(passphrase+"x", b.KdfSalt, b.KDF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Decrypt(key, b.Artifacts[0].IV, b.Artifacts[0].EncryptedBlob); err != ErrDecrypt {
+		t.Fatalf("Decrypt with wrong passphrase = %v, want ErrDecrypt", err)
+	}
+}
+
+func TestEncryptRoundTrip(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, KeySize)
+	iv, blob, err := Encrypt(key, []byte("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Decrypt(key, iv, blob)
+	if err != nil || string(got) != "hello" {
+		t.Fatalf("Decrypt = %q, %v", got, err)
+	}
+}
@@ -0,0 +1,122 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"time"
+
+	"github.com/vmpyr/afterlight/internal/core"
+	"github.com/vmpyr/afterlight/internal/notify"
+	"github.com/vmpyr/afterlight/internal/store"
+)
+
+// How long a deletion waits for its notices to be delivered before going ahead
+const deletionNoticeTimeout = 24 * time.Hour
+
+// Reaper carries out scheduled account deletions in two steps. Once a
+// deletion is due, the account is locked and its beneficiaries are told. The
+// outbox rows of those messages belong to the account, so the account itself
+// is only deleted once none of them are pending.
+type Reaper struct {
+	store    *store.Store
+	notifier *notify.Notifier
+	interval time.Duration
+}
+
+func NewReaper(s *store.Store, n *notify.Notifier, interval time.Duration) *Reaper {
+	return &Reaper{store: s, notifier: n, interval: interval}
+}
+
+func (r *Reaper) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := r.Reap(ctx, time.Now().UTC()); err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "account deletion failed", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// A failure for one account is logged and the others are still processed
+func (r *Reaper) Reap(ctx context.Context, now time.Time) error {
+	due, err := r.store.ListUsersDueForDeletion(ctx, sql.NullTime{Time: now, Valid: true})
+	if err != nil {
+		return err
+	}
+	for _, u := range due {
+		if err := r.start(ctx, u, now); err != nil {
+			slog.ErrorContext(ctx, "starting account deletion failed", "user_id", u.ID, "error", err)
+		}
+	}
+
+	deleting, err := r.store.ListUsersBeingDeleted(ctx)
+	if err != nil {
+		return err
+	}
+	for _, u := range deleting {
+		if err := r.finish(ctx, u, now); err != nil {
+			slog.ErrorContext(ctx, "account deletion failed", "user_id", u.ID, "error", err)
+		}
+	}
+	return nil
+}
+
+// Notices are queued in the transaction that locks the account, so they go
+// out exactly once, and only if the deletion really started
+func (r *Reaper) start(ctx context.Context, u store.User, now time.Time) error {
+	beneficiaries, err := r.store.ListBeneficiariesByUser(ctx, u.ID)
+	if err != nil {
+		return err
+	}
+	var notices []store.CreateNotificationParams
+	for _, b := range beneficiaries {
+		contacts, err := r.store.ListContactMethodsByBeneficiaryID(ctx, sql.NullString{String: b.ID, Valid: true})
+		if err != nil {
+			return err
+		}
+		for _, c := range contacts {
+			params, err := r.notifier.Prepare(u.ID, c, core.EventBeneficiaryRemoved, notify.TemplateData{
+				OwnerName:     string(u.Name),
+				RecipientName: string(b.BeneficiaryName),
+			})
+			if err != nil {
+				return err
+			}
+			notices = append(notices, params)
+		}
+	}
+
+	started, err := r.store.StartDeletionTx(ctx, u.ID, now, notices)
+	if err != nil {
+		return err
+	}
+	if !started {
+		slog.InfoContext(ctx, "account deletion cancelled or already started, skipping", "user_id", u.ID)
+		return nil
+	}
+	slog.InfoContext(ctx, "account deletion started", "user_id", u.ID, "beneficiaries", len(beneficiaries), "notices", len(notices))
+	return nil
+}
+
+func (r *Reaper) finish(ctx context.Context, u store.User, now time.Time) error {
+	pending, err := r.store.CountPendingNotificationsByUser(ctx, u.ID)
+	if err != nil {
+		return err
+	}
+	if pending > 0 && now.Sub(u.DeletionStartedAt.Time) < deletionNoticeTimeout {
+		return nil
+	}
+	if err := r.store.DeleteAccount(ctx, u.ID); err != nil {
+		return err
+	}
+	slog.InfoContext(ctx, "account deleted", "user_id", u.ID, "undelivered_notices", pending)
+	return nil
+}
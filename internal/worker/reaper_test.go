@@ -0,0 +1,155 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/vmpyr/afterlight/internal/core"
+	"github.com/vmpyr/afterlight/internal/notify"
+	"github.com/vmpyr/afterlight/internal/store"
+)
+
+type reaperTest struct {
+	db     *sql.DB
+	store  *store.Store
+	reaper *Reaper
+}
+
+func newReaperTest(t *testing.T) reaperTest {
+	t.Helper()
+	storage, err := store.NewStorage(filepath.Join(t.TempDir(), "afterlight.db"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { storage.Close() })
+	templates, err := notify.NewTemplates("", notify.DefaultLocale)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := store.NewStore(storage.DB())
+	return reaperTest{
+		db:     storage.DB(),
+		store:  s,
+		reaper: NewReaper(s, notify.NewNotifier(s, templates, "https://afterlight.example"), time.Hour),
+	}
+}
+
+// An owner with one beneficiary reachable by email, whose deletion was due an hour ago
+func (rt reaperTest) dueOwner(t *testing.T, email string) store.User {
+	t.Helper()
+	ctx := context.Background()
+	u, err := rt.store.CreateUserTx(ctx, core.RegisterRequest{
+		Name:     "Owner",
+		Email:    email,
+		Password: "Correct-horse-9",
+	}, store.UserDefaults{CheckInInterval: time.Hour, TriggerIntervals: 1}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := rt.store.CreateBeneficiary(ctx, store.CreateBeneficiaryParams{
+		ID:              "beneficiary-" + u.ID,
+		UserID:          u.ID,
+		BeneficiaryName: "Beneficiary",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rt.store.CreateContactMethod(ctx, store.CreateContactMethodParams{
+		ID:            "contact-" + u.ID,
+		BeneficiaryID: sql.NullString{String: b.ID, Valid: true},
+		Channel:       core.ChannelEmail,
+		Destination:   "beneficiary@example.com",
+		Metadata:      core.Metadata{},
+		CreatedAt:     time.Now().UTC(),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rt.db.Exec("UPDATE users SET deletion_due_at = ? WHERE id = ?", time.Now().UTC().Add(-time.Hour), u.ID); err != nil {
+		t.Fatal(err)
+	}
+	if u, err = rt.store.GetUserByID(ctx, u.ID); err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
+
+func (rt reaperTest) notices(t *testing.T, userID string) int {
+	t.Helper()
+	n, err := rt.store.ListNotificationsByUser(context.Background(), userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(n)
+}
+
+func TestReapNotifiesOnceThenDeletes(t *testing.T) {
+	rt := newReaperTest(t)
+	ctx := context.Background()
+	now := time.Now().UTC()
+	stale := rt.dueOwner(t, "owner@example.com")
+
+	if err := rt.reaper.Reap(ctx, now); err != nil {
+		t.Fatal(err)
+	}
+	u, err := rt.store.GetUserByID(ctx, stale.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !u.DeletionStartedAt.Valid || !u.DisabledAt.Valid {
+		t.Fatalf("deletion not started: %+v", u)
+	}
+	if n := rt.notices(t, u.ID); n != 1 {
+		t.Fatalf("queued notices = %d, want 1", n)
+	}
+
+	// A second start from a stale list must not queue the notices again
+	if err := rt.reaper.start(ctx, stale, now); err != nil {
+		t.Fatal(err)
+	}
+	if n := rt.notices(t, u.ID); n != 1 {
+		t.Fatalf("queued notices after a repeated start = %d, want 1", n)
+	}
+
+	// The account waits for its pending notice, then goes once the wait is over
+	if err := rt.reaper.Reap(ctx, now.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rt.store.GetUserByID(ctx, u.ID); err != nil {
+		t.Fatalf("account deleted with a notice pending: %v", err)
+	}
+	if err := rt.reaper.Reap(ctx, now.Add(deletionNoticeTimeout+time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rt.store.GetUserByID(ctx, u.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("account after the notice timeout: %v, want it deleted", err)
+	}
+}
+
+func TestStartSkipsCancelledDeletion(t *testing.T) {
+	rt := newReaperTest(t)
+	ctx := context.Background()
+	stale := rt.dueOwner(t, "owner@example.com")
+
+	// The owner cancels after the reaper listed them as due
+	if _, err := rt.store.CancelUserDeletion(ctx, stale.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := rt.reaper.start(ctx, stale, time.Now().UTC()); err != nil {
+		t.Fatal(err)
+	}
+
+	u, err := rt.store.GetUserByID(ctx, stale.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.DeletionStartedAt.Valid || u.DisabledAt.Valid {
+		t.Fatalf("cancelled deletion was started: %+v", u)
+	}
+	if n := rt.notices(t, u.ID); n != 0 {
+		t.Fatalf("queued notices for a cancelled deletion = %d, want 0", n)
+	}
+}
@@ -0,0 +1,74 @@
+// Package worker runs the server's background jobs and stops them together on
+// shutdown.
+package worker
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// How long a worker that panicked waits before it is started again
+const restartDelay = 5 * time.Second
+
+// Supervisor runs named workers until Stop is called. A worker that panics is
+// restarted; one that returns on its own is not.
+type Supervisor struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewSupervisor(parent context.Context) *Supervisor {
+	ctx, cancel := context.WithCancel(parent)
+	return &Supervisor{ctx: ctx, cancel: cancel}
+}
+
+func (s *Supervisor) Go(name string, run func(ctx context.Context)) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		for s.runOnce(name, run) {
+			select {
+			case <-s.ctx.Done():
+				return
+			case <-time.After(restartDelay):
+				slog.Info("restarting worker", "worker", name)
+			}
+		}
+	}()
+}
+
+// Reports whether the worker panicked
+func (s *Supervisor) runOnce(name string, run func(ctx context.Context)) (panicked bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			slog.Error("worker panicked", "worker", name, "panic", fmt.Sprint(r), "stack", string(debug.Stack()))
+			panicked = true
+		}
+	}()
+	run(s.ctx)
+	return false
+}
+
+// Stop cancels every worker and waits for them to return, giving up when ctx
+// is done
+func (s *Supervisor) Stop(ctx context.Context) error {
+	s.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,55 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/vmpyr/afterlight/internal/store"
+)
+
+// Sweeper removes expired login sessions, single sign-on attempts and
+// abandoned vault rotations
+type Sweeper struct {
+	store    *store.Store
+	interval time.Duration
+}
+
+func NewSweeper(s *store.Store, interval time.Duration) *Sweeper {
+	return &Sweeper{store: s, interval: interval}
+}
+
+func (s *Sweeper) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Sweep(ctx, time.Now().UTC()); err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "sweep failed", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Sweeper) Sweep(ctx context.Context, now time.Time) error {
+	sessions, err := s.store.DeleteExpiredSessions(ctx)
+	if err != nil {
+		return err
+	}
+	rotations, err := s.store.DeleteExpiredVaultRotations(ctx, now)
+	if err != nil {
+		return err
+	}
+	if _, err := s.store.DeleteExpiredOIDCLogins(ctx, now); err != nil {
+		return err
+	}
+	if sessions > 0 || rotations > 0 {
+		slog.InfoContext(ctx, "removed expired sessions and rotations", "sessions", sessions, "rotations", rotations)
+	}
+	return nil
+}
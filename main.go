@@ -1,71 +1,332 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
 	"embed"
+	"errors"
+	"flag"
+	"fmt"
 	"io/fs"
-	"log"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"path"
 	"path/filepath"
-	"time"
+	"strconv"
+	"strings"
+	"syscall"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 
 	"github.com/vmpyr/afterlight/internal/api"
+	"github.com/vmpyr/afterlight/internal/backup"
+	"github.com/vmpyr/afterlight/internal/certs"
+	"github.com/vmpyr/afterlight/internal/config"
+	"github.com/vmpyr/afterlight/internal/health"
+	"github.com/vmpyr/afterlight/internal/keys"
+	"github.com/vmpyr/afterlight/internal/liveness"
+	"github.com/vmpyr/afterlight/internal/logging"
+	"github.com/vmpyr/afterlight/internal/metrics"
+	"github.com/vmpyr/afterlight/internal/notify"
+	"github.com/vmpyr/afterlight/internal/seal"
+	"github.com/vmpyr/afterlight/internal/sso"
 	"github.com/vmpyr/afterlight/internal/store"
+	"github.com/vmpyr/afterlight/internal/worker"
 )
 
 //go:embed all:web/dist
 var dist embed.FS
 
 func main() {
-	dbPath := os.Getenv("DB_PATH")
-	if dbPath == "" {
-		dbPath = "afterlight.db"
+	configPath := flag.String("config", os.Getenv("AFTERLIGHT_CONFIG"), "TOML config `file` (environment variables override it)")
+	printConfig := flag.Bool("print-config", false, "print the effective configuration with secrets redacted and exit")
+	flag.Usage = func() { fmt.Fprint(flag.CommandLine.Output(), usage) }
+	flag.Parse()
+
+	if flag.NArg() > 0 {
+		os.Exit(runCommand(flag.Args(), *configPath))
 	}
-	if dir := filepath.Dir(dbPath); dir != "." {
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			log.Fatalf("Failed to create data directory: %v", err)
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		fatal("invalid configuration", err)
+	}
+	level, _ := logging.ParseLevel(cfg.Log.Level)
+	slog.SetDefault(logging.New(os.Stderr, level, cfg.Log.Format))
+
+	if *printConfig {
+		if err := cfg.Print(os.Stdout); err != nil {
+			fatal("printing configuration", err)
 		}
+		return
 	}
-	storage, err := store.NewStorage(dbPath)
+
+	storage, err := openStorage(cfg)
 	if err != nil {
-		log.Fatalf("Failed to initialize storage: %v", err)
+		fatal("failed to initialize storage", err)
+	}
+
+	if err := os.MkdirAll(cfg.Storage.ArtifactsPath, 0700); err != nil {
+		fatal("failed to create artifacts directory", err)
+	}
+
+	keyring, err := keys.LoadKeyring(cfg.Keys.MasterKeyFile, string(cfg.Keys.MasterKey))
+	if err != nil {
+		fatal("failed to load master key", err)
+	}
+	if keyring == nil {
+		slog.Warn("no master key configured, sealed release is disabled")
 	}
-	defer storage.Close()
 
 	authRepo := store.NewStore(storage.DB())
 	vaultRepo := store.NewStore(storage.DB())
+	livenessRepo := store.NewStore(storage.DB())
+
+	templates, err := notify.NewTemplates(cfg.Notifications.TemplatesPath, cfg.Notifications.DefaultLocale)
+	if err != nil {
+		fatal("failed to load notification templates", err)
+	}
+	notifier := notify.NewNotifier(livenessRepo, templates, cfg.Notifications.PublicURL)
+
+	sealer := seal.NewSealer(vaultRepo, keyring)
+
+	authHandler := api.NewAuthHandler(authRepo, cfg.Session.TTL.Std(), store.UserDefaults{
+		CheckInInterval:  cfg.Liveness.CheckInInterval.Std(),
+		TriggerIntervals: int64(cfg.Liveness.TriggerIntervals),
+		BufferPeriod:     cfg.Liveness.BufferPeriod.Std(),
+		VerifierQuorum:   int64(cfg.Liveness.VerifierQuorum),
+	}, cfg.Registration.Mode, newForwardAuth(cfg))
+	vaultHandler := api.NewVaultHandler(vaultRepo, sealer, cfg.Quotas.Limits())
+	livenessHandler := api.NewLivenessHandler(livenessRepo)
+	contactHandler := api.NewContactHandler(livenessRepo, notifier)
+	beneficiaryHandler := api.NewBeneficiaryHandler(livenessRepo, contactHandler)
+	notificationHandler := api.NewNotificationHandler(templates)
+	releaseHandler := api.NewReleaseHandler(vaultRepo)
+	verifierHandler := api.NewVerifierHandler(livenessRepo)
+	accountHandler := api.NewAccountHandler(authRepo, sealer, cfg.Account.DeletionDelay.Std())
+	oidcHandler := api.NewOIDCHandler(authRepo, authHandler, newSSOProvider(cfg), cfg.OIDC.Name, cfg.OIDC.LinkByEmail)
+	inviteHandler := api.NewInviteHandler(authRepo, notifier, cfg.Notifications.PublicURL,
+		int64(cfg.Registration.UserInvites), cfg.Registration.InviteTTL.Std())
 
-	authHandler := api.NewAuthHandler(authRepo)
-	vaultHandler := api.NewVaultHandler(vaultRepo)
+	// Background workers, stopped on SIGINT or SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	workers := worker.NewSupervisor(ctx)
+	email := &notify.EmailSender{
+		Host:     cfg.SMTP.Host,
+		Port:     strconv.Itoa(cfg.SMTP.Port),
+		Username: cfg.SMTP.Username,
+		Password: string(cfg.SMTP.Password),
+		From:     cfg.SMTP.From,
+	}
+	if !email.Configured() {
+		slog.Warn("SMTP not configured, email notifications stay queued until it is")
+	}
+	dispatcher := notify.NewDispatcher(livenessRepo, email)
+	dispatcher.Observe(metrics.ObserveNotification)
+	engine := liveness.NewEngine(livenessRepo, notifier, sealer, cfg.Liveness.CheckInterval.Std())
+	workers.Go("liveness", engine.Run)
+	workers.Go("notifier", func(ctx context.Context) { dispatcher.Run(ctx, cfg.Notifications.DispatchInterval.Std()) })
+	workers.Go("sweeper", worker.NewSweeper(authRepo, cfg.Session.SweepInterval.Std()).Run)
+	workers.Go("reaper", worker.NewReaper(authRepo, notifier, cfg.Session.SweepInterval.Std()).Run)
+
+	backupRecipients, err := backup.ParseRecipients(cfg.Backup.Recipients)
+	if err != nil {
+		fatal("invalid backup recipients", err)
+	}
+	if cfg.Backup.Interval > 0 {
+		scheduler := backup.NewScheduler(storage.DB(), cfg.Storage.ArtifactsPath, cfg.Backup.Dir,
+			cfg.Backup.Interval.Std(), cfg.Backup.Keep, backupRecipients)
+		workers.Go("backup", scheduler.Run)
+	}
+
+	checker := health.NewChecker(storage, engine, cfg.Storage.ArtifactsPath,
+		uint64(cfg.Health.MinFreeMB)<<20, int64(cfg.Health.MaxOutboxBacklog))
+	backupHandler := api.NewBackupHandler(storage.DB(), cfg.Storage.ArtifactsPath, backupRecipients, string(cfg.Backup.APIToken))
+	adminHandler := api.NewAdminHandler(authRepo, checker, backupHandler, cfg.Registration.Mode, cfg.Quotas.Limits())
 
 	r := chi.NewRouter()
 
-	r.Use(middleware.Logger)
-	r.Use(middleware.Recoverer)
+	r.Use(api.PeerAddress)
 	r.Use(middleware.RealIP)
-	r.Use(middleware.Timeout(60 * time.Second))
+	r.Use(logging.Middleware)
+	r.Use(metrics.Middleware)
+	r.Use(middleware.Recoverer)
+	r.Use(api.SecureTransport(cfg.Server.TrustProxyHeaders, cfg.Server.HSTSMaxAge.Std()))
+	r.Use(middleware.Timeout(cfg.Server.RequestTimeout.Std()))
 
 	r.Route("/api/v1", func(r chi.Router) {
 		r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
 			w.Write([]byte("Afterlight Systems: ONLINE"))
 		})
 
+		r.Mount("/auth/oidc", oidcHandler.Routes(authHandler.AuthMiddleware))
 		r.Mount("/auth", authHandler.Routes())
 		r.Mount("/vaults", vaultHandler.Routes(authHandler.AuthMiddleware))
+		r.Mount("/liveness", livenessHandler.Routes(authHandler.AuthMiddleware))
+		r.Mount("/contact-methods", contactHandler.Routes(authHandler.AuthMiddleware))
+		r.Mount("/beneficiaries", beneficiaryHandler.Routes(authHandler.AuthMiddleware))
+		r.Mount("/notifications", notificationHandler.Routes(authHandler.AuthMiddleware))
+		r.Mount("/release", releaseHandler.Routes())
+		r.Mount("/verify", verifierHandler.Routes())
+		r.Mount("/invites", inviteHandler.Routes(authHandler.AuthMiddleware))
+		r.Mount("/account", accountHandler.Routes(authHandler.AuthMiddleware))
+		r.Mount("/admin", adminHandler.Routes(authHandler.AuthMiddleware))
+		if cfg.Backup.APIToken != "" {
+			r.Mount("/backup", backupHandler.Routes())
+		}
 	})
 
+	r.Get("/healthz", checker.Live)
+	r.Get("/readyz", checker.Ready)
+
+	if cfg.Metrics.Enabled {
+		r.Handle("/metrics", metrics.NewRegistry(livenessRepo, storage.DB(), string(cfg.Metrics.Token)).Handler())
+	}
+
 	contentStatic, _ := fs.Sub(dist, "web/dist")
-	r.Handle("/*", http.FileServer(http.FS(contentStatic)))
+	r.Handle("/*", spaHandler(contentStatic))
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+	servers := []*http.Server{{Addr: cfg.Server.ListenAddr, Handler: r}}
+	serveErr := make(chan error, 2)
+	if tlsCfg := cfg.Server.TLS; tlsCfg.CertFile != "" {
+		reloader, err := certs.NewReloader(tlsCfg.CertFile, tlsCfg.KeyFile)
+		if err != nil {
+			fatal("failed to load TLS certificate", err)
+		}
+		workers.Go("certs", func(ctx context.Context) { reloader.Watch(ctx, tlsCfg.ReloadInterval.Std()) })
+		servers[0].TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12, GetCertificate: reloader.GetCertificate}
+
+		slog.Info("afterlight running", "url", "https://"+cfg.Server.ListenAddr)
+		go func() { serveErr <- servers[0].ListenAndServeTLS("", "") }()
+
+		if tlsCfg.RedirectAddr != "" {
+			redirect := &http.Server{Addr: tlsCfg.RedirectAddr, Handler: redirectToHTTPS(cfg.Server.ListenAddr)}
+			servers = append(servers, redirect)
+			slog.Info("redirecting plain HTTP to HTTPS", "addr", tlsCfg.RedirectAddr)
+			go func() { serveErr <- redirect.ListenAndServe() }()
+		}
+	} else {
+		slog.Info("afterlight running", "url", "http://"+cfg.Server.ListenAddr)
+		go func() { serveErr <- servers[0].ListenAndServe() }()
+	}
+
+	var failed error
+	select {
+	case failed = <-serveErr:
+		slog.Error("server stopped", "error", failed)
+	case <-ctx.Done():
+		slog.Info("shutting down")
+	}
+	stop()
+
+	// Drain requests first, then stop the workers, then close the database they use
+	drainCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout.Std())
+	defer cancel()
+	for _, srv := range servers {
+		if err := srv.Shutdown(drainCtx); err != nil {
+			slog.Error("failed to drain requests", "error", err)
+		}
+	}
+	if err := workers.Stop(drainCtx); err != nil {
+		slog.Error("failed to stop background workers", "error", err)
+	}
+	if err := storage.Close(); err != nil {
+		slog.Error("failed to close database", "error", err)
+	}
+	if failed != nil {
+		os.Exit(1)
+	}
+}
+
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
+
+// Serves the web client. Paths that are not files, like /verify/<code> or
+// /register?invite=, are client-side routes and get index.html.
+func spaHandler(content fs.FS) http.Handler {
+	files := http.FileServer(http.FS(content))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(path.Clean(r.URL.Path), "/")
+		if name != "" {
+			if _, err := fs.Stat(content, name); errors.Is(err, fs.ErrNotExist) {
+				r = r.Clone(r.Context())
+				r.URL.Path = "/"
+			}
+		}
+		files.ServeHTTP(w, r)
+	})
+}
+
+// Sends plain HTTP requests to the same host on the HTTPS listener's port
+func redirectToHTTPS(httpsAddr string) http.Handler {
+	_, port, _ := net.SplitHostPort(httpsAddr)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(r.Host); err == nil {
+			host = h
+		}
+		if port != "443" {
+			host = net.JoinHostPort(host, port)
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+	})
+}
+
+// nil when single sign-on is not configured
+func newSSOProvider(cfg *config.Config) *sso.Provider {
+	if !cfg.OIDC.Enabled() {
+		return nil
+	}
+	redirectURL := cfg.OIDC.RedirectURL
+	if redirectURL == "" {
+		redirectURL = strings.TrimSuffix(cfg.Notifications.PublicURL, "/") + "/api/v1/auth/oidc/callback"
+	}
+	slog.Info("single sign-on enabled", "issuer", cfg.OIDC.Issuer)
+	return sso.NewProvider(sso.Config{
+		Issuer:       cfg.OIDC.Issuer,
+		ClientID:     cfg.OIDC.ClientID,
+		ClientSecret: string(cfg.OIDC.ClientSecret),
+		RedirectURL:  redirectURL,
+		Scopes:       cfg.OIDC.Scopes,
+	})
+}
+
+// nil unless an authenticating reverse proxy is configured
+func newForwardAuth(cfg *config.Config) *api.ForwardAuth {
+	if !cfg.ForwardAuth.Enabled() {
+		return nil
+	}
+	networks, _ := cfg.ForwardAuth.Networks() // Checked by Validate
+	slog.Info("trusting forward-auth header", "header", cfg.ForwardAuth.Header, "proxies", cfg.ForwardAuth.TrustedProxies)
+	return &api.ForwardAuth{
+		Header:         cfg.ForwardAuth.Header,
+		NameHeader:     cfg.ForwardAuth.NameHeader,
+		TrustedProxies: networks,
+		AutoProvision:  cfg.ForwardAuth.AutoProvision,
+	}
+}
+
+func openStorage(cfg *config.Config) (*store.SQLiteStorage, error) {
+	dbPath := cfg.Database.Path
+	if dir := filepath.Dir(dbPath); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create data directory: %w", err)
+		}
+	}
+	kek, err := keys.LoadKeyring(cfg.Keys.EncryptionKeyFile, string(cfg.Keys.EncryptionKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load encryption key: %w", err)
 	}
-	log.Printf("Afterlight running on http://localhost:%s", port)
-	if err := http.ListenAndServe(":"+port, r); err != nil {
-		log.Fatal(err)
+	if kek == nil {
+		slog.Warn("no encryption key configured, sensitive columns are stored in plaintext")
 	}
+	return store.NewStorage(dbPath, kek)
 }
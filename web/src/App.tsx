@@ -5,6 +5,7 @@ import Register from "./pages/Register"
 import Dashboard from "./pages/Dashboard"
 import Vaults from "./pages/Vaults"
 import VaultDetail from "./pages/VaultDetail"
+import Verify from "./pages/Verify"
 import Layout from "./components/layout"
 import PrivateRoute from "./components/private-route"
 
@@ -51,6 +52,8 @@ function App() {
           }
         />
 
+        <Route path="/verify/:token" element={<Verify />} />
+
         <Route element={<PrivateRoute user={user} isLoading={loading} />}>
           <Route element={<Layout user={user} onLogout={handleLogout} />}>
             <Route path="/" element={<Dashboard user={user} />} />
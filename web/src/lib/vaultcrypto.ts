@@ -0,0 +1,85 @@
+// Artifact encryption, envelope version 1. The key is derived from the vault
+// passphrase and the hex kdf_salt with the vault's KDF; artifacts are
+// AES-256-GCM with the tag appended, under a 12 byte IV sent as hex.
+// internal/vaultcrypt implements the same for the decrypt command, and its
+// tests decrypt fixtures made with this file.
+
+export interface KDFParams {
+  algorithm: string
+  iterations: number
+  memory_kib?: number
+  parallelism?: number
+}
+
+// What vaults created before KDF parameters were stored were encrypted with
+export const defaultKDF: KDFParams = { algorithm: "PBKDF2-SHA256", iterations: 600000 }
+
+const hex2buf = (hex: string) => {
+  if (hex.length % 2 !== 0 || !/^[0-9a-fA-F]*$/.test(hex)) {
+    throw new Error("invalid hex")
+  }
+  const bytes = new Uint8Array(hex.length / 2)
+  for (let i = 0; i < bytes.length; i++) {
+    bytes[i] = parseInt(hex.slice(i * 2, i * 2 + 2), 16)
+  }
+  return bytes
+}
+
+const buf2hex = (buffer: ArrayBuffer | Uint8Array) => {
+  return [...new Uint8Array(buffer)]
+    .map(x => x.toString(16).padStart(2, '0'))
+    .join('');
+}
+
+const buf2base64 = (buffer: ArrayBuffer) => {
+  let binary = '';
+  const bytes = new Uint8Array(buffer);
+  for (let i = 0; i < bytes.byteLength; i++) {
+    binary += String.fromCharCode(bytes[i]);
+  }
+  return btoa(binary);
+}
+
+const base642buf = (b64: string) => {
+  const binary = atob(b64)
+  const bytes = new Uint8Array(binary.length)
+  for (let i = 0; i < binary.length; i++) {
+    bytes[i] = binary.charCodeAt(i)
+  }
+  return bytes
+}
+
+// Argon2id is not part of WebCrypto, so vaults using it cannot be written from the browser
+export async function deriveVaultKey(passphrase: string, kdfSalt: string, kdf: KDFParams = defaultKDF) {
+  if (kdf.algorithm !== "PBKDF2-SHA256") {
+    throw new Error(`This browser cannot derive keys with ${kdf.algorithm}`)
+  }
+  const material = await crypto.subtle.importKey(
+    "raw",
+    new TextEncoder().encode(passphrase),
+    "PBKDF2",
+    false,
+    ["deriveKey"]
+  )
+  return crypto.subtle.deriveKey(
+    { name: "PBKDF2", hash: "SHA-256", salt: hex2buf(kdfSalt), iterations: kdf.iterations },
+    material,
+    { name: "AES-GCM", length: 256 },
+    false,
+    ["encrypt", "decrypt"]
+  )
+}
+
+export async function encryptArtifact(key: CryptoKey, plaintext: Uint8Array) {
+  const iv = crypto.getRandomValues(new Uint8Array(12))
+  const ciphertext = await crypto.subtle.encrypt({ name: "AES-GCM", iv: iv }, key, plaintext)
+  return {
+    iv: buf2hex(iv),                   // Hex string for DB
+    blob: buf2base64(ciphertext),      // Base64 string for Go []byte
+  }
+}
+
+export async function decryptArtifact(key: CryptoKey, iv: string, blob: string) {
+  const plaintext = await crypto.subtle.decrypt({ name: "AES-GCM", iv: hex2buf(iv) }, key, base642buf(blob))
+  return new Uint8Array(plaintext)
+}
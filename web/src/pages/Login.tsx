@@ -1,5 +1,5 @@
-import { useState } from "react"
-import { useNavigate } from "react-router-dom"
+import { useEffect, useState } from "react"
+import { useNavigate, useSearchParams } from "react-router-dom"
 import { Button } from "@/components/ui/button"
 import { Input } from "@/components/ui/input"
 import { Label } from "@/components/ui/label"
@@ -14,6 +14,14 @@ import {
 import { AlertCircle } from "lucide-react"
 import { ModeToggle } from "@/components/mode-toggle"
 
+const ssoErrors: Record<string, string> = {
+  denied: "Single sign-on was cancelled.",
+  expired: "The single sign-on attempt expired. Please try again.",
+  not_linked: "No account is linked to that identity. Sign in with your password and link it first.",
+  already_linked: "That identity is already linked to another account.",
+  disabled: "This account has been disabled by an administrator.",
+}
+
 interface LoginProps {
   onLoginSuccess: (user: any) => void
 }
@@ -24,6 +32,22 @@ export default function Login({ onLoginSuccess }: LoginProps) {
   const [error, setError] = useState("")
   const [isLoading, setIsLoading] = useState(false)
   const navigate = useNavigate()
+  const [searchParams] = useSearchParams()
+  const ssoError = searchParams.get("sso_error")
+  const [sso, setSso] = useState<{ enabled: boolean; name?: string }>({ enabled: false })
+
+  useEffect(() => {
+    fetch("/api/v1/auth/oidc/")
+      .then((res) => (res.ok ? res.json() : { enabled: false }))
+      .then(setSso)
+      .catch(() => {})
+  }, [])
+
+  useEffect(() => {
+    if (ssoError) {
+      setError(ssoErrors[ssoError] ?? "Single sign-on failed. Please try again.")
+    }
+  }, [ssoError])
 
   const handleSubmit = async (e: React.FormEvent) => {
     e.preventDefault()
@@ -97,6 +121,11 @@ export default function Login({ onLoginSuccess }: LoginProps) {
             <Button className="w-full" type="submit" disabled={isLoading}>
               {isLoading ? "Signing in..." : "Sign in"}
             </Button>
+            {sso.enabled && (
+              <Button className="w-full" type="button" variant="outline" asChild>
+                <a href="/api/v1/auth/oidc/login">Sign in with {sso.name}</a>
+              </Button>
+            )}
             <div className="text-sm text-center text-muted-foreground">
               Don't have an account?{" "}
               <a href="/register" className="text-primary underline-offset-4 hover:underline">
@@ -7,46 +7,7 @@ import { Label } from "@/components/ui/label"
 import { Alert } from "@/components/ui/alert"
 import { Separator } from "@/components/ui/separator"
 import { ArrowLeft, Plus, AlertCircle, Lock } from "lucide-react"
-
-const buf2hex = (buffer: ArrayBuffer) => {
-  return [...new Uint8Array(buffer)]
-    .map(x => x.toString(16).padStart(2, '0'))
-    .join('');
-}
-
-const buf2base64 = (buffer: ArrayBuffer) => {
-  let binary = '';
-  const bytes = new Uint8Array(buffer);
-  const len = bytes.byteLength;
-  for (let i = 0; i < len; i++) {
-    binary += String.fromCharCode(bytes[i]);
-  }
-  return window.btoa(binary);
-}
-
-async function encryptData(plaintext: string) {
-  const enc = new TextEncoder();
-  const encoded = enc.encode(plaintext);
-
-  const iv = window.crypto.getRandomValues(new Uint8Array(12));
-
-  const key = await window.crypto.subtle.generateKey(
-    { name: "AES-GCM", length: 256 },
-    true,
-    ["encrypt", "decrypt"]
-  );
-
-  const ciphertext = await window.crypto.subtle.encrypt(
-    { name: "AES-GCM", iv: iv },
-    key,
-    encoded
-  );
-
-  return {
-    iv: buf2hex(iv.buffer),            // Hex string for DB
-    blob: buf2base64(ciphertext),      // Base64 string for Go []byte
-  };
-}
+import { deriveVaultKey, encryptArtifact, type KDFParams } from "@/lib/vaultcrypto"
 
 interface Artifact {
   id: string
@@ -60,6 +21,8 @@ interface Artifact {
 interface ArtifactList {
   vault_name: string
   hint?: string
+  kdf_salt: string
+  kdf?: KDFParams
   artifacts: Artifact[]
   created_at: string
 }
@@ -74,6 +37,7 @@ export default function VaultDetail() {
   // Form State
   const [messageType, setMessageType] = useState("TEXT_MESSAGE")
   const [secretMessage, setSecretMessage] = useState("") // CHANGED: Plain text input
+  const [passphrase, setPassphrase] = useState("")
   const [error, setError] = useState("")
   const [creating, setCreating] = useState(false)
 
@@ -107,8 +71,12 @@ export default function VaultDetail() {
     setCreating(true)
 
     try {
-      // 1. Client-Side Encryption
-      const { iv, blob } = await encryptData(secretMessage)
+      // 1. Client-Side Encryption, under the key derived from the vault passphrase
+      if (!artifactList) {
+        throw new Error("vault not loaded")
+      }
+      const key = await deriveVaultKey(passphrase, artifactList.kdf_salt, artifactList.kdf)
+      const { iv, blob } = await encryptArtifact(key, new TextEncoder().encode(secretMessage))
 
       // 2. Send to API
       const res = await fetch(`/api/v1/vaults/${id}/artifacts`, {
@@ -118,6 +86,7 @@ export default function VaultDetail() {
           message_type: messageType,
           encrypted_blob: blob, // Sending Base64 string
           iv: iv,               // Sending Hex string
+          envelope: { version: 1 },
         }),
       })
 
@@ -132,6 +101,7 @@ export default function VaultDetail() {
         setShowCreateModal(false)
         setMessageType("TEXT_MESSAGE")
         setSecretMessage("")
+        setPassphrase("")
       } else {
         setError("Failed to create artifact")
       }
@@ -221,6 +191,22 @@ export default function VaultDetail() {
                 </p>
               </div>
 
+              <div className="grid gap-2">
+                <Label htmlFor="passphrase">Vault Passphrase</Label>
+                <Input
+                  id="passphrase"
+                  type="password"
+                  required
+                  autoComplete="off"
+                  value={passphrase}
+                  onChange={(e) => setPassphrase(e.target.value)}
+                />
+                <p className="text-xs text-muted-foreground">
+                  {artifactList?.hint ? `Hint: ${artifactList.hint}. ` : ""}
+                  Use the same passphrase for every secret in this vault; it is never sent to the server.
+                </p>
+              </div>
+
               <div className="flex gap-2 justify-end pt-2">
                 <Button
                   type="button"
@@ -0,0 +1,107 @@
+import { useEffect, useState } from "react"
+import { useParams } from "react-router-dom"
+import { Button } from "@/components/ui/button"
+import {
+  Card,
+  CardContent,
+  CardDescription,
+  CardFooter,
+  CardHeader,
+  CardTitle,
+} from "@/components/ui/card"
+import { AlertCircle } from "lucide-react"
+import { ModeToggle } from "@/components/mode-toggle"
+
+interface Verification {
+  owner_name: string
+  verifier_name: string
+  awaiting_confirmation: boolean
+  confirmed: boolean
+  confirmed_at?: string
+}
+
+export default function Verify() {
+  const { token } = useParams()
+  const [verification, setVerification] = useState<Verification | null>(null)
+  const [error, setError] = useState("")
+  const [isLoading, setIsLoading] = useState(false)
+
+  useEffect(() => {
+    fetch(`/api/v1/verify/${encodeURIComponent(token ?? "")}/`)
+      .then(async (res) => {
+        if (res.ok) {
+          setVerification(await res.json())
+        } else {
+          setError("This link is no longer valid.")
+        }
+      })
+      .catch(() => setError("Something went wrong. Please try again."))
+  }, [token])
+
+  const handleConfirm = async () => {
+    setError("")
+    setIsLoading(true)
+
+    try {
+      const res = await fetch(`/api/v1/verify/${encodeURIComponent(token ?? "")}/confirm`, {
+        method: "POST",
+      })
+
+      if (res.ok) {
+        setVerification(await res.json())
+      } else {
+        setError(await res.text())
+      }
+    } catch {
+      setError("Something went wrong. Please try again.")
+    } finally {
+      setIsLoading(false)
+    }
+  }
+
+  return (
+    <div className="flex h-screen w-full items-center justify-center bg-muted/40 px-4">
+      <div className="absolute top-4 right-4">
+        <ModeToggle />
+      </div>
+      <Card className="w-full max-w-md">
+        <CardHeader>
+          <CardTitle className="text-2xl">Confirm a passing</CardTitle>
+          {verification && (
+            <CardDescription>
+              {verification.owner_name} named you, {verification.verifier_name}, as a trusted verifier.
+            </CardDescription>
+          )}
+        </CardHeader>
+        <CardContent className="grid gap-4 text-sm">
+          {error && (
+            <div className="flex items-center gap-2 rounded-md bg-destructive/15 p-3 text-destructive">
+              <AlertCircle className="h-4 w-4" />
+              <span>{error}</span>
+            </div>
+          )}
+          {verification?.confirmed && (
+            <p>Thank you. Your confirmation has been recorded.</p>
+          )}
+          {verification && !verification.confirmed && verification.awaiting_confirmation && (
+            <p>
+              {verification.owner_name} has not checked in for longer than they told us to expect.
+              Please only confirm if you are certain they have passed away. If you are unsure, try to
+              reach them or their family first.
+            </p>
+          )}
+          {verification && !verification.confirmed && !verification.awaiting_confirmation && (
+            <p>No confirmation is needed at the moment.</p>
+          )}
+        </CardContent>
+        {verification && !verification.confirmed && verification.awaiting_confirmation && (
+          <CardFooter>
+            <Button className="w-full" variant="destructive" onClick={handleConfirm} disabled={isLoading}>
+              {isLoading ? "Confirming..." : `I confirm ${verification.owner_name} has passed away`}
+            </Button>
+          </CardFooter>
+        )}
+      </Card>
+    </div>
+  )
+}